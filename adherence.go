@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// doseRetention сколько хранится журнал приемов
+	doseRetention = 60 * 24 * time.Hour
+	// adherenceReportHour час отправки еженедельного отчета по понедельникам
+	adherenceReportHour = 9
+	adherenceDays       = 7
+)
+
+// Dose прием лекарства по напоминанию, TakenAt пустое для пропущенного приема
+type Dose struct {
+	ID          int       `json:"id"`
+	ReminderID  int       `json:"reminder_id"`
+	ChatID      int64     `json:"chat_id"`
+	UserID      int64     `json:"user_id"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	TakenAt     time.Time `json:"taken_at,omitempty"`
+}
+
+var weekdayNames = []string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"}
+
+// adherenceBar рисует долю принятых доз полосой из десяти делений
+func adherenceBar(taken, total int) string {
+	if total == 0 {
+		return strings.Repeat("░", 10)
+	}
+	filled := taken * 10 / total
+
+	return strings.Repeat("█", filled) + strings.Repeat("░", 10-filled)
+}
+
+// takenStreak считает подряд принятые дозы начиная с последней
+func takenStreak(doses []Dose) int {
+	streak := 0
+	for index := len(doses) - 1; index >= 0; index-- {
+		if doses[index].TakenAt.IsZero() {
+			break
+		}
+		streak++
+	}
+
+	return streak
+}
+
+// formatAdherence готовит отчет о приеме лекарств за последние дни
+func formatAdherence(chatID int64, now time.Time) string {
+	location := chatLocation(chatID)
+	local := now.In(location)
+	since := time.Date(local.Year(), local.Month(), local.Day()-adherenceDays+1, 0, 0, 0, 0, location)
+	doses := Storage.DosesSince(chatID, since)
+	if len(doses) == 0 {
+		return "За последнюю неделю напоминаний о приеме не было."
+	}
+
+	var text strings.Builder
+	text.WriteString(bold("Прием лекарств за неделю") + "\n")
+
+	byReminder := map[int][]Dose{}
+	order := []int{}
+	for _, dose := range doses {
+		if _, ok := byReminder[dose.ReminderID]; !ok {
+			order = append(order, dose.ReminderID)
+		}
+		byReminder[dose.ReminderID] = append(byReminder[dose.ReminderID], dose)
+	}
+
+	for _, reminderID := range order {
+		reminderDoses := byReminder[reminderID]
+		title := "Удаленное напоминание"
+		if reminder, ok := Storage.Reminder(reminderID); ok {
+			title = reminder.Title()
+		}
+
+		taken := 0
+		for _, dose := range reminderDoses {
+			if !dose.TakenAt.IsZero() {
+				taken++
+			}
+		}
+
+		text.WriteString(fmt.Sprintf("\n%s\n%s %d из %d, пропущено %d",
+			bold(title), adherenceBar(taken, len(reminderDoses)), taken, len(reminderDoses), len(reminderDoses)-taken))
+		if streak := takenStreak(reminderDoses); streak > 1 {
+			text.WriteString(fmt.Sprintf("\nПодряд без пропусков: %d", streak))
+		}
+		text.WriteString("\n")
+	}
+
+	text.WriteString("\n")
+	for day := 0; day < adherenceDays; day++ {
+		start := since.AddDate(0, 0, day)
+		end := start.AddDate(0, 0, 1)
+		marks := ""
+		for _, dose := range doses {
+			if dose.ScheduledAt.Before(start) || !dose.ScheduledAt.Before(end) {
+				continue
+			}
+			if dose.TakenAt.IsZero() {
+				marks += "○"
+			} else {
+				marks += "●"
+			}
+		}
+		if len(marks) == 0 {
+			marks = "—"
+		}
+		text.WriteString(fmt.Sprintf("<code>%s %s</code> %s\n", weekdayNames[start.Weekday()], start.Format("02.01"), marks))
+	}
+	text.WriteString("\n● принято ○ пропущено")
+
+	return text.String()
+}
+
+func adherenceHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	_, arg, _ := strings.Cut(update.Message.Text, " ")
+
+	var note string
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "on", "вкл":
+		Storage.UpdateChatSettings(update.Message.Chat.ID, func(settings *ChatSettings) {
+			settings.AdherenceReport = true
+			// Первый отчет придет в ближайший понедельник, а не сразу после включения
+			settings.AdherenceSentAt = time.Now()
+		})
+		note = "Еженедельный отчет включен, он будет приходить по понедельникам."
+	case "off", "выкл":
+		Storage.UpdateChatSettings(update.Message.Chat.ID, func(settings *ChatSettings) {
+			settings.AdherenceReport = false
+		})
+		note = "Еженедельный отчет выключен."
+	default:
+		if Storage.ChatSettings(update.Message.Chat.ID).AdherenceReport {
+			note = "Еженедельный отчет включен. Выключить: /adherence off"
+		} else {
+			note = "Чтобы получать этот отчет каждый понедельник, отправьте /adherence on"
+		}
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      formatAdherence(update.Message.Chat.ID, time.Now()) + "\n\n" + escapeHTML(note),
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+// adherenceJob рассылает еженедельные отчеты в понедельник утром по времени каждого чата
+func adherenceJob(b *bot.Bot) Job {
+	return Job{
+		Name: "adherence_reports",
+		Next: every(10 * time.Minute),
+		Run: func(ctx context.Context) {
+			sendAdherenceReports(ctx, b, time.Now())
+		},
+	}
+}
+
+func sendAdherenceReports(ctx context.Context, b *bot.Bot, now time.Time) {
+	for chatID, settings := range Storage.AdherenceChats() {
+		local := now.In(chatLocation(chatID))
+		monday := time.Date(local.Year(), local.Month(), local.Day()-(int(local.Weekday())+6)%7, adherenceReportHour, 0, 0, 0, local.Location())
+		if now.Before(monday) || settings.AdherenceSentAt.After(monday) {
+			continue
+		}
+		// Отчет отправится при следующей проверке после тихих часов
+		if _, quiet := quietUntil(settings, local.Location(), now); quiet {
+			continue
+		}
+
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.AdherenceSentAt = now
+		})
+
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      formatAdherence(chatID, now),
+			ParseMode: models.ParseModeHTML,
+		})
+		if err != nil {
+			logError(err)
+		}
+	}
+}
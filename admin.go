@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleModerator Role = "moderator"
+	RoleAnalyst   Role = "analyst"
+)
+
+type Permission string
+
+const (
+	PermissionDashboard Permission = "dashboard"
+	PermissionFlags     Permission = "flags"
+	PermissionRoles     Permission = "roles"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner:     {PermissionDashboard, PermissionFlags, PermissionRoles},
+	RoleModerator: {PermissionDashboard, PermissionFlags},
+	RoleAnalyst:   {PermissionDashboard},
+}
+
+// commandPermissions перечисляет административные команды, их проверяет authMiddleware
+var commandPermissions = map[string]Permission{
+	"/admin":        PermissionDashboard,
+	"/digest":       PermissionDashboard,
+	"/grant":        PermissionRoles,
+	"/revoke":       PermissionRoles,
+	"/roles":        PermissionRoles,
+	"/restrictions": PermissionFlags,
+	"/entry_set":    PermissionFlags,
+	"/unmute":       PermissionFlags,
+	"/graylist":     PermissionFlags,
+	"/quota":        PermissionRoles,
+	"/apikeys":      PermissionFlags,
+	"/gtin_import":  PermissionFlags,
+	"/override":     PermissionFlags,
+}
+
+var commandNameRe = regexp.MustCompile(`^(/[a-zA-Z0-9_]+)(@\w+)?`)
+
+// parseAdminRoles разбирает список администраторов вида "id:role" через запятую,
+// id без роли считается владельцем
+func parseAdminRoles(value string) map[int64]Role {
+	roles := map[int64]Role{}
+	for _, item := range strings.Split(value, ",") {
+		idValue, roleValue, _ := strings.Cut(strings.TrimSpace(item), ":")
+		id, err := strconv.ParseInt(idValue, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		role := RoleOwner
+		if len(roleValue) > 0 {
+			role = Role(roleValue)
+		}
+		if _, ok := rolePermissions[role]; !ok {
+			continue
+		}
+		roles[id] = role
+	}
+
+	return roles
+}
+
+// userRole возвращает роль пользователя: сначала из окружения, затем из хранилища
+func userRole(userID int64) Role {
+	if role, ok := AdminRoles[userID]; ok {
+		return role
+	}
+
+	return Storage.Role(userID)
+}
+
+func hasPermission(userID int64, permission Permission) bool {
+	for _, item := range rolePermissions[userRole(userID)] {
+		if item == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// commandName возвращает команду из текста сообщения без имени бота
+func commandName(text string) string {
+	match := commandNameRe.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+
+	return strings.ToLower(match[1])
+}
+
+func commandRegexp(name string) *regexp.Regexp {
+	return regexp.MustCompile(`^/` + name + `(@\w+)?(\s|$)`)
+}
+
+// authMiddleware не пропускает административные команды от пользователей без нужной роли
+func authMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		if update.Message != nil {
+			permission, ok := commandPermissions[commandName(update.Message.Text)]
+			if ok && (update.Message.From == nil || !hasPermission(update.Message.From.ID, permission)) {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: update.Message.Chat.ID,
+					Text:   "Команда недоступна.",
+				})
+				return
+			}
+		}
+
+		next(ctx, b, update)
+	}
+}
+
+// providersText состояние источников данных для /admin
+func providersText() string {
+	var text strings.Builder
+	text.WriteString("Источники данных:")
+	for _, provider := range providerHealth() {
+		state := "✅"
+		if provider.Down {
+			state = "⛔ проверка в " + provider.DownUntil.Format("15:04:05") + ","
+		}
+		text.WriteString(fmt.Sprintf("\n%s %s %.0f%% из %d, %d мс", state, provider.Name, provider.SuccessRate*100, provider.Requests, provider.Latency.Milliseconds()))
+	}
+
+	return text.String()
+}
+
+func adminHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if len(WebAppURL) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   providersText() + "\n\nПанель администратора не настроена: не указан WEBAPP_URL.",
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   providersText() + "\n\nПанель администратора:",
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{
+					{
+						Text:   "Открыть панель",
+						WebApp: &models.WebAppInfo{URL: strings.TrimRight(WebAppURL, "/") + "/admin/"},
+					},
+				},
+			},
+		},
+	})
+}
+
+func grantHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	args := strings.Fields(update.Message.Text)
+	if len(args) != 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Использование: /grant <id пользователя> <owner|moderator|analyst>",
+		})
+		return
+	}
+
+	userID, err := strconv.ParseInt(args[1], 10, 64)
+	role := Role(args[2])
+	if _, ok := rolePermissions[role]; err != nil || !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Неверный пользователь или роль.",
+		})
+		return
+	}
+
+	Storage.SetRole(userID, role)
+	publishAdminCommands(ctx, b, userID)
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("Пользователю %d назначена роль %s.", userID, role),
+	})
+}
+
+func revokeHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	args := strings.Fields(update.Message.Text)
+	if len(args) != 2 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Использование: /revoke <id пользователя>",
+		})
+		return
+	}
+
+	userID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Неверный пользователь.",
+		})
+		return
+	}
+
+	text := fmt.Sprintf("Роль пользователя %d отозвана.", userID)
+	if _, ok := AdminRoles[userID]; ok {
+		text = fmt.Sprintf("Роль пользователя %d задана в ADMIN_IDS и не может быть отозвана командой.", userID)
+	} else {
+		Storage.SetRole(userID, "")
+		removeAdminCommands(ctx, b, userID)
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
+
+func rolesHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	roles := Storage.Roles()
+	for userID, role := range AdminRoles {
+		roles[userID] = role
+	}
+
+	ids := []int64{}
+	for userID := range roles {
+		ids = append(ids, userID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var text strings.Builder
+	text.WriteString("Роли:")
+	for _, userID := range ids {
+		text.WriteString(fmt.Sprintf("\n%d: %s", userID, roles[userID]))
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text.String(),
+	})
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Возрастные группы профиля, пустая группа означает взрослого
+const (
+	ageAdult  = "adult"
+	ageChild  = "child"
+	ageInfant = "infant"
+)
+
+// ageGroupRange возраст группы в месяцах: с какого и до какого включительно
+var ageGroupRange = map[string][2]int{
+	ageInfant: {0, 11},
+	ageChild:  {12, 18*12 - 1},
+}
+
+var ageGroupLabels = map[string]string{
+	ageAdult:  "🧑 Взрослый",
+	ageChild:  "🧒 Ребенок",
+	ageInfant: "👶 Младенец до года",
+}
+
+// ageCheckLimit сколько первых аналогов проверяется по возрасту
+const ageCheckLimit = 15
+
+// filterAnalogsByAge убирает аналоги, разрешенные только с возраста старше всей группы профиля,
+// и возвращает число скрытых. Детали аналогов запрашиваются параллельно и кешируются клиентом API
+func filterAnalogsByAge(ctx context.Context, chatID int64, analogs []Analog) ([]Analog, int) {
+	limits, ok := ageGroupRange[profileHealth(chatID).AgeGroup]
+	if !ok || len(analogs) == 0 {
+		return analogs, 0
+	}
+
+	checked := analogs
+	if len(checked) > ageCheckLimit {
+		checked = checked[:ageCheckLimit]
+	}
+	minAges := make([]int, len(checked))
+	var wg sync.WaitGroup
+	for index, analog := range checked {
+		analogID, err := strconv.Atoi(analog.AnalogID)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(index int, analogID int) {
+			defer wg.Done()
+			if details, err := medicineDetails(ctx, analogID); err == nil {
+				minAges[index] = details.MinAgeMonths
+			}
+		}(index, analogID)
+	}
+	wg.Wait()
+
+	suitable := []Analog{}
+	for index, analog := range checked {
+		if minAges[index] > limits[1] {
+			continue
+		}
+		suitable = append(suitable, analog)
+	}
+	hidden := len(checked) - len(suitable)
+
+	return append(suitable, analogs[len(checked):]...), hidden
+}
+
+// ageNote подписывает аналог, который подходит не всей возрастной группе профиля
+func ageNote(ctx context.Context, chatID int64, analog Analog) string {
+	limits, ok := ageGroupRange[profileHealth(chatID).AgeGroup]
+	if !ok {
+		return ""
+	}
+	analogID, err := strconv.Atoi(analog.AnalogID)
+	if err != nil {
+		return ""
+	}
+	details, err := medicineDetails(ctx, analogID)
+	if err != nil || details.MinAgeMonths <= limits[0] {
+		return ""
+	}
+
+	return " · " + formatMinAge(details.MinAgeMonths)
+}
+
+func formatMinAge(months int) string {
+	if months < 12 {
+		return fmt.Sprintf("с %d мес.", months)
+	}
+	years := months / 12
+	if years%10 == 1 && years%100 != 11 {
+		return fmt.Sprintf("с %d года", years)
+	}
+
+	return fmt.Sprintf("с %d лет", years)
+}
+
+// ageHandler /age выбирает возрастную группу профиля для подбора аналогов
+func ageHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        ageStatus(chatID, profileHealth(chatID)),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: ageMarkup(),
+	})
+}
+
+func ageStatus(chatID int64, health ProfileHealth) string {
+	group := health.AgeGroup
+	if len(group) == 0 {
+		group = ageAdult
+	}
+
+	return fmt.Sprintf("%sВозраст: %s\n\nДля ребенка и младенца аналоги, которые разрешены только в более старшем возрасте, скрываются, а подходящие не всем детям подписываются.",
+		escapeHTML(profileLabel(chatID, activeProfile(chatID))), bold(ageGroupLabels[group]))
+}
+
+func ageMarkup() *models.InlineKeyboardMarkup {
+	row := []models.InlineKeyboardButton{}
+	for _, group := range []string{ageAdult, ageChild, ageInfant} {
+		row = append(row, models.InlineKeyboardButton{Text: ageGroupLabels[group], CallbackData: "age:" + group})
+	}
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{row}}
+}
+
+func ageCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	group := strings.TrimPrefix(update.CallbackQuery.Data, "age:")
+	if _, ok := ageGroupRange[group]; !ok {
+		group = ""
+	}
+	health := updateProfileHealth(chatID, func(health *ProfileHealth) {
+		health.AgeGroup = group
+	})
+
+	editCallbackMessage(ctx, b, update.CallbackQuery, ageStatus(chatID, health))
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+//go:embed data/allergens.json
+var embeddedAllergens []byte
+
+// AllergenGroup группа веществ, на которые бывает аллергия, с торговыми названиями
+type AllergenGroup struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords"`
+}
+
+// allergenGroups встроенные группы аллергенов для выбора кнопками
+var allergenGroups = []AllergenGroup{}
+
+func init() {
+	if err := json.Unmarshal(embeddedAllergens, &allergenGroups); err != nil {
+		panic(err)
+	}
+}
+
+// allergyLimit ограничивает число аллергий профиля, allergyNameLimit длину своего вещества
+const (
+	allergyLimit     = 10
+	allergyNameLimit = 20
+)
+
+// allergenKeywords возвращает слова для поиска аллергена: группы или само вещество
+func allergenKeywords(allergy string) (string, []string) {
+	for _, group := range allergenGroups {
+		if group.ID == allergy {
+			return group.Name, group.Keywords
+		}
+	}
+
+	return allergy, []string{allergy}
+}
+
+// matchAllergies возвращает названия аллергий, найденных в тексте с названием и составом лекарства
+func matchAllergies(allergies []string, texts ...string) []string {
+	text := strings.ToLower(strings.Join(texts, " "))
+	found := []string{}
+	for _, allergy := range allergies {
+		name, keywords := allergenKeywords(allergy)
+		for _, keyword := range keywords {
+			if len(keyword) > 0 && strings.Contains(text, strings.ToLower(keyword)) {
+				found = append(found, name)
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+// allergyMarker возвращает ⚠️ для лекарства с компонентом из списка аллергий
+func allergyMarker(allergies []string, texts ...string) string {
+	if len(matchAllergies(allergies, texts...)) > 0 {
+		return "⚠️ "
+	}
+
+	return ""
+}
+
+// allergyWarning предупреждает над списком аналогов, что в составе лекарства есть аллерген
+func allergyWarning(allergies []string, medicineName string, components string) string {
+	found := matchAllergies(allergies, medicineName, components)
+	if len(found) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\n⚠️ %s: %s. Аналоги с тем же составом отмечены ⚠️",
+		bold("Аллергия"), escapeHTML(strings.Join(found, ", ")))
+}
+
+func allergyHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	_, arg, _ := strings.Cut(update.Message.Text, " ")
+	arg = strings.ToLower(strings.TrimSpace(arg))
+
+	health := profileHealth(chatID)
+	if len(arg) > 0 {
+		if utf8.RuneCountInString(arg) > allergyNameLimit || (len(health.Allergies) >= allergyLimit && !health.hasAllergy(arg)) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   fmt.Sprintf("Можно указать до %d аллергий, название вещества не длиннее %d символов.", allergyLimit, allergyNameLimit),
+			})
+			return
+		}
+		health = updateProfileHealth(chatID, func(health *ProfileHealth) {
+			health.toggleAllergy(arg)
+		})
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        allergyStatus(chatID, health),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: allergyMarkup(health),
+	})
+}
+
+func (h ProfileHealth) hasAllergy(allergy string) bool {
+	for _, value := range h.Allergies {
+		if value == allergy {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toggleAllergy добавляет аллергию или убирает уже отмеченную
+func (h *ProfileHealth) toggleAllergy(allergy string) {
+	allergies := []string{}
+	for _, value := range h.Allergies {
+		if value != allergy {
+			allergies = append(allergies, value)
+		}
+	}
+	if len(allergies) == len(h.Allergies) {
+		allergies = append(allergies, allergy)
+	}
+	h.Allergies = allergies
+}
+
+func allergyStatus(chatID int64, health ProfileHealth) string {
+	names := []string{}
+	for _, allergy := range health.Allergies {
+		name, _ := allergenKeywords(allergy)
+		names = append(names, name)
+	}
+	status := "не указаны"
+	if len(names) > 0 {
+		status = strings.Join(names, ", ")
+	}
+
+	return fmt.Sprintf("%sАллергии: %s\n\nЛекарства и аналоги с этими веществами будут отмечены ⚠️. Отметьте группу кнопкой "+
+		"или отправьте /allergy название вещества. Повторное нажатие или команда снимает отметку.",
+		escapeHTML(profileLabel(chatID, activeProfile(chatID))), bold(status))
+}
+
+func allergyMarkup(health ProfileHealth) *models.InlineKeyboardMarkup {
+	selected := map[string]bool{}
+	for _, allergy := range health.Allergies {
+		selected[allergy] = true
+	}
+
+	buttons := [][]models.InlineKeyboardButton{}
+	for _, group := range allergenGroups {
+		title := group.Name
+		if selected[group.ID] {
+			title = "✅ " + title
+		}
+		buttons = append(buttons, []models.InlineKeyboardButton{{Text: title, CallbackData: "allergy:" + group.ID}})
+	}
+	for _, allergy := range health.Allergies {
+		if name, keywords := allergenKeywords(allergy); len(keywords) == 1 && keywords[0] == name {
+			buttons = append(buttons, []models.InlineKeyboardButton{{Text: "✅ " + name, CallbackData: "allergy:" + allergy}})
+		}
+	}
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+func allergyCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	query := update.CallbackQuery
+	chatID := callbackChatID(query)
+	allergy := strings.TrimPrefix(query.Data, "allergy:")
+	health := updateProfileHealth(chatID, func(health *ProfileHealth) {
+		health.toggleAllergy(allergy)
+	})
+
+	if query.Message.Message == nil {
+		return
+	}
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   query.Message.Message.ID,
+		Text:        allergyStatus(chatID, health),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: allergyMarkup(health),
+	})
+	if err != nil {
+		logError(err)
+	}
+}
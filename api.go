@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RestAPIEnabled включает публичный JSON API на HTTP_ADDR
+var RestAPIEnabled bool
+
+// APILimiter ограничивает запросы к API с одного адреса
+var APILimiter = NewRateLimiter(60, 10)
+
+type APIAnalogs struct {
+	Medicine  MedicineInfo `json:"medicine"`
+	CountryID int          `json:"country_id"`
+	Analogs   []Analog     `json:"analogs"`
+}
+
+// registerRestAPI подключает маршруты /api/medicines и /api/medicines/{id}/analogs
+func registerRestAPI(mux *http.ServeMux) {
+	mux.HandleFunc("/api/medicines", limitAPI(apiMedicinesHandler))
+	mux.HandleFunc("/api/medicines/", limitAPI(apiAnalogsHandler))
+}
+
+// clientAddress возвращает адрес клиента для ограничения частоты запросов
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// limitAPI пропускает только GET запросы с действующим ключом или адресом в пределах ограничения частоты
+func limitAPI(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+		if !checkAPIAccess(w, r) {
+			return
+		}
+		AppMetrics.Incr("rest_api_requests")
+		next(w, r)
+	}
+}
+
+// apiMedicinesHandler GET /api/medicines?q=нурофен
+func apiMedicinesHandler(w http.ResponseWriter, r *http.Request) {
+	medicines, err := findMedicines(r.Context(), "api", r.URL.Query().Get("q"))
+	if err != nil {
+		writeUseCaseError(w, err)
+		return
+	}
+
+	writeJSON(w, medicines)
+}
+
+// apiAnalogsHandler GET /api/medicines/{id}/analogs?country=TH, страна кодом или идентификатором
+func apiAnalogsHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/medicines/")
+	value, ok := strings.CutSuffix(path, "/analogs")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	medicineID, err := strconv.Atoi(value)
+	if err != nil || medicineID <= 0 {
+		http.Error(w, "неверный идентификатор лекарства", http.StatusBadRequest)
+		return
+	}
+
+	countryID, ok := parseCountryParam(r.URL.Query().Get("country"))
+	if !ok {
+		http.Error(w, "неизвестная страна", http.StatusBadRequest)
+		return
+	}
+
+	result, err := findAnalogsContext(r.Context(), medicineID, countryID)
+	if err != nil {
+		writeUseCaseError(w, err)
+		return
+	}
+
+	writeJSON(w, APIAnalogs{
+		Medicine:  result.Medicine,
+		CountryID: result.CountryID,
+		Analogs:   result.Analogs,
+	})
+}
+
+// writeUseCaseError отвечает кодом HTTP, соответствующим ошибке сценария поиска
+func writeUseCaseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errSearchDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, errShortQuery):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errSensitiveQuery):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+// parseCountryParam понимает код страны из COUNTRIES или ее идентификатор, по умолчанию TARGET_COUNTRY_ID
+func parseCountryParam(value string) (int, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return TargetCountryID, true
+	}
+	if country, ok := countryByCode(value); ok {
+		return country.ID, true
+	}
+	if id, err := strconv.Atoi(value); err == nil && id > 0 {
+		return id, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// apiKeyPrefix начало выданных ключей, по нему ключи легко найти в логах и репозиториях
+	apiKeyPrefix = "pk_"
+	// apiAbuseLimit отклоненных запросов за apiAbuseWindow считаются злоупотреблением
+	apiAbuseLimit  = 100
+	apiAbuseWindow = 10 * time.Minute
+	// apiAddressBlock блокировка адреса без ключа за злоупотребление
+	apiAddressBlock = time.Hour
+	// apiKeysListLimit ключей в списке администратора
+	apiKeysListLimit = 50
+	// apiKeyUsageSave как часто счетчики запросов ключей записываются на диск
+	apiKeyUsageSave = time.Minute
+)
+
+// APIKey ключ REST API. Хранится только хэш ключа, сам ключ показывается владельцу один раз
+type APIKey struct {
+	Hash      string    `json:"hash"`
+	ID        string    `json:"id"`
+	OwnerID   int64     `json:"owner_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UsedAt    time.Time `json:"used_at,omitempty"`
+	Requests  int       `json:"requests"`
+	// BlockedAt время блокировки за злоупотребление или администратором, BlockReason ее причина
+	BlockedAt   time.Time `json:"blocked_at,omitempty"`
+	BlockReason string    `json:"block_reason,omitempty"`
+}
+
+func (k APIKey) Blocked() bool {
+	return !k.BlockedAt.IsZero()
+}
+
+// APIKeysRequired требует ключ для REST API из API_KEYS_REQUIRED, без него ограничение
+// считается по адресу клиента
+var APIKeysRequired bool
+
+// APIKeyLimiter ограничивает запросы по одному ключу, настраивается API_KEY_RATE_LIMIT
+var APIKeyLimiter = NewRateLimiter(120, 20)
+
+// APIAbuse считает отклоненные запросы ключей и адресов
+var APIAbuse = &abuseTracker{rejected: map[string][]time.Time{}, blocked: map[string]time.Time{}}
+
+type abuseTracker struct {
+	mu       sync.Mutex
+	rejected map[string][]time.Time
+	// blocked временные блокировки адресов без ключа
+	blocked map[string]time.Time
+}
+
+// Reject учитывает отклоненный запрос source и сообщает, что порог злоупотребления превышен
+func (t *abuseTracker) Reject(source string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	times := t.rejected[source]
+	kept := times[:0]
+	for _, rejected := range times {
+		if now.Sub(rejected) < apiAbuseWindow {
+			kept = append(kept, rejected)
+		}
+	}
+	kept = append(kept, now)
+	if len(kept) < apiAbuseLimit {
+		t.rejected[source] = kept
+		return false
+	}
+
+	delete(t.rejected, source)
+	return true
+}
+
+// Block блокирует адрес до until
+func (t *abuseTracker) Block(address string, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.blocked[address] = until
+}
+
+func (t *abuseTracker) Blocked(address string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	until, ok := t.blocked[address]
+	if ok && now.After(until) {
+		delete(t.blocked, address)
+		return false
+	}
+
+	return ok
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// newAPIKey создает ключ, ID первые символы ключа для списков и команд администратора
+func newAPIKey(ownerID int64, now time.Time) (string, APIKey) {
+	key := apiKeyPrefix + newShareToken() + newShareToken()
+
+	return key, APIKey{
+		Hash:      hashAPIKey(key),
+		ID:        key[:len(apiKeyPrefix)+8],
+		OwnerID:   ownerID,
+		CreatedAt: now,
+	}
+}
+
+// requestAPIKey достает ключ из X-API-Key или Authorization: Bearer
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); len(key) > 0 {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
+// checkAPIAccess проверяет ключ или адрес клиента, ограничение частоты и блокировки.
+// Превышение частоты засчитывается в злоупотребление, после порога ключ блокируется в Store,
+// а адрес без ключа временно
+func checkAPIAccess(w http.ResponseWriter, r *http.Request) bool {
+	now := time.Now()
+	key := requestAPIKey(r)
+	if len(key) == 0 {
+		address := clientAddress(r)
+		if APIKeysRequired {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "нужен ключ API, получите его командой /apikey в боте", http.StatusUnauthorized)
+			return false
+		}
+		if APIAbuse.Blocked(address, now) {
+			http.Error(w, "адрес заблокирован за превышение ограничений", http.StatusForbidden)
+			return false
+		}
+		if !APILimiter.Allow(address) {
+			if APIAbuse.Reject("ip:"+address, now) {
+				APIAbuse.Block(address, now.Add(apiAddressBlock))
+				AppMetrics.Incr("api_addresses_blocked")
+			}
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "слишком много запросов", http.StatusTooManyRequests)
+			return false
+		}
+		return true
+	}
+
+	apiKey, ok := Storage.UseAPIKey(hashAPIKey(key), now)
+	if !ok {
+		http.Error(w, "неверный ключ API", http.StatusUnauthorized)
+		return false
+	}
+	if apiKey.Blocked() {
+		http.Error(w, "ключ API заблокирован: "+apiKey.BlockReason, http.StatusForbidden)
+		return false
+	}
+	if !APIKeyLimiter.Allow(apiKey.ID) {
+		if APIAbuse.Reject("key:"+apiKey.ID, now) {
+			Storage.BlockAPIKey(apiKey.ID, "превышение ограничений частоты", now)
+			AppMetrics.Incr("api_keys_blocked")
+		}
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "слишком много запросов", http.StatusTooManyRequests)
+		return false
+	}
+	AppMetrics.Incr("rest_api_key_requests")
+
+	return true
+}
+
+// apiKeyHandler выдает ключ REST API: /apikey показывает ключи, /apikey new создает ключ
+// вместо прежних, /apikey revoke удаляет ключи пользователя
+func apiKeyHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message.From == nil || update.Message.Chat.Type != models.ChatTypePrivate {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Ключ API можно получить в личном чате с ботом.",
+		})
+		return
+	}
+	if !RestAPIEnabled {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "REST API не включен.",
+		})
+		return
+	}
+
+	userID := update.Message.From.ID
+	_, arg, _ := strings.Cut(update.Message.Text, " ")
+	switch strings.TrimSpace(arg) {
+	case "new":
+		key, apiKey := newAPIKey(userID, time.Now())
+		if !Storage.IssueAPIKey(apiKey) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: update.Message.Chat.ID,
+				Text:   "Ваш ключ заблокирован, новый ключ выдать нельзя. Напишите в /feedback.",
+			})
+			return
+		}
+		AppMetrics.Incr("api_keys_issued")
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text: fmt.Sprintf("Ваш ключ API:\n<code>%s</code>\n\nПередавайте его в заголовке X-API-Key. "+
+				"Ключ показывается один раз, прежние ключи больше не действуют.", key),
+			ParseMode: models.ParseModeHTML,
+		})
+		return
+	case "revoke":
+		Storage.RevokeAPIKeys(userID)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Ваши ключи API удалены.",
+		})
+		return
+	}
+
+	keys := Storage.APIKeys(userID)
+	if len(keys) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "У вас нет ключа API. Создать: /apikey new",
+		})
+		return
+	}
+
+	var text strings.Builder
+	for _, key := range keys {
+		text.WriteString(formatAPIKey(key) + "\n")
+	}
+	text.WriteString("\nНовый ключ: /apikey new\nУдалить: /apikey revoke")
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text.String(),
+	})
+}
+
+func formatAPIKey(key APIKey) string {
+	text := fmt.Sprintf("%s… — запросов: %d", key.ID, key.Requests)
+	if !key.UsedAt.IsZero() {
+		text += ", последний " + key.UsedAt.Format("02.01.2006 15:04")
+	}
+	if key.Blocked() {
+		text += fmt.Sprintf(", заблокирован %s: %s", key.BlockedAt.Format("02.01.2006"), key.BlockReason)
+	}
+
+	return text
+}
+
+// apiKeysAdminHandler список ключей и блокировка: /apikeys [block|unblock <id>]
+func apiKeysAdminHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	args := strings.Fields(update.Message.Text)
+	if len(args) == 3 && (args[1] == "block" || args[1] == "unblock") {
+		reason := ""
+		if args[1] == "block" {
+			reason = "заблокирован администратором"
+		}
+		text := "Ключ не найден."
+		if Storage.BlockAPIKey(args[2], reason, time.Now()) {
+			text = fmt.Sprintf("Ключ %s: %s.", args[2], map[string]string{"block": "заблокирован", "unblock": "разблокирован"}[args[1]])
+		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   text,
+		})
+		return
+	}
+
+	keys := Storage.APIKeys(0)
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Ключей API: %d\n", len(keys)))
+	for index, key := range keys {
+		if index == apiKeysListLimit {
+			text.WriteString("…\n")
+			break
+		}
+		text.WriteString(fmt.Sprintf("%d: %s\n", key.OwnerID, formatAPIKey(key)))
+	}
+	text.WriteString("\n/apikeys block <id>, /apikeys unblock <id>")
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text.String(),
+	})
+}
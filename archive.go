@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// tripsArchiveLimit количество прошлых поездок в /trips
+const tripsArchiveLimit = 10
+
+// pastTrips возвращает завершенные поездки чата, новые первыми
+func pastTrips(chatID int64) []Trip {
+	trips := []Trip{}
+	for _, trip := range Storage.Trips(chatID) {
+		if trip.Ended {
+			trips = append(trips, trip)
+		}
+	}
+	sort.Slice(trips, func(i, j int) bool {
+		return trips[i].Start > trips[j].Start
+	})
+
+	return trips
+}
+
+// previousTrip возвращает последнюю завершенную поездку в ту же страну со списком в дорогу
+func previousTrip(trip Trip) (Trip, bool) {
+	for _, past := range pastTrips(trip.ChatID) {
+		if past.CountryID == trip.CountryID && len(past.Checklist) > 0 {
+			return past, true
+		}
+	}
+
+	return Trip{}, false
+}
+
+func tripsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	trips := pastTrips(chatID)
+	if len(trips) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Завершенных поездок пока нет. Запланировать поездку можно командой /trip.",
+		})
+		return
+	}
+
+	buttons := [][]models.InlineKeyboardButton{}
+	for index, trip := range trips {
+		if index == tripsArchiveLimit {
+			break
+		}
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: trip.CountryName() + ", " + trip.Dates(), CallbackData: "trip_view:" + strconv.Itoa(trip.ID)},
+		})
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "Прошлые поездки. Выберите поездку, чтобы посмотреть найденные аналоги, поиски и покупки:",
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: buttons,
+		},
+	})
+}
+
+// formatTripArchive показывает аналоги, поиски и покупки завершенной поездки такими, какими они были тогда
+func formatTripArchive(trip Trip) string {
+	var text strings.Builder
+	text.WriteString(bold(fmt.Sprintf("Поездка в %s, %s", trip.CountryName(), trip.Dates())) + "\n")
+
+	if len(trip.Checklist) > 0 {
+		text.WriteString("\n" + bold("Аналоги") + "\n")
+		for _, item := range trip.Checklist {
+			if len(item.AnalogName) == 0 {
+				text.WriteString(fmt.Sprintf("%s — аналог не найден\n", escapeHTML(item.Medicine)))
+				continue
+			}
+			text.WriteString(fmt.Sprintf("%s → %s (%d%%)\n", escapeHTML(item.Medicine), link(item.AnalogName, item.AnalogURL), item.Percentage))
+		}
+	}
+
+	if len(trip.History) > 0 {
+		names := []string{}
+		seen := map[string]bool{}
+		for _, entry := range trip.History {
+			name := entry.MedicineName
+			if len(name) == 0 {
+				name = entry.Query
+			}
+			if len(name) == 0 || seen[strings.ToLower(name)] {
+				continue
+			}
+			seen[strings.ToLower(name)] = true
+			names = append(names, escapeHTML(name))
+		}
+		text.WriteString("\n" + bold("Поиски") + "\n" + strings.Join(names, ", ") + "\n")
+	}
+
+	if len(trip.Expenses) > 0 {
+		text.WriteString("\n" + formatExpenses(trip) + "\n")
+	}
+
+	if len(trip.Checklist) == 0 && len(trip.History) == 0 && len(trip.Expenses) == 0 {
+		text.WriteString("\nВ этой поездке ничего не сохранено.")
+	}
+
+	return strings.TrimSpace(text.String())
+}
+
+func tripViewHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	trip, ok := tripCallback(update)
+	if !ok {
+		return
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             callbackChatID(update.CallbackQuery),
+		Text:               formatTripArchive(trip),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+// tripReuseHandler переносит список в дорогу из прошлой поездки в новую: trip_reuse:<новая>:<прошлая>
+func tripReuseHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	parts := strings.Split(update.CallbackQuery.Data, ":")
+	if len(parts) != 3 {
+		return
+	}
+	tripID, _ := strconv.Atoi(parts[1])
+	pastID, _ := strconv.Atoi(parts[2])
+	chatID := callbackChatID(update.CallbackQuery)
+
+	trip, ok := Storage.Trip(tripID)
+	if !ok || trip.ChatID != chatID {
+		return
+	}
+	past, ok := Storage.Trip(pastID)
+	if !ok || past.ChatID != chatID {
+		return
+	}
+
+	checklist := []ChecklistItem{}
+	for _, item := range past.Checklist {
+		item.Packed = false
+		item.ClaimedBy = 0
+		item.ClaimedName = ""
+		checklist = append(checklist, item)
+	}
+
+	trip, _ = Storage.UpdateTrip(trip.ID, func(trip *Trip) {
+		trip.Checklist = checklist
+		trip.ChecklistMessages = nil
+		trip.Rechecked = false
+	})
+
+	AppMetrics.Incr("trip_reuses")
+
+	sendChecklist(ctx, b, trip, chatID)
+}
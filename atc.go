@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+//go:embed data/atc.json
+var embeddedATC []byte
+
+// ATCSubstance действующее вещество с кодом ATC и установленной суточной дозой DDD.
+// INN международное непатентованное название, Name оно же по-русски,
+// Keywords названия вещества и известных торговых марок
+type ATCSubstance struct {
+	Code     string   `json:"code"`
+	INN      string   `json:"inn"`
+	Name     string   `json:"name"`
+	DDD      string   `json:"ddd,omitempty"`
+	Keywords []string `json:"keywords"`
+}
+
+// ATCIndex классификация ATC/DDD ВОЗ. Groups названия групп по коду первого и третьего уровня
+type ATCIndex struct {
+	Groups     map[string]string `json:"groups"`
+	Substances []ATCSubstance    `json:"substances"`
+}
+
+// ATC классификация из data/atc.json или ATC_FILE, работает без API
+var ATC = ATCIndex{}
+
+// atcClassLength длина кода терапевтической группы, по ней группируются аналоги
+const atcClassLength = 4
+
+func loadATC(path string) error {
+	body := embeddedATC
+	if len(path) > 0 {
+		file, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		body = file
+	}
+
+	index := ATCIndex{}
+	if err := json.Unmarshal(body, &index); err != nil {
+		return err
+	}
+	ATC = index
+
+	return nil
+}
+
+// atcWords приводит текст к словам через пробел, чтобы искать ключевые слова с начала слова
+func atcWords(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '+'
+	})
+
+	return " " + strings.Join(words, " ")
+}
+
+// Classify находит вещество по названию и составу лекарства. Комбинации в файле идут раньше
+// отдельных веществ, поэтому выигрывает первое совпадение
+func (i ATCIndex) Classify(texts ...string) (ATCSubstance, bool) {
+	text := atcWords(strings.Join(texts, " "))
+	for _, substance := range i.Substances {
+		for _, keyword := range substance.Keywords {
+			if len(keyword) > 0 && strings.Contains(text, atcWords(keyword)) {
+				return substance, true
+			}
+		}
+	}
+
+	return ATCSubstance{}, false
+}
+
+// Class код и название терапевтической группы вещества, для неизвестной группы название
+// анатомической группы первого уровня
+func (i ATCIndex) Class(code string) (string, string) {
+	if len(code) < atcClassLength {
+		return code, i.Groups[code]
+	}
+	class := code[:atcClassLength]
+	if name, ok := i.Groups[class]; ok {
+		return class, name
+	}
+
+	return class, i.Groups[code[:1]]
+}
+
+// atcLine строка классификации лекарства для списка аналогов и описания
+func atcLine(substance ATCSubstance) string {
+	_, class := ATC.Class(substance.Code)
+	line := fmt.Sprintf("🏷 ATC %s, %s", substance.Code, substance.Name)
+	if len(class) > 0 {
+		line += " — " + class
+	}
+	if len(substance.DDD) > 0 {
+		line += ", DDD " + substance.DDD
+	}
+
+	return escapeHTML(line)
+}
+
+// atcSummary классифицирует лекарство и, если аналоги относятся к разным терапевтическим
+// группам, перечисляет аналоги по группам, начиная с группы самого лекарства
+func atcSummary(ctx context.Context, info MedicineInfo, analogs []Analog) string {
+	substance, ok := ATC.Classify(info.MedicineName, medicineComponents(ctx, info))
+	text := ""
+	medicineClass := ""
+	if ok {
+		AppMetrics.Incr("atc_classified")
+		text = "\n" + atcLine(substance)
+		medicineClass, _ = ATC.Class(substance.Code)
+	}
+
+	classes := []string{}
+	names := map[string][]string{}
+	for index, analog := range analogs {
+		if index == 10 {
+			break
+		}
+		found, ok := ATC.Classify(analog.AnalogName)
+		if !ok {
+			continue
+		}
+		class, _ := ATC.Class(found.Code)
+		if _, seen := names[class]; !seen {
+			classes = append(classes, class)
+		}
+		names[class] = append(names[class], analog.AnalogName)
+	}
+	// Группы не нужны, если все аналоги из группы самого лекарства
+	if len(classes) == 0 || len(classes) == 1 && (classes[0] == medicineClass || len(medicineClass) == 0) {
+		return text
+	}
+
+	ordered := []string{}
+	if _, ok := names[medicineClass]; ok {
+		ordered = append(ordered, medicineClass)
+	}
+	for _, class := range classes {
+		if class != medicineClass {
+			ordered = append(ordered, class)
+		}
+	}
+
+	text += "\n" + bold("Аналоги по группам ATC:")
+	for _, class := range ordered {
+		_, name := ATC.Class(class)
+		text += fmt.Sprintf("\n%s %s: %s", escapeHTML(class), escapeHTML(name), escapeHTML(strings.Join(names[class], ", ")))
+	}
+
+	return text
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+const (
+	// auditChangeShare доля исчезнувших и новых аналогов, начиная с которой расхождение считается большим
+	auditChangeShare = 0.5
+	// auditPercentageDrift изменение процента совпадения лучшего аналога, о котором стоит сообщить
+	auditPercentageDrift = 20
+	// auditReportLimit сколько расхождений попадает в отчет
+	auditReportLimit = 20
+)
+
+// AuditFinding расхождение между закэшированными и свежими аналогами лекарства
+type AuditFinding struct {
+	MedicineID   int
+	MedicineName string
+	CountryID    int
+	// Vanished API больше не знает лекарства или не находит ему аналогов
+	Vanished bool
+	Removed  int
+	Added    int
+	Total    int
+	// BestBefore и BestAfter лучший аналог до и после, если он сменился или его процент заметно изменился
+	BestBefore Analog
+	BestAfter  Analog
+}
+
+// diffAnalogs сравнивает прежние аналоги с ответом API, false если расхождение небольшое
+func diffAnalogs(cached pills.CachedAnalogs, analogs []Analog, info MedicineInfo) (AuditFinding, bool) {
+	finding := AuditFinding{
+		MedicineID:   cached.MedicineID,
+		MedicineName: cached.Info.MedicineName,
+		CountryID:    cached.CountryID,
+		Total:        len(cached.Analogs),
+	}
+	if len(cached.Analogs) > 0 && len(analogs) == 0 || len(cached.Info.MedicineName) > 0 && len(info.MedicineName) == 0 {
+		finding.Vanished = true
+		return finding, true
+	}
+
+	before := map[string]bool{}
+	for _, analog := range cached.Analogs {
+		before[analog.AnalogID] = true
+	}
+	after := map[string]bool{}
+	for _, analog := range analogs {
+		after[analog.AnalogID] = true
+		if !before[analog.AnalogID] {
+			finding.Added++
+		}
+	}
+	for id := range before {
+		if !after[id] {
+			finding.Removed++
+		}
+	}
+
+	large := false
+	if total := len(before) + finding.Added; total > 0 {
+		large = float64(finding.Removed+finding.Added)/float64(total) >= auditChangeShare
+	}
+	bestBefore, okBefore := pills.BestAnalog(cached.Analogs)
+	bestAfter, okAfter := pills.BestAnalog(analogs)
+	if okBefore && okAfter {
+		drift := bestBefore.Percentage - bestAfter.Percentage
+		if drift < 0 {
+			drift = -drift
+		}
+		if bestBefore.AnalogID != bestAfter.AnalogID || drift >= auditPercentageDrift {
+			finding.BestBefore, finding.BestAfter = bestBefore, bestAfter
+			large = true
+		}
+	}
+
+	return finding, large
+}
+
+// runAudit заново запрашивает у API sample случайных наборов аналогов из кэша и сравнивает
+// их с прежними. Свежие ответы заменяют прежние в кэше
+func runAudit(ctx context.Context, sample int) (int, []AuditFinding) {
+	cached := API.CachedAnalogs()
+	rand.Shuffle(len(cached), func(i, j int) {
+		cached[i], cached[j] = cached[j], cached[i]
+	})
+	if len(cached) > sample {
+		cached = cached[:sample]
+	}
+
+	checked := 0
+	findings := []AuditFinding{}
+	for _, item := range cached {
+		if ctx.Err() != nil {
+			break
+		}
+		analogs, info, err := API.RefreshAnalogs(ctx, item.MedicineID, item.CountryID)
+		if err != nil {
+			// Без ответа API сравнивать не с чем, бюджет или сеть проверим в следующий раз
+			logError(err)
+			break
+		}
+		checked++
+		if finding, ok := diffAnalogs(item, analogs, info); ok {
+			AppMetrics.Incr("audit_discrepancies")
+			findings = append(findings, finding)
+		}
+	}
+
+	return checked, findings
+}
+
+// formatAudit отчет о проверке для чата администраторов
+func formatAudit(checked int, findings []AuditFinding) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Проверка данных: наборов аналогов %d, расхождений %d", checked, len(findings)))
+	for index, finding := range findings {
+		if index == auditReportLimit {
+			text.WriteString(fmt.Sprintf("\n… и еще %d", len(findings)-auditReportLimit))
+			break
+		}
+		country := fmt.Sprint(finding.CountryID)
+		if found, ok := countryByID(finding.CountryID); ok {
+			country = found.Name
+		}
+		text.WriteString(fmt.Sprintf("\n• %s (%d), %s: ", finding.MedicineName, finding.MedicineID, country))
+		if finding.Vanished {
+			text.WriteString("лекарство или аналоги пропали из API")
+			continue
+		}
+		changes := []string{}
+		if finding.Removed > 0 || finding.Added > 0 {
+			changes = append(changes, fmt.Sprintf("убрано %d, добавлено %d из %d", finding.Removed, finding.Added, finding.Total))
+		}
+		if len(finding.BestBefore.AnalogID) > 0 {
+			changes = append(changes, fmt.Sprintf("лучший аналог %s %d%% → %s %d%%",
+				finding.BestBefore.AnalogName, finding.BestBefore.Percentage, finding.BestAfter.AnalogName, finding.BestAfter.Percentage))
+		}
+		text.WriteString(strings.Join(changes, ", "))
+	}
+	if len(findings) > 0 {
+		text.WriteString("\n\nОшибочные аналоги можно скрыть или исправить: /override")
+	}
+
+	return text.String()
+}
+
+// auditJob каждую ночь в AUDIT_TIME по часовому поясу чата администраторов проверяет sample
+// наборов аналогов из кэша и отправляет отчет, если нашлись расхождения
+func auditJob(b *bot.Bot, at string, sample int) Job {
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		clock, _ = time.Parse("15:04", "04:00")
+	}
+
+	return Job{
+		Name: "audit",
+		Next: dailyAt(clock.Hour(), clock.Minute(), func() *time.Location {
+			return chatLocation(AdminChatID)
+		}),
+		Run: func(ctx context.Context) {
+			checked, findings := runAudit(ctx, sample)
+			if AdminChatID == 0 || len(findings) == 0 {
+				return
+			}
+
+			text := formatAudit(checked, findings)
+			runOutsideQuietHours(ctx, AdminChatID, func(ctx context.Context) {
+				_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: AdminChatID,
+					Text:   text,
+				})
+				if err != nil {
+					logError(err)
+				}
+			})
+		},
+	}
+}
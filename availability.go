@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// liveSource проверяет, что ответ пришел от API. Снимок и запасной файл бывают старше
+// прошлой проверки, и отсутствие аналога в них не значит, что он пропал из продажи
+func liveSource(info MedicineInfo) bool {
+	return len(info.Source) == 0
+}
+
+// vanishedAnalogs аналоги, которых больше нет в списке страны поиска: сняты с продажи
+// или исключены из реестра. Пропавшими считаются только аналоги, которых нет в ответе API
+func vanishedAnalogs(before []Analog, after []Analog, info MedicineInfo) []Analog {
+	if !liveSource(info) {
+		return []Analog{}
+	}
+	current := map[string]bool{}
+	for _, analog := range after {
+		current[analog.AnalogID] = true
+	}
+	removed := []Analog{}
+	for _, analog := range before {
+		if !current[analog.AnalogID] {
+			removed = append(removed, analog)
+		}
+	}
+
+	return removed
+}
+
+// availabilityText сообщает о пропавших аналогах и предлагает лучший из оставшихся
+func availabilityText(removed []Analog, after []Analog) string {
+	names := []string{}
+	for _, analog := range removed {
+		names = append(names, analog.AnalogName)
+	}
+	text := "➖ Больше нет в продаже: " + escapeHTML(strings.Join(names, ", "))
+
+	best, ok := pills.BestAnalog(after)
+	if !ok {
+		return text + "\nДругих аналогов в стране сейчас нет."
+	}
+
+	return text + fmt.Sprintf("\n🔁 Лучшая замена сейчас: %s (%s%%)", link(best.AnalogName, analogURL(best)), strconv.Itoa(best.Percentage))
+}
+
+// availablePriceAlerts оставляет целевые цены только для аналогов, которые еще продаются.
+// Без ответа API цели не снимаются
+func availablePriceAlerts(alerts []PriceAlert, analogs []Analog, info MedicineInfo) []PriceAlert {
+	if len(alerts) == 0 || !liveSource(info) {
+		return alerts
+	}
+	current := map[string]bool{}
+	for _, analog := range analogs {
+		current[analog.AnalogID] = true
+	}
+	kept := []PriceAlert{}
+	for _, alert := range alerts {
+		if current[alert.AnalogID] {
+			kept = append(kept, alert)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// BarcodeScanner находит штрихкоды на изображении и возвращает их содержимое
+type BarcodeScanner interface {
+	Scan(ctx context.Context, image []byte) ([]string, error)
+}
+
+// ExecScanner запускает внешнюю программу, которая читает изображение из stdin
+// и печатает по одному коду на строку, например zbarimg или dmtxread
+type ExecScanner struct {
+	Binary string
+	Args   []string
+}
+
+func (s *ExecScanner) Scan(ctx context.Context, image []byte) ([]string, error) {
+	cmd := exec.CommandContext(ctx, s.Binary, s.Args...)
+	cmd.Stdin = bytes.NewReader(image)
+
+	output, err := cmd.Output()
+	// zbarimg завершается с кодом 4, если на изображении нет кодов
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(output) == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Binary, err)
+	}
+
+	codes := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			codes = append(codes, line)
+		}
+	}
+
+	return codes, nil
+}
+
+// MultiScanner опрашивает несколько сканеров и объединяет найденные коды
+type MultiScanner []BarcodeScanner
+
+func (m MultiScanner) Scan(ctx context.Context, image []byte) ([]string, error) {
+	codes := []string{}
+	var lastErr error
+	for _, scanner := range m {
+		found, err := scanner.Scan(ctx, image)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		codes = append(codes, found...)
+	}
+	if len(codes) == 0 {
+		return nil, lastErr
+	}
+
+	return codes, nil
+}
+
+// newBarcodeScanner создает сканер по списку программ из BARCODE_SCANNERS,
+// nil если сканирование отключено
+func newBarcodeScanner(names string) BarcodeScanner {
+	scanners := MultiScanner{}
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "zbar":
+			scanners = append(scanners, &ExecScanner{Binary: "zbarimg", Args: []string{"--raw", "-q", "-"}})
+		case "dmtx":
+			scanners = append(scanners, &ExecScanner{Binary: "dmtxread", Args: []string{"-n", "-m", "2000"}})
+		}
+	}
+	if len(scanners) == 0 {
+		return nil
+	}
+
+	return scanners
+}
+
+// parseGTIN извлекает GTIN-14 из EAN-13 или из строки GS1 DataMatrix
+// с идентификатором применения (01)
+func parseGTIN(code string) (string, bool) {
+	code = strings.TrimPrefix(code, "]d2")
+	code = strings.TrimLeft(code, "\x1d")
+
+	var gtin string
+	switch {
+	case len(code) == 13 && isDigits(code):
+		gtin = "0" + code
+	case len(code) >= 16 && strings.HasPrefix(code, "01") && isDigits(code[2:16]):
+		gtin = code[2:16]
+	default:
+		return "", false
+	}
+
+	return gtin, validGTIN(gtin)
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return len(text) > 0
+}
+
+// validGTIN проверяет контрольную цифру GTIN-14
+func validGTIN(gtin string) bool {
+	sum := 0
+	for i := 0; i < 13; i++ {
+		digit := int(gtin[i] - '0')
+		if i%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	return (10-sum%10)%10 == int(gtin[13]-'0')
+}
+
+// GTINEntry описывает лекарство в справочнике штрихкодов. Country код страны, для
+// которой выпущена упаковка, MedicineID лекарство в домашней стране, если оно известно
+type GTINEntry struct {
+	MedicineID   int    `json:"medicine_id,omitempty"`
+	MedicineName string `json:"name"`
+	Country      string `json:"country,omitempty"`
+}
+
+// GTINTable справочник штрихкодов, ключ GTIN-14
+type GTINTable map[string]GTINEntry
+
+// LoadGTINTable читает CSV файл со строками gtin,name[,medicine_id[,country]]
+func LoadGTINTable(path string) (GTINTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseGTINTable(file)
+}
+
+func parseGTINTable(source io.Reader) (GTINTable, error) {
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = -1
+
+	table := GTINTable{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			continue
+		}
+
+		gtin := strings.TrimSpace(record[0])
+		if len(gtin) == 13 {
+			gtin = "0" + gtin
+		}
+		if len(gtin) != 14 || !isDigits(gtin) {
+			continue
+		}
+
+		entry := GTINEntry{MedicineName: strings.TrimSpace(record[1])}
+		if len(record) > 2 {
+			entry.MedicineID, _ = strconv.Atoi(strings.TrimSpace(record[2]))
+		}
+		if len(record) > 3 {
+			entry.Country = strings.ToUpper(strings.TrimSpace(record[3]))
+		}
+		table[gtin] = entry
+	}
+
+	return table, nil
+}
+
+// handleBarcode ищет на фотографии штрихкод и показывает аналоги найденного лекарства,
+// возвращает false, если лекарство по коду определить не удалось
+func handleBarcode(ctx context.Context, b *bot.Bot, message *models.Message, image []byte) bool {
+	codes, err := Barcodes.Scan(ctx, image)
+	if err != nil {
+		logError(err)
+		return false
+	}
+
+	for _, code := range codes {
+		gtin, ok := parseGTIN(code)
+		if !ok {
+			continue
+		}
+
+		AppMetrics.Incr("barcode_scans")
+
+		entry, ok := resolveGTIN(ctx, gtin)
+		if !ok {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    message.Chat.ID,
+				Text:      fmt.Sprintf("Штрихкод %s не найден в справочнике, попробую прочитать название с упаковки.", bold(gtin)),
+				ParseMode: models.ParseModeHTML,
+			})
+			return false
+		}
+
+		medicineID := entry.MedicineID
+		if medicineID == 0 {
+			medicineID = findMedicineID(ctx, entry.MedicineName)
+		}
+		if medicineID == 0 {
+			sendMedicineSearch(ctx, b, message.Chat.ID, message.From, gtinQuery(ctx, b, message.Chat.ID, entry))
+			return true
+		}
+
+		sendAnalogs(ctx, b, message.Chat.ID, message.From, medicineID)
+		return true
+	}
+
+	return false
+}
+
+// findMedicineID ищет лекарство с точным совпадением названия
+func findMedicineID(ctx context.Context, name string) int {
+	medicines, err := searchMedicinesContext(ctx, name)
+	if err != nil {
+		return 0
+	}
+
+	for _, medicine := range medicines {
+		if strings.EqualFold(medicine.Name, name) {
+			medicineID, _ := strconv.Atoi(medicine.ID)
+			return medicineID
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+)
+
+// APIMonthlyBudget число запросов к API в месяц из API_MONTHLY_BUDGET, 0 отключает учет бюджета
+var APIMonthlyBudget int
+
+// APIRequestCost стоимость одного запроса к API для оценки расходов из API_REQUEST_COST
+var APIRequestCost float64
+
+// BudgetThresholds проценты бюджета, при пересечении которых администраторы получают предупреждение
+var BudgetThresholds = []int{50, 80, 100}
+
+// BudgetDegrade после исчерпания бюджета второстепенные запросы берутся только из кэша
+var BudgetDegrade bool
+
+// nonEssentialMethods запросы, без которых поиск продолжает работать: описания лекарств
+// для фильтров, предупреждений и карточек
+var nonEssentialMethods = map[string]bool{"Details": true}
+
+var errBudgetExhausted = errors.New("месячный бюджет запросов к API исчерпан")
+
+func loadBudget() {
+	APIMonthlyBudget, _ = strconv.Atoi(os.Getenv("API_MONTHLY_BUDGET"))
+	APIRequestCost, _ = strconv.ParseFloat(os.Getenv("API_REQUEST_COST"), 64)
+	BudgetDegrade, _ = strconv.ParseBool(os.Getenv("API_BUDGET_DEGRADE"))
+	if value := os.Getenv("API_BUDGET_ALERTS"); len(value) > 0 {
+		thresholds := []int{}
+		for _, item := range strings.Split(value, ",") {
+			if percent, err := strconv.Atoi(strings.TrimSpace(item)); err == nil && percent > 0 {
+				thresholds = append(thresholds, percent)
+			}
+		}
+		sort.Ints(thresholds)
+		BudgetThresholds = thresholds
+	}
+}
+
+func startOfMonth(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+// monthAPIRequests число запросов к API с начала месяца
+func monthAPIRequests(now time.Time) int {
+	return AppMetrics.UsageSince(startOfMonth(now))["api_requests"]
+}
+
+// budgetGuard отменяет второстепенные запросы мимо кэша после исчерпания бюджета в режиме BudgetDegrade
+func budgetGuard(method string) error {
+	if !BudgetDegrade || APIMonthlyBudget <= 0 || !nonEssentialMethods[method] {
+		return nil
+	}
+	if monthAPIRequests(time.Now()) < APIMonthlyBudget {
+		return nil
+	}
+
+	AppMetrics.Incr("api_budget_skipped")
+	return errBudgetExhausted
+}
+
+// formatBudget строка расхода бюджета за месяц для сводок
+func formatBudget(now time.Time) string {
+	used := monthAPIRequests(now)
+	text := fmt.Sprintf("Запросов к API за месяц: %d", used)
+	if APIMonthlyBudget > 0 {
+		text += fmt.Sprintf(" из %d (%d%%)", APIMonthlyBudget, used*100/APIMonthlyBudget)
+	}
+	if APIRequestCost > 0 {
+		text += fmt.Sprintf(", расходы %.2f", float64(used)*APIRequestCost)
+		if APIMonthlyBudget > 0 {
+			text += fmt.Sprintf(" из %.2f", float64(APIMonthlyBudget)*APIRequestCost)
+		}
+	}
+
+	return text
+}
+
+// budgetJob проверяет расход бюджета и один раз за месяц предупреждает о каждом пересеченном пороге
+func budgetJob(b *bot.Bot) Job {
+	return Job{
+		Name: "api_budget",
+		Next: every(15 * time.Minute),
+		Run: func(ctx context.Context) {
+			checkBudget(ctx, b, time.Now())
+		},
+	}
+}
+
+func checkBudget(ctx context.Context, b *bot.Bot, now time.Time) {
+	if APIMonthlyBudget <= 0 {
+		return
+	}
+
+	month := now.Format("2006-01")
+	percent := monthAPIRequests(now) * 100 / APIMonthlyBudget
+	crossed := 0
+	for _, threshold := range BudgetThresholds {
+		if percent >= threshold {
+			crossed = threshold
+		}
+	}
+	if crossed == 0 || crossed <= Storage.BudgetAlert(month) {
+		return
+	}
+	Storage.SetBudgetAlert(month, crossed)
+
+	text := fmt.Sprintf("⚠️ Израсходовано %d%% месячного бюджета запросов к API.\n%s", percent, formatBudget(now))
+	if percent >= 100 && BudgetDegrade {
+		text += "\nОписания лекарств теперь берутся только из кэша."
+	}
+	log.Println(text)
+
+	if AdminChatID == 0 {
+		return
+	}
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: AdminChatID,
+		Text:   text,
+	})
+	if err != nil {
+		logError(err)
+	}
+}
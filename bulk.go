@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// bulkLimit ограничивает количество лекарств в одном списке
+	bulkLimit = 30
+	// bulkFileLimit максимальный размер загружаемого списка в байтах
+	bulkFileLimit = 256 * 1024
+	// bulkAnalogsLimit количество аналогов на лекарство в сводном сообщении
+	bulkAnalogsLimit = 3
+)
+
+// BulkResult результат поиска аналогов для одной строки списка
+type BulkResult struct {
+	Query        string
+	MedicineID   int
+	MedicineName string
+	Analogs      []Analog
+	DateRevision string
+}
+
+// matchMedicine находит лекарство по названию: точное совпадение или первый результат поиска
+func matchMedicine(ctx context.Context, query string) (Medicine, bool) {
+	medicines, err := searchMedicinesContext(ctx, query)
+	if err != nil || len(medicines) == 0 {
+		return Medicine{}, false
+	}
+
+	for _, candidate := range medicines {
+		if strings.EqualFold(candidate.Name, query) {
+			return candidate, true
+		}
+	}
+
+	return medicines[0], true
+}
+
+// bulkSearch ищет аналоги для каждого названия из списка по первому найденному лекарству
+func bulkSearch(ctx context.Context, queries []string, targetCountryID int) []BulkResult {
+	results := []BulkResult{}
+	for _, query := range queries {
+		result := BulkResult{Query: query}
+
+		if medicine, ok := matchMedicine(ctx, query); ok {
+			result.MedicineID, _ = strconv.Atoi(medicine.ID)
+			result.MedicineName = medicine.Name
+			var info MedicineInfo
+			result.Analogs, info, _ = searchAnalogsContext(ctx, result.MedicineID, targetCountryID)
+			result.DateRevision = info.DateRevision
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// parseMedicationList разбирает загруженный список, из CSV берется первая колонка
+func parseMedicationList(fileName string, body []byte) ([]string, error) {
+	lines := []string{}
+	if strings.EqualFold(filepath.Ext(fileName), ".csv") {
+		reader := csv.NewReader(bytes.NewReader(body))
+		reader.FieldsPerRecord = -1
+		if bytes.Count(body, []byte(";")) > bytes.Count(body, []byte(",")) {
+			reader.Comma = ';'
+		}
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if len(record) > 0 {
+				lines = append(lines, record[0])
+			}
+		}
+	} else {
+		lines = strings.Split(string(body), "\n")
+	}
+
+	seen := map[string]bool{}
+	queries := []string{}
+	for index, line := range lines {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		key := strings.ToLower(line)
+		if index == 0 && (key == "name" || key == "название" || key == "лекарство") {
+			continue
+		}
+		if len(line) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		queries = append(queries, line)
+		if len(queries) == bulkLimit {
+			break
+		}
+	}
+
+	return queries, nil
+}
+
+func documentHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	document := update.Message.Document
+	extension := strings.ToLower(filepath.Ext(document.FileName))
+	if extension != ".csv" && extension != ".txt" {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Я принимаю списки лекарств в файлах .csv или .txt, по одному названию в строке.",
+		})
+		return
+	}
+
+	if document.FileSize > bulkFileLimit {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Файл слишком большой. Отправьте список не больше 256 КБ.",
+		})
+		return
+	}
+
+	// После проверки файл нужно отправить заново, отложенного поиска для него нет.
+	// Число поисков известно только после чтения файла, лимит списывается ниже
+	if !allowSearch(ctx, b, update.Message.From, PendingSearch{ChatID: update.Message.Chat.ID}, 0) {
+		return
+	}
+
+	body, err := downloadFile(ctx, b, document.FileID)
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	queries, err := parseMedicationList(document.FileName, body)
+	if err != nil || len(queries) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Не удалось прочитать список. Укажите по одному названию лекарства в строке.",
+		})
+		return
+	}
+
+	if !consumeQuota(update.Message.From, len(queries)) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("В списке %d лекарств, это больше остатка дневного лимита. %s", len(queries), quotaRemaining(update.Message.From)),
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("Ищу аналоги для %d лекарств, это может занять некоторое время.", len(queries)),
+	})
+
+	AppMetrics.Incr("bulk_searches")
+	sendBulkReport(ctx, b, update.Message.Chat.ID, update.Message.From, bulkSearch(ctx, queries, targetCountry(update.Message.Chat.ID)))
+}
+
+// sendBulkReport отправляет сводное сообщение и файл с полными результатами
+func sendBulkReport(ctx context.Context, b *bot.Bot, chatID int64, from *models.User, results []BulkResult) {
+	for _, result := range results {
+		if _, ok := sensitiveCategory(result.Query); ok {
+			continue
+		}
+		Storage.AddHistory(from, HistoryEntry{
+			Query:        result.Query,
+			MedicineID:   result.MedicineID,
+			MedicineName: result.MedicineName,
+		})
+	}
+
+	sent, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               formatBulkReport(results),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{sheetButtons("bulk_sheet")},
+		},
+	})
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	BulkReports.Set(replyKey{chatID: chatID, messageID: sent.ID}, bulkSheetRows(results))
+}
+
+func formatBulkReport(results []BulkResult) string {
+	var text strings.Builder
+	text.WriteString(bold("Аналоги по вашему списку") + "\n")
+	for index, result := range results {
+		text.WriteString(fmt.Sprintf("\n%d. %s", index+1, bold(result.Query)))
+		if result.MedicineID == 0 {
+			text.WriteString(" — не найдено")
+			continue
+		}
+		if !strings.EqualFold(result.MedicineName, result.Query) {
+			text.WriteString(" (" + escapeHTML(result.MedicineName) + ")")
+		}
+		if len(result.Analogs) == 0 {
+			text.WriteString(" — аналогов нет")
+			continue
+		}
+		for i, analog := range result.Analogs {
+			if i == bulkAnalogsLimit {
+				text.WriteString(fmt.Sprintf("\n   и еще %d в файле", len(result.Analogs)-bulkAnalogsLimit))
+				break
+			}
+			text.WriteString(fmt.Sprintf("\n   %s (%d%%)", link(analog.AnalogName, analogURL(analog)), analog.Percentage))
+		}
+	}
+
+	return text.String()
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// bundleVersion версия формата пакета для офлайн приложений
+const bundleVersion = 1
+
+// Bundle избранное пользователя с полными карточками для просмотра без интернета
+type Bundle struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	CountryID  int              `json:"country_id"`
+	Country    string           `json:"country,omitempty"`
+	Medicines  []BundleMedicine `json:"medicines"`
+}
+
+type BundleMedicine struct {
+	ID           int            `json:"id"`
+	Name         string         `json:"name"`
+	Profile      string         `json:"profile,omitempty"`
+	Components   string         `json:"components,omitempty"`
+	Forms        []MedicineForm `json:"forms,omitempty"`
+	DateRevision string         `json:"date_revision,omitempty"`
+	Analogs      []BundleAnalog `json:"analogs"`
+	Restrictions []Restriction  `json:"restrictions,omitempty"`
+}
+
+type BundleAnalog struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Percentage int    `json:"percentage"`
+	URL        string `json:"url"`
+}
+
+// buildBundle собирает карточки всех лекарств из избранного пользователя во всех профилях
+func buildBundle(ctx context.Context, userID int64, countryID int, now time.Time) Bundle {
+	bundle := Bundle{Version: bundleVersion, ExportedAt: now, CountryID: countryID, Medicines: []BundleMedicine{}}
+	if country, ok := countryByID(countryID); ok {
+		bundle.Country = country.Name
+	}
+
+	for _, favorite := range Storage.Favorites(userID) {
+		medicine := BundleMedicine{
+			ID:      favorite.MedicineID,
+			Name:    favorite.MedicineName,
+			Profile: favorite.Profile,
+			Analogs: []BundleAnalog{},
+		}
+		if result, err := findAnalogsContext(ctx, favorite.MedicineID, countryID); err == nil {
+			medicine.DateRevision = result.Medicine.DateRevision
+			medicine.Restrictions = result.Restrictions
+			medicine.Components = medicineComponents(ctx, result.Medicine)
+			for _, analog := range result.Analogs {
+				medicine.Analogs = append(medicine.Analogs, BundleAnalog{
+					ID:         analog.AnalogID,
+					Name:       analog.AnalogName,
+					Percentage: analog.Percentage,
+					URL:        analogURL(analog),
+				})
+			}
+		}
+		if details, err := medicineDetails(ctx, favorite.MedicineID); err == nil {
+			medicine.Forms = details.Forms
+		}
+		bundle.Medicines = append(bundle.Medicines, medicine)
+	}
+
+	return bundle
+}
+
+var bundlePage = template.Must(template.New("bundle").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Моя аптечка</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; max-width: 720px; margin: 0 auto; padding: 16px; color: #202020; }
+section { border-bottom: 1px solid #ddd; padding: 8px 0 16px; }
+.muted { color: #707070; font-size: 14px; }
+.warning { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Моя аптечка</h1>
+<p class="muted">Аналоги: {{.Country}} · выгружено {{.ExportedAt.Format "02.01.2006 15:04"}}</p>
+{{range .Medicines}}<section>
+<h2>{{.Name}}{{if .Profile}} <span class="muted">({{.Profile}})</span>{{end}}</h2>
+{{if .Components}}<p>Состав: {{.Components}}</p>{{end}}
+{{if .Forms}}<p>Формы выпуска: {{range $index, $form := .Forms}}{{if $index}}, {{end}}{{$form.Name}}{{if $form.Concentration}} {{$form.Concentration}}{{end}}{{end}}</p>{{end}}
+{{if .Analogs}}<ol>{{range .Analogs}}<li>{{.Name}} — {{.Percentage}}% <span class="muted">{{.URL}}</span></li>{{end}}</ol>{{else}}<p class="muted">Аналоги не найдены, возьмите лекарство с собой.</p>{{end}}
+{{range .Restrictions}}<p class="warning">⚠️ {{.Substance}}: {{.Note}}</p>{{end}}
+</section>
+{{end}}<p class="muted">Перед приемом посоветуйтесь с фармацевтом или врачом.</p>
+</body>
+</html>
+`))
+
+// bundleZip упаковывает bundle.json для приложений и index.html для просмотра в любом браузере
+func bundleZip(bundle Bundle) ([]byte, error) {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	var page bytes.Buffer
+	if err := bundlePage.Execute(&page, bundle); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	parts := []struct {
+		name string
+		body []byte
+	}{
+		{"index.html", page.Bytes()},
+		{"bundle.json", data},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.Write(part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// exportHandler /export отправляет избранное архивом для использования без интернета
+func exportHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message.From == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	if len(Storage.Favorites(update.Message.From.ID)) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "В избранном пока ничего нет. Поставьте 👍 на список аналогов, чтобы сохранить лекарство.",
+		})
+		return
+	}
+
+	b.SendChatAction(ctx, &bot.SendChatActionParams{
+		ChatID: chatID,
+		Action: models.ChatActionUploadDocument,
+	})
+
+	file, err := bundleZip(buildBundle(ctx, update.Message.From.ID, targetCountry(chatID), time.Now()))
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	AppMetrics.Incr("bundle_exports")
+	_, err = b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: "pills-bundle.zip", Data: bytes.NewReader(file)},
+		Caption:  "Избранное с карточками лекарств. Откройте index.html в браузере, интернет не нужен. bundle.json подходит для офлайн приложений.",
+	})
+	if err != nil {
+		logError(err)
+	}
+}
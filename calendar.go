@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// calendarDuration длительность события приема в календаре
+const calendarDuration = 15 * time.Minute
+
+// calendarURL возвращает ссылку подписки на календарь чата или пустую строку без WEBAPP_URL
+func calendarURL(token string) string {
+	if len(WebAppURL) == 0 || len(token) == 0 {
+		return ""
+	}
+
+	return strings.TrimRight(WebAppURL, "/") + "/calendar/" + token + ".ics"
+}
+
+// calendarHandler /calendar отправляет расписание напоминаний файлом .ics и ссылку подписки,
+// /calendar reset выпускает новую ссылку вместо старой
+func calendarHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	reminders := Storage.Reminders(chatID)
+	if len(reminders) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Напоминаний нет.\n\n" + remindUsage,
+		})
+		return
+	}
+
+	_, arg, _ := strings.Cut(update.Message.Text, " ")
+	reset := strings.TrimSpace(strings.ToLower(arg)) == "reset"
+	settings := Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+		if reset || len(settings.CalendarToken) == 0 {
+			settings.CalendarToken = newShareToken()
+		}
+	})
+
+	caption := "Импортируйте файл в календарь телефона."
+	if url := calendarURL(settings.CalendarToken); len(url) > 0 {
+		caption += "\n\nИли подпишитесь по ссылке, календарь будет обновляться сам:\n" + url +
+			"\n\nЧтобы отключить старую ссылку, отправьте /calendar reset."
+	}
+
+	AppMetrics.Incr("calendar_exports")
+	_, err := b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: "reminders.ics", Data: bytes.NewReader(remindersCalendar(reminders, time.Now()))},
+		Caption:  caption,
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+// calendarFeedHandler GET /calendar/<token>.ics отдает актуальное расписание для подписки
+func calendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/calendar/"), ".ics")
+	if !ok || len(token) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	chatID, ok := Storage.ChatByCalendarToken(token)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write(remindersCalendar(Storage.Reminders(chatID), time.Now()))
+}
+
+// remindersCalendar описывает каждое время приема ежедневным повторяющимся событием в часовом поясе
+// напоминания. Курс с датой окончания ограничивает повторы
+func remindersCalendar(reminders []Reminder, now time.Time) []byte {
+	var calendar bytes.Buffer
+	line := func(format string, args ...any) {
+		calendar.WriteString(fmt.Sprintf(format, args...) + "\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//pills-bot//reminders//RU")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:%s", icalEscape("Прием лекарств"))
+	for _, reminder := range reminders {
+		location := reminder.Location()
+		created := reminder.CreatedAt.In(location)
+		if created.IsZero() {
+			created = now.In(location)
+		}
+		for _, value := range reminder.Times {
+			clock, err := time.Parse("15:04", value)
+			if err != nil {
+				continue
+			}
+			start := time.Date(created.Year(), created.Month(), created.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+
+			line("BEGIN:VEVENT")
+			line("UID:reminder-%d-%s@pills-bot", reminder.ID, strings.ReplaceAll(value, ":", ""))
+			line("DTSTAMP:%s", now.UTC().Format("20060102T150405Z"))
+			line("DTSTART%s", icalTime(start))
+			line("DTEND%s", icalTime(start.Add(calendarDuration)))
+			rule := "FREQ=DAILY"
+			if !reminder.CourseEnd.IsZero() {
+				rule += ";UNTIL=" + reminder.CourseEnd.UTC().Format("20060102T150405Z")
+			}
+			line("RRULE:%s", rule)
+			line("SUMMARY:%s", icalEscape("💊 "+reminder.Title()))
+			if len(reminder.Profile) > 0 {
+				line("DESCRIPTION:%s", icalEscape("Профиль: "+reminder.Profile))
+			}
+			line("BEGIN:VALARM")
+			line("ACTION:DISPLAY")
+			line("DESCRIPTION:%s", icalEscape(reminder.Title()))
+			line("TRIGGER:PT0M")
+			line("END:VALARM")
+			line("END:VEVENT")
+		}
+	}
+	line("END:VCALENDAR")
+
+	return calendar.Bytes()
+}
+
+// icalTime форматирует время с часовым поясом IANA, для системного пояса время остается плавающим
+func icalTime(value time.Time) string {
+	if name := value.Location().String(); name != "Local" && name != "UTC" {
+		return ";TZID=" + name + ":" + value.Format("20060102T150405")
+	}
+	if value.Location() == time.UTC {
+		return ":" + value.Format("20060102T150405Z")
+	}
+
+	return ":" + value.Format("20060102T150405")
+}
+
+func icalEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(value)
+}
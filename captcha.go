@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// CaptchaMode проверка новых пользователей перед поиском из CAPTCHA:
+// button кнопка «Я человек», emoji выбор названного эмодзи, пустое значение отключает проверку
+var CaptchaMode string
+
+// captchaEmoji варианты эмодзи с подписями для задания
+var captchaEmoji = []struct {
+	Emoji string
+	Name  string
+}{
+	{"🍎", "яблоко"},
+	{"🐱", "кошку"},
+	{"🚗", "машину"},
+	{"🌙", "луну"},
+	{"⚽", "мяч"},
+	{"🎈", "шарик"},
+	{"🌲", "елку"},
+	{"🐟", "рыбу"},
+}
+
+// captchaOptions число кнопок в задании с эмодзи
+const captchaOptions = 4
+
+// CaptchaAnswers правильный ответ на последнее задание пользователя
+var CaptchaAnswers = NewRecentMap[int64, string](1000)
+
+// userVerified проверяет, что пользователь прошел проверку. Пользователи,
+// искавшие до включения проверки, считаются проверенными
+func userVerified(from *models.User) bool {
+	if len(CaptchaMode) == 0 || from == nil {
+		return true
+	}
+	user, ok := Storage.User(from.ID)
+
+	return ok && (!user.VerifiedAt.IsZero() || user.Searches > 0)
+}
+
+// requireVerification отправляет задание непроверенному пользователю и откладывает поиск pending
+func requireVerification(ctx context.Context, b *bot.Bot, from *models.User, pending PendingSearch) bool {
+	if userVerified(from) {
+		return true
+	}
+
+	PendingSearches.Set(from.ID, pending)
+	text, markup := captchaChallenge(from.ID)
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      pending.ChatID,
+		Text:        text,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		logError(err)
+	}
+
+	return false
+}
+
+// captchaChallenge готовит задание для режима CaptchaMode и запоминает ответ
+func captchaChallenge(userID int64) (string, *models.InlineKeyboardMarkup) {
+	if CaptchaMode != "emoji" {
+		CaptchaAnswers.Set(userID, "human")
+		return "Подтвердите, что вы не бот, и я начну поиск.", &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{{Text: "Я человек", CallbackData: "captcha:human"}},
+			},
+		}
+	}
+
+	options := rand.Perm(len(captchaEmoji))[:captchaOptions]
+	answer := captchaEmoji[options[rand.Intn(captchaOptions)]]
+	CaptchaAnswers.Set(userID, answer.Emoji)
+
+	row := []models.InlineKeyboardButton{}
+	for _, index := range options {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         captchaEmoji[index].Emoji,
+			CallbackData: "captcha:" + strconv.Itoa(index),
+		})
+	}
+
+	return "Подтвердите, что вы не бот: нажмите на " + answer.Name + ".", &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{row},
+	}
+}
+
+// captchaHandler проверяет ответ на задание и выполняет отложенный поиск: captcha:<ответ>
+func captchaHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+	from := query.From
+	value := strings.TrimPrefix(query.Data, "captcha:")
+	if index, err := strconv.Atoi(value); err == nil && index >= 0 && index < len(captchaEmoji) {
+		value = captchaEmoji[index].Emoji
+	}
+
+	answer, ok := CaptchaAnswers.Get(from.ID)
+	if !ok || answer != value {
+		AppMetrics.Incr("captcha_failed")
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: query.ID,
+			Text:            "Неверно, попробуйте еще раз.",
+		})
+		if query.Message.Message == nil {
+			return
+		}
+		text, markup := captchaChallenge(from.ID)
+		_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      callbackChatID(query),
+			MessageID:   query.Message.Message.ID,
+			Text:        text,
+			ReplyMarkup: markup,
+		})
+		if err != nil {
+			logError(err)
+		}
+		return
+	}
+
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: query.ID,
+		ShowAlert:       false,
+	})
+	CaptchaAnswers.Delete(from.ID)
+	Storage.VerifyUser(&from, time.Now())
+	AppMetrics.Incr("captcha_passed")
+	editCallbackMessage(ctx, b, query, "Спасибо!")
+
+	runPendingSearch(ctx, b, &from)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	cardWidth   = 1080
+	cardHeight  = 720
+	cardPadding = 60
+	// cardTextWidth ширина строк карточки без полей
+	cardTextWidth = cardWidth - 2*cardPadding
+)
+
+var (
+	cardAccent = color.RGBA{R: 0x1b, G: 0x8a, B: 0x5a, A: 0xff}
+	cardText   = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff}
+	cardMuted  = color.RGBA{R: 0x70, G: 0x70, B: 0x70, A: 0xff}
+)
+
+// PharmacyCard данные карточки: местное название, действующие вещества и форма выпуска
+type PharmacyCard struct {
+	LocalName  string
+	HomeName   string
+	Components string
+	Form       string
+	Country    string
+}
+
+// pharmacyCardHandler отправляет карточку для аптеки по лучшему аналогу: pharmacy_card:<лекарство>
+func pharmacyCardHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	medicineID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "pharmacy_card:"))
+	result, err := findAnalogsContext(ctx, medicineID, targetCountry(chatID))
+	if err != nil {
+		return
+	}
+	analog, ok := pills.BestAnalog(result.Analogs)
+	if !ok {
+		return
+	}
+
+	card := PharmacyCard{
+		LocalName:  analog.AnalogName,
+		HomeName:   result.Medicine.MedicineName,
+		Components: medicineComponents(ctx, result.Medicine),
+	}
+	if country, ok := countryByID(result.CountryID); ok {
+		card.Country = country.Name
+	}
+	if details, err := medicineDetails(ctx, medicineID); err == nil && len(details.Forms) > 0 {
+		card.Form = details.Forms[0].Name
+		if len(details.Forms[0].Concentration) > 0 {
+			card.Form += ", " + details.Forms[0].Concentration
+		}
+	}
+
+	file, err := renderPharmacyCard(card)
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	AppMetrics.Incr("pharmacy_cards")
+	_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID:  chatID,
+		Photo:   &models.InputFileUpload{Filename: "pharmacy-card.png", Data: bytes.NewReader(file)},
+		Caption: "Покажите карточку в аптеке, интернет для этого не нужен",
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+// renderPharmacyCard рисует карточку PNG. Подписи двуязычные, чтобы их понял фармацевт
+func renderPharmacyCard(card PharmacyCard) ([]byte, error) {
+	regular, err := opentype.Parse(fontRegular)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := opentype.Parse(fontBold)
+	if err != nil {
+		return nil, err
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, image.Rect(0, 0, cardWidth, 110), image.NewUniform(cardAccent), image.Point{}, draw.Src)
+
+	y := 75
+	y = drawText(canvas, bold, 40, image.White, "✚ PHARMACY · АПТЕКА", cardPadding, y, cardTextWidth, 1)
+
+	y = 200
+	y = drawText(canvas, regular, 26, cardMuted, "Medicine / Лекарство", cardPadding, y, cardTextWidth, 1)
+	y = drawText(canvas, bold, 64, cardText, card.LocalName, cardPadding, y+70, cardTextWidth, 2)
+	if len(card.Components) > 0 {
+		y = drawText(canvas, regular, 26, cardMuted, "Active ingredients / Действующие вещества", cardPadding, y+60, cardTextWidth, 1)
+		y = drawText(canvas, bold, 38, cardText, card.Components, cardPadding, y+50, cardTextWidth, 3)
+	}
+	if len(card.Form) > 0 {
+		y = drawText(canvas, regular, 26, cardMuted, "Dosage form / Форма выпуска", cardPadding, y+55, cardTextWidth, 1)
+		drawText(canvas, bold, 34, cardText, card.Form, cardPadding, y+46, cardTextWidth, 1)
+	}
+
+	footer := "Analog of / Аналог: " + card.HomeName
+	if len(card.Country) > 0 {
+		footer += " · " + card.Country
+	}
+	drawText(canvas, regular, 24, cardMuted, footer, cardPadding, cardHeight-40, cardTextWidth, 1)
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, canvas); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// drawText пишет текст с переносом по словам в пределах width не более чем в lines строк начиная
+// с точки x и базовой линии y и возвращает базовую линию последней строки
+func drawText(canvas *image.RGBA, parsed *opentype.Font, size float64, textColor color.Color, text string, x int, y int, width int, lines int) int {
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		logError(err)
+		return y
+	}
+	defer face.Close()
+
+	drawer := &font.Drawer{Dst: canvas, Src: image.NewUniform(textColor), Face: face}
+	limit := fixed.I(width)
+	lineHeight := int(size * 1.25)
+
+	wrapped := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := strings.TrimSpace(line + " " + word)
+		if len(line) > 0 && drawer.MeasureString(candidate) > limit {
+			wrapped = append(wrapped, line)
+			line = word
+			continue
+		}
+		line = candidate
+	}
+	wrapped = append(wrapped, line)
+	if len(wrapped) > lines {
+		wrapped = wrapped[:lines]
+		wrapped[lines-1] += "…"
+	}
+
+	for index, line := range wrapped {
+		if index > 0 {
+			y += lineHeight
+		}
+		drawer.Dot = fixed.P(x, y)
+		drawer.DrawString(line)
+	}
+
+	return y
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const channelUsage = "Чтобы сохранять результаты в семейный канал, добавьте бота администратором канала с правом публикации " +
+	"и отправьте /channel @имя_канала или перешлите сюда любой текстовый пост из канала.\n\nОтключить канал: /channel off"
+
+// channelHandler привязывает канал, куда кнопкой 📌 отправляются результаты поиска
+func channelHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	if update.Message.Chat.Type != models.ChatTypePrivate || update.Message.From == nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Канал можно привязать в личном чате с ботом.",
+		})
+		return
+	}
+
+	_, arg, _ := strings.Cut(update.Message.Text, " ")
+	arg = strings.TrimSpace(arg)
+	switch {
+	case len(arg) == 0:
+		settings := Storage.ChatSettings(chatID)
+		text := channelUsage
+		if settings.FamilyChannelID != 0 {
+			text = fmt.Sprintf("Результаты сохраняются в канал %s.\n\n%s", bold(settings.FamilyChannelTitle), escapeHTML(channelUsage))
+		}
+		ChatDialogs.Start(chatID, "channel_link", nil)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      text,
+			ParseMode: models.ParseModeHTML,
+		})
+	case strings.EqualFold(arg, "off"):
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.FamilyChannelID = 0
+			settings.FamilyChannelTitle = ""
+		})
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Канал отключен.",
+		})
+	default:
+		var channel any = arg
+		if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+			channel = id
+		}
+		linkChannel(ctx, b, update.Message, channel)
+	}
+}
+
+// channelLinkDialog принимает пересланный из канала пост или имя канала
+func channelLinkDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	origin := update.Message.ForwardOrigin
+	if origin != nil && origin.MessageOriginChannel != nil {
+		linkChannel(ctx, b, update.Message, origin.MessageOriginChannel.Chat.ID)
+		return
+	}
+
+	linkChannel(ctx, b, update.Message, strings.TrimSpace(update.Message.Text))
+}
+
+// linkChannel проверяет, что пользователь администратор канала, а бот может в нем публиковать
+func linkChannel(ctx context.Context, b *bot.Bot, message *models.Message, channel any) {
+	reply := func(text string) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    message.Chat.ID,
+			Text:      text,
+			ParseMode: models.ParseModeHTML,
+		})
+	}
+
+	chat, err := b.GetChat(ctx, &bot.GetChatParams{ChatID: channel})
+	if err != nil || chat.Type != models.ChatTypeChannel {
+		reply("Канал не найден. Проверьте, что бот добавлен в канал.\n\n" + escapeHTML(channelUsage))
+		return
+	}
+
+	member, err := b.GetChatMember(ctx, &bot.GetChatMemberParams{ChatID: chat.ID, UserID: message.From.ID})
+	if err != nil || (member.Type != models.ChatMemberTypeOwner && member.Type != models.ChatMemberTypeAdministrator) {
+		reply("Привязать канал может только его администратор.")
+		return
+	}
+	self, err := b.GetChatMember(ctx, &bot.GetChatMemberParams{ChatID: chat.ID, UserID: b.ID()})
+	if err != nil || self.Administrator == nil || !self.Administrator.CanPostMessages {
+		reply("Дайте боту право публиковать сообщения в канале и попробуйте еще раз.")
+		return
+	}
+
+	Storage.UpdateChatSettings(message.Chat.ID, func(settings *ChatSettings) {
+		settings.FamilyChannelID = chat.ID
+		settings.FamilyChannelTitle = chat.Title
+	})
+	reply(fmt.Sprintf("Канал %s привязан. Нажимайте 📌 под результатами поиска, чтобы сохранить их туда.", bold(chat.Title)))
+}
+
+// analogPinHandler публикует аналоги лекарства в привязанном канале: analog_pin:<лекарство>
+func analogPinHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := callbackChatID(update.CallbackQuery)
+	settings := Storage.ChatSettings(chatID)
+	answer := func(text string) {
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: update.CallbackQuery.ID,
+			Text:            text,
+		})
+	}
+	if settings.FamilyChannelID == 0 {
+		answer("Канал не привязан, отправьте /channel.")
+		return
+	}
+
+	medicineID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "analog_pin:"))
+	result, err := findAnalogsContext(ctx, medicineID, targetCountry(chatID))
+	if err != nil || len(result.Analogs) == 0 {
+		answer("Не удалось получить аналоги, попробуйте позже.")
+		return
+	}
+
+	country := ""
+	if c, ok := countryByID(result.CountryID); ok {
+		country = " · " + escapeHTML(c.Name)
+	}
+	text := fmt.Sprintf("📌 %s%s\n", escapeHTML(update.CallbackQuery.From.FirstName), country) +
+		analogSummary(result.Medicine.MedicineName, result.Analogs) +
+		restrictionWarnings(result.CountryID, analogNames(result.Medicine.MedicineName, result.Analogs)...) +
+		"\n\n" + dataAttribution(result.Medicine)
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             settings.FamilyChannelID,
+		Text:               text,
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+	if err != nil {
+		logError(err)
+		answer("Не удалось отправить в канал. Проверьте права бота.")
+		return
+	}
+
+	AppMetrics.Incr("channel_posts")
+	answer("Сохранено в " + settings.FamilyChannelTitle)
+}
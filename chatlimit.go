@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// ChatLimiter ограничивает частоту сообщений и нажатий кнопок в одном личном чате,
+// настраивается CHAT_RATE_LIMIT в минуту и CHAT_RATE_BURST
+var ChatLimiter = NewRateLimiter(20, 5)
+
+// GroupLimiter ограничивает группу целиком, сообщения всех участников расходуют одну корзину,
+// настраивается GROUP_RATE_LIMIT и GROUP_RATE_BURST
+var GroupLimiter = NewRateLimiter(40, 10)
+
+// GroupsLimiter общее ограничение всех групп из GROUPS_RATE_LIMIT и GROUPS_RATE_BURST,
+// чтобы шумные группы не вытесняли личные чаты
+var GroupsLimiter = NewRateLimiter(300, 50)
+
+// groupsKey корзина GroupsLimiter
+const groupsKey = "groups"
+
+// cooldownNotices время окончания паузы, о которой уже предупрежден чат, чтобы не отвечать на каждое лишнее сообщение
+var cooldownNotices = NewRecentMap[int64, time.Time](1000)
+
+func loadChatLimiter() {
+	ChatLimiter = loadRateLimiter("CHAT_RATE", 5, ChatLimiter)
+	GroupLimiter = loadRateLimiter("GROUP_RATE", 10, GroupLimiter)
+	GroupsLimiter = loadRateLimiter("GROUPS_RATE", 50, GroupsLimiter)
+}
+
+// loadRateLimiter читает ограничение из <prefix>_LIMIT и <prefix>_BURST, без них оставляет current
+func loadRateLimiter(prefix string, burst int, current *RateLimiter) *RateLimiter {
+	limit, err := strconv.Atoi(os.Getenv(prefix + "_LIMIT"))
+	if err != nil {
+		return current
+	}
+	if value, err := strconv.Atoi(os.Getenv(prefix + "_BURST")); err == nil {
+		burst = value
+	}
+
+	return NewRateLimiter(limit, burst)
+}
+
+// allowChat расходует запрос чата: личные чаты из ChatLimiter, группы из своей корзины
+// и общей корзины групп. Возвращает паузу до следующего разрешенного запроса
+func allowChat(chatID int64) (bool, time.Duration) {
+	key := strconv.FormatInt(chatID, 10)
+	if chatID > 0 {
+		if ChatLimiter.Allow(key) {
+			return true, 0
+		}
+		return false, ChatLimiter.Retry(key)
+	}
+
+	if !GroupLimiter.Allow(key) {
+		AppMetrics.Incr("group_rate_limited")
+		return false, GroupLimiter.Retry(key)
+	}
+	if !GroupsLimiter.Allow(groupsKey) {
+		AppMetrics.Incr("groups_rate_limited")
+		return false, GroupsLimiter.Retry(groupsKey)
+	}
+
+	return true, 0
+}
+
+// updateChat возвращает чат, от имени которого пришло обновление, inline запросы считаются по пользователю
+func updateChat(update *models.Update) (int64, bool) {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID, true
+	case update.EditedMessage != nil:
+		return update.EditedMessage.Chat.ID, true
+	case update.CallbackQuery != nil:
+		return callbackChatID(update.CallbackQuery), true
+	case update.InlineQuery != nil && update.InlineQuery.From != nil:
+		return update.InlineQuery.From.ID, true
+	}
+
+	return 0, false
+}
+
+// cooldownText вежливо просит подождать retry
+func cooldownText(retry time.Duration) string {
+	seconds := int(math.Ceil(retry.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return fmt.Sprintf("⏳ Слишком много запросов подряд. Пожалуйста, подождите %d сек. и попробуйте снова.", seconds)
+}
+
+// rateLimitMiddleware отбрасывает обновления сверх ограничений чата или группы, чтобы они не копились
+// в очереди запросов к API. Чат администраторов не ограничивается
+func rateLimitMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		chatID, ok := updateChat(update)
+		if !ok || chatID == AdminChatID {
+			next(ctx, b, update)
+			return
+		}
+
+		allowed, retry := allowChat(chatID)
+		if allowed {
+			next(ctx, b, update)
+			return
+		}
+
+		AppMetrics.Incr("rate_limited")
+		switch {
+		case update.CallbackQuery != nil:
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: update.CallbackQuery.ID,
+				Text:            cooldownText(retry),
+				ShowAlert:       true,
+			})
+		case update.InlineQuery != nil:
+			b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+				InlineQueryID: update.InlineQuery.ID,
+				Results:       []models.InlineQueryResult{},
+			})
+		case update.Message != nil:
+			// Повторное предупреждение отправляется не раньше, чем закончится прошлая пауза
+			if until, ok := cooldownNotices.Get(chatID); ok && time.Now().Before(until) {
+				return
+			}
+			cooldownNotices.Set(chatID, time.Now().Add(retry))
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   cooldownText(retry),
+			})
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// ChecklistItem лекарство из списка в дорогу и его лучший аналог в стране поездки
+type ChecklistItem struct {
+	Medicine   string `json:"medicine"`
+	MedicineID int    `json:"medicine_id,omitempty"`
+	AnalogID   string `json:"analog_id,omitempty"`
+	AnalogName string `json:"analog_name,omitempty"`
+	AnalogURL  string `json:"analog_url,omitempty"`
+	Percentage int    `json:"percentage,omitempty"`
+	Packed     bool   `json:"packed,omitempty"`
+	// ClaimedBy попутчик, который берет лекарство с собой
+	ClaimedBy   int64  `json:"claimed_by,omitempty"`
+	ClaimedName string `json:"claimed_name,omitempty"`
+	// DateRevision дата обновления данных лекарства в API на момент поиска
+	DateRevision string `json:"date_revision,omitempty"`
+}
+
+// setAnalog запоминает лучший аналог из списка
+func (item *ChecklistItem) setAnalog(analogs []Analog) {
+	analog, _ := pills.BestAnalog(analogs)
+	item.AnalogID = analog.AnalogID
+	item.AnalogName = analog.AnalogName
+	item.Percentage = analog.Percentage
+	item.AnalogURL = ""
+	if len(analog.AnalogID) > 0 {
+		item.AnalogURL = analogURL(analog)
+	}
+}
+
+// buildChecklist собирает список в дорогу из избранного профиля и лекарств из напоминаний чата
+func buildChecklist(ctx context.Context, chatID int64, userID int64, countryID int) []ChecklistItem {
+	items := []ChecklistItem{}
+	seen := map[string]bool{}
+
+	addItem := func(name string, medicineID int, analogs []Analog, revision string) {
+		item := ChecklistItem{Medicine: name, MedicineID: medicineID, DateRevision: revision}
+		item.setAnalog(analogs)
+		items = append(items, item)
+	}
+
+	for _, favorite := range profileFavorites(userID, activeProfile(chatID)) {
+		if seen[strings.ToLower(favorite.MedicineName)] {
+			continue
+		}
+		seen[strings.ToLower(favorite.MedicineName)] = true
+		analogs, info, _ := searchAnalogsContext(ctx, favorite.MedicineID, countryID)
+		addItem(favorite.MedicineName, favorite.MedicineID, analogs, info.DateRevision)
+	}
+
+	queries := []string{}
+	for _, reminder := range Storage.Reminders(chatID) {
+		if seen[strings.ToLower(reminder.Medicine)] {
+			continue
+		}
+		seen[strings.ToLower(reminder.Medicine)] = true
+		queries = append(queries, reminder.Medicine)
+	}
+	for _, result := range bulkSearch(ctx, queries, countryID) {
+		addItem(result.Query, result.MedicineID, result.Analogs, result.DateRevision)
+	}
+
+	return items
+}
+
+// formatChecklist показывает список в дорогу, в группе попутчиков с тем, кто что берет
+func formatChecklist(trip Trip, group bool) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("%s: %s, %s\n", bold("Список в дорогу"), escapeHTML(trip.CountryName()), trip.Dates()))
+	for _, item := range trip.Checklist {
+		mark := "☐"
+		if item.Packed {
+			mark = "☑️"
+		}
+		if len(item.AnalogName) == 0 {
+			text.WriteString(fmt.Sprintf("\n%s %s — аналог не найден, возьмите с собой", mark, bold(item.Medicine)))
+		} else {
+			text.WriteString(fmt.Sprintf("\n%s %s → %s (%d%%)", mark, bold(item.Medicine), link(item.AnalogName, item.AnalogURL), item.Percentage))
+		}
+		if item.ClaimedBy != 0 {
+			text.WriteString(", берет " + escapeHTML(item.ClaimedName))
+		}
+	}
+
+	names := []string{}
+	for _, item := range trip.Checklist {
+		names = append(names, item.Medicine, item.AnalogName)
+	}
+	if warnings := restrictionWarnings(trip.CountryID, names...); len(warnings) > 0 {
+		text.WriteString("\n" + warnings)
+	}
+	if group {
+		text.WriteString("\n\nНажмите на лекарство, которое возьмете с собой.")
+	} else {
+		text.WriteString("\n\nОтмечайте лекарства кнопками, когда положите их в чемодан.")
+	}
+
+	return text.String()
+}
+
+// checklistMarkup в личном чате отмечает собранные лекарства, в группе попутчиков распределяет их
+func checklistMarkup(trip Trip, group bool) *models.InlineKeyboardMarkup {
+	buttons := [][]models.InlineKeyboardButton{}
+	for index, item := range trip.Checklist {
+		if group {
+			text := "🙋 Я беру " + item.Medicine
+			if item.ClaimedBy != 0 {
+				text = item.Medicine + ": " + item.ClaimedName
+			}
+			buttons = append(buttons, []models.InlineKeyboardButton{
+				{Text: text, CallbackData: fmt.Sprintf("trip_claim:%d:%d", trip.ID, index)},
+			})
+			continue
+		}
+
+		mark := "☐ "
+		if item.Packed {
+			mark = "☑️ "
+		}
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: mark + item.Medicine, CallbackData: fmt.Sprintf("trip_pack:%d:%d", trip.ID, index)},
+		})
+	}
+	buttons = append(buttons, append(sheetButtons(fmt.Sprintf("trip_sheet:%d", trip.ID)),
+		models.InlineKeyboardButton{Text: "📝 Markdown", CallbackData: fmt.Sprintf("trip_md:%d", trip.ID)}))
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+// sendChecklist отправляет список в чат и запоминает сообщение для синхронизации
+func sendChecklist(ctx context.Context, b *bot.Bot, trip Trip, chatID int64) {
+	group := chatID == trip.GroupChatID
+	sent, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               formatChecklist(trip, group),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+		ReplyMarkup:        checklistMarkup(trip, group),
+	})
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	Storage.UpdateTrip(trip.ID, func(trip *Trip) {
+		trip.ChecklistMessages = append(trip.ChecklistMessages, MessageRef{ChatID: chatID, MessageID: sent.ID})
+	})
+}
+
+// syncChecklist обновляет все сообщения со списком поездки
+func syncChecklist(ctx context.Context, b *bot.Bot, trip Trip) {
+	for _, message := range trip.ChecklistMessages {
+		group := message.ChatID == trip.GroupChatID
+		_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:             message.ChatID,
+			MessageID:          message.MessageID,
+			Text:               formatChecklist(trip, group),
+			ParseMode:          models.ParseModeHTML,
+			LinkPreviewOptions: disabledLinkPreview(),
+			ReplyMarkup:        checklistMarkup(trip, group),
+		})
+		if err != nil {
+			logError(err)
+		}
+	}
+}
+
+func tripChecklistHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	trip, ok := tripCallback(update)
+	if !ok || trip.ChatID != callbackChatID(update.CallbackQuery) {
+		return
+	}
+
+	b.SendChatAction(ctx, &bot.SendChatActionParams{
+		ChatID: trip.ChatID,
+		Action: models.ChatActionTyping,
+	})
+
+	checklist := buildChecklist(ctx, trip.ChatID, update.CallbackQuery.From.ID, trip.CountryID)
+	if len(checklist) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: trip.ChatID,
+			Text:   "Чтобы собрать список в дорогу, добавьте лекарства в избранное (👍 на списке аналогов) или создайте напоминание через /remind.",
+		})
+		return
+	}
+
+	// Новый список заменяет прежний, старые сообщения больше не обновляются
+	trip, _ = Storage.UpdateTrip(trip.ID, func(trip *Trip) {
+		trip.Checklist = checklist
+		trip.ChecklistMessages = nil
+	})
+
+	AppMetrics.Incr("trip_checklists")
+
+	sendChecklist(ctx, b, trip, trip.ChatID)
+	if trip.GroupChatID != 0 {
+		sendChecklist(ctx, b, trip, trip.GroupChatID)
+	}
+
+	if warnings := checklistInteractions(ctx, trip.Checklist); len(warnings) > 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    trip.ChatID,
+			Text:      bold("Опасные сочетания в списке") + warnings,
+			ParseMode: models.ParseModeHTML,
+		})
+	}
+}
+
+// checklistCallback разбирает поездку и номер лекарства из кнопки списка
+func checklistCallback(update *models.Update) (Trip, int, bool) {
+	parts := strings.Split(update.CallbackQuery.Data, ":")
+	if len(parts) != 3 {
+		return Trip{}, 0, false
+	}
+	tripID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Trip{}, 0, false
+	}
+	index, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return Trip{}, 0, false
+	}
+
+	trip, ok := Storage.Trip(tripID)
+	if !ok || !trip.VisibleIn(callbackChatID(update.CallbackQuery)) || index < 0 || index >= len(trip.Checklist) {
+		return Trip{}, 0, false
+	}
+
+	return trip, index, true
+}
+
+func tripPackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	trip, index, ok := checklistCallback(update)
+	if !ok {
+		return
+	}
+
+	trip, _ = Storage.UpdateTrip(trip.ID, func(trip *Trip) {
+		if index < len(trip.Checklist) {
+			trip.Checklist[index].Packed = !trip.Checklist[index].Packed
+		}
+	})
+
+	syncChecklist(ctx, b, trip)
+}
+
+// tripClaimHandler закрепляет лекарство за попутчиком, повторное нажатие снимает отметку
+func tripClaimHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	trip, index, ok := checklistCallback(update)
+	if !ok {
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: update.CallbackQuery.ID,
+			ShowAlert:       false,
+		})
+		return
+	}
+
+	from := update.CallbackQuery.From
+	claimedByOther := false
+	trip, _ = Storage.UpdateTrip(trip.ID, func(trip *Trip) {
+		if index >= len(trip.Checklist) {
+			return
+		}
+		item := &trip.Checklist[index]
+		switch item.ClaimedBy {
+		case 0:
+			item.ClaimedBy = from.ID
+			item.ClaimedName = from.FirstName
+		case from.ID:
+			item.ClaimedBy = 0
+			item.ClaimedName = ""
+		default:
+			claimedByOther = true
+		}
+	})
+
+	text := ""
+	if claimedByOther {
+		text = "Это лекарство уже кто-то берет."
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            text,
+		ShowAlert:       false,
+	})
+	if !claimedByOther {
+		syncChecklist(ctx, b, trip)
+	}
+}
+
+// linkTripGroup привязывает поездку к группе по ссылке t.me/<bot>?startgroup=trip_<token>
+func linkTripGroup(ctx context.Context, b *bot.Bot, message *models.Message, token string) {
+	if message.Chat.Type != models.ChatTypeGroup && message.Chat.Type != models.ChatTypeSupergroup {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: message.Chat.ID,
+			Text:   "Эта ссылка добавляет поездку в группу попутчиков. Откройте ее и выберите группу.",
+		})
+		return
+	}
+
+	trip, ok := Storage.TripByToken(token)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: message.Chat.ID,
+			Text:   "Ссылка недействительна.",
+		})
+		return
+	}
+
+	trip, _ = Storage.UpdateTrip(trip.ID, func(trip *Trip) {
+		trip.GroupChatID = message.Chat.ID
+	})
+
+	AppMetrics.Incr("trip_groups")
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    message.Chat.ID,
+		Text:      fmt.Sprintf("Поездка в %s на %s добавлена в группу. Здесь можно договориться, кто какие лекарства берет.", bold(trip.CountryName()), trip.Dates()),
+		ParseMode: models.ParseModeHTML,
+	})
+	if len(trip.Checklist) > 0 {
+		sendChecklist(ctx, b, trip, message.Chat.ID)
+	}
+}
+
+// tripGroupLink возвращает ссылку для добавления поездки в группу
+func tripGroupLink(token string) string {
+	return "https://t.me/" + BotUsername + "?startgroup=trip_" + token
+}
+
+// TripRecheckDays за сколько дней до поездки перепроверяются аналоги из списка, 0 отключает проверку
+var TripRecheckDays = 3
+
+// recheckTrips повторяет поиск аналогов для списков поездок, до начала которых осталось TripRecheckDays дней
+func recheckTrips(ctx context.Context, b *bot.Bot, now time.Time) {
+	if TripRecheckDays <= 0 {
+		return
+	}
+
+	for _, trip := range Storage.AllTrips() {
+		if trip.Rechecked || trip.Ended || len(trip.Checklist) == 0 {
+			continue
+		}
+		today := now.In(chatLocation(trip.ChatID))
+		if trip.Start > today.AddDate(0, 0, TripRecheckDays).Format("2006-01-02") {
+			continue
+		}
+		if _, quiet := chatQuietUntil(trip.ChatID, now); quiet {
+			continue
+		}
+
+		checklist := append([]ChecklistItem{}, trip.Checklist...)
+		changes := []string{}
+		for index := range checklist {
+			change, ok := recheckItem(ctx, &checklist[index], trip.CountryID)
+			if ok {
+				changes = append(changes, change)
+			}
+		}
+
+		trip, _ = Storage.UpdateTrip(trip.ID, func(trip *Trip) {
+			trip.Rechecked = true
+			if len(changes) > 0 {
+				trip.Checklist = checklist
+			}
+		})
+		if len(changes) == 0 {
+			continue
+		}
+
+		AppMetrics.Incr("trip_rechecks")
+
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:             trip.ChatID,
+			Text:               fmt.Sprintf("Перед поездкой в %s я еще раз проверил список в дорогу:\n%s", bold(trip.CountryName()), strings.Join(changes, "\n")),
+			ParseMode:          models.ParseModeHTML,
+			LinkPreviewOptions: disabledLinkPreview(),
+		})
+		if err != nil {
+			logError(err)
+		}
+		syncChecklist(ctx, b, trip)
+	}
+}
+
+// recheckItem заново ищет аналоги лекарства и описывает изменения, отметки о сборах сохраняются
+func recheckItem(ctx context.Context, item *ChecklistItem, countryID int) (string, bool) {
+	if item.MedicineID == 0 {
+		return "", false
+	}
+
+	analogs, info, err := searchAnalogsContext(ctx, item.MedicineID, countryID)
+	if err != nil {
+		return "", false
+	}
+
+	best, found := pills.BestAnalog(analogs)
+	listed := false
+	for _, analog := range analogs {
+		if len(item.AnalogID) > 0 && analog.AnalogID == item.AnalogID {
+			listed = true
+		}
+	}
+
+	change := ""
+	switch {
+	case len(item.AnalogID) > 0 && !listed && found:
+		change = fmt.Sprintf("• %s: аналог %s больше не указан, теперь лучший %s (%d%%)",
+			bold(item.Medicine), escapeHTML(item.AnalogName), link(best.AnalogName, analogURL(best)), best.Percentage)
+	case len(item.AnalogID) > 0 && !listed:
+		change = fmt.Sprintf("• %s: аналог %s больше не указан, лучше взять лекарство с собой",
+			bold(item.Medicine), escapeHTML(item.AnalogName))
+	case found && best.Percentage > item.Percentage:
+		change = fmt.Sprintf("• %s: появился аналог с большим совпадением — %s (%d%%)",
+			bold(item.Medicine), link(best.AnalogName, analogURL(best)), best.Percentage)
+	case len(info.DateRevision) > 0 && len(item.DateRevision) > 0 && info.DateRevision != item.DateRevision:
+		change = fmt.Sprintf("• %s: данные обновлены %s, стоит перечитать описание аналога",
+			bold(item.Medicine), escapeHTML(info.DateRevision))
+	default:
+		return "", false
+	}
+
+	if !listed || best.Percentage > item.Percentage {
+		item.setAnalog(analogs)
+	}
+	item.DateRevision = info.DateRevision
+
+	return change, true
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// CLISearchResult результат команды search для вывода в JSON
+type CLISearchResult struct {
+	Medicine  pills.Medicine `json:"medicine"`
+	CountryID int            `json:"country_id"`
+	Analogs   []pills.Analog `json:"analogs"`
+}
+
+// isCLI проверяет, что бот запущен как консольная команда, а не как Telegram бот
+func isCLI(args []string) bool {
+	return len(args) > 1 && args[1] == "search"
+}
+
+// runCLI выполняет pills-bot search "нурофен" --target TH [--format json|table] [--limit 10]
+// и возвращает код завершения. Нужны только API_KEY, HOME_COUNTRY_ID и COUNTRIES для кодов стран
+func runCLI(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	target := flags.String("target", os.Getenv("TARGET_COUNTRY_ID"), "страна поиска: код из COUNTRIES или идентификатор")
+	format := flags.String("format", "table", "формат вывода: table или json")
+	limit := flags.Int("limit", 10, "количество аналогов")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Использование: pills-bot search <название> [--target TH] [--format table|json] [--limit 10]")
+		flags.PrintDefaults()
+	}
+
+	// Флаги можно указывать и после названия лекарства
+	words := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return 2
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		words = append(words, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	query := strings.TrimSpace(strings.Join(words, " "))
+	if len(query) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	Countries = parseCountries(os.Getenv("COUNTRIES"))
+	countryID, ok := parseCountryParam(*target)
+	if !ok || countryID == 0 {
+		fmt.Fprintln(stderr, "Неизвестная страна поиска:", *target)
+		return 2
+	}
+	homeCountry, _ := strconv.Atoi(os.Getenv("HOME_COUNTRY_ID"))
+	apiKey := os.Getenv("API_KEY")
+	if len(apiKey) == 0 || homeCountry == 0 {
+		fmt.Fprintln(stderr, "Не указаны API_KEY или HOME_COUNTRY_ID")
+		return 2
+	}
+
+	result, err := cliSearch(context.Background(), pills.NewClient(apiKey, homeCountry), query, countryID)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if *limit > 0 && len(result.Analogs) > *limit {
+		result.Analogs = result.Analogs[:*limit]
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	case "table":
+		writeCLITable(stdout, result)
+	default:
+		fmt.Fprintln(stderr, "Неизвестный формат:", *format)
+		return 2
+	}
+
+	return 0
+}
+
+// cliSearch находит лекарство по названию и его аналоги, лучшие первыми
+func cliSearch(ctx context.Context, client *pills.Client, query string, countryID int) (CLISearchResult, error) {
+	medicines, err := client.SearchMedicines(ctx, query)
+	if err != nil {
+		return CLISearchResult{}, err
+	}
+	if len(medicines) == 0 {
+		return CLISearchResult{}, errors.New("лекарство не найдено: " + query)
+	}
+
+	medicine := medicines[0]
+	for _, candidate := range medicines {
+		if strings.EqualFold(candidate.Name, query) {
+			medicine = candidate
+			break
+		}
+	}
+	medicineID, _ := strconv.Atoi(medicine.ID)
+
+	analogs, _, err := client.SearchAnalogs(ctx, medicineID, countryID)
+	if err != nil {
+		return CLISearchResult{}, err
+	}
+	pills.SortAnalogs(analogs)
+
+	return CLISearchResult{Medicine: medicine, CountryID: countryID, Analogs: analogs}, nil
+}
+
+func writeCLITable(w io.Writer, result CLISearchResult) {
+	fmt.Fprintf(w, "%s (%s)\n\n", result.Medicine.Name, result.Medicine.Components)
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "#\tАналог\tСовпадение\tСсылка")
+	for index, analog := range result.Analogs {
+		fmt.Fprintf(table, "%d\t%s\t%d%%\t%s\n", index+1, analog.AnalogName, analog.Percentage, analogURL(analog))
+	}
+	table.Flush()
+}
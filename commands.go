@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// defaultCommandLanguage используется для пользователей без отдельного перевода меню
+const defaultCommandLanguage = "ru"
+
+type CommandInfo struct {
+	Command      string
+	Descriptions map[string]string
+}
+
+var privateCommands = []CommandInfo{
+	{Command: "start", Descriptions: map[string]string{"ru": "Начать работу", "en": "Get started"}},
+	{Command: "trip", Descriptions: map[string]string{"ru": "Планировать поездку", "en": "Plan a trip"}},
+	{Command: "trips", Descriptions: map[string]string{"ru": "Прошлые поездки", "en": "Past trips"}},
+	{Command: "compare", Descriptions: map[string]string{"ru": "Сравнить аналоги в разных странах", "en": "Compare analogs across countries"}},
+	{Command: "kit", Descriptions: map[string]string{"ru": "Аптечка в дорогу", "en": "Travel first-aid kit"}},
+	{Command: "entry", Descriptions: map[string]string{"ru": "Как провезти лекарства в страну", "en": "Carrying prescriptions abroad"}},
+	{Command: "spent", Descriptions: map[string]string{"ru": "Записать покупку в поездке", "en": "Log a purchase during a trip"}},
+	{Command: "watchlist", Descriptions: map[string]string{"ru": "Слежение за аналогами", "en": "Watched medicines"}},
+	{Command: "subscriptions", Descriptions: map[string]string{"ru": "Подписки и уведомления", "en": "Subscriptions and alerts"}},
+	{Command: "favorites", Descriptions: map[string]string{"ru": "Избранные лекарства", "en": "Favorite medicines"}},
+	{Command: "prescription", Descriptions: map[string]string{"ru": "Распознать рецепт", "en": "Scan a prescription"}},
+	{Command: "identify", Descriptions: map[string]string{"ru": "Определить таблетку", "en": "Identify a pill"}},
+	{Command: "share", Descriptions: map[string]string{"ru": "Поделиться избранным", "en": "Share favorites"}},
+	{Command: "profile", Descriptions: map[string]string{"ru": "Профили семьи", "en": "Family profiles"}},
+	{Command: "dose", Descriptions: map[string]string{"ru": "Расчет дозы по весу", "en": "Weight-based dose"}},
+	{Command: "today", Descriptions: map[string]string{"ru": "Приемы на сегодня", "en": "Today's doses"}},
+	{Command: "remind", Descriptions: map[string]string{"ru": "Напомнить о приеме", "en": "Add a pill reminder"}},
+	{Command: "reminders", Descriptions: map[string]string{"ru": "Мои напоминания", "en": "My reminders"}},
+	{Command: "calendar", Descriptions: map[string]string{"ru": "Напоминания в календарь", "en": "Reminders calendar export"}},
+	{Command: "channel", Descriptions: map[string]string{"ru": "Семейный канал для результатов", "en": "Family channel for results"}},
+	{Command: "export", Descriptions: map[string]string{"ru": "Избранное для офлайн доступа", "en": "Offline favorites bundle"}},
+	{Command: "pregnancy", Descriptions: map[string]string{"ru": "Беременность и кормление грудью", "en": "Pregnancy and breastfeeding"}},
+	{Command: "allergy", Descriptions: map[string]string{"ru": "Аллергии на компоненты", "en": "Component allergies"}},
+	{Command: "age", Descriptions: map[string]string{"ru": "Возраст для подбора аналогов", "en": "Age group for analogs"}},
+	{Command: "home", Descriptions: map[string]string{"ru": "Домашняя страна", "en": "Home country"}},
+	{Command: "apikey", Descriptions: map[string]string{"ru": "Ключ REST API", "en": "REST API key"}},
+	{Command: "limits", Descriptions: map[string]string{"ru": "Дневной лимит поисков", "en": "Daily search limit"}},
+	{Command: "privacy", Descriptions: map[string]string{"ru": "Приватность и мои данные", "en": "Privacy and my data"}},
+	{Command: "adherence", Descriptions: map[string]string{"ru": "Отчет о приеме", "en": "Adherence report"}},
+	{Command: "weekly", Descriptions: map[string]string{"ru": "Сводка за неделю", "en": "Weekly digest"}},
+	{Command: "quiet", Descriptions: map[string]string{"ru": "Тихие часы", "en": "Quiet hours"}},
+	{Command: "timezone", Descriptions: map[string]string{"ru": "Часовой пояс", "en": "Time zone"}},
+	{Command: "voice", Descriptions: map[string]string{"ru": "Голосовые ответы", "en": "Voice replies"}},
+	{Command: "stats", Descriptions: map[string]string{"ru": "Моя статистика", "en": "My stats"}},
+	{Command: "feedback", Descriptions: map[string]string{"ru": "Написать разработчикам", "en": "Send feedback"}},
+	{Command: "cancel", Descriptions: map[string]string{"ru": "Отменить текущее действие", "en": "Cancel current action"}},
+}
+
+var groupCommands = []CommandInfo{
+	{Command: "start", Descriptions: map[string]string{"ru": "Как пользоваться ботом", "en": "How to use the bot"}},
+	{Command: "feedback", Descriptions: map[string]string{"ru": "Написать разработчикам", "en": "Send feedback"}},
+}
+
+var adminCommands = []CommandInfo{
+	{Command: "admin", Descriptions: map[string]string{"ru": "Панель администратора", "en": "Admin dashboard"}},
+	{Command: "digest", Descriptions: map[string]string{"ru": "Сводка за сутки", "en": "Daily digest"}},
+	{Command: "roles", Descriptions: map[string]string{"ru": "Список ролей", "en": "List roles"}},
+	{Command: "grant", Descriptions: map[string]string{"ru": "Назначить роль", "en": "Grant a role"}},
+	{Command: "revoke", Descriptions: map[string]string{"ru": "Отозвать роль", "en": "Revoke a role"}},
+	{Command: "apikeys", Descriptions: map[string]string{"ru": "Ключи REST API", "en": "REST API keys"}},
+	{Command: "quota", Descriptions: map[string]string{"ru": "Назначить тариф лимита поисков", "en": "Set a search quota tier"}},
+	{Command: "graylist", Descriptions: map[string]string{"ru": "Серый список", "en": "Graylist"}},
+	{Command: "unmute", Descriptions: map[string]string{"ru": "Снять ограничение за флуд", "en": "Lift a flood mute"}},
+	{Command: "entry_set", Descriptions: map[string]string{"ru": "Изменить правила ввоза по рецепту", "en": "Edit prescription entry rules"}},
+	{Command: "override", Descriptions: map[string]string{"ru": "Исправить данные об аналогах", "en": "Override analog data"}},
+	{Command: "gtin_import", Descriptions: map[string]string{"ru": "Загрузить справочник штрихкодов", "en": "Import a barcode table"}},
+	{Command: "restrictions", Descriptions: map[string]string{"ru": "Обновить правила ввоза", "en": "Reload import rules"}},
+}
+
+var commandLanguages = []string{"ru", "en"}
+
+// botCommands собирает меню на языке language, недостающие переводы берутся из языка по умолчанию
+func botCommands(commands []CommandInfo, language string) []models.BotCommand {
+	result := []models.BotCommand{}
+	for _, command := range commands {
+		description, ok := command.Descriptions[language]
+		if !ok {
+			description = command.Descriptions[defaultCommandLanguage]
+		}
+		result = append(result, models.BotCommand{
+			Command:     command.Command,
+			Description: description,
+		})
+	}
+
+	return result
+}
+
+// setCommands публикует меню для области scope на всех поддерживаемых языках
+func setCommands(ctx context.Context, b *bot.Bot, scope models.BotCommandScope, commands []CommandInfo) {
+	for _, language := range commandLanguages {
+		params := &bot.SetMyCommandsParams{
+			Commands: botCommands(commands, language),
+			Scope:    scope,
+		}
+		if language != defaultCommandLanguage {
+			params.LanguageCode = language
+		}
+
+		if _, err := b.SetMyCommands(ctx, params); err != nil {
+			logError(err)
+		}
+	}
+}
+
+// publishCommands публикует меню команд для личных чатов, групп и администраторов
+func publishCommands(ctx context.Context, b *bot.Bot) {
+	setCommands(ctx, b, &models.BotCommandScopeAllPrivateChats{}, privateCommands)
+	setCommands(ctx, b, &models.BotCommandScopeAllGroupChats{}, groupCommands)
+
+	roles := Storage.Roles()
+	for userID, role := range AdminRoles {
+		roles[userID] = role
+	}
+	for userID := range roles {
+		publishAdminCommands(ctx, b, userID)
+	}
+}
+
+// publishAdminCommands показывает администратору расширенное меню в личном чате с ботом
+func publishAdminCommands(ctx context.Context, b *bot.Bot, userID int64) {
+	commands := append(append([]CommandInfo{}, privateCommands...), adminCommands...)
+	setCommands(ctx, b, &models.BotCommandScopeChat{ChatID: userID}, commands)
+}
+
+// removeAdminCommands возвращает пользователю обычное меню
+func removeAdminCommands(ctx context.Context, b *bot.Bot, userID int64) {
+	for _, language := range commandLanguages {
+		params := &bot.DeleteMyCommandsParams{
+			Scope: &models.BotCommandScopeChat{ChatID: userID},
+		}
+		if language != defaultCommandLanguage {
+			params.LanguageCode = language
+		}
+
+		if _, err := b.DeleteMyCommands(ctx, params); err != nil {
+			logError(err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// CountryComparison результат поиска аналогов лекарства в одной стране
+type CountryComparison struct {
+	Country Country
+	Best    Analog
+	Found   bool
+	Failed  bool
+}
+
+// compareCountries параллельно ищет аналоги лекарства в каждой из стран
+func compareCountries(ctx context.Context, medicineID int, countries []Country) []CountryComparison {
+	results := make([]CountryComparison, len(countries))
+
+	var wg sync.WaitGroup
+	for index, country := range countries {
+		wg.Add(1)
+		go func(index int, country Country) {
+			defer wg.Done()
+
+			result := CountryComparison{Country: country}
+			analogs, _, err := searchAnalogsContext(ctx, medicineID, country.ID)
+			if err != nil {
+				result.Failed = true
+			}
+			result.Best, result.Found = pills.BestAnalog(analogs)
+			results[index] = result
+		}(index, country)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// parseCompare разбирает аргументы /compare: название лекарства и коды стран в конце
+func parseCompare(args string) (string, []Country) {
+	fields := strings.Fields(strings.ReplaceAll(args, ",", " "))
+	countries := []Country{}
+	for len(fields) > 1 {
+		country, ok := countryByCode(fields[len(fields)-1])
+		if !ok {
+			break
+		}
+		countries = append([]Country{country}, countries...)
+		fields = fields[:len(fields)-1]
+	}
+	if len(countries) == 0 {
+		countries = Countries
+	}
+
+	return strings.Join(fields, " "), countries
+}
+
+func formatComparison(medicineName string, results []CountryComparison) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Аналоги %s по странам:\n", bold(medicineName)))
+	for _, result := range results {
+		text.WriteString("\n" + bold(result.Country.Name) + ": ")
+		switch {
+		case result.Found && pills.IsExact(result.Best):
+			text.WriteString(fmt.Sprintf("✅ есть точный аналог — %s", link(result.Best.AnalogName, analogURL(result.Best))))
+		case result.Found:
+			text.WriteString(fmt.Sprintf("🟡 только частичный — %s (%d%%)", link(result.Best.AnalogName, analogURL(result.Best)), result.Best.Percentage))
+		default:
+			text.WriteString("⚪️ нет данных")
+		}
+		names := []string{medicineName}
+		if result.Found {
+			names = append(names, result.Best.AnalogName)
+		}
+		if len(Restrictions.Find(result.Country.ID, names...)) > 0 {
+			text.WriteString(" ⚠️ ограничения на ввоз")
+		}
+	}
+	text.WriteString("\n\nЕсли точного аналога нет, лекарство лучше взять с собой.")
+
+	return text.String()
+}
+
+func compareHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	_, args, _ := strings.Cut(update.Message.Text, " ")
+	query, countries := parseCompare(args)
+	if len(query) == 0 || len(countries) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Укажите лекарство и коды стран, например: /compare нурофен TH AE\n\nДоступные страны: " + countryNames(),
+		})
+		return
+	}
+
+	if !flagEnabled("search") {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Поиск временно недоступен. Попробуйте позже.",
+		})
+		return
+	}
+
+	b.SendChatAction(ctx, &bot.SendChatActionParams{
+		ChatID: chatID,
+		Action: models.ChatActionTyping,
+	})
+
+	medicine, ok := matchMedicine(ctx, query)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      fmt.Sprintf("Лекарство %s не найдено.", bold(query)),
+			ParseMode: models.ParseModeHTML,
+		})
+		return
+	}
+	medicineID, _ := strconv.Atoi(medicine.ID)
+
+	AppMetrics.Incr("country_comparisons")
+
+	results := compareCountries(ctx, medicineID, countries)
+	sent, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               formatComparison(medicine.Name, results),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{{
+				{Text: "📄 PDF", CallbackData: "compare_sheet:pdf"},
+				{Text: "🖼 Картинка", CallbackData: "compare_sheet:png"},
+			}},
+		},
+	})
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	Comparisons.Set(replyKey{chatID: chatID, messageID: sent.ID}, ComparisonSheet{MedicineName: medicine.Name, Results: results})
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+	"golang.org/x/image/font/opentype"
+)
+
+// ComparisonSheet результаты сравнения стран для выгрузки таблицей
+type ComparisonSheet struct {
+	MedicineName string
+	Results      []CountryComparison
+}
+
+// Comparisons связывает сообщения /compare с результатами для выгрузки в PDF и PNG
+var Comparisons = NewRecentMap[replyKey, ComparisonSheet](200)
+
+// comparisonColumns заголовки и доли ширины колонок таблицы сравнения
+var comparisonColumns = []struct {
+	Title string
+	Width float64
+}{
+	{"Страна", 0.20},
+	{"Лучший аналог", 0.40},
+	{"Совпадение", 0.18},
+	{"Ввоз", 0.22},
+}
+
+// cells возвращает значения строки таблицы сравнения для одной страны
+func (sheet ComparisonSheet) cells(result CountryComparison) []string {
+	analog, percentage := "нет данных", "—"
+	if result.Found {
+		analog = result.Best.AnalogName
+		percentage = strconv.Itoa(result.Best.Percentage) + "%"
+		if pills.IsExact(result.Best) {
+			percentage += " ✓"
+		}
+	}
+
+	names := []string{sheet.MedicineName}
+	if result.Found {
+		names = append(names, result.Best.AnalogName)
+	}
+	entry := "без ограничений"
+	for _, rule := range Restrictions.Find(result.Country.ID, names...) {
+		entry = "ограничено"
+		if rule.Level == "banned" {
+			entry = "запрещено"
+			break
+		}
+	}
+
+	return []string{result.Country.Name, analog, percentage, entry}
+}
+
+// comparisonPDF верстает таблицу сравнения на альбомном листе A4
+func comparisonPDF(sheet ComparisonSheet) ([]byte, error) {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes("DejaVu", "", fontRegular)
+	pdf.AddUTF8FontFromBytes("DejaVu", "B", fontBold)
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 12)
+	pdf.SetTitle("Аналоги "+sheet.MedicineName, true)
+	pdf.AddPage()
+
+	width, _ := pdf.GetPageSize()
+	content := width - 24
+
+	pdf.SetFont("DejaVu", "B", 16)
+	pdf.CellFormat(content, 10, "Аналоги "+sheet.MedicineName+" по странам", "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	pdf.SetFont("DejaVu", "B", 11)
+	pdf.SetFillColor(230, 230, 230)
+	for index, column := range comparisonColumns {
+		line := 0
+		if index == len(comparisonColumns)-1 {
+			line = 1
+		}
+		pdf.CellFormat(content*column.Width, 8, column.Title, "1", line, "L", true, 0, "")
+	}
+
+	pdf.SetFont("DejaVu", "", 11)
+	for _, result := range sheet.Results {
+		for index, value := range sheet.cells(result) {
+			line := 0
+			if index == len(comparisonColumns)-1 {
+				line = 1
+			}
+			url := ""
+			if index == 1 && result.Found {
+				url = analogURL(result.Best)
+			}
+			pdf.CellFormat(content*comparisonColumns[index].Width, 8, value, "1", line, "L", false, 0, url)
+		}
+	}
+
+	pdf.Ln(4)
+	pdf.SetFont("DejaVu", "", 9)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.MultiCell(content, 4.5, "Если точного аналога нет, лекарство лучше взять с собой. Правила ввоза меняются, уточните их перед поездкой.", "", "L", false)
+
+	var buffer bytes.Buffer
+	if err := pdf.Output(&buffer); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+const (
+	sheetImageWidth = 1200
+	sheetRowHeight  = 56
+	sheetHeaderTop  = 120
+)
+
+// comparisonPNG рисует таблицу сравнения изображением с выровненными колонками
+func comparisonPNG(sheet ComparisonSheet) ([]byte, error) {
+	regular, err := opentype.Parse(fontRegular)
+	if err != nil {
+		return nil, err
+	}
+	bold, err := opentype.Parse(fontBold)
+	if err != nil {
+		return nil, err
+	}
+
+	height := sheetHeaderTop + (len(sheet.Results)+1)*sheetRowHeight + 80
+	canvas := image.NewRGBA(image.Rect(0, 0, sheetImageWidth, height))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+
+	tableWidth := sheetImageWidth - 2*cardPadding
+	drawText(canvas, bold, 36, cardText, "Аналоги "+sheet.MedicineName+" по странам", cardPadding, 70, tableWidth, 1)
+
+	divider := image.NewUniform(color.RGBA{R: 0xdd, G: 0xdd, B: 0xdd, A: 0xff})
+	drawRow := func(top int, values []string, parsed *opentype.Font, textColor color.Color) {
+		x := cardPadding
+		for index, value := range values {
+			width := int(float64(tableWidth) * comparisonColumns[index].Width)
+			drawText(canvas, parsed, 24, textColor, value, x, top+36, width-16, 1)
+			x += width
+		}
+		draw.Draw(canvas, image.Rect(cardPadding, top+sheetRowHeight-1, sheetImageWidth-cardPadding, top+sheetRowHeight), divider, image.Point{}, draw.Src)
+	}
+
+	header := []string{}
+	for _, column := range comparisonColumns {
+		header = append(header, column.Title)
+	}
+	draw.Draw(canvas, image.Rect(cardPadding, sheetHeaderTop, sheetImageWidth-cardPadding, sheetHeaderTop+sheetRowHeight), image.NewUniform(color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}), image.Point{}, draw.Src)
+	drawRow(sheetHeaderTop, header, bold, cardText)
+	for index, result := range sheet.Results {
+		drawRow(sheetHeaderTop+(index+1)*sheetRowHeight, sheet.cells(result), regular, cardText)
+	}
+
+	drawText(canvas, regular, 20, cardMuted, "Если точного аналога нет, лекарство лучше взять с собой.", cardPadding, height-36, tableWidth, 1)
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, canvas); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// compareSheetHandler выгружает сравнение стран: compare_sheet:pdf или compare_sheet:png
+func compareSheetHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := callbackChatID(update.CallbackQuery)
+	var sheet ComparisonSheet
+	ok := false
+	if update.CallbackQuery.Message.Message != nil {
+		sheet, ok = Comparisons.Get(replyKey{chatID: chatID, messageID: update.CallbackQuery.Message.Message.ID})
+	}
+	if !ok {
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: update.CallbackQuery.ID,
+			Text:            "Результаты устарели, повторите /compare.",
+			ShowAlert:       true,
+		})
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	AppMetrics.Incr("comparison_exports")
+	if strings.HasSuffix(update.CallbackQuery.Data, ":png") {
+		image, err := comparisonPNG(sheet)
+		if err != nil {
+			logError(err)
+			return
+		}
+		_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
+			ChatID: chatID,
+			Photo:  &models.InputFileUpload{Filename: "comparison.png", Data: bytes.NewReader(image)},
+		})
+		if err != nil {
+			logError(err)
+		}
+		return
+	}
+
+	file, err := comparisonPDF(sheet)
+	if err != nil {
+		logError(err)
+		return
+	}
+	_, err = b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: "comparison.pdf", Data: bytes.NewReader(file)},
+	})
+	if err != nil {
+		logError(err)
+	}
+}
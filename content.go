@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"unicode"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// acceptedInputHelp перечисляет, что бот умеет обрабатывать
+const acceptedInputHelp = "Я понимаю:\n" +
+	"• название лекарства текстом, например «нурофен»\n" +
+	"• фотографию упаковки или штрихкода, подпись к фото тоже подойдет\n" +
+	"• фотографию рецепта с подписью «рецепт»\n" +
+	"• файл .csv или .txt со списком лекарств\n" +
+	"• геопозицию, чтобы выбрать страну поиска и найти аптеки рядом"
+
+// routeMessageContent направляет сообщение по типу содержимого,
+// возвращает false для текстовых сообщений
+func routeMessageContent(ctx context.Context, b *bot.Bot, update *models.Update) bool {
+	message := update.Message
+
+	var reply string
+	switch {
+	case len(message.Photo) > 0:
+		photoHandler(ctx, b, update)
+		return true
+	case message.Document != nil:
+		documentHandler(ctx, b, update)
+		return true
+	case message.Sticker != nil:
+		reply = "Стикер милый, но лекарства по нему не найти."
+	case message.Contact != nil:
+		reply = "Контакты мне ни к чему."
+	case message.Voice != nil || message.Audio != nil || message.VideoNote != nil:
+		reply = "Голосовые и аудио сообщения я пока не понимаю."
+	case message.Video != nil || message.Animation != nil:
+		reply = "Видео я пока не понимаю."
+	case len(message.Text) > 0:
+		if hasLettersOrDigits(message.Text) {
+			return false
+		}
+		reply = "В сообщении нет названия лекарства."
+	case message.Location != nil || message.Venue != nil:
+		locationHandler(ctx, b, update)
+		return true
+	case message.Poll != nil || message.Dice != nil:
+		reply = "Такие сообщения я не обрабатываю."
+	default:
+		// Служебные сообщения о новых участниках, закрепах и т. п. не требуют ответа
+		return true
+	}
+
+	// В группах бот не отвечает на случайные стикеры и медиа участников
+	if !isPrivateChat(message.Chat.ID) {
+		return true
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: message.Chat.ID,
+		Text:   reply + "\n\n" + acceptedInputHelp,
+	})
+
+	return true
+}
+
+// hasLettersOrDigits отличает запрос от сообщения только из эмодзи и знаков
+func hasLettersOrDigits(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Сценарии поиска, общие для Telegram, HTTP API, gRPC и других мессенджеров.
+// Транспорт отвечает только за разбор запроса и оформление ответа
+
+var (
+	errSearchDisabled = errors.New("поиск временно недоступен")
+	errShortQuery     = errors.New("запрос должен быть не короче 2 символов")
+	errSearchFailed   = errors.New("ошибка поиска")
+)
+
+// AnalogsResult аналоги лекарства в стране поиска с правилами ввоза в нее
+type AnalogsResult struct {
+	MedicineID   int
+	Medicine     MedicineInfo
+	CountryID    int
+	Analogs      []Analog
+	Restrictions []Restriction
+}
+
+// findMedicines ищет лекарства по названию, source транспорт запроса для вебхуков
+func findMedicines(ctx context.Context, source string, query string) ([]Medicine, error) {
+	if !flagEnabled("search") {
+		return nil, errSearchDisabled
+	}
+
+	query = strings.TrimSpace(query)
+	if len([]rune(query)) < 2 {
+		return nil, errShortQuery
+	}
+	if sensitiveQuery(query) {
+		return nil, errSensitiveQuery
+	}
+
+	AppMetrics.Incr("searches")
+	Webhooks.Emit(EventSearchPerformed, map[string]any{"source": source, "query": query})
+
+	medicines, err := searchMedicinesContext(ctx, query)
+	if err != nil {
+		return nil, errSearchFailed
+	}
+
+	return medicines, nil
+}
+
+// findAnalogsContext ищет аналоги лекарства в стране countryID с контекстом ctx, он может
+// ограничить поиск кэшем
+func findAnalogsContext(ctx context.Context, medicineID int, countryID int) (AnalogsResult, error) {
+	if !flagEnabled("search") {
+		return AnalogsResult{}, errSearchDisabled
+	}
+
+	AppMetrics.Incr("analog_searches")
+
+	analogs, info, err := searchAnalogsContext(ctx, medicineID, countryID)
+	result := AnalogsResult{
+		MedicineID: medicineID,
+		Medicine:   info,
+		CountryID:  countryID,
+		Analogs:    analogs,
+	}
+	if err != nil {
+		return result, errSearchFailed
+	}
+	result.Restrictions = Restrictions.Find(countryID, analogNames(info.MedicineName, analogs)...)
+
+	return result, nil
+}
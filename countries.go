@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Country страна поиска аналогов, ID соответствует идентификатору страны в API
+type Country struct {
+	Code string
+	ID   int
+	Name string
+	// Timezone основной часовой пояс страны для определения по геопозиции
+	Timezone string
+}
+
+// Countries поддерживаемые страны из настройки COUNTRIES
+var Countries []Country
+
+// parseCountries разбирает список вида RU:94:Россия:Europe/Moscow,TH:113:Таиланд,
+// название и часовой пояс необязательны
+func parseCountries(value string) []Country {
+	countries := []Country{}
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), ":", 4)
+		if len(parts) < 2 {
+			continue
+		}
+
+		id, err := strconv.Atoi(parts[1])
+		if err != nil || id <= 0 {
+			continue
+		}
+
+		country := Country{Code: strings.ToUpper(parts[0]), ID: id, Name: strings.ToUpper(parts[0])}
+		if len(parts) > 2 && len(parts[2]) > 0 {
+			country.Name = parts[2]
+		}
+		if len(parts) > 3 {
+			if _, err := loadTimezone(parts[3]); err == nil {
+				country.Timezone = parts[3]
+			}
+		}
+		countries = append(countries, country)
+	}
+
+	return countries
+}
+
+func countryByCode(code string) (Country, bool) {
+	for _, country := range Countries {
+		if strings.EqualFold(country.Code, code) {
+			return country, true
+		}
+	}
+
+	return Country{}, false
+}
+
+func countryByID(id int) (Country, bool) {
+	for _, country := range Countries {
+		if country.ID == id {
+			return country, true
+		}
+	}
+
+	return Country{}, false
+}
+
+// targetCountry возвращает страну поиска чата: страну текущей поездки,
+// затем страну из настроек чата, по умолчанию TARGET_COUNTRY_ID
+func targetCountry(chatID int64) int {
+	if trip, ok := activeTrip(chatID); ok {
+		return trip.CountryID
+	}
+	if countryID := Storage.ChatSettings(chatID).CountryID; countryID != 0 {
+		return countryID
+	}
+
+	return TargetCountryID
+}
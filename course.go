@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// refillWarningDays за сколько дней предупреждать об окончании курса или запаса
+const refillWarningDays = 3
+
+// courseRegexp находит длительность курса: «курс 10 дней», «курс 14»
+var courseRegexp = regexp.MustCompile(`(?i)курс\s*(\d+)\s*(?:дн\S*|день|д)?`)
+
+// supplyRegexp находит запас: «осталось 30 шт», «20 таблеток», «по 2 таб» задает разовую дозу
+var supplyRegexp = regexp.MustCompile(`(?i)(по\s+)?(\d+)\s*(?:шт\S*|таб\S*|капс\S*)`)
+
+// parseCourse извлекает из текста напоминания длительность курса и запас таблеток
+func parseCourse(reminder *Reminder, text string) string {
+	if match := courseRegexp.FindStringSubmatchIndex(text); match != nil {
+		reminder.CourseDays, _ = strconv.Atoi(text[match[2]:match[3]])
+		text = text[:match[0]] + text[match[1]:]
+	}
+
+	for {
+		match := supplyRegexp.FindStringSubmatchIndex(text)
+		if match == nil {
+			break
+		}
+		count, _ := strconv.Atoi(text[match[4]:match[5]])
+		if match[2] >= 0 {
+			reminder.PillsPerDose = count
+		} else {
+			reminder.PillsLeft = count
+		}
+		text = text[:match[0]] + text[match[1]:]
+	}
+	text = strings.ReplaceAll(text, "осталось", "")
+
+	return text
+}
+
+// courseEnd возвращает конец последнего дня курса, начинающегося в день start
+func courseEnd(start time.Time, days int, location *time.Location) time.Time {
+	local := start.In(location)
+
+	return time.Date(local.Year(), local.Month(), local.Day()+days, 0, 0, 0, 0, location)
+}
+
+// supplyDaysLeft оценивает, на сколько дней хватит оставшихся таблеток
+func supplyDaysLeft(reminder Reminder) int {
+	perDay := reminder.PillsPerDose * len(reminder.Times)
+	if perDay == 0 {
+		perDay = len(reminder.Times)
+	}
+	if perDay == 0 {
+		return 0
+	}
+
+	return reminder.PillsLeft / perDay
+}
+
+// courseJob проверяет сроки курсов и запасы таблеток
+func courseJob(b *bot.Bot) Job {
+	return Job{
+		Name: "courses",
+		Next: every(time.Hour),
+		Run: func(ctx context.Context) {
+			checkCourses(ctx, b, time.Now())
+		},
+	}
+}
+
+func checkCourses(ctx context.Context, b *bot.Bot, now time.Time) {
+	for _, reminder := range Storage.AllReminders() {
+		// Предупреждения повторятся при следующей проверке после тихих часов
+		if _, quiet := chatQuietUntil(reminder.ChatID, now); quiet {
+			continue
+		}
+
+		if !reminder.CourseEnd.IsZero() && !now.Before(reminder.CourseEnd) {
+			Storage.DeleteReminder(reminder.ID)
+			sendCourseNotice(ctx, b, reminder, fmt.Sprintf("Курс %s закончился, напоминание удалено.", bold(reminder.Title())), false)
+			continue
+		}
+
+		if !reminder.CourseEnd.IsZero() && !reminder.CourseWarned && reminder.CourseEnd.Sub(now) <= refillWarningDays*24*time.Hour {
+			Storage.UpdateReminder(reminder.ID, func(reminder *Reminder) {
+				reminder.CourseWarned = true
+			})
+			sendCourseNotice(ctx, b, reminder, fmt.Sprintf("Курс %s заканчивается %s. Если лекарство понадобится дольше, а купить его здесь не получится, поищите аналоги.",
+				bold(reminder.Title()), reminder.CourseEnd.Add(-time.Minute).In(reminder.Location()).Format("02.01")), true)
+			continue
+		}
+
+		if reminder.PillsLeft > 0 && !reminder.SupplyWarned && supplyDaysLeft(reminder) <= refillWarningDays {
+			Storage.UpdateReminder(reminder.ID, func(reminder *Reminder) {
+				reminder.SupplyWarned = true
+			})
+			sendCourseNotice(ctx, b, reminder, fmt.Sprintf("%s осталось на %d дн. (%d шт.). Пора пополнить запас, а если лекарство здесь не продается, поищите аналоги.",
+				bold(reminder.Title()), supplyDaysLeft(reminder), reminder.PillsLeft), true)
+		}
+	}
+}
+
+// sendCourseNotice отправляет предупреждение о курсе, search добавляет кнопку поиска аналогов
+// в текущей стране поиска чата
+func sendCourseNotice(ctx context.Context, b *bot.Bot, reminder Reminder, text string, search bool) {
+	params := &bot.SendMessageParams{
+		ChatID:    reminder.ChatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}
+	if search {
+		title := "Найти аналоги"
+		if country, ok := countryByID(targetCountry(reminder.ChatID)); ok {
+			title += " (" + country.Name + ")"
+		}
+		params.ReplyMarkup = &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{
+					{Text: title, CallbackData: truncateBytes("search_query:"+reminder.Medicine, callbackDataLimit)},
+				},
+			},
+		}
+	}
+
+	if _, err := b.SendMessage(ctx, params); err != nil {
+		logError(err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// InFlight нажатия кнопок, обработка которых еще не закончилась, по чату и данным кнопки
+var InFlight = &inFlightActions{actions: map[string]struct{}{}}
+
+type inFlightActions struct {
+	mu      sync.Mutex
+	actions map[string]struct{}
+}
+
+// Start отмечает начало действия key и сообщает false, если оно уже выполняется
+func (a *inFlightActions) Start(key string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.actions[key]; ok {
+		return false
+	}
+	a.actions[key] = struct{}{}
+
+	return true
+}
+
+func (a *inFlightActions) Finish(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.actions, key)
+}
+
+// dedupCallbackMiddleware не запускает повторно кнопку, нажатую до завершения первого нажатия
+func dedupCallbackMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		if update.CallbackQuery == nil {
+			next(ctx, b, update)
+			return
+		}
+
+		key := strconv.FormatInt(callbackChatID(update.CallbackQuery), 10) + ":" + update.CallbackQuery.Data
+		if update.CallbackQuery.InlineMessageID != "" {
+			key = update.CallbackQuery.InlineMessageID + ":" + update.CallbackQuery.Data
+		}
+		if !InFlight.Start(key) {
+			AppMetrics.Incr("callbacks_deduplicated")
+			b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+				CallbackQueryID: update.CallbackQuery.ID,
+				Text:            "Уже выполняется…",
+			})
+			return
+		}
+		defer InFlight.Finish(key)
+
+		next(ctx, b, update)
+	}
+}
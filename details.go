@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// medicineInfoHandler отправляет описание лекарства: medicine_info:<лекарство>
+func medicineInfoHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	medicineID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "medicine_info:"))
+	details, err := medicineDetails(ctx, medicineID)
+	if err != nil {
+		details = MedicineDetails{}
+	}
+	label, _ := usLabel(ctx, medicineID)
+
+	AppMetrics.Incr("medicine_details")
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               medicineDetailsText(ctx, chatID, medicineID, details, label),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+// medicineDetailsText описание лекарства с формами выпуска, безопасностью и инструкцией FDA,
+// если она нашлась
+func medicineDetailsText(ctx context.Context, chatID int64, medicineID int, details MedicineDetails, label DrugLabel) string {
+	var text strings.Builder
+	name := details.MedicineName
+	if len(name) == 0 {
+		name = "Лекарство " + strconv.Itoa(medicineID)
+	}
+	text.WriteString(bold(name))
+
+	if substance, ok := ATC.Classify(name, medicineComponents(ctx, MedicineInfo{MedicineID: strconv.Itoa(medicineID), MedicineName: name})); ok {
+		text.WriteString("\n" + atcLine(substance))
+	}
+
+	if len(details.Forms) > 0 {
+		text.WriteString("\n\n" + bold("Формы выпуска"))
+		for _, form := range details.Forms {
+			line := "\n• " + escapeHTML(form.Name)
+			if len(form.Concentration) > 0 {
+				line += ", " + escapeHTML(form.Concentration)
+			}
+			text.WriteString(line)
+		}
+	}
+	if details.DoseMgPerKg > 0 && featureAvailable(chatID, "dose") {
+		text.WriteString(fmt.Sprintf("\n\nДоза: %s мг/кг, рассчитать по весу: /dose", formatAmount(details.DoseMgPerKg)))
+	}
+	if warnings := safetyWarnings(ctx, chatID, medicineID); len(warnings) > 0 {
+		text.WriteString("\n" + warnings)
+	}
+
+	if len(label.Indications) > 0 || len(label.Warnings) > 0 {
+		text.WriteString("\n\n🇺🇸 " + bold("Инструкция FDA: "+label.Name) + " " + italic("(на английском)"))
+		if len(label.Indications) > 0 {
+			text.WriteString("\n" + bold("Indications:") + " " + escapeHTML(label.Indications))
+		}
+		if len(label.Warnings) > 0 {
+			text.WriteString("\n" + bold("Warnings:") + " " + escapeHTML(label.Warnings))
+		}
+		source := "open.fda.gov"
+		if len(label.URL) > 0 {
+			source = link("DailyMed", label.URL)
+		}
+		text.WriteString("\n" + italic("источник: ") + source)
+	}
+	if len(details.Forms) == 0 && len(label.Indications) == 0 && len(label.Warnings) == 0 {
+		text.WriteString("\n\nПодробного описания пока нет.")
+	}
+
+	text.WriteString("\n\n" + italic(footerText(chatID)))
+
+	return text.String()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Dialog описывает ожидаемый от пользователя ответ в многошаговом сценарии
+type Dialog struct {
+	Kind string
+	Data map[string]string
+}
+
+type DialogHandler func(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog)
+
+// Dialogs хранит незавершенные сценарии по чатам
+type Dialogs struct {
+	mu    sync.Mutex
+	chats map[int64]Dialog
+}
+
+var ChatDialogs = &Dialogs{chats: map[int64]Dialog{}}
+
+func (d *Dialogs) Start(chatID int64, kind string, data map[string]string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if data == nil {
+		data = map[string]string{}
+	}
+	d.chats[chatID] = Dialog{Kind: kind, Data: data}
+}
+
+// Take возвращает сценарий чата и удаляет его
+func (d *Dialogs) Take(chatID int64) (Dialog, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dialog, ok := d.chats[chatID]
+	delete(d.chats, chatID)
+
+	return dialog, ok
+}
+
+// Peek возвращает сценарий чата, не удаляя его
+func (d *Dialogs) Peek(chatID int64) (Dialog, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dialog, ok := d.chats[chatID]
+
+	return dialog, ok
+}
+
+// handleDialog передает сообщение обработчику незавершенного сценария
+func handleDialog(ctx context.Context, b *bot.Bot, update *models.Update) bool {
+	if len(update.Message.Text) == 0 {
+		return false
+	}
+
+	dialog, ok := ChatDialogs.Take(update.Message.Chat.ID)
+	if !ok {
+		return false
+	}
+
+	handler, ok := dialogHandlers[dialog.Kind]
+	if !ok {
+		return false
+	}
+
+	handler(ctx, b, update, dialog)
+
+	return true
+}
+
+func cancelHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	text := "Нечего отменять."
+	if _, ok := ChatDialogs.Take(update.Message.Chat.ID); ok {
+		text = "Отменено."
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
+
+// dialogHandlers сопоставляет виды сценариев с их обработчиками
+var dialogHandlers map[string]DialogHandler
+
+func init() {
+	dialogHandlers = map[string]DialogHandler{
+		"feedback":           feedbackDialog,
+		"report":             reportDialog,
+		"pill":               pillDialog,
+		"reminder_time":      reminderTimeDialog,
+		"dose_concentration": doseConcentrationDialog,
+		"dose_weight":        doseWeightDialog,
+		"dose_per_kg":        dosePerKgDialog,
+		"channel_link":       channelLinkDialog,
+		"price_target":       priceTargetDialog,
+		"prescription":       prescriptionDialog,
+	}
+}
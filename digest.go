@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// adminDigestJob возвращает задачу отправки сводки по настройкам DIGEST и DIGEST_TIME
+func adminDigestJob(b *bot.Bot, period string, at string) (Job, bool) {
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		clock, _ = time.Parse("15:04", "09:00")
+	}
+
+	// Сводка приходит по часовому поясу чата администраторов
+	location := func() *time.Location {
+		return chatLocation(AdminChatID)
+	}
+
+	job := Job{Name: "admin_digest"}
+	switch period {
+	case "daily":
+		job.Next = dailyAt(clock.Hour(), clock.Minute(), location)
+		job.Run = func(ctx context.Context) {
+			sendAdminDigest(ctx, b, "за сутки", time.Now().AddDate(0, 0, -1))
+		}
+	case "weekly":
+		job.Next = weeklyAt(time.Monday, clock.Hour(), clock.Minute(), location)
+		job.Run = func(ctx context.Context) {
+			sendAdminDigest(ctx, b, "за неделю", time.Now().AddDate(0, 0, -7))
+		}
+	default:
+		return Job{}, false
+	}
+
+	return job, true
+}
+
+func sendAdminDigest(ctx context.Context, b *bot.Bot, title string, since time.Time) {
+	if AdminChatID == 0 {
+		return
+	}
+
+	text := formatAdminDigest(title, since)
+	runOutsideQuietHours(ctx, AdminChatID, func(ctx context.Context) {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: AdminChatID,
+			Text:   text,
+		})
+		if err != nil {
+			logError(err)
+		}
+	})
+}
+
+func formatAdminDigest(title string, since time.Time) string {
+	usage := AppMetrics.UsageSince(since)
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Сводка %s\n\n", title))
+	text.WriteString(fmt.Sprintf("Новых пользователей: %d\n", Storage.NewUsersSince(since)))
+	text.WriteString(fmt.Sprintf("Поисков: %d\n", usage["searches"]))
+	text.WriteString(fmt.Sprintf("Ошибок: %d\n", usage["errors"]))
+	text.WriteString(fmt.Sprintf("Запросов к API: %d", usage["api_requests"]))
+	if ApiDailyQuota > 0 {
+		today := AppMetrics.UsageSince(startOfDay(time.Now()))
+		text.WriteString(fmt.Sprintf(" (сегодня %d из %d)", today["api_requests"], ApiDailyQuota))
+	}
+	if APIMonthlyBudget > 0 || APIRequestCost > 0 {
+		text.WriteString("\n" + formatBudget(time.Now()))
+	}
+
+	if top := Storage.TopMedicinesSince(since, 5); len(top) > 0 {
+		text.WriteString("\n\nПопулярные лекарства:")
+		for index, medicine := range top {
+			text.WriteString(fmt.Sprintf("\n%d. %s (%d)", index+1, medicine.Name, medicine.Count))
+		}
+	}
+
+	return text.String()
+}
+
+func digestHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   formatAdminDigest("за сутки", time.Now().AddDate(0, 0, -1)),
+	})
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// disclaimerTexts предупреждение, которое пользователь принимает перед первым поиском,
+// если в правилах домашней страны нет своего текста
+var disclaimerTexts = map[string]string{
+	"ru": "⚕️ " + bold("Важно") + "\n\nБот подбирает аналоги по составу и показывает справочную информацию из открытых источников. " +
+		"Это не медицинская консультация и не назначение лечения. Дозировки, противопоказания и совместимость " +
+		"лекарств уточняйте у врача или фармацевта. В экстренной ситуации обращайтесь за медицинской помощью.",
+	"en": "⚕️ " + bold("Important") + "\n\nThe bot matches medicines by their active ingredients and shows reference information from public sources. " +
+		"It is not medical advice or a prescription. Check dosage, contraindications and interactions " +
+		"with a doctor or pharmacist. In an emergency, seek medical help.",
+}
+
+// defaultDisclaimerKey ключ disclaimerTexts, disclaimerVersion увеличивается при каждом изменении их текста
+const (
+	defaultDisclaimerKey = "default"
+	disclaimerVersion    = 1
+)
+
+// disclaimerUpdated предваряет предупреждение, если пользователь принимал его прежнюю версию
+var disclaimerUpdated = map[string]string{
+	"ru": "🔄 Условия использования обновились. Пожалуйста, прочитайте и примите их снова.\n\n",
+	"en": "🔄 The terms of use have been updated. Please read and accept them again.\n\n",
+}
+
+var disclaimerButtons = map[string]string{
+	"ru": "Понятно, принимаю",
+	"en": "I understand and accept",
+}
+
+// disclaimerFooter подпись под карточками аналогов по умолчанию
+const disclaimerFooter = "ℹ️ Справочная информация, не медицинская рекомендация."
+
+// PendingSearch поиск, отложенный до принятия предупреждения или проверки в captcha.go
+type PendingSearch struct {
+	ChatID     int64
+	Query      string
+	MedicineID int
+}
+
+// PendingSearches поиски пользователей, ожидающие принятия предупреждения
+var PendingSearches = NewRecentMap[int64, PendingSearch](1000)
+
+func disclaimerLanguage(from *models.User) string {
+	if from != nil && !strings.HasPrefix(from.LanguageCode, "ru") && len(from.LanguageCode) > 0 {
+		return "en"
+	}
+
+	return "ru"
+}
+
+// disclaimerAccepted проверяет без отправки сообщений, что пользователь принял текущую версию
+// предупреждения для чата chatID
+func disclaimerAccepted(from *models.User, chatID int64) bool {
+	if from == nil {
+		return true
+	}
+	disclaimer := chatDisclaimer(chatID, disclaimerLanguage(from))
+
+	return Storage.AcceptedDisclaimer(from.ID, disclaimer.Key) >= disclaimer.Version
+}
+
+// requireDisclaimer проверяет, что пользователь принял предупреждение. Если нет, отправляет его
+// и откладывает поиск pending до нажатия кнопки
+func requireDisclaimer(ctx context.Context, b *bot.Bot, from *models.User, pending PendingSearch) bool {
+	if disclaimerAccepted(from, pending.ChatID) {
+		return true
+	}
+	language := disclaimerLanguage(from)
+	disclaimer := chatDisclaimer(pending.ChatID, language)
+	accepted := Storage.AcceptedDisclaimer(from.ID, disclaimer.Key)
+
+	text := disclaimer.Text
+	if accepted > 0 {
+		text = disclaimerUpdated[language] + text
+	}
+
+	PendingSearches.Set(from.ID, pending)
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    pending.ChatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{{{
+				Text:         disclaimerButtons[language],
+				CallbackData: fmt.Sprintf("disclaimer_accept:%s:%d", disclaimer.Key, disclaimer.Version),
+			}}},
+		},
+	})
+	if err != nil {
+		logError(err)
+	}
+
+	return false
+}
+
+// disclaimerAcceptHandler запоминает согласие нажавшего пользователя с версией предупреждения
+// и выполняет отложенный поиск: disclaimer_accept:<ключ>:<версия>
+func disclaimerAcceptHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	from := update.CallbackQuery.From
+	key, value, _ := strings.Cut(strings.TrimPrefix(update.CallbackQuery.Data, "disclaimer_accept:"), ":")
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		// Кнопка без версии отправлена до появления версий предупреждения
+		disclaimer := chatDisclaimer(callbackChatID(update.CallbackQuery), disclaimerLanguage(&from))
+		key, version = disclaimer.Key, disclaimer.Version
+	}
+	Storage.AcceptDisclaimer(&from, key, version, time.Now())
+	AppMetrics.Incr("disclaimers_accepted")
+
+	runPendingSearch(ctx, b, &from)
+}
+
+// runPendingSearch выполняет поиск, отложенный до принятия предупреждения или проверки
+func runPendingSearch(ctx context.Context, b *bot.Bot, from *models.User) {
+	pending, ok := PendingSearches.Get(from.ID)
+	if !ok {
+		return
+	}
+	PendingSearches.Delete(from.ID)
+
+	if pending.MedicineID > 0 {
+		sendAnalogs(ctx, b, pending.ChatID, from, pending.MedicineID)
+		return
+	}
+	if len(pending.Query) > 0 {
+		sendMedicineSearch(ctx, b, pending.ChatID, from, pending.Query)
+	}
+}
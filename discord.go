@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	discordAPI = "https://discord.com/api/v10"
+	// discordSelectLimit максимальное количество вариантов в меню выбора Discord
+	discordSelectLimit = 25
+	// discordContentLimit максимальная длина сообщения Discord
+	discordContentLimit = 2000
+)
+
+// Типы взаимодействий и ответов Discord Interactions API
+const (
+	discordInteractionPing      = 1
+	discordInteractionCommand   = 2
+	discordInteractionComponent = 3
+
+	discordResponsePong            = 1
+	discordResponseDeferredMessage = 5
+	discordResponseDeferredUpdate  = 6
+	discordComponentActionRow      = 1
+	discordComponentStringSelect   = 3
+	discordOptionSubcommand        = 1
+	discordOptionString            = 3
+	discordCustomIDAnalogs         = "analogs"
+)
+
+// Discord адаптер Discord, nil если он не настроен
+var Discord *DiscordAdapter
+
+// DiscordAdapter принимает взаимодействия Discord по HTTP и отвечает через общие сценарии поиска
+type DiscordAdapter struct {
+	AppID     string
+	BotToken  string
+	PublicKey ed25519.PublicKey
+	Client    *http.Client
+}
+
+type discordInteraction struct {
+	Type  int                    `json:"type"`
+	Token string                 `json:"token"`
+	Data  discordInteractionData `json:"data"`
+}
+
+type discordInteractionData struct {
+	Name     string          `json:"name"`
+	Options  []discordOption `json:"options"`
+	CustomID string          `json:"custom_id"`
+	Values   []string        `json:"values"`
+}
+
+type discordOption struct {
+	Name    string          `json:"name"`
+	Type    int             `json:"type"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Options []discordOption `json:"options,omitempty"`
+}
+
+type discordMessage struct {
+	Content    string             `json:"content"`
+	Components []discordComponent `json:"components"`
+}
+
+type discordComponent struct {
+	Type        int                `json:"type"`
+	CustomID    string             `json:"custom_id,omitempty"`
+	Placeholder string             `json:"placeholder,omitempty"`
+	Options     []discordSelect    `json:"options,omitempty"`
+	Components  []discordComponent `json:"components,omitempty"`
+}
+
+type discordSelect struct {
+	Label       string `json:"label"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+type discordCommand struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Options     []discordCommandOption `json:"options,omitempty"`
+}
+
+type discordCommandOption struct {
+	Type        int                    `json:"type"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Required    bool                   `json:"required,omitempty"`
+	Options     []discordCommandOption `json:"options,omitempty"`
+}
+
+// newDiscordAdapter создает адаптер, если заданы DISCORD_APP_ID, DISCORD_BOT_TOKEN и DISCORD_PUBLIC_KEY
+func newDiscordAdapter(appID string, botToken string, publicKey string) (*DiscordAdapter, bool) {
+	key, err := hex.DecodeString(publicKey)
+	if len(appID) == 0 || len(botToken) == 0 || err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, false
+	}
+
+	return &DiscordAdapter{
+		AppID:     appID,
+		BotToken:  botToken,
+		PublicKey: ed25519.PublicKey(key),
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}, true
+}
+
+// RegisterCommands объявляет slash команду /pill search query:<название> [country:<код>]
+func (d *DiscordAdapter) RegisterCommands() error {
+	commands := []discordCommand{
+		{
+			Name:        "pill",
+			Description: "Поиск аналогов лекарств",
+			Options: []discordCommandOption{
+				{
+					Type:        discordOptionSubcommand,
+					Name:        "search",
+					Description: "Найти лекарство и его аналоги",
+					Options: []discordCommandOption{
+						{Type: discordOptionString, Name: "query", Description: "Название лекарства", Required: true},
+						{Type: discordOptionString, Name: "country", Description: "Код страны поиска, например TH"},
+					},
+				},
+			},
+		},
+	}
+
+	return d.request(http.MethodPut, "/applications/"+d.AppID+"/commands", commands)
+}
+
+func (d *DiscordAdapter) request(method string, path string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(method, discordAPI+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bot "+d.BotToken)
+
+	response, err := d.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("discord: %s %s: %d %s", method, path, response.StatusCode, message)
+	}
+
+	return nil
+}
+
+// editOriginal заменяет отложенный ответ на взаимодействие
+func (d *DiscordAdapter) editOriginal(token string, message discordMessage) {
+	if err := d.request(http.MethodPatch, "/webhooks/"+d.AppID+"/"+token+"/messages/@original", message); err != nil {
+		logError(err)
+	}
+}
+
+// ServeHTTP проверяет подпись Discord и обрабатывает взаимодействие
+func (d *DiscordAdapter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	signature, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	timestamp := r.Header.Get("X-Signature-Timestamp")
+	if err != nil || !ed25519.Verify(d.PublicKey, append([]byte(timestamp), body...), signature) {
+		http.Error(w, "неверная подпись", http.StatusUnauthorized)
+		return
+	}
+
+	interaction := discordInteraction{}
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch interaction.Type {
+	case discordInteractionPing:
+		writeJSON(w, map[string]int{"type": discordResponsePong})
+	case discordInteractionCommand:
+		// Поиск может занять больше 3 секунд, поэтому ответ откладывается и приходит правкой сообщения
+		// Контекст запроса отменяется после ответа, поиск идет в своем
+		writeJSON(w, map[string]int{"type": discordResponseDeferredMessage})
+		go d.handleSearch(context.Background(), interaction)
+	case discordInteractionComponent:
+		writeJSON(w, map[string]int{"type": discordResponseDeferredUpdate})
+		go d.handleAnalogs(context.Background(), interaction)
+	default:
+		http.Error(w, "неизвестное взаимодействие", http.StatusBadRequest)
+	}
+}
+
+// find находит строковый параметр команды, в том числе внутри подкоманды
+func (o discordOption) find(name string) string {
+	for _, option := range o.Options {
+		if option.Name == name {
+			value := ""
+			json.Unmarshal(option.Value, &value)
+			return value
+		}
+		if value := option.find(name); len(value) > 0 {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func (d *DiscordAdapter) handleSearch(ctx context.Context, interaction discordInteraction) {
+	AppMetrics.Incr("discord_searches")
+
+	root := discordOption{Options: interaction.Data.Options}
+	query := root.find("query")
+	countryID, ok := parseCountryParam(root.find("country"))
+	if !ok {
+		d.editOriginal(interaction.Token, discordMessage{Content: "Неизвестная страна. Доступные страны: " + countryNames()})
+		return
+	}
+
+	medicines, err := findMedicines(ctx, "discord", query)
+	if errors.Is(err, errSensitiveQuery) {
+		d.editOriginal(interaction.Token, discordMessage{Content: sensitivePolicy})
+		return
+	}
+	if err != nil || len(medicines) == 0 {
+		d.editOriginal(interaction.Token, discordMessage{Content: fmt.Sprintf("Мне не удалось ничего найти по запросу **%s**.", query)})
+		return
+	}
+
+	options := []discordSelect{}
+	for index, medicine := range medicines {
+		if index == discordSelectLimit {
+			break
+		}
+		options = append(options, discordSelect{
+			Label:       truncateRunes(medicine.Name, 100),
+			Value:       medicine.ID,
+			Description: truncateRunes(medicine.Components, 100),
+		})
+	}
+
+	d.editOriginal(interaction.Token, discordMessage{
+		Content: fmt.Sprintf("Вот что я нашел по запросу **%s**. Выберите лекарство, для которого нужно найти аналоги.", query),
+		Components: []discordComponent{
+			{
+				Type: discordComponentActionRow,
+				Components: []discordComponent{
+					{
+						Type:        discordComponentStringSelect,
+						CustomID:    discordCustomIDAnalogs + ":" + strconv.Itoa(countryID),
+						Placeholder: "Лекарство",
+						Options:     options,
+					},
+				},
+			},
+		},
+	})
+}
+
+func (d *DiscordAdapter) handleAnalogs(ctx context.Context, interaction discordInteraction) {
+	countryValue, ok := strings.CutPrefix(interaction.Data.CustomID, discordCustomIDAnalogs+":")
+	if !ok || len(interaction.Data.Values) == 0 {
+		return
+	}
+	countryID, _ := strconv.Atoi(countryValue)
+	medicineID, err := strconv.Atoi(interaction.Data.Values[0])
+	if err != nil {
+		return
+	}
+
+	result, err := findAnalogsContext(ctx, medicineID, countryID)
+	if err != nil || len(result.Analogs) == 0 {
+		d.editOriginal(interaction.Token, discordMessage{Content: fmt.Sprintf("Мне не удалось найти аналоги для **%s**.", result.Medicine.MedicineName), Components: []discordComponent{}})
+		return
+	}
+
+	d.editOriginal(interaction.Token, discordMessage{Content: formatAnalogsMarkdown(result, discordContentLimit), Components: []discordComponent{}})
+}
+
+// formatAnalogsMarkdown оформляет аналоги в Markdown для мессенджеров без HTML разметки
+func formatAnalogsMarkdown(result AnalogsResult, limit int) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Вот аналоги для **%s**:\n", result.Medicine.MedicineName))
+	for index, analog := range result.Analogs {
+		line := fmt.Sprintf("%d. [%s](%s) (%d%%)\n", index+1, analog.AnalogName, analogURL(analog), analog.Percentage)
+		if index == 10 || text.Len()+len(line) > limit-200 {
+			break
+		}
+		text.WriteString(line)
+	}
+	for _, rule := range result.Restrictions {
+		text.WriteString(fmt.Sprintf("⚠️ %s: %s\n", rule.Substance, rule.Note))
+	}
+
+	return truncateRunes(text.String(), limit)
+}
+
+// truncateRunes обрезает строку до limit символов
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return string(runes[:limit-1]) + "…"
+}
+
+// startDiscord объявляет команды Discord и подключает обработчик взаимодействий
+func startDiscord(mux *http.ServeMux) {
+	if Discord == nil {
+		return
+	}
+
+	mux.Handle("/discord/interactions", Discord)
+	go func() {
+		if err := Discord.RegisterCommands(); err != nil {
+			logError(err)
+			return
+		}
+		log.Println("Команды Discord зарегистрированы")
+	}()
+}
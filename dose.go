@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// maxWeightKg ограничивает вводимый вес от опечаток
+	maxWeightKg = 150
+	// maxDoseMgPerKg ограничивает вводимую дозировку на кг веса
+	maxDoseMgPerKg = 100
+)
+
+// doseDisclaimer сопровождает любой расчет дозы
+const doseDisclaimer = "⚠️ Расчет справочный и не заменяет консультацию врача. Сверьте дозировку с инструкцией к купленному препарату: концентрация сиропов одного и того же лекарства в разных странах отличается. Детям до 3 месяцев, при хронических болезнях и сомнениях обратитесь к врачу."
+
+// concentrationRegexp понимает «100 мг/5 мл», «40 mg/ml», «2 мг в 1 мл»
+var concentrationRegexp = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:мг|mg)\s*(?:/|в|per)\s*(\d+(?:[.,]\d+)?)?\s*(?:мл|ml)`)
+
+func parseNumber(text string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(text), ",", "."), 64)
+}
+
+// parseConcentration возвращает концентрацию в мг на мл
+func parseConcentration(text string) (float64, bool) {
+	match := concentrationRegexp.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+
+	mg, err := parseNumber(match[1])
+	if err != nil || mg <= 0 {
+		return 0, false
+	}
+	ml := 1.0
+	if len(match[2]) > 0 {
+		if ml, err = parseNumber(match[2]); err != nil || ml <= 0 {
+			return 0, false
+		}
+	}
+
+	return mg / ml, true
+}
+
+func formatAmount(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(strconv.FormatFloat(value, 'f', 1, 64), "0"), ".")
+}
+
+func doseHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if !featureAvailable(update.Message.Chat.ID, "dose") {
+		sendFeatureUnavailable(ctx, b, update.Message.Chat.ID)
+		return
+	}
+
+	_, query, _ := strings.Cut(update.Message.Text, " ")
+	query = strings.TrimSpace(query)
+	if len(query) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Укажите лекарство, для которого нужно рассчитать дозу по весу, например: /dose ибупрофен\n\n" + doseDisclaimer,
+		})
+		return
+	}
+
+	medicineID := findMedicineID(ctx, query)
+	if medicineID == 0 {
+		if medicines, err := searchMedicinesContext(ctx, query); err == nil && len(medicines) > 0 {
+			medicineID, _ = strconv.Atoi(medicines[0].ID)
+		}
+	}
+
+	details := MedicineDetails{MedicineName: query}
+	if medicineID != 0 {
+		if found, err := medicineDetails(ctx, medicineID); err == nil {
+			details = found
+		}
+	}
+	if len(details.MedicineName) == 0 {
+		details.MedicineName = query
+	}
+
+	AppMetrics.Incr("dose_calculations")
+
+	data := map[string]string{
+		"medicine":    details.MedicineName,
+		"dose_per_kg": strconv.FormatFloat(details.DoseMgPerKg, 'f', -1, 64),
+		"max_per_kg":  strconv.FormatFloat(details.MaxDailyMgPerKg, 'f', -1, 64),
+	}
+
+	forms := []MedicineForm{}
+	for _, form := range details.Forms {
+		if _, ok := parseConcentration(form.Concentration); ok {
+			forms = append(forms, form)
+		}
+	}
+
+	if len(forms) == 0 {
+		ChatDialogs.Start(update.Message.Chat.ID, "dose_concentration", data)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    update.Message.Chat.ID,
+			Text:      fmt.Sprintf("Какая концентрация указана на упаковке %s? Например: 100 мг/5 мл. Для отмены отправьте /cancel.", bold(details.MedicineName)),
+			ParseMode: models.ParseModeHTML,
+		})
+		return
+	}
+
+	// Формы выпуска хранятся в сценарии, кнопки передают только номер формы
+	buttons := [][]models.InlineKeyboardButton{}
+	for index, form := range forms {
+		data["form_"+strconv.Itoa(index)] = form.Name + " " + form.Concentration
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: form.Name + " " + form.Concentration, CallbackData: "dose_form:" + strconv.Itoa(index)},
+		})
+	}
+	ChatDialogs.Start(update.Message.Chat.ID, "dose_concentration", data)
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      fmt.Sprintf("Выберите форму %s или напишите концентрацию с упаковки, например: 100 мг/5 мл.", bold(details.MedicineName)),
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: buttons,
+		},
+	})
+}
+
+func doseFormHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	if !featureAvailable(chatID, "dose") {
+		sendFeatureUnavailable(ctx, b, chatID)
+		return
+	}
+	dialog, ok := ChatDialogs.Peek(chatID)
+	if !ok || dialog.Kind != "dose_concentration" {
+		return
+	}
+
+	form := dialog.Data["form_"+strings.TrimPrefix(update.CallbackQuery.Data, "dose_form:")]
+	askWeight(ctx, b, chatID, dialog.Data, form)
+}
+
+func doseConcentrationDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	askWeight(ctx, b, update.Message.Chat.ID, dialog.Data, update.Message.Text)
+}
+
+// askWeight запоминает концентрацию и спрашивает вес
+func askWeight(ctx context.Context, b *bot.Bot, chatID int64, data map[string]string, concentration string) {
+	perMl, ok := parseConcentration(concentration)
+	if !ok {
+		ChatDialogs.Start(chatID, "dose_concentration", data)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Не понял концентрацию. Напишите ее как на упаковке, например: 100 мг/5 мл. Для отмены отправьте /cancel.",
+		})
+		return
+	}
+
+	data["concentration"] = strings.TrimSpace(concentration)
+	data["mg_per_ml"] = strconv.FormatFloat(perMl, 'f', -1, 64)
+	ChatDialogs.Start(chatID, "dose_weight", data)
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "Сколько весит человек, для которого нужна доза? Укажите вес в килограммах, например: 18.",
+	})
+}
+
+func doseWeightDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	weight, err := parseNumber(strings.TrimSuffix(strings.TrimSpace(update.Message.Text), "кг"))
+	if err != nil || weight <= 0 || weight > maxWeightKg {
+		ChatDialogs.Start(update.Message.Chat.ID, "dose_weight", dialog.Data)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Укажите вес числом в килограммах, например: 18. Для отмены отправьте /cancel.",
+		})
+		return
+	}
+	dialog.Data["weight"] = strconv.FormatFloat(weight, 'f', -1, 64)
+
+	if perKg, _ := parseNumber(dialog.Data["dose_per_kg"]); perKg > 0 {
+		sendDose(ctx, b, update.Message.Chat.ID, dialog.Data, perKg)
+		return
+	}
+
+	ChatDialogs.Start(update.Message.Chat.ID, "dose_per_kg", dialog.Data)
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "В справочнике нет дозировки на вес для этого лекарства. Сколько мг на кг на один прием указано в инструкции? Например: 10.",
+	})
+}
+
+func dosePerKgDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	perKg, err := parseNumber(strings.TrimSuffix(strings.TrimSpace(update.Message.Text), "мг/кг"))
+	if err != nil || perKg <= 0 || perKg > maxDoseMgPerKg {
+		ChatDialogs.Start(update.Message.Chat.ID, "dose_per_kg", dialog.Data)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Укажите дозировку числом в мг на кг, например: 10. Для отмены отправьте /cancel.",
+		})
+		return
+	}
+
+	sendDose(ctx, b, update.Message.Chat.ID, dialog.Data, perKg)
+}
+
+// sendDose рассчитывает разовую дозу в мг и мл
+func sendDose(ctx context.Context, b *bot.Bot, chatID int64, data map[string]string, perKg float64) {
+	weight, _ := parseNumber(data["weight"])
+	perMl, _ := parseNumber(data["mg_per_ml"])
+	mg := weight * perKg
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("%s, %s\n", bold(data["medicine"]), escapeHTML(data["concentration"])))
+	text.WriteString(fmt.Sprintf("Вес: %s кг, дозировка %s мг/кг\n\n", formatAmount(weight), formatAmount(perKg)))
+	text.WriteString(fmt.Sprintf("Разовая доза: %s = %s\n", bold(formatAmount(mg)+" мг"), bold(formatAmount(mg/perMl)+" мл")))
+	if maxPerKg, _ := parseNumber(data["max_per_kg"]); maxPerKg > 0 {
+		text.WriteString(fmt.Sprintf("Не больше %s мг (%s мл) в сутки\n", formatAmount(weight*maxPerKg), formatAmount(weight*maxPerKg/perMl)))
+	}
+	text.WriteString("\n" + escapeHTML(doseDisclaimer))
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text.String(),
+		ParseMode: models.ParseModeHTML,
+	})
+}
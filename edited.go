@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type replyKey struct {
+	chatID    int64
+	messageID int
+}
+
+// SearchReplies связывает поисковые запросы пользователей с ответами бота на них
+var SearchReplies = NewRecentMap[replyKey, int](1000)
+
+// SearchQueries связывает ответы бота со списком лекарств с исходным запросом
+var SearchQueries = NewRecentMap[replyKey, string](1000)
+
+// editedMessageHandler повторяет поиск по исправленному запросу и обновляет прежний ответ
+func editedMessageHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	message := update.EditedMessage
+	if len(message.Text) == 0 || strings.HasPrefix(message.Text, "/") {
+		return
+	}
+
+	replyID, ok := SearchReplies.Get(replyKey{chatID: message.Chat.ID, messageID: message.ID})
+	if !ok {
+		return
+	}
+
+	text, markup := sensitivePolicy, (*models.InlineKeyboardMarkup)(nil)
+	if !sensitiveQuery(message.Text) {
+		if !allowSearch(ctx, b, message.From, PendingSearch{ChatID: message.Chat.ID, Query: message.Text}, 1) {
+			return
+		}
+		AppMetrics.Incr("searches")
+		Storage.AddHistory(message.From, HistoryEntry{Query: message.Text})
+		text, markup = medicineSearchReply(ctx, message.Chat.ID, message.Text, Refinement{})
+	}
+
+	params := &bot.EditMessageTextParams{
+		ChatID:    message.Chat.ID,
+		MessageID: replyID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}
+	if markup != nil {
+		params.ReplyMarkup = markup
+	}
+
+	if _, err := b.EditMessageText(ctx, params); err != nil {
+		logError(err)
+		return
+	}
+
+	SearchQueries.Set(replyKey{chatID: message.Chat.ID, messageID: replyID}, message.Text)
+}
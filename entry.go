@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+//go:embed data/entry.json
+var embeddedEntryRules []byte
+
+// entryRules встроенные правила ввоза лекарств по рецепту по кодам стран
+var entryRules = map[string]string{}
+
+func init() {
+	if err := json.Unmarshal(embeddedEntryRules, &entryRules); err != nil {
+		panic(err)
+	}
+}
+
+// entryGuidance возвращает правила ввоза лекарств в страну, изменения администраторов важнее встроенных
+func entryGuidance(code string) (string, bool) {
+	code = strings.ToUpper(code)
+	if text, ok := Storage.EntryOverride(code); ok {
+		return text, len(text) > 0
+	}
+	text, ok := entryRules[code]
+
+	return text, ok
+}
+
+// entryLink возвращает ссылку на правила ввоза в страну для сообщений с предупреждениями
+func entryLink(countryID int) string {
+	country, ok := countryByID(countryID)
+	if !ok || len(BotUsername) == 0 {
+		return ""
+	}
+	if _, ok := entryGuidance(country.Code); !ok {
+		return ""
+	}
+
+	return link("Как провезти лекарства в "+country.Name, "https://t.me/"+BotUsername+"?start=entry_"+country.Code)
+}
+
+func entryHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	_, args, _ := strings.Cut(update.Message.Text, " ")
+	args = strings.TrimSpace(args)
+	country, ok := findCountry(args)
+	if len(args) == 0 {
+		country, ok = countryByID(targetCountry(chatID))
+	}
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Укажите страну, например: /entry TH\n\nДоступные страны: " + countryNames(),
+		})
+		return
+	}
+
+	sendEntryGuidance(ctx, b, chatID, country)
+}
+
+func sendEntryGuidance(ctx context.Context, b *bot.Bot, chatID int64, country Country) {
+	guidance, ok := entryGuidance(country.Code)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      fmt.Sprintf("Для %s у меня пока нет правил ввоза лекарств. Уточните их в посольстве страны.", bold(country.Name)),
+			ParseMode: models.ParseModeHTML,
+		})
+		return
+	}
+
+	AppMetrics.Incr("entry_lookups")
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text: fmt.Sprintf("%s\n\n%s\n\n%s", bold("Ввоз лекарств: "+country.Name), escapeHTML(guidance),
+			italic("Информация справочная, правила меняются. Перед поездкой уточните их в посольстве страны.")),
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+// entrySetHandler изменяет правила ввоза для страны: /entry_set TH текст, без текста возвращает встроенные
+func entrySetHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	fields := strings.SplitN(update.Message.Text, " ", 3)
+	if len(fields) < 2 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Использование: /entry_set TH текст правил. Без текста вернутся встроенные правила.",
+		})
+		return
+	}
+
+	code := strings.ToUpper(fields[1])
+	text := ""
+	if len(fields) == 3 {
+		text = strings.TrimSpace(fields[2])
+	}
+	Storage.SetEntryOverride(code, text)
+
+	reply := "Правила ввоза для " + code + " обновлены."
+	if len(text) == 0 {
+		reply = "Для " + code + " снова используются встроенные правила ввоза."
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   reply,
+	})
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Expense покупка лекарства во время поездки
+type Expense struct {
+	Medicine  string    `json:"medicine"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var expenseRegexp = regexp.MustCompile(`^(.+?)\s+(\d+(?:[.,]\d+)?)\s*([^\d\s]*)$`)
+
+// currencySymbols приводит символы и сокращения валют к кодам ISO 4217
+var currencySymbols = map[string]string{
+	"฿":    "THB",
+	"бат":  "THB",
+	"$":    "USD",
+	"€":    "EUR",
+	"₽":    "RUB",
+	"р":    "RUB",
+	"руб":  "RUB",
+	"¥":    "JPY",
+	"иен":  "JPY",
+	"дирх": "AED",
+	"₺":    "TRY",
+	"лир":  "TRY",
+}
+
+// parseExpense разбирает запись вида «нурофен 250 THB», валюта необязательна
+func parseExpense(text string) (Expense, bool) {
+	match := expenseRegexp.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return Expense{}, false
+	}
+
+	amount, err := parseNumber(match[2])
+	if err != nil || amount <= 0 {
+		return Expense{}, false
+	}
+
+	currency := strings.Trim(strings.ToLower(match[3]), ".")
+	if code, ok := currencySymbols[currency]; ok {
+		currency = code
+	}
+	for prefix, code := range currencySymbols {
+		if len([]rune(prefix)) > 1 && strings.HasPrefix(currency, prefix) {
+			currency = code
+		}
+	}
+
+	return Expense{
+		Medicine: strings.TrimSpace(match[1]),
+		Amount:   amount,
+		Currency: strings.ToUpper(currency),
+	}, true
+}
+
+func formatMoney(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(strconv.FormatFloat(value, 'f', 2, 64), "0"), ".")
+}
+
+// formatExpenses возвращает список покупок поездки с итогами по валютам
+func formatExpenses(trip Trip) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("%s: %s, %s\n", bold("Покупки лекарств"), escapeHTML(trip.CountryName()), trip.Dates()))
+
+	totals := map[string]float64{}
+	for _, expense := range trip.Expenses {
+		text.WriteString(fmt.Sprintf("\n%s — %s — %s %s", expense.CreatedAt.In(chatLocation(trip.ChatID)).Format("02.01"),
+			escapeHTML(expense.Medicine), formatMoney(expense.Amount), escapeHTML(expense.Currency)))
+		totals[expense.Currency] += expense.Amount
+	}
+
+	currencies := []string{}
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	parts := []string{}
+	for _, currency := range currencies {
+		parts = append(parts, strings.TrimSpace(formatMoney(totals[currency])+" "+currency))
+	}
+	text.WriteString("\n\n" + bold("Итого: "+strings.Join(parts, ", ")))
+
+	return text.String()
+}
+
+// spentHandler записывает покупку в текущую поездку: /spent нурофен 250 THB, без аргументов показывает покупки
+func spentHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	trip, ok := activeTrip(chatID)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Покупки записываются в текущую поездку. Добавьте поездку через /trip.",
+		})
+		return
+	}
+
+	_, args, _ := strings.Cut(update.Message.Text, " ")
+	if len(strings.TrimSpace(args)) == 0 {
+		text := "В этой поездке покупок пока нет. Запишите покупку, например: /spent нурофен 250 THB"
+		if len(trip.Expenses) > 0 {
+			text = formatExpenses(trip)
+		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      text,
+			ParseMode: models.ParseModeHTML,
+		})
+		return
+	}
+
+	expense, ok := parseExpense(args)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Укажите лекарство, цену и валюту, например: /spent нурофен 250 THB",
+		})
+		return
+	}
+	expense.CreatedAt = time.Now()
+
+	Storage.UpdateTrip(trip.ID, func(trip *Trip) {
+		trip.Expenses = append(trip.Expenses, expense)
+	})
+
+	AppMetrics.Incr("trip_expenses")
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text: fmt.Sprintf("Записал: %s — %s %s. Итог по покупкам придет после окончания поездки, а пока посмотреть их можно командой /spent.",
+			bold(expense.Medicine), formatMoney(expense.Amount), escapeHTML(expense.Currency)),
+		ParseMode: models.ParseModeHTML,
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// profileFavorites возвращает избранное пользователя в профиле profile
+func profileFavorites(userID int64, profile string) []Favorite {
+	favorites := []Favorite{}
+	for _, favorite := range Storage.Favorites(userID) {
+		if favorite.Profile == profile || (len(favorite.Profile) == 0 && profile == defaultProfile) {
+			favorites = append(favorites, favorite)
+		}
+	}
+
+	return favorites
+}
+
+func favoritesHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message.From == nil {
+		return
+	}
+
+	profile := activeProfile(update.Message.Chat.ID)
+	favorites := profileFavorites(update.Message.From.ID, profile)
+	shared := Storage.SharesFor(update.Message.From.ID)
+	if len(favorites) == 0 && len(shared) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "В избранном пока ничего нет. Поставьте 👍 на список аналогов, чтобы сохранить лекарство.",
+		})
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(profileLabel(update.Message.Chat.ID, profile) + "Избранное. Выберите лекарство, чтобы посмотреть аналоги. Сменить профиль: /profile, поделиться: /share")
+
+	buttons := [][]models.InlineKeyboardButton{}
+	for _, favorite := range favorites {
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{
+				Text:         favorite.MedicineName,
+				CallbackData: "search_analog:" + strconv.Itoa(favorite.MedicineID),
+			},
+		})
+	}
+
+	// Общие списки читаются из избранного владельца, поэтому изменения видны сразу
+	if len(shared) > 0 {
+		text.WriteString("\n\nОбщие списки отмечены 👥")
+	}
+	for _, list := range shared {
+		for _, favorite := range sharedListFavorites(list) {
+			buttons = append(buttons, []models.InlineKeyboardButton{
+				{
+					Text:         "👥 " + favorite.MedicineName,
+					CallbackData: "search_analog:" + strconv.Itoa(favorite.MedicineID),
+				},
+			})
+		}
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{
+				Text:         "Отключиться: " + list.Title(),
+				CallbackData: "share_leave:" + list.Token,
+			},
+		})
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text.String(),
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: buttons,
+		},
+	})
+}
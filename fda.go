@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+const (
+	openFDAURL = "https://api.fda.gov/drug/label.json"
+	// labelTextLimit символов в разделе инструкции, полный текст есть по ссылке
+	labelTextLimit = 600
+	// labelCandidates сколько американских аналогов проверяется в поиске инструкции
+	labelCandidates = 3
+)
+
+// DrugLabel разделы инструкции лекарства на английском: показания и предупреждения
+type DrugLabel struct {
+	Name        string
+	Indications string
+	Warnings    string
+	URL         string
+}
+
+// LabelProvider находит инструкцию по названию лекарства, под которым оно продается в США
+type LabelProvider interface {
+	Label(ctx context.Context, name string) (DrugLabel, bool, error)
+}
+
+// OpenFDA инструкции из открытого API FDA, ключ необязателен и только повышает ограничения
+type OpenFDA struct {
+	URL    string
+	APIKey string
+	client http.Client
+	// labels ответы по названию, инструкции меняются редко
+	labels *RecentMap[string, openFDALabel]
+}
+
+type openFDALabel struct {
+	label DrugLabel
+	found bool
+}
+
+type openFDAResponse struct {
+	Results []struct {
+		Indications []string `json:"indications_and_usage"`
+		Warnings    []string `json:"warnings"`
+		Cautions    []string `json:"warnings_and_cautions"`
+		Boxed       []string `json:"boxed_warning"`
+		OpenFDA     struct {
+			BrandName []string `json:"brand_name"`
+			SetID     []string `json:"spl_set_id"`
+		} `json:"openfda"`
+	} `json:"results"`
+}
+
+func (o *OpenFDA) Label(ctx context.Context, name string) (DrugLabel, bool, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if cached, ok := o.labels.Get(name); ok {
+		return cached.label, cached.found, nil
+	}
+
+	phrase := strings.ReplaceAll(name, `"`, "")
+	query := url.Values{}
+	query.Set("search", `openfda.brand_name:"`+phrase+`" openfda.generic_name:"`+phrase+`"`)
+	query.Set("limit", "1")
+	if len(o.APIKey) > 0 {
+		query.Set("api_key", o.APIKey)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, o.URL+"?"+query.Encode(), nil)
+	if err != nil {
+		return DrugLabel{}, false, err
+	}
+
+	response, err := o.client.Do(request)
+	if err != nil {
+		return DrugLabel{}, false, err
+	}
+	defer response.Body.Close()
+	// Если ничего не найдено, openFDA отвечает 404
+	if response.StatusCode == http.StatusNotFound {
+		o.labels.Set(name, openFDALabel{})
+		return DrugLabel{}, false, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return DrugLabel{}, false, errors.New("openfda: " + response.Status)
+	}
+
+	var result openFDAResponse
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return DrugLabel{}, false, err
+	}
+	if len(result.Results) == 0 {
+		o.labels.Set(name, openFDALabel{})
+		return DrugLabel{}, false, nil
+	}
+
+	found := result.Results[0]
+	label := DrugLabel{
+		Name:        name,
+		Indications: labelSection(found.Indications),
+		Warnings:    labelSection(found.Boxed, found.Warnings, found.Cautions),
+	}
+	if len(found.OpenFDA.BrandName) > 0 {
+		label.Name = found.OpenFDA.BrandName[0]
+	}
+	if len(found.OpenFDA.SetID) > 0 {
+		label.URL = "https://dailymed.nlm.nih.gov/dailymed/lookup.cfm?setid=" + found.OpenFDA.SetID[0]
+	}
+	o.labels.Set(name, openFDALabel{label: label, found: true})
+
+	return label, true, nil
+}
+
+// labelSection берет первый непустой раздел и сокращает его до labelTextLimit
+func labelSection(sections ...[]string) string {
+	for _, section := range sections {
+		if len(section) > 0 && len(strings.TrimSpace(section[0])) > 0 {
+			return truncateRunes(strings.Join(strings.Fields(section[0]), " "), labelTextLimit)
+		}
+	}
+
+	return ""
+}
+
+// newLabelProvider создает источник инструкций по настройке LABELS_PROVIDER: openfda
+// или пустое значение, если инструкции не нужны
+func newLabelProvider(name string) LabelProvider {
+	switch name {
+	case "openfda":
+		return &OpenFDA{
+			URL:    openFDAURL,
+			APIKey: os.Getenv("OPENFDA_API_KEY"),
+			labels: NewRecentMap[string, openFDALabel](1000),
+		}
+	}
+
+	return nil
+}
+
+// usLabel ищет инструкцию FDA по американским аналогам лекарства, если среди стран есть США,
+// а затем по международному названию действующего вещества
+func usLabel(ctx context.Context, medicineID int) (DrugLabel, bool) {
+	if Labels == nil {
+		return DrugLabel{}, false
+	}
+	country, ok := countryByCode("US")
+	if !ok {
+		return DrugLabel{}, false
+	}
+	result, err := findAnalogsContext(ctx, medicineID, country.ID)
+	if err != nil {
+		return DrugLabel{}, false
+	}
+
+	analogs := append([]Analog{}, result.Analogs...)
+	pills.SortAnalogs(analogs)
+	names := []string{}
+	for index, analog := range analogs {
+		if index == labelCandidates {
+			break
+		}
+		names = append(names, analog.AnalogName)
+	}
+	if substance, ok := ATC.Classify(result.Medicine.MedicineName, medicineComponents(ctx, result.Medicine)); ok {
+		names = append(names, substance.INN)
+	}
+
+	for _, name := range names {
+		label, found, err := Labels.Label(ctx, name)
+		if err != nil {
+			logError(err)
+			return DrugLabel{}, false
+		}
+		if found {
+			AppMetrics.Incr("fda_labels")
+			return label, true
+		}
+	}
+
+	return DrugLabel{}, false
+}
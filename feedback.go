@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func feedbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if AdminChatID == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Обратная связь временно недоступна.",
+		})
+		return
+	}
+
+	_, text, _ := strings.Cut(update.Message.Text, " ")
+	if len(strings.TrimSpace(text)) == 0 {
+		ChatDialogs.Start(update.Message.Chat.ID, "feedback", nil)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Напишите ваше сообщение: вопрос, пожелание или неточность в данных об аналогах. Для отмены отправьте /cancel.",
+		})
+		return
+	}
+
+	sendFeedback(ctx, b, update.Message, strings.TrimSpace(text))
+}
+
+func feedbackDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	sendFeedback(ctx, b, update.Message, update.Message.Text)
+}
+
+// sendFeedback пересылает сообщение пользователя в чат администраторов с контекстом
+func sendFeedback(ctx context.Context, b *bot.Bot, message *models.Message, text string) {
+	var header strings.Builder
+	header.WriteString(bold("Обратная связь"))
+	if message.From != nil {
+		header.WriteString(fmt.Sprintf(" от %s (id %d", escapeHTML(message.From.FirstName), message.From.ID))
+		if len(message.From.Username) > 0 {
+			header.WriteString(", @" + escapeHTML(message.From.Username))
+		}
+		header.WriteString(")")
+		if len(message.From.LanguageCode) > 0 {
+			header.WriteString("\nЯзык: " + escapeHTML(message.From.LanguageCode))
+		}
+		if query := lastQuery(message.From.ID); len(query) > 0 {
+			header.WriteString("\nПоследний поиск: " + italic(query))
+		}
+	}
+
+	sent, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    AdminChatID,
+		Text:      header.String() + "\n\n" + escapeHTML(text) + "\n\nОтветьте на это сообщение, чтобы написать пользователю.",
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		logError(err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: message.Chat.ID,
+			Text:   "Не удалось отправить сообщение. Попробуйте позже.",
+		})
+		return
+	}
+
+	Storage.SetFeedbackThread(sent.ID, message.Chat.ID)
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: message.Chat.ID,
+		Text:   "Спасибо! Сообщение отправлено.",
+	})
+}
+
+// handleSupportReply отправляет пользователю ответ администратора на его обращение
+func handleSupportReply(ctx context.Context, b *bot.Bot, update *models.Update) bool {
+	message := update.Message
+	if AdminChatID == 0 || message.Chat.ID != AdminChatID || message.ReplyToMessage == nil {
+		return false
+	}
+
+	chatID, ok := Storage.FeedbackThread(message.ReplyToMessage.ID)
+	if !ok || len(message.Text) == 0 {
+		return false
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      bold("Ответ поддержки") + "\n\n" + escapeHTML(message.Text),
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		logError(err)
+		return true
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          AdminChatID,
+		Text:            "Ответ отправлен.",
+		ReplyParameters: &models.ReplyParameters{MessageID: message.ID},
+	})
+
+	return true
+}
+
+func lastQuery(userID int64) string {
+	history := Storage.History(userID)
+	for i := len(history) - 1; i >= 0; i-- {
+		if len(history[i].Query) > 0 {
+			return history[i].Query
+		}
+	}
+
+	return ""
+}
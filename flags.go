@@ -0,0 +1,34 @@
+package main
+
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+}
+
+// featureFlags перечисляет функции, которые администратор может отключить
+var featureFlags = []FeatureFlag{
+	{Name: "search", Description: "Поиск лекарств и аналогов", Default: true},
+	{Name: "stats", Description: "Команда /stats", Default: true},
+	{Name: "inline", Description: "Inline режим", Default: true},
+	{Name: "ocr", Description: "Распознавание фотографий упаковок", Default: true},
+}
+
+func findFeatureFlag(name string) (FeatureFlag, bool) {
+	for _, flag := range featureFlags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+
+	return FeatureFlag{}, false
+}
+
+func flagEnabled(name string) bool {
+	flag, ok := findFeatureFlag(name)
+	if !ok {
+		return false
+	}
+
+	return Storage.Flag(name, flag.Default)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// floodRepeats одинаковых запросов подряд за floodWindow считаются флудом
+	floodRepeats = 20
+	floodWindow  = 10 * time.Minute
+	// floodCallbacks нажатий кнопок или floodMessages сообщений за минуту считаются спамом
+	floodCallbacks = 60
+	floodMessages  = 60
+	// muteForgetAfter после такого перерыва без ограничений счетчик ограничений сбрасывается
+	muteForgetAfter = 7 * 24 * time.Hour
+)
+
+// muteDurations длительность ограничения по номеру нарушения, последнее значение повторяется
+var muteDurations = []time.Duration{15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// floodState активность пользователя в текущих окнах наблюдения
+type floodState struct {
+	query      string
+	repeats    int
+	queryStart time.Time
+	minute     time.Time
+	messages   int
+	callbacks  int
+}
+
+// FloodDetector находит однообразные запросы и спам кнопками по пользователям
+type FloodDetector struct {
+	mu     sync.Mutex
+	states *RecentMap[int64, *floodState]
+}
+
+var Flood = &FloodDetector{states: NewRecentMap[int64, *floodState](5000)}
+
+// Observe учитывает сообщение text или нажатие кнопки, если text пустой, и сообщает о флуде
+func (d *FloodDetector) Observe(userID int64, text string, callback bool, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, ok := d.states.Get(userID)
+	if !ok {
+		state = &floodState{}
+		d.states.Set(userID, state)
+	}
+
+	if now.Sub(state.minute) > time.Minute {
+		state.minute, state.messages, state.callbacks = now, 0, 0
+	}
+	if callback {
+		state.callbacks++
+		return state.callbacks >= floodCallbacks
+	}
+
+	state.messages++
+	query := strings.ToLower(strings.Join(strings.Fields(text), " "))
+	if query != state.query || now.Sub(state.queryStart) > floodWindow {
+		state.query, state.repeats, state.queryStart = query, 0, now
+	}
+	state.repeats++
+
+	return state.repeats >= floodRepeats || state.messages >= floodMessages
+}
+
+// Reset забывает активность пользователя после ограничения
+func (d *FloodDetector) Reset(userID int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.states.Delete(userID)
+}
+
+// updateUser возвращает автора обновления и чат для ответа
+func updateUser(update *models.Update) (*models.User, int64) {
+	switch {
+	case update.Message != nil:
+		return update.Message.From, update.Message.Chat.ID
+	case update.EditedMessage != nil:
+		return update.EditedMessage.From, update.EditedMessage.Chat.ID
+	case update.CallbackQuery != nil:
+		return &update.CallbackQuery.From, callbackChatID(update.CallbackQuery)
+	case update.InlineQuery != nil:
+		return update.InlineQuery.From, 0
+	}
+
+	return nil, 0
+}
+
+func muteText(chatID int64, until time.Time) string {
+	return fmt.Sprintf("🔇 Слишком много однотипных запросов. Бот не будет отвечать вам до %s.",
+		until.In(chatLocation(chatID)).Format("02.01 15:04"))
+}
+
+// floodMiddleware временно ограничивает пользователей с однообразными запросами и спамом кнопками.
+// Ограничение хранится в Store и растет с каждым повторным нарушением, администраторы не ограничиваются
+func floodMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		from, chatID := updateUser(update)
+		if from == nil || userRole(from.ID) != "" {
+			next(ctx, b, update)
+			return
+		}
+
+		now := time.Now()
+		until := Storage.MutedUntil(from.ID)
+		if now.Before(until) {
+			switch {
+			case update.CallbackQuery != nil:
+				b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+					CallbackQueryID: update.CallbackQuery.ID,
+					Text:            muteText(chatID, until),
+					ShowAlert:       true,
+				})
+			case update.InlineQuery != nil:
+				b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+					InlineQueryID: update.InlineQuery.ID,
+					Results:       []models.InlineQueryResult{},
+				})
+			}
+			return
+		}
+
+		text := ""
+		switch {
+		case update.Message != nil:
+			text = update.Message.Text
+		case update.EditedMessage != nil:
+			text = update.EditedMessage.Text
+		}
+		if update.InlineQuery != nil || !Flood.Observe(from.ID, text, update.CallbackQuery != nil, now) {
+			next(ctx, b, update)
+			return
+		}
+
+		until = Storage.Mute(from.ID, now)
+		Flood.Reset(from.ID)
+		AppMetrics.Incr("flood_mutes")
+		if chatID != 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   muteText(chatID, until),
+			})
+		}
+	}
+}
+
+// unmuteHandler снимает ограничение с пользователя: /unmute <id>
+func unmuteHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	args := strings.Fields(update.Message.Text)
+	if len(args) != 2 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Использование: /unmute <id пользователя>",
+		})
+		return
+	}
+
+	userID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Неверный пользователь.",
+		})
+		return
+	}
+
+	Storage.Unmute(userID)
+	Flood.Reset(userID)
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("Ограничение пользователя %d снято.", userID),
+	})
+}
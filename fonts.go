@@ -0,0 +1,11 @@
+package main
+
+import _ "embed"
+
+// Шрифты с кириллицей для PDF и изображений
+//
+//go:embed data/fonts/DejaVuSans.ttf
+var fontRegular []byte
+
+//go:embed data/fonts/DejaVuSans-Bold.ttf
+var fontBold []byte
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"html"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// Сообщения бота отправляются с ParseMode HTML, поэтому любой текст
+// от пользователя или из API нужно экранировать
+
+func escapeHTML(text string) string {
+	return html.EscapeString(text)
+}
+
+func bold(text string) string {
+	return "<b>" + escapeHTML(text) + "</b>"
+}
+
+func italic(text string) string {
+	return "<i>" + escapeHTML(text) + "</i>"
+}
+
+func link(text string, url string) string {
+	return "<a href=\"" + escapeHTML(url) + "\">" + escapeHTML(text) + "</a>"
+}
+
+func disabledLinkPreview() *models.LinkPreviewOptions {
+	disabled := true
+	return &models.LinkPreviewOptions{IsDisabled: &disabled}
+}
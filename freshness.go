@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// DataSource источник данных об аналогах, указывается в каждом ответе
+const DataSource = "pillintrip.com"
+
+// DataStaleAfter возраст ревизии, после которого данные считаются устаревшими
+var DataStaleAfter = 365 * 24 * time.Hour
+
+// revisionLayouts форматы DateRevision, которые встречаются в ответах API
+var revisionLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "02.01.2006"}
+
+func parseRevision(revision string) (time.Time, bool) {
+	revision = strings.TrimSpace(revision)
+	for _, layout := range revisionLayouts {
+		if date, err := time.Parse(layout, revision); err == nil {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// dataIsStale считает устаревшими данные с ревизией старше DataStaleAfter. Без даты ревизии
+// возраст данных неизвестен, и они устаревшими не считаются
+func dataIsStale(info MedicineInfo, now time.Time) bool {
+	date, ok := parseRevision(info.DateRevision)
+
+	return ok && now.Sub(date) > DataStaleAfter
+}
+
+// dataAttribution строка с источником и датой обновления данных
+func dataAttribution(info MedicineInfo) string {
+	text := "данные: " + link(DataSource, "https://"+DataSource)
+	if date, ok := parseRevision(info.DateRevision); ok {
+		text += ", обновлены " + date.Format("02.01.2006")
+	} else if len(info.DateRevision) > 0 {
+		text += ", обновлены " + escapeHTML(info.DateRevision)
+	} else {
+		text += ", дата обновления неизвестна"
+	}
+	if dataIsStale(info, time.Now()) {
+		text += " ⏳"
+	}
+
+	return text
+}
+
+// analogRefreshHandler повторяет поиск аналогов мимо кэша: analog_refresh:<лекарство>
+func analogRefreshHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            "Обновляю данные…",
+		ShowAlert:       false,
+	})
+
+	medicineID, err := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "analog_refresh:"))
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	chatID := callbackChatID(update.CallbackQuery)
+	countryID := targetCountry(chatID)
+	AppMetrics.Incr("data_refreshes")
+
+	// Свежий ответ заменяет кэш только при успехе, при ошибке API прежние данные остаются
+	_, _, err = API.RefreshAnalogs(ctx, medicineID, countryID)
+	if err != nil && !errors.Is(err, pills.ErrCacheOnly) {
+		logError(err)
+	}
+	result, findErr := findAnalogsContext(ctx, medicineID, countryID)
+	if err != nil || findErr != nil || len(result.Analogs) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Не удалось обновить данные. Попробуйте позже.",
+		})
+		return
+	}
+
+	sendAnalogList(ctx, b, chatID, medicineID, result.Medicine, result.Analogs,
+		fmt.Sprintf("Обновленные аналоги для %s:", bold(result.Medicine.MedicineName)))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// GraylistDelay задержка ответов пользователям из серого списка, настраивается GRAYLIST_DELAY
+var GraylistDelay = 3 * time.Second
+
+// graylistAfterMutes после стольких ограничений за флуд пользователь попадает в серый список
+const graylistAfterMutes = 2
+
+func loadGraylistDelay() {
+	if value := os.Getenv("GRAYLIST_DELAY"); len(value) > 0 {
+		delay, err := time.ParseDuration(value)
+		if err != nil {
+			logError(err)
+			return
+		}
+		if delay < 0 {
+			logError(fmt.Errorf("GRAYLIST_DELAY must not be negative: %s", value))
+			return
+		}
+		GraylistDelay = delay
+	}
+}
+
+// graylistMiddleware не блокирует пользователей из серого списка, а отвечает им с задержкой,
+// только из кэша API и после остальных сообщений в очереди отправки
+func graylistMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		from, _ := updateUser(update)
+		if from == nil || !Storage.Graylisted(from.ID) {
+			next(ctx, b, update)
+			return
+		}
+
+		AppMetrics.Incr("graylisted_updates")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(GraylistDelay):
+		}
+
+		next(pills.WithCacheOnly(withSendPriority(ctx, PriorityBackground)), b, update)
+	}
+}
+
+// graylistHandler управляет серым списком: /graylist показывает список,
+// /graylist <id> добавляет пользователя, /graylist <id> off убирает
+func graylistHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	args := strings.Fields(update.Message.Text)
+	if len(args) == 1 {
+		users := Storage.GraylistedUsers()
+		text := "Серый список пуст."
+		if len(users) > 0 {
+			lines := []string{fmt.Sprintf("В сером списке: %d", len(users))}
+			for _, user := range users {
+				lines = append(lines, fmt.Sprintf("%d %s — %s, %s", user.ID, user.Username, user.GraylistReason, user.GraylistedAt.Format("02.01.2006")))
+			}
+			text = strings.Join(lines, "\n")
+		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   text + "\n\n/graylist <id> [off]",
+		})
+		return
+	}
+
+	userID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || len(args) > 3 || (len(args) == 3 && args[2] != "off") {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Использование: /graylist <id пользователя> [off]",
+		})
+		return
+	}
+
+	text := fmt.Sprintf("Пользователь %d добавлен в серый список.", userID)
+	reason := "добавлен администратором"
+	if len(args) == 3 {
+		text = fmt.Sprintf("Пользователь %d убран из серого списка.", userID)
+		reason = ""
+	}
+	Storage.SetGraylisted(userID, reason, time.Now())
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
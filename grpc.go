@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/nighthtr/pills-bot/pillspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// medicineService реализует pillspb.MedicineService поверх внутреннего клиента API
+type medicineService struct {
+	pillspb.UnimplementedMedicineServiceServer
+}
+
+func (medicineService) SearchMedicines(ctx context.Context, request *pillspb.SearchMedicinesRequest) (*pillspb.SearchMedicinesResponse, error) {
+	medicines, err := findMedicines(ctx, "grpc", request.GetQuery())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	response := &pillspb.SearchMedicinesResponse{}
+	for _, medicine := range medicines {
+		response.Medicines = append(response.Medicines, &pillspb.Medicine{
+			Id:         medicine.ID,
+			Name:       medicine.Name,
+			Components: medicine.Components,
+			Slug:       medicine.Slug,
+			Popular:    medicine.IsPopular == 1,
+		})
+	}
+
+	return response, nil
+}
+
+func (medicineService) SearchAnalogs(ctx context.Context, request *pillspb.SearchAnalogsRequest) (*pillspb.SearchAnalogsResponse, error) {
+	if request.GetMedicineId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "не указано лекарство")
+	}
+	countryID := int(request.GetCountryId())
+	if countryID <= 0 {
+		countryID = TargetCountryID
+	}
+
+	result, err := findAnalogsContext(ctx, int(request.GetMedicineId()), countryID)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	info := result.Medicine
+
+	response := &pillspb.SearchAnalogsResponse{
+		Medicine: &pillspb.MedicineInfo{
+			MedicineId:   info.MedicineID,
+			MedicineName: info.MedicineName,
+			MedicineSlug: info.MedicineSlug,
+			DateRevision: info.DateRevision,
+		},
+		CountryId: int32(countryID),
+	}
+	for _, analog := range result.Analogs {
+		response.Analogs = append(response.Analogs, &pillspb.Analog{
+			AnalogId:        analog.AnalogID,
+			AnalogName:      analog.AnalogName,
+			AnalogSlug:      analog.AnalogSlug,
+			ComponentsMatch: int32(analog.ComponentsMatch),
+			ApplyingsMatch:  int32(analog.ApplyingsMatch),
+			TreatmentsMatch: int32(analog.TreatmentsMatch),
+			Percentage:      int32(analog.Percentage),
+		})
+	}
+
+	return response, nil
+}
+
+func (medicineService) GetDetails(ctx context.Context, request *pillspb.GetDetailsRequest) (*pillspb.GetDetailsResponse, error) {
+	if request.GetMedicineId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "не указано лекарство")
+	}
+
+	if !flagEnabled("search") {
+		return nil, grpcError(errSearchDisabled)
+	}
+
+	details, err := medicineDetails(ctx, int(request.GetMedicineId()))
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, "ошибка получения описания")
+	}
+
+	medicine := &pillspb.MedicineDetails{
+		MedicineId:      details.MedicineID,
+		MedicineName:    details.MedicineName,
+		DoseMgPerKg:     details.DoseMgPerKg,
+		MaxDailyMgPerKg: details.MaxDailyMgPerKg,
+	}
+	for _, form := range details.Forms {
+		medicine.Forms = append(medicine.Forms, &pillspb.MedicineForm{
+			Name:          form.Name,
+			Concentration: form.Concentration,
+		})
+	}
+
+	return &pillspb.GetDetailsResponse{Medicine: medicine}, nil
+}
+
+// grpcError переводит ошибку сценария поиска в статус gRPC
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, errShortQuery), errors.Is(err, errSensitiveQuery):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
+
+// grpcAuth проверяет токен из метаданных authorization: Bearer <token>
+func grpcAuth(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(values[0], "Bearer ")), []byte(token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "неверный токен")
+		}
+		AppMetrics.Incr("grpc_requests")
+
+		return handler(ctx, request)
+	}
+}
+
+// startGRPCServer запускает gRPC сервис на addr до отмены ctx, без токена сервис не запускается
+func startGRPCServer(ctx context.Context, addr string, token string) {
+	if len(token) == 0 {
+		logError(errors.New("GRPC_TOKEN is required when GRPC_ADDR is set"))
+		return
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(grpcAuth(token)))
+	pillspb.RegisterMedicineServiceServer(server, medicineService{})
+
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	log.Printf("gRPC сервер запущен на %s\n", addr)
+
+	if err := server.Serve(listener); err != nil {
+		logError(err)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// gtinImportLimit размер файла справочника, который принимает /gtin_import
+const gtinImportLimit = 20 << 20
+
+// GTINResolver находит лекарство по штрихкоду GTIN-14
+type GTINResolver interface {
+	Resolve(ctx context.Context, gtin string) (GTINEntry, bool, error)
+}
+
+// LocalGTINs справочник из файла GTIN_TABLE, который дополняется выгрузками через /gtin_import
+type LocalGTINs struct {
+	Path string
+
+	mu    sync.RWMutex
+	table GTINTable
+}
+
+func (l *LocalGTINs) Resolve(ctx context.Context, gtin string) (GTINEntry, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entry, ok := l.table[gtin]
+
+	return entry, ok, nil
+}
+
+func (l *LocalGTINs) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.table)
+}
+
+// Import добавляет записи выгрузки, совпадающие коды заменяются, и сохраняет справочник в Path
+func (l *LocalGTINs) Import(table GTINTable) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for gtin, entry := range table {
+		l.table[gtin] = entry
+	}
+	if len(l.Path) == 0 {
+		return nil
+	}
+
+	gtins := make([]string, 0, len(l.table))
+	for gtin := range l.table {
+		gtins = append(gtins, gtin)
+	}
+	sort.Strings(gtins)
+
+	var body bytes.Buffer
+	writer := csv.NewWriter(&body)
+	for _, gtin := range gtins {
+		entry := l.table[gtin]
+		medicineID := ""
+		if entry.MedicineID != 0 {
+			medicineID = strconv.Itoa(entry.MedicineID)
+		}
+		writer.Write([]string{gtin, entry.MedicineName, medicineID, entry.Country})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	tmp := l.Path + ".tmp"
+	if err := os.WriteFile(tmp, body.Bytes(), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, l.Path)
+}
+
+// HTTPGTINs справочник внешнего сервиса: GET URL?gtin=04600000000000 отвечает
+// {"name": "...", "medicine_id": 0, "country": "RU"} или 404, если код неизвестен
+type HTTPGTINs struct {
+	URL    string
+	client http.Client
+	// found ответы сервиса, справочник штрихкодов меняется редко
+	found *RecentMap[string, httpGTIN]
+}
+
+type httpGTIN struct {
+	entry GTINEntry
+	ok    bool
+}
+
+func (h *HTTPGTINs) Resolve(ctx context.Context, gtin string) (GTINEntry, bool, error) {
+	if cached, ok := h.found.Get(gtin); ok {
+		return cached.entry, cached.ok, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL+"?gtin="+url.QueryEscape(gtin), nil)
+	if err != nil {
+		return GTINEntry{}, false, err
+	}
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return GTINEntry{}, false, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		h.found.Set(gtin, httpGTIN{})
+		return GTINEntry{}, false, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return GTINEntry{}, false, errors.New("gtin: " + response.Status)
+	}
+
+	entry := GTINEntry{}
+	if err := json.NewDecoder(response.Body).Decode(&entry); err != nil {
+		return GTINEntry{}, false, err
+	}
+	entry.Country = strings.ToUpper(entry.Country)
+	found := len(entry.MedicineName) > 0 || entry.MedicineID != 0
+	h.found.Set(gtin, httpGTIN{entry: entry, ok: found})
+
+	return entry, found, nil
+}
+
+// MultiGTINs ищет код в справочниках по очереди, локальный справочник идет первым
+type MultiGTINs []GTINResolver
+
+func (m MultiGTINs) Resolve(ctx context.Context, gtin string) (GTINEntry, bool, error) {
+	var lastErr error
+	for _, resolver := range m {
+		entry, ok, err := resolver.Resolve(ctx, gtin)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if ok {
+			return entry, true, nil
+		}
+	}
+
+	return GTINEntry{}, false, lastErr
+}
+
+// GTINDump локальный справочник штрихкодов, пустой если GTIN_TABLE не задан
+var GTINDump = &LocalGTINs{table: GTINTable{}}
+
+// loadGTINs читает справочник GTIN_TABLE и подключает сервис GTIN_URL
+func loadGTINs() error {
+	GTINDump.Path = os.Getenv("GTIN_TABLE")
+	if len(GTINDump.Path) > 0 {
+		table, err := LoadGTINTable(GTINDump.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if table != nil {
+			GTINDump.table = table
+		}
+	}
+
+	GTINs = MultiGTINs{GTINDump}
+	if address := os.Getenv("GTIN_URL"); len(address) > 0 {
+		GTINs = append(GTINs, &HTTPGTINs{URL: address, found: NewRecentMap[string, httpGTIN](1000)})
+	}
+
+	return nil
+}
+
+// resolveGTIN ищет код во всех справочниках, ошибки сервисов попадают в журнал
+func resolveGTIN(ctx context.Context, gtin string) (GTINEntry, bool) {
+	entry, ok, err := GTINs.Resolve(ctx, gtin)
+	if err != nil {
+		logError(err)
+	}
+	return entry, ok
+}
+
+// gtinQuery запрос для поиска лекарства по записи справочника. Упаковка из другой страны
+// ищется по международному названию действующего вещества, торговые названия за границей другие
+func gtinQuery(ctx context.Context, b *bot.Bot, chatID int64, entry GTINEntry) string {
+	home, ok := countryByID(HoumeCountryID)
+	if !ok || len(entry.Country) == 0 || strings.EqualFold(entry.Country, home.Code) {
+		return entry.MedicineName
+	}
+	inn, ok := innQuery(entry.MedicineName)
+	if !ok {
+		return entry.MedicineName
+	}
+
+	country := entry.Country
+	if found, ok := countryByCode(entry.Country); ok {
+		country = found.Name
+	}
+	AppMetrics.Incr("barcode_foreign")
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      fmt.Sprintf("Упаковка %s (%s) выпущена для другой страны, ищу по действующему веществу %s.", bold(entry.MedicineName), escapeHTML(country), bold(inn)),
+		ParseMode: models.ParseModeHTML,
+	})
+
+	return inn
+}
+
+// gtinImportHandler загружает выгрузку справочника штрихкодов: /gtin_import в ответ на CSV файл
+// со строками gtin,name[,medicine_id[,country]]
+func gtinImportHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	reply := update.Message.ReplyToMessage
+	if reply == nil || reply.Document == nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   fmt.Sprintf("Штрихкодов в справочнике: %d.\nЧтобы загрузить выгрузку, ответьте /gtin_import на CSV файл со строками gtin,name[,medicine_id[,country]].", GTINDump.Len()),
+		})
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(reply.Document.FileName), ".csv") || reply.Document.FileSize > gtinImportLimit {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Нужен CSV файл не больше 20 МБ.",
+		})
+		return
+	}
+
+	body, err := downloadFile(ctx, b, reply.Document.FileID)
+	if err != nil {
+		logError(err)
+		return
+	}
+	table, err := parseGTINTable(bytes.NewReader(body))
+	if err == nil {
+		err = GTINDump.Import(table)
+	}
+	if err != nil {
+		logError(err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Не удалось загрузить справочник: " + err.Error(),
+		})
+		return
+	}
+
+	AppMetrics.Incr("gtin_imports")
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("Загружено штрихкодов: %d, всего в справочнике: %d.", len(table), GTINDump.Len()),
+	})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	inlineResultsLimit = 10
+	inlineAnalogsLimit = 10
+	inlineCacheTime    = 300
+)
+
+func inlineQueryHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := strings.TrimSpace(update.InlineQuery.Query)
+	if len([]rune(query)) < 2 || !flagEnabled("search") || !flagEnabled("inline") || !userVerified(update.InlineQuery.From) {
+		b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+			InlineQueryID: update.InlineQuery.ID,
+			Results:       []models.InlineQueryResult{},
+		})
+		return
+	}
+	// Предупреждение нельзя показать в inline режиме, кнопка открывает его в личном чате
+	if !disclaimerAccepted(update.InlineQuery.From, update.InlineQuery.From.ID) {
+		b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+			InlineQueryID: update.InlineQuery.ID,
+			Results:       []models.InlineQueryResult{},
+			Button: &models.InlineQueryResultsButton{
+				Text:           "Принять условия использования",
+				StartParameter: "terms",
+			},
+		})
+		return
+	}
+
+	// Запросы по мере набора не списывают лимит, поиск списывается при выборе результата
+	if !quotaAvailable(update.InlineQuery.From) {
+		b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+			InlineQueryID: update.InlineQuery.ID,
+			Results:       []models.InlineQueryResult{},
+			Button: &models.InlineQueryResultsButton{
+				Text:           "Дневной лимит поисков исчерпан",
+				StartParameter: "limits",
+			},
+		})
+		return
+	}
+
+	AppMetrics.Incr("inline_queries")
+
+	medicines, err := searchMedicinesContext(ctx, query)
+	if err != nil {
+		return
+	}
+	if len(medicines) > inlineResultsLimit {
+		medicines = medicines[:inlineResultsLimit]
+	}
+
+	// Поиск аналогов выполняется после выбора результата в chosenInlineResultHandler,
+	// клавиатура нужна, чтобы Telegram передал inline_message_id для редактирования
+	results := []models.InlineQueryResult{}
+	for _, medicine := range medicines {
+		results = append(results, &models.InlineQueryResultArticle{
+			ID:          medicine.ID,
+			Title:       medicine.Name,
+			Description: medicine.Components,
+			InputMessageContent: &models.InputTextMessageContent{
+				MessageText: fmt.Sprintf("Ищу аналоги для %s…", bold(medicine.Name)),
+				ParseMode:   models.ParseModeHTML,
+			},
+			ReplyMarkup: medicineLinkMarkup(medicine.Slug),
+		})
+	}
+
+	_, err = b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+		InlineQueryID: update.InlineQuery.ID,
+		Results:       results,
+		CacheTime:     inlineCacheTime,
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+// chosenInlineResultHandler дописывает аналоги в сообщение, отправленное через inline режим.
+// Для получения этих обновлений в @BotFather должен быть включен /setinlinefeedback
+func chosenInlineResultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	result := update.ChosenInlineResult
+	medicineID, err := strconv.Atoi(result.ResultID)
+	if err != nil {
+		return
+	}
+
+	AppMetrics.Incr("inline_chosen")
+
+	refusal := ""
+	switch {
+	case !flagEnabled("search"):
+		refusal = "Поиск временно недоступен. Попробуйте позже."
+	case !consumeQuota(&result.From, 1):
+		refusal = quotaExceededText(&result.From)
+	}
+	if len(refusal) > 0 {
+		if len(result.InlineMessageID) > 0 {
+			b.EditMessageText(ctx, &bot.EditMessageTextParams{
+				InlineMessageID: result.InlineMessageID,
+				Text:            refusal,
+			})
+		}
+		return
+	}
+
+	analogs, medicineInfo, err := searchAnalogsContext(ctx, medicineID, targetCountry(result.From.ID))
+	if err != nil {
+		analogs = []Analog{}
+	}
+
+	Storage.AddHistory(&result.From, HistoryEntry{
+		MedicineID:   medicineID,
+		MedicineName: medicineInfo.MedicineName,
+	})
+
+	if len(result.InlineMessageID) == 0 {
+		return
+	}
+
+	_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		InlineMessageID:    result.InlineMessageID,
+		Text:               analogSummary(medicineInfo.MedicineName, analogs),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+		ReplyMarkup:        medicineLinkMarkup(medicineInfo.MedicineSlug),
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+func medicineLinkMarkup(slug string) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{
+					Text: "Открыть на pillintrip.com",
+					URL:  "https://pillintrip.com/ru/medicine/" + slug,
+				},
+			},
+		},
+	}
+}
+
+// analogSummary форматирует список аналогов одним сообщением
+func analogSummary(medicineName string, analogs []Analog) string {
+	if len(analogs) == 0 {
+		return fmt.Sprintf("Мне не удалось найти аналоги для %s.", bold(medicineName))
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Аналоги для %s:", bold(medicineName)))
+	for index, analog := range analogs {
+		if index == inlineAnalogsLimit {
+			break
+		}
+		text.WriteString(fmt.Sprintf("\n%d. %s (%d%%)", index+1, link(analog.AnalogName, analogURL(analog)), analog.Percentage))
+	}
+
+	return text.String()
+}
+
+func analogURL(analog Analog) string {
+	return "https://pillintrip.com/ru/medicine/" + analog.AnalogSlug
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Торговые названия сравниваются без регистра, знаков ® и ™ и дозировки
+var medicineNameUnits = map[string]bool{
+	"мг": true, "mg": true, "г": true, "g": true, "мкг": true, "mcg": true, "мл": true, "ml": true, "%": true,
+}
+
+// normalizeMedicineName приводит торговое название к виду для сравнения: «Нурофен® 200 мг» и
+// «НУРОФЕН» становятся «нурофен»
+func normalizeMedicineName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '%' && r != '-'
+	})
+
+	kept := []string{}
+	for _, word := range words {
+		if medicineNameUnits[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		// Дозировка без пробела: 200мг, 5mg
+		if unit := strings.TrimLeftFunc(word, unicode.IsDigit); len(unit) < len(word) && medicineNameUnits[unit] {
+			continue
+		}
+		kept = append(kept, word)
+	}
+
+	return strings.Join(kept, " ")
+}
+
+// innQuery возвращает русское МНН из классификации ATC для запроса с торговым названием,
+// если оно отличается от самого запроса
+func innQuery(query string) (string, bool) {
+	substance, ok := ATC.Classify(query)
+	if !ok || normalizeMedicineName(query) == normalizeMedicineName(substance.Name) {
+		return "", false
+	}
+
+	return substance.Name, true
+}
+
+// dedupMedicines убирает повторы одного лекарства, которые отличаются только записью названия
+func dedupMedicines(medicines []Medicine) []Medicine {
+	seen := map[string]bool{}
+	unique := make([]Medicine, 0, len(medicines))
+	for _, medicine := range medicines {
+		key := normalizeMedicineName(medicine.Name) + "|" + strings.ToLower(strings.TrimSpace(medicine.Components))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, medicine)
+	}
+
+	return unique
+}
+
+// dedupAnalogs оставляет одну запись торгового названия с наибольшим совпадением
+func dedupAnalogs(analogs []Analog) []Analog {
+	index := map[string]int{}
+	unique := make([]Analog, 0, len(analogs))
+	for _, analog := range analogs {
+		key := normalizeMedicineName(analog.AnalogName)
+		if len(key) == 0 {
+			unique = append(unique, analog)
+			continue
+		}
+		if found, ok := index[key]; ok {
+			if analog.Percentage > unique[found].Percentage {
+				unique[found] = analog
+			}
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, analog)
+	}
+
+	return unique
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed data/interactions.json
+var embeddedInteractions []byte
+
+const (
+	interactionSevere   = "severe"
+	interactionModerate = "moderate"
+)
+
+// InteractionMedicine лекарство для проверки взаимодействий: название и действующие вещества
+type InteractionMedicine struct {
+	Name       string `json:"name"`
+	Substances string `json:"substances"`
+}
+
+// Interaction найденное взаимодействие двух лекарств
+type Interaction struct {
+	First    string `json:"first"`
+	Second   string `json:"second"`
+	Severity string `json:"severity"`
+	Note     string `json:"note"`
+}
+
+// InteractionProvider проверяет взаимодействие лекарства с остальными лекарствами пользователя
+type InteractionProvider interface {
+	Interactions(ctx context.Context, medicine InteractionMedicine, others []InteractionMedicine) ([]Interaction, error)
+}
+
+// interactionRule пара групп веществ и торговых названий, которые нельзя сочетать
+type interactionRule struct {
+	First    []string `json:"first"`
+	Second   []string `json:"second"`
+	Severity string   `json:"severity"`
+	Note     string   `json:"note"`
+}
+
+// LocalInteractions проверяет взаимодействия по встроенному справочнику или INTERACTIONS_FILE
+type LocalInteractions struct {
+	mu    sync.Mutex
+	rules []interactionRule
+}
+
+func (l *LocalInteractions) Load(path string) error {
+	body := embeddedInteractions
+	if len(path) > 0 {
+		file, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		body = file
+	}
+
+	rules := []interactionRule{}
+	if err := json.Unmarshal(body, &rules); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.rules = rules
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *LocalInteractions) Interactions(ctx context.Context, medicine InteractionMedicine, others []InteractionMedicine) ([]Interaction, error) {
+	l.mu.Lock()
+	rules := l.rules
+	l.mu.Unlock()
+
+	found := []Interaction{}
+	for _, other := range others {
+		for _, rule := range rules {
+			if (matchesAny(medicine, rule.First) && matchesAny(other, rule.Second)) ||
+				(matchesAny(medicine, rule.Second) && matchesAny(other, rule.First)) {
+				found = append(found, Interaction{First: medicine.Name, Second: other.Name, Severity: rule.Severity, Note: rule.Note})
+				break
+			}
+		}
+	}
+
+	return found, nil
+}
+
+func matchesAny(medicine InteractionMedicine, keywords []string) bool {
+	text := strings.ToLower(medicine.Name + " " + medicine.Substances)
+	for _, keyword := range keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HTTPInteractions отправляет лекарства на внешний сервис INTERACTIONS_URL
+type HTTPInteractions struct {
+	URL    string
+	client http.Client
+}
+
+func (h *HTTPInteractions) Interactions(ctx context.Context, medicine InteractionMedicine, others []InteractionMedicine) ([]Interaction, error) {
+	body, err := json.Marshal(map[string]any{"medicine": medicine, "others": others})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New("interactions: " + response.Status)
+	}
+
+	var result struct {
+		Interactions []Interaction `json:"interactions"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.Interactions, nil
+}
+
+// newInteractionProvider создает проверку по настройке INTERACTIONS_PROVIDER: local по умолчанию,
+// http для внешнего сервиса, off отключает проверку
+func newInteractionProvider(name string) InteractionProvider {
+	switch name {
+	case "", "local":
+		provider := &LocalInteractions{}
+		if err := provider.Load(os.Getenv("INTERACTIONS_FILE")); err != nil {
+			logError(err)
+			return nil
+		}
+		return provider
+	case "http":
+		url := os.Getenv("INTERACTIONS_URL")
+		if len(url) == 0 {
+			return nil
+		}
+		return &HTTPInteractions{URL: url}
+	}
+
+	return nil
+}
+
+// interactionMedicine дополняет название лекарства его действующими веществами
+func interactionMedicine(ctx context.Context, medicineID string, name string) InteractionMedicine {
+	return InteractionMedicine{Name: name, Substances: medicineComponents(ctx, MedicineInfo{MedicineID: medicineID, MedicineName: name})}
+}
+
+// severeInteractions возвращает тяжелые взаимодействия лекарства с остальными
+func severeInteractions(ctx context.Context, medicine InteractionMedicine, others []InteractionMedicine) []Interaction {
+	if Interactions == nil || len(others) == 0 {
+		return nil
+	}
+
+	found, err := Interactions.Interactions(ctx, medicine, others)
+	if err != nil {
+		logError(err)
+		return nil
+	}
+
+	severe := []Interaction{}
+	for _, interaction := range found {
+		if interaction.Severity == interactionSevere {
+			severe = append(severe, interaction)
+		}
+	}
+	if len(severe) > 0 {
+		AppMetrics.Incr("interaction_warnings")
+	}
+
+	return severe
+}
+
+func formatInteractions(interactions []Interaction) string {
+	if len(interactions) == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	for _, interaction := range interactions {
+		text.WriteString(fmt.Sprintf("\n⛔️ %s + %s: %s", bold(interaction.First), bold(interaction.Second), escapeHTML(interaction.Note)))
+	}
+	text.WriteString("\n" + italic("Не принимайте эти лекарства вместе без согласования с врачом."))
+
+	return text.String()
+}
+
+// favoriteInteractions проверяет новое лекарство из избранного против остальных лекарств того же профиля
+func favoriteInteractions(ctx context.Context, userID int64, favorite Favorite) string {
+	others := []InteractionMedicine{}
+	for _, saved := range profileFavorites(userID, favorite.Profile) {
+		if saved.MedicineID != favorite.MedicineID {
+			others = append(others, interactionMedicine(ctx, strconv.Itoa(saved.MedicineID), saved.MedicineName))
+		}
+	}
+
+	return formatInteractions(severeInteractions(ctx, interactionMedicine(ctx, strconv.Itoa(favorite.MedicineID), favorite.MedicineName), others))
+}
+
+// checklistInteractions проверяет попарно все лекарства списка в дорогу
+func checklistInteractions(ctx context.Context, items []ChecklistItem) string {
+	medicines := []InteractionMedicine{}
+	for _, item := range items {
+		medicines = append(medicines, interactionMedicine(ctx, strconv.Itoa(item.MedicineID), item.Medicine))
+	}
+
+	found := []Interaction{}
+	for index := range medicines {
+		found = append(found, severeInteractions(ctx, medicines[index], medicines[index+1:])...)
+	}
+
+	return formatInteractions(found)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+//go:embed data/jurisdictions.json
+var embeddedJurisdictions []byte
+
+// JurisdictionRule тексты предупреждений и отключенные функции для домашней страны пользователя,
+// правило со страной * применяется ко всем остальным странам
+type JurisdictionRule struct {
+	Country    string            `json:"country"`
+	Disclaimer map[string]string `json:"disclaimer,omitempty"`
+	// Version версия текста Disclaimer, при увеличении пользователи принимают его заново
+	Version int               `json:"version,omitempty"`
+	Footer  map[string]string `json:"footer,omitempty"`
+	// Disabled функции, недоступные в стране: dose, pregnancy
+	Disabled []string `json:"disabled,omitempty"`
+}
+
+// Jurisdictions правила из data/jurisdictions.json или JURISDICTIONS_FILE
+var Jurisdictions = []JurisdictionRule{}
+
+func loadJurisdictions(path string) error {
+	body := embeddedJurisdictions
+	if len(path) > 0 {
+		file, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		body = file
+	}
+
+	rules := []JurisdictionRule{}
+	if err := json.Unmarshal(body, &rules); err != nil {
+		return err
+	}
+	Jurisdictions = rules
+
+	return nil
+}
+
+// homeCountry возвращает домашнюю страну чата из /home, по умолчанию страну HOME_COUNTRY_ID
+func homeCountry(chatID int64) (Country, bool) {
+	if code := Storage.ChatSettings(chatID).HomeCountry; len(code) > 0 {
+		if country, ok := countryByCode(code); ok {
+			return country, true
+		}
+	}
+
+	return countryByID(HoumeCountryID)
+}
+
+// jurisdictionRules возвращает правило домашней страны чата и общее правило, если они есть
+func jurisdictionRules(chatID int64) []JurisdictionRule {
+	code := ""
+	if country, ok := homeCountry(chatID); ok {
+		code = country.Code
+	}
+
+	rules := []JurisdictionRule{}
+	for _, rule := range Jurisdictions {
+		if len(code) > 0 && strings.EqualFold(rule.Country, code) {
+			rules = append([]JurisdictionRule{rule}, rules...)
+		} else if rule.Country == "*" {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+// jurisdictionText выбирает текст на языке language из первого правила, где он задан
+func jurisdictionText(chatID int64, language string, field func(rule JurisdictionRule) map[string]string, fallback string) string {
+	for _, rule := range jurisdictionRules(chatID) {
+		if text, ok := field(rule)[language]; ok && len(text) > 0 {
+			return text
+		}
+	}
+
+	return fallback
+}
+
+// Disclaimer текст предупреждения с его версией, Key отличает тексты разных стран
+type Disclaimer struct {
+	Key     string
+	Version int
+	Text    string
+}
+
+// chatDisclaimer выбирает предупреждение домашней страны чата, по умолчанию disclaimerTexts
+func chatDisclaimer(chatID int64, language string) Disclaimer {
+	for _, rule := range jurisdictionRules(chatID) {
+		if text, ok := rule.Disclaimer[language]; ok && len(text) > 0 {
+			version := rule.Version
+			if version < 1 {
+				version = 1
+			}
+			return Disclaimer{Key: rule.Country, Version: version, Text: text}
+		}
+	}
+
+	return Disclaimer{Key: defaultDisclaimerKey, Version: disclaimerVersion, Text: disclaimerTexts[language]}
+}
+
+func footerText(chatID int64) string {
+	return jurisdictionText(chatID, "ru", func(rule JurisdictionRule) map[string]string {
+		return rule.Footer
+	}, disclaimerFooter)
+}
+
+// featureAvailable проверяет, что функция не отключена для домашней страны чата
+func featureAvailable(chatID int64, feature string) bool {
+	for _, rule := range jurisdictionRules(chatID) {
+		for _, disabled := range rule.Disabled {
+			if disabled == feature {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// sendFeatureUnavailable сообщает, что функция недоступна в домашней стране чата
+func sendFeatureUnavailable(ctx context.Context, b *bot.Bot, chatID int64) {
+	country := "вашей стране"
+	if c, ok := homeCountry(chatID); ok {
+		country = "стране " + c.Name
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("Эта функция недоступна в %s. Домашнюю страну можно изменить командой /home.", country),
+	})
+}
+
+// homeHandler показывает и меняет домашнюю страну: /home <код страны>
+func homeHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	_, code, _ := strings.Cut(update.Message.Text, " ")
+	code = strings.TrimSpace(code)
+
+	if len(code) > 0 {
+		country, ok := countryByCode(code)
+		if !ok {
+			codes := []string{}
+			for _, c := range Countries {
+				codes = append(codes, c.Code)
+			}
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "Не знаю такой страны. Доступные коды: " + strings.Join(codes, ", "),
+			})
+			return
+		}
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.HomeCountry = country.Code
+		})
+	}
+
+	text := "Домашняя страна не выбрана."
+	if country, ok := homeCountry(chatID); ok {
+		text = fmt.Sprintf("Домашняя страна: %s. От нее зависят предупреждения и доступные функции.", bold(escapeHTML(country.Name)))
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text + "\nИзменить: /home <код страны>",
+		ParseMode: models.ParseModeHTML,
+	})
+}
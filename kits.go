@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// KitItem категория аптечки и действующее вещество, по которому ищутся лекарства
+type KitItem struct {
+	Category  string
+	Substance string
+}
+
+// KitTemplate шаблон дорожной аптечки
+type KitTemplate struct {
+	Key   string
+	Title string
+	Items []KitItem
+}
+
+var kitBasics = []KitItem{
+	{Category: "Жаропонижающее и обезболивающее", Substance: "парацетамол"},
+	{Category: "Противовоспалительное", Substance: "ибупрофен"},
+	{Category: "От аллергии", Substance: "цетиризин"},
+	{Category: "От диареи", Substance: "лоперамид"},
+	{Category: "Сорбент", Substance: "смектит диоктаэдрический"},
+	{Category: "Антисептик", Substance: "хлоргексидин"},
+}
+
+// KitTemplates шаблоны аптечек, которые предлагает /kit
+var KitTemplates = []KitTemplate{
+	{
+		Key:   "tropics",
+		Title: "Тропики",
+		Items: append(append([]KitItem{}, kitBasics...),
+			KitItem{Category: "Регидратация", Substance: "натрия хлорид + калия хлорид + декстроза"},
+			KitItem{Category: "После солнечных ожогов", Substance: "декспантенол"},
+			KitItem{Category: "От укусов насекомых", Substance: "диметинден"},
+			KitItem{Category: "От грибковых инфекций", Substance: "клотримазол"},
+		),
+	},
+	{
+		Key:   "mountains",
+		Title: "Горы",
+		Items: append(append([]KitItem{}, kitBasics...),
+			KitItem{Category: "От горной болезни", Substance: "ацетазоламид"},
+			KitItem{Category: "Мазь от ушибов и растяжений", Substance: "диклофенак"},
+			KitItem{Category: "Пластырь от мозолей", Substance: "гидроколлоидный пластырь"},
+			KitItem{Category: "От укачивания", Substance: "дименгидринат"},
+		),
+	},
+	{
+		Key:   "kids",
+		Title: "С детьми",
+		Items: []KitItem{
+			{Category: "Жаропонижающее для детей", Substance: "парацетамол суспензия"},
+			{Category: "Противовоспалительное для детей", Substance: "ибупрофен суспензия"},
+			{Category: "От аллергии для детей", Substance: "диметинден капли"},
+			{Category: "Регидратация", Substance: "натрия хлорид + калия хлорид + декстроза"},
+			{Category: "Сорбент", Substance: "смектит диоктаэдрический"},
+			{Category: "Сосудосуживающие капли в нос", Substance: "ксилометазолин детский"},
+			{Category: "Антисептик", Substance: "хлоргексидин"},
+			{Category: "После солнечных ожогов", Substance: "декспантенол"},
+		},
+	},
+}
+
+func kitTemplate(key string) (KitTemplate, bool) {
+	for _, template := range KitTemplates {
+		if template.Key == key {
+			return template, true
+		}
+	}
+
+	return KitTemplate{}, false
+}
+
+func kitHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	buttons := [][]models.InlineKeyboardButton{}
+	for _, template := range KitTemplates {
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: template.Title, CallbackData: "kit:" + template.Key},
+		})
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "Выберите, куда собираетесь, и я подберу аптечку из лекарств, которые продаются в стране поиска:",
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: buttons,
+		},
+	})
+}
+
+func kitCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	_, key, _ := strings.Cut(update.CallbackQuery.Data, ":")
+	template, ok := kitTemplate(key)
+	if !ok {
+		return
+	}
+	chatID := callbackChatID(update.CallbackQuery)
+
+	b.SendChatAction(ctx, &bot.SendChatActionParams{
+		ChatID: chatID,
+		Action: models.ChatActionTyping,
+	})
+
+	countryID := targetCountry(chatID)
+	queries := []string{}
+	for _, item := range template.Items {
+		queries = append(queries, item.Substance)
+	}
+	results := bulkSearch(ctx, queries, countryID)
+
+	AppMetrics.Incr("kit_templates")
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               formatKit(template, results, countryID),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+// formatKit показывает для каждой категории шаблона найденные в стране лекарства
+func formatKit(template KitTemplate, results []BulkResult, countryID int) string {
+	var text strings.Builder
+	title := "Аптечка «" + template.Title + "»"
+	if country, ok := countryByID(countryID); ok {
+		title += ": " + country.Name
+	}
+	text.WriteString(bold(title) + "\n")
+
+	for index, item := range template.Items {
+		text.WriteString("\n" + bold(item.Category) + " (" + escapeHTML(item.Substance) + ")")
+		if index >= len(results) || len(results[index].Analogs) == 0 {
+			text.WriteString("\n   нет данных")
+			continue
+		}
+		for analogIndex, analog := range results[index].Analogs {
+			if analogIndex == bulkAnalogsLimit {
+				break
+			}
+			text.WriteString(fmt.Sprintf("\n   %s", link(analog.AnalogName, analogURL(analog))))
+		}
+	}
+	text.WriteString("\n\n" + italic("Перед приемом прочитайте инструкцию и проконсультируйтесь с врачом."))
+
+	return text.String()
+}
@@ -1,91 +1,74 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/joho/godotenv"
+	"github.com/nighthtr/pills-bot/pills"
 )
 
-type SearchMedicineRequest struct {
-	ApiKey       string `json:"api_key"`
-	State        string `json:"state"`
-	HoumeCountry int    `json:"home_country"`
-	Query        string `json:"query"`
-}
-
-type SearchMedicineResponse struct {
-	Medicines []Medicine `json:"medicines"`
-}
-
-type SearchAnalogRequest struct {
-	ApiKey        string `json:"api_key"`
-	State         string `json:"state"`
-	HoumeCountry  int    `json:"home_country"`
-	TargetCountry int    `json:"target_country"`
-	Language      string `json:"language"`
-	Medicine      int    `json:"medicine"`
-}
-
-type SearchAnalogResponse struct {
-	MedicineInfo MedicineInfo `json:"medicine_info"`
-	HomeCountry  MedicineInfo `json:"home_country"`
-	Analogs      []Analog     `json:"medicine_analogs"`
-}
-
-type Medicine struct {
-	ID         string `json:"id"`
-	Name       string `json:"name"`
-	Components string `json:"components"`
-	Slug       string `json:"slug"`
-	IsPopular  int    `json:"ispopular"`
-}
-
-type MedicineInfo struct {
-	MedicineID   string `json:"medicine_id"`
-	MedicineName string `json:"medicine_name"`
-	MedicineSlug string `json:"medicine_slug"`
-	DateRevision string `json:"date_revision"`
-}
-
-type Analog struct {
-	AnalogID        string `json:"analog_id"`
-	AnalogName      string `json:"analog_name"`
-	AnalogSlug      string `json:"analog_slug"`
-	ComponentsMatch int    `json:"components_match"`
-	ApplyingsMatch  int    `json:"applyings_match"`
-	TreatmentsMatch int    `json:"treatments_match"`
-	Percentage      int    `json:"percentage"`
-}
+type (
+	Medicine        = pills.Medicine
+	MedicineInfo    = pills.MedicineInfo
+	Analog          = pills.Analog
+	MedicineDetails = pills.MedicineDetails
+	MedicineForm    = pills.MedicineForm
+	SafetyCategory  = pills.SafetyCategory
+	// MedicineProvider источник данных о лекарствах, API или цепочка API и запасных источников
+	MedicineProvider = pills.Provider
+)
 
 var (
-	ApiUrl          string = "https://api.pillintrip.com/search"
-	ApiKey          string
-	HoumeCountryID  int
-	TargetCountryID int
-	err             error
+	ApiUrl             string = pills.DefaultURL
+	ApiKey             string
+	API                *pills.Client
+	Medicines          MedicineProvider
+	BotToken           string
+	WebAppURL          string
+	BotUsername        string
+	AdminRoles         map[int64]Role
+	AdminChatID        int64
+	ApiDailyQuota      int
+	OCR                OCRProvider
+	Barcodes           BarcodeScanner
+	GTINs              MultiGTINs
+	PillIdentification PillIdentifier
+	TTS                TTSProvider
+	Places             PlacesProvider
+	Interactions       InteractionProvider
+	Labels             LabelProvider
+	Prices             PriceProvider
+	HoumeCountryID     int
+	TargetCountryID    int
+	Storage            *Store
+	err                error
 )
 
 func init() {
 	err := godotenv.Load(".env")
 
-	if err != nil {
+	// В консольном режиме настройки можно передать переменными окружения без .env
+	if err != nil && !isCLI(os.Args) {
 		log.Fatal("Error loading .env file")
 	}
 }
 
 func main() {
-	BotToken := os.Getenv("BOT_TOKEN")
+	if isCLI(os.Args) {
+		os.Exit(runCLI(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	BotToken = os.Getenv("BOT_TOKEN")
 	if len(BotToken) == 0 {
 		log.Fatal("Не указан токен телеграм бота")
 		os.Exit(2)
@@ -115,13 +98,172 @@ func main() {
 		os.Exit(2)
 	}
 
+	API = newAPIClient()
+	if err := loadSnapshot(); err != nil {
+		log.Fatal(err)
+		os.Exit(2)
+	}
+	Medicines, err = newMedicineProvider(API, os.Getenv("MEDICINE_FALLBACK_FILE"))
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(2)
+	}
+
+	storePath := os.Getenv("STORE_PATH")
+	if len(storePath) == 0 {
+		storePath = "store.json"
+	}
+	Storage, err = NewStore(storePath)
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(2)
+	}
+	AppMetrics.Persist(Storage)
+
+	OCR = newOCRProvider(os.Getenv("OCR_PROVIDER"))
+	PillIdentification = newPillIdentifier()
+	TTS = newTTSProvider(os.Getenv("TTS_PROVIDER"))
+	Places = newPlacesProvider(os.Getenv("PLACES_PROVIDER"))
+	Interactions = newInteractionProvider(os.Getenv("INTERACTIONS_PROVIDER"))
+	Labels = newLabelProvider(os.Getenv("LABELS_PROVIDER"))
+	Prices = newPriceProvider(os.Getenv("PRICE_PROVIDERS"))
+	if err := loadJurisdictions(os.Getenv("JURISDICTIONS_FILE")); err != nil {
+		log.Fatal(err)
+		os.Exit(2)
+	}
+	if err := loadSensitiveDenylist(os.Getenv("SENSITIVE_FILE")); err != nil {
+		log.Fatal(err)
+		os.Exit(2)
+	}
+	if err := loadATC(os.Getenv("ATC_FILE")); err != nil {
+		log.Fatal(err)
+		os.Exit(2)
+	}
+	Countries = parseCountries(os.Getenv("COUNTRIES"))
+	if err := Restrictions.Load(os.Getenv("RESTRICTIONS_FILE")); err != nil {
+		log.Fatal(err)
+		os.Exit(2)
+	}
+	Barcodes = newBarcodeScanner(os.Getenv("BARCODE_SCANNERS"))
+	if err := loadGTINs(); err != nil {
+		log.Fatal(err)
+		os.Exit(2)
+	}
+
+	AdminRoles = parseAdminRoles(os.Getenv("ADMIN_IDS"))
+	WebAppURL = os.Getenv("WEBAPP_URL")
+	AdminChatID, _ = strconv.ParseInt(os.Getenv("ADMIN_CHAT_ID"), 10, 64)
+	ApiDailyQuota, _ = strconv.Atoi(os.Getenv("API_DAILY_QUOTA"))
+	if days, err := strconv.Atoi(os.Getenv("TRIP_RECHECK_DAYS")); err == nil {
+		TripRecheckDays = days
+	}
+	RestAPIEnabled, _ = strconv.ParseBool(os.Getenv("REST_API"))
+	var ok bool
+	Discord, ok = newDiscordAdapter(os.Getenv("DISCORD_APP_ID"), os.Getenv("DISCORD_BOT_TOKEN"), os.Getenv("DISCORD_PUBLIC_KEY"))
+	checkAdapterConfig("Discord", ok, "DISCORD_APP_ID", "DISCORD_BOT_TOKEN", "DISCORD_PUBLIC_KEY")
+	Slack, ok = newSlackAdapter(os.Getenv("SLACK_SIGNING_SECRET"))
+	checkAdapterConfig("Slack", ok, "SLACK_SIGNING_SECRET")
+	MCPToken = os.Getenv("MCP_TOKEN")
+	loadPositiveDuration("PERMALINK_TTL", &PermalinkTTL)
+	loadPositiveDuration("DATA_STALE_AFTER", &DataStaleAfter)
+	loadPositiveDuration("WATCH_INTERVAL", &WatchInterval)
+	Matrix, ok = newMatrixAdapter(os.Getenv("MATRIX_HOMESERVER"), os.Getenv("MATRIX_TOKEN"))
+	checkAdapterConfig("Matrix", ok, "MATRIX_HOMESERVER", "MATRIX_TOKEN")
+	WhatsApp, ok = newWhatsAppAdapter(os.Getenv("WHATSAPP_TOKEN"), os.Getenv("WHATSAPP_PHONE_ID"), os.Getenv("WHATSAPP_VERIFY_TOKEN"), os.Getenv("WHATSAPP_APP_SECRET"))
+	checkAdapterConfig("WhatsApp", ok, "WHATSAPP_TOKEN", "WHATSAPP_PHONE_ID", "WHATSAPP_VERIFY_TOKEN", "WHATSAPP_APP_SECRET")
+	Webhooks, ok = newWebhookDispatcher(os.Getenv("WEBHOOK_URLS"), os.Getenv("WEBHOOK_SECRET"))
+	checkAdapterConfig("webhooks", ok, "WEBHOOK_URLS", "WEBHOOK_SECRET")
+	if limit, err := strconv.Atoi(os.Getenv("REST_API_RATE_LIMIT")); err == nil {
+		APILimiter = NewRateLimiter(limit, 10)
+	}
+	loadChatLimiter()
+	loadBudget()
+	loadGraylistDelay()
+	APIKeysRequired, _ = strconv.ParseBool(os.Getenv("API_KEYS_REQUIRED"))
+	if limit, err := strconv.Atoi(os.Getenv("API_KEY_RATE_LIMIT")); err == nil {
+		APIKeyLimiter = NewRateLimiter(limit, 20)
+	}
+	CaptchaMode = os.Getenv("CAPTCHA")
+	QuotaTiers = parseQuotaTiers(os.Getenv("QUOTA_TIERS"))
+	if tier := os.Getenv("QUOTA_DEFAULT_TIER"); len(tier) > 0 {
+		DefaultQuotaTier = strings.ToLower(tier)
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	if httpAddr := os.Getenv("HTTP_ADDR"); len(httpAddr) > 0 {
+		go startHTTPServer(ctx, httpAddr)
+	}
+	if grpcAddr := os.Getenv("GRPC_ADDR"); len(grpcAddr) > 0 {
+		go startGRPCServer(ctx, grpcAddr, os.Getenv("GRPC_TOKEN"))
+	}
+	if Matrix != nil {
+		go Matrix.Run(ctx)
+	}
+	if Webhooks != nil {
+		go Webhooks.Run(ctx)
+	}
+
 	opts := []bot.Option{
-		bot.WithDefaultHandler(searchMedicineHandler),
+		bot.WithMiddlewares(authMiddleware, floodMiddleware, dedupCallbackMiddleware, rateLimitMiddleware, graylistMiddleware),
+		bot.WithHTTPClient(time.Minute, newTelegramSender()),
+		bot.WithAllowedUpdates(bot.AllowedUpdates{
+			models.AllowedUpdateMessage,
+			models.AllowedUpdateEditedMessage,
+			models.AllowedUpdateCallbackQuery,
+			models.AllowedUpdateInlineQuery,
+			models.AllowedUpdateChosenInlineResult,
+			models.AllowedUpdateMessageReaction,
+		}),
+		bot.WithDefaultHandler(defaultHandler),
 		bot.WithCallbackQueryDataHandler("search_analog", bot.MatchTypePrefix, searcheAnalogHandler),
 		bot.WithCallbackQueryDataHandler("show_medicine", bot.MatchTypePrefix, showMedicineHandler),
+		bot.WithCallbackQueryDataHandler("report_analog", bot.MatchTypePrefix, reportAnalogHandler),
+		bot.WithCallbackQueryDataHandler("analog_pdf", bot.MatchTypePrefix, analogPDFHandler),
+		bot.WithCallbackQueryDataHandler("analog_qr", bot.MatchTypePrefix, analogQRHandler),
+		bot.WithCallbackQueryDataHandler("analog_link", bot.MatchTypePrefix, analogLinkHandler),
+		bot.WithCallbackQueryDataHandler("analog_pin", bot.MatchTypePrefix, analogPinHandler),
+		bot.WithCallbackQueryDataHandler("captcha:", bot.MatchTypePrefix, captchaHandler),
+		bot.WithCallbackQueryDataHandler("disclaimer_accept", bot.MatchTypePrefix, disclaimerAcceptHandler),
+		bot.WithCallbackQueryDataHandler("pregnancy:", bot.MatchTypePrefix, pregnancyCallbackHandler),
+		bot.WithCallbackQueryDataHandler("allergy:", bot.MatchTypePrefix, allergyCallbackHandler),
+		bot.WithCallbackQueryDataHandler("age:", bot.MatchTypePrefix, ageCallbackHandler),
+		bot.WithCallbackQueryDataHandler("privacy:", bot.MatchTypePrefix, privacyCallbackHandler),
+		bot.WithCallbackQueryDataHandler("analog_refresh:", bot.MatchTypePrefix, analogRefreshHandler),
+		bot.WithCallbackQueryDataHandler("pharmacy_card", bot.MatchTypePrefix, pharmacyCardHandler),
+		bot.WithCallbackQueryDataHandler("medicine_info:", bot.MatchTypePrefix, medicineInfoHandler),
+		bot.WithCallbackQueryDataHandler("watch:", bot.MatchTypePrefix, watchHandler),
+		bot.WithCallbackQueryDataHandler("unwatch:", bot.MatchTypePrefix, unwatchHandler),
+		bot.WithCallbackQueryDataHandler("price_alert:", bot.MatchTypePrefix, priceAlertHandler),
+		bot.WithCallbackQueryDataHandler("price_target:", bot.MatchTypePrefix, priceTargetHandler),
+		bot.WithCallbackQueryDataHandler("subs:", bot.MatchTypePrefix, subscriptionCallbackHandler),
+		bot.WithCallbackQueryDataHandler("search_query", bot.MatchTypePrefix, searchQueryHandler),
+		bot.WithCallbackQueryDataHandler("prescription", bot.MatchTypePrefix, prescriptionCallbackHandler),
+		bot.WithCallbackQueryDataHandler("pill_color", bot.MatchTypePrefix, pillColorHandler),
+		bot.WithCallbackQueryDataHandler("pill_shape", bot.MatchTypePrefix, pillShapeHandler),
+		bot.WithCallbackQueryDataHandler("nearby_pharmacies", bot.MatchTypeExact, nearbyPharmaciesHandler),
+		bot.WithCallbackQueryDataHandler("reminder_taken", bot.MatchTypePrefix, reminderTakenHandler),
+		bot.WithCallbackQueryDataHandler("reminder_snooze", bot.MatchTypePrefix, reminderSnoozeHandler),
+		bot.WithCallbackQueryDataHandler("reminder_delete", bot.MatchTypePrefix, reminderDeleteHandler),
+		bot.WithCallbackQueryDataHandler("reminder_reschedule", bot.MatchTypePrefix, reminderRescheduleHandler),
+		bot.WithCallbackQueryDataHandler("profile_switch", bot.MatchTypePrefix, profileSwitchHandler),
+		bot.WithCallbackQueryDataHandler("profile_delete", bot.MatchTypePrefix, profileDeleteHandler),
+		bot.WithCallbackQueryDataHandler("share_leave", bot.MatchTypePrefix, shareLeaveHandler),
+		bot.WithCallbackQueryDataHandler("dose_form", bot.MatchTypePrefix, doseFormHandler),
+		bot.WithCallbackQueryDataHandler("trip_cancel", bot.MatchTypePrefix, tripCancelHandler),
+		bot.WithCallbackQueryDataHandler("trip_archive", bot.MatchTypePrefix, tripArchiveHandler),
+		bot.WithCallbackQueryDataHandler("trip_keep", bot.MatchTypePrefix, tripKeepHandler),
+		bot.WithCallbackQueryDataHandler("trip_checklist", bot.MatchTypePrefix, tripChecklistHandler),
+		bot.WithCallbackQueryDataHandler("trip_pack", bot.MatchTypePrefix, tripPackHandler),
+		bot.WithCallbackQueryDataHandler("trip_claim", bot.MatchTypePrefix, tripClaimHandler),
+		bot.WithCallbackQueryDataHandler("trip_view", bot.MatchTypePrefix, tripViewHandler),
+		bot.WithCallbackQueryDataHandler("trip_reuse", bot.MatchTypePrefix, tripReuseHandler),
+		bot.WithCallbackQueryDataHandler("trip_sheet", bot.MatchTypePrefix, tripSheetHandler),
+		bot.WithCallbackQueryDataHandler("trip_md", bot.MatchTypePrefix, tripMarkdownHandler),
+		bot.WithCallbackQueryDataHandler("bulk_sheet", bot.MatchTypePrefix, bulkSheetHandler),
+		bot.WithCallbackQueryDataHandler("kit:", bot.MatchTypePrefix, kitCallbackHandler),
+		bot.WithCallbackQueryDataHandler("compare_sheet", bot.MatchTypePrefix, compareSheetHandler),
 	}
 
 	b, err := bot.New(BotToken, opts...)
@@ -130,16 +272,206 @@ func main() {
 		os.Exit(2)
 	}
 
-	b.RegisterHandler(bot.HandlerTypeMessageText, "/start", bot.MatchTypeExact, startHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("start"), startHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/stats", bot.MatchTypeExact, statsHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/favorites", bot.MatchTypeExact, favoritesHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/admin", bot.MatchTypeExact, adminHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("grant"), grantHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("revoke"), revokeHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("unmute"), unmuteHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/gtin_import", bot.MatchTypeExact, gtinImportHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("graylist"), graylistHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("quota"), quotaHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("apikeys"), apiKeysAdminHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("apikey"), apiKeyHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/limits", bot.MatchTypeExact, limitsHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/roles", bot.MatchTypeExact, rolesHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("feedback"), feedbackHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/cancel", bot.MatchTypeExact, cancelHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/digest", bot.MatchTypeExact, digestHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/prescription", bot.MatchTypeExact, prescriptionHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/identify", bot.MatchTypeExact, identifyHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/voice", bot.MatchTypeExact, voiceHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("remind"), remindHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/reminders", bot.MatchTypeExact, remindersHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("calendar"), calendarHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("channel"), channelHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/export", bot.MatchTypeExact, exportHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/pregnancy", bot.MatchTypeExact, pregnancyHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("allergy"), allergyHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/age", bot.MatchTypeExact, ageHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/privacy", bot.MatchTypeExact, privacyHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("home"), homeHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("timezone"), timezoneHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("adherence"), adherenceHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("weekly"), weeklyHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("profile"), profileHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("share"), shareHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("dose"), doseHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/today", bot.MatchTypeExact, todayHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("quiet"), quietHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("trip"), tripHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("compare"), compareHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/kit", bot.MatchTypeExact, kitHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/trips", bot.MatchTypeExact, tripsHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/watchlist", bot.MatchTypeExact, watchlistHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/subscriptions", bot.MatchTypeExact, subscriptionsHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("spent"), spentHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("entry"), entryHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("entry_set"), entrySetHandler)
+	b.RegisterHandlerRegexp(bot.HandlerTypeMessageText, commandRegexp("override"), overrideHandler)
+	b.RegisterHandler(bot.HandlerTypeMessageText, "/restrictions", bot.MatchTypeExact, restrictionsReloadHandler)
+
+	if me, err := b.GetMe(ctx); err == nil {
+		BotUsername = me.Username
+	} else {
+		logError(err)
+	}
+
+	publishCommands(ctx, b)
+
+	scheduler := NewScheduler()
+	scheduler.Add(metricsJob())
+	if job, ok := adminDigestJob(b, os.Getenv("DIGEST"), os.Getenv("DIGEST_TIME")); ok {
+		scheduler.Add(job)
+	}
+	scheduler.Add(remindersJob(b))
+	scheduler.Add(adherenceJob(b))
+	scheduler.Add(weeklyDigestJob(b))
+	scheduler.Add(courseJob(b))
+	scheduler.Add(todayJob(b))
+	scheduler.Add(tripsJob(b))
+	scheduler.Add(permalinksJob())
+	scheduler.Add(budgetJob(b))
+	scheduler.Add(watchJob(b))
+	if len(SnapshotPath) > 0 {
+		scheduler.Add(snapshotJob())
+	}
+	if sample, err := strconv.Atoi(os.Getenv("AUDIT_SAMPLE")); err == nil && sample > 0 {
+		scheduler.Add(auditJob(b, os.Getenv("AUDIT_TIME"), sample))
+	}
+	go scheduler.Start(ctx)
 
 	b.Start(ctx)
+	AppMetrics.Flush()
+}
+
+// defaultHandler обрабатывает сообщения, для которых не нашлось команды
+func defaultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.InlineQuery != nil {
+		inlineQueryHandler(ctx, b, update)
+		return
+	}
+
+	if update.ChosenInlineResult != nil {
+		chosenInlineResultHandler(ctx, b, update)
+		return
+	}
+
+	if update.MessageReaction != nil {
+		messageReactionHandler(ctx, b, update)
+		return
+	}
+
+	if update.EditedMessage != nil {
+		editedMessageHandler(ctx, b, update)
+		return
+	}
+
+	if update.Message == nil {
+		return
+	}
+
+	if handleSupportReply(ctx, b, update) {
+		return
+	}
+
+	if handleDialog(ctx, b, update) {
+		return
+	}
+
+	if routeMessageContent(ctx, b, update) {
+		return
+	}
+
+	if handleRefinement(ctx, b, update) {
+		return
+	}
+
+	searchMedicineHandler(ctx, b, update)
 }
 
 func startHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
-	b.SendMessage(ctx, &bot.SendMessageParams{
+	// Ссылки вида t.me/<bot>?start=med_12345 сразу показывают аналоги лекарства
+	_, payload, _ := strings.Cut(update.Message.Text, " ")
+	if medicineID, ok := parseMedicinePayload(payload); ok {
+		sendAnalogs(ctx, b, update.Message.Chat.ID, update.Message.From, medicineID)
+		return
+	}
+	if token, ok := strings.CutPrefix(strings.TrimSpace(payload), "share_"); ok {
+		joinSharedList(ctx, b, update.Message, token)
+		return
+	}
+	if code, ok := strings.CutPrefix(strings.TrimSpace(payload), "entry_"); ok {
+		if country, ok := countryByCode(code); ok {
+			sendEntryGuidance(ctx, b, update.Message.Chat.ID, country)
+			return
+		}
+	}
+	if token, ok := strings.CutPrefix(strings.TrimSpace(payload), "trip_"); ok {
+		linkTripGroup(ctx, b, update.Message, token)
+		return
+	}
+	if strings.TrimSpace(payload) == "limits" {
+		limitsHandler(ctx, b, update)
+		return
+	}
+	// Ссылка из inline режима показывает предупреждение, которое нужно принять перед поиском
+	if strings.TrimSpace(payload) == "terms" && !requireDisclaimer(ctx, b, update.Message.From, PendingSearch{ChatID: update.Message.Chat.ID}) {
+		return
+	}
+
+	params := &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
 		Text:   "Привет. Я помогу вам найти аналоги лекарств в Таиланде. Для поиска введите название лекарства.",
-	})
+	}
+	// Кнопки Web App на клавиатуре доступны только в личных чатах
+	if len(WebAppURL) > 0 && update.Message.Chat.Type == models.ChatTypePrivate {
+		params.ReplyMarkup = miniAppKeyboard()
+	}
+
+	b.SendMessage(ctx, params)
+}
+
+// isPrivateChat определяет личный чат по идентификатору: у групп и каналов он отрицательный
+func isPrivateChat(chatID int64) bool {
+	return chatID > 0
+}
+
+// callbackChatID возвращает чат сообщения, к которому относится нажатая кнопка
+func callbackChatID(query *models.CallbackQuery) int64 {
+	if query.Message.Message != nil {
+		return query.Message.Message.Chat.ID
+	}
+	if query.Message.InaccessibleMessage != nil {
+		return query.Message.InaccessibleMessage.Chat.ID
+	}
+
+	return query.From.ID
+}
+
+func parseMedicinePayload(payload string) (int, bool) {
+	value, ok := strings.CutPrefix(strings.TrimSpace(payload), "med_")
+	if !ok {
+		return 0, false
+	}
+
+	medicineID, err := strconv.Atoi(value)
+	if err != nil || medicineID <= 0 {
+		return 0, false
+	}
+
+	return medicineID, true
 }
 
 func searchMedicineHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -147,13 +479,109 @@ func searchMedicineHandler(ctx context.Context, b *bot.Bot, update *models.Updat
 		return
 	}
 
-	medicines, err := searchMedicines(update.Message.Text)
-	if err != nil || len(medicines) == 0 {
+	sentID, ok := sendMedicineSearch(ctx, b, update.Message.Chat.ID, update.Message.From, update.Message.Text)
+	if !ok {
+		return
+	}
+
+	SearchReplies.Set(replyKey{chatID: update.Message.Chat.ID, messageID: update.Message.ID}, sentID)
+}
+
+// allowSearch проверяет перед обращением к API, что поиск включен, пользователь прошел проверку
+// и принял предупреждение, и списывает count поисков из дневного лимита. Через нее проходят
+// все поиски из Telegram, отказ уже объяснен пользователю, а поиск pending выполнится после
+// проверки и принятия
+func allowSearch(ctx context.Context, b *bot.Bot, from *models.User, pending PendingSearch, count int) bool {
+	if !flagEnabled("search") {
 		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.Message.Chat.ID,
-			Text:   "Мне не удалось ничего найти.",
+			ChatID: pending.ChatID,
+			Text:   "Поиск временно недоступен. Попробуйте позже.",
 		})
-		return
+		return false
+	}
+	if !requireVerification(ctx, b, from, pending) || !requireDisclaimer(ctx, b, from, pending) {
+		return false
+	}
+	if count > 0 && !consumeQuota(from, count) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: pending.ChatID,
+			Text:   quotaExceededText(from),
+		})
+		return false
+	}
+
+	return true
+}
+
+// sendMedicineSearch ищет лекарства по запросу и отправляет список в чат,
+// возвращает идентификатор отправленного сообщения
+func sendMedicineSearch(ctx context.Context, b *bot.Bot, chatID int64, from *models.User, query string) (int, bool) {
+	if sensitiveQuery(query) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   sensitivePolicy,
+		})
+		return 0, false
+	}
+
+	if !allowSearch(ctx, b, from, PendingSearch{ChatID: chatID, Query: query}, 1) {
+		return 0, false
+	}
+
+	AppMetrics.Incr("searches")
+	Storage.AddHistory(from, HistoryEntry{Query: query})
+	if analyticsAllowed(from) {
+		Webhooks.Emit(EventSearchPerformed, map[string]any{"source": "telegram", "query": query})
+	}
+
+	text, markup := medicineSearchReply(ctx, chatID, query, Refinement{})
+	if remaining := quotaRemaining(from); len(remaining) > 0 {
+		text += "\n\n" + italic(remaining)
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	}
+	if markup != nil {
+		params.ReplyMarkup = markup
+	}
+
+	sent, err := b.SendMessage(ctx, params)
+	if err != nil {
+		logError(err)
+		return 0, false
+	}
+
+	SearchQueries.Set(replyKey{chatID: chatID, messageID: sent.ID}, query)
+
+	return sent.ID, true
+}
+
+// medicineSearchReply ищет лекарства и готовит ответ со списком для выбора,
+// refinement оставляет только лекарства, подходящие под уточнение
+func medicineSearchReply(ctx context.Context, chatID int64, query string, refinement Refinement) (string, *models.InlineKeyboardMarkup) {
+	allergies := profileHealth(chatID).Allergies
+	medicines, err := searchMedicinesContext(ctx, query)
+	if err == nil && !refinement.Empty() {
+		filtered := []Medicine{}
+		for _, medicine := range medicines {
+			if refinement.Match(medicine.Name + " " + medicine.Components) {
+				filtered = append(filtered, medicine)
+			}
+		}
+		medicines = filtered
+		query = query + " (" + refinement.Text + ")"
+	}
+	if err != nil || len(medicines) == 0 {
+		return fmt.Sprintf("Мне не удалось ничего найти по запросу %s.", bold(query)), nil
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Вот что я нашел по запросу %s:\n", bold(query)))
+	if warning := fallbackWarning(medicines[0].Source); len(warning) > 0 {
+		text.WriteString(strings.TrimPrefix(warning, "\n") + "\n")
 	}
 
 	buttons := [][]models.InlineKeyboardButton{}
@@ -161,21 +589,24 @@ func searchMedicineHandler(ctx context.Context, b *bot.Bot, update *models.Updat
 		if index == 10 {
 			break
 		}
+		marker := allergyMarker(allergies, medicine.Name, medicine.Components)
+		text.WriteString(fmt.Sprintf("\n%d. %s%s", index+1, marker, bold(medicine.Name)))
+		if len(medicine.Components) > 0 {
+			text.WriteString("\n" + italic(medicine.Components))
+		}
 		buttons = append(buttons, []models.InlineKeyboardButton{
 			{
-				Text:         medicine.Name,
+				Text:         marker + medicine.Name,
 				CallbackData: "search_analog:" + medicine.ID,
 			},
 		})
 	}
 
-	b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   "Вот что я нашел. Выберите лекарство, для которого нужно найти аналоги.",
-		ReplyMarkup: &models.InlineKeyboardMarkup{
-			InlineKeyboard: buttons,
-		},
-	})
+	text.WriteString("\n\nВыберите лекарство, для которого нужно найти аналоги.")
+
+	return text.String(), &models.InlineKeyboardMarkup{
+		InlineKeyboard: buttons,
+	}
 }
 
 func searcheAnalogHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -186,134 +617,327 @@ func searcheAnalogHandler(ctx context.Context, b *bot.Bot, update *models.Update
 
 	medicineID, _ := strconv.Atoi(strings.Split(update.CallbackQuery.Data, ":")[1])
 
-	analogs, medicineInfo, err := searchAnalogs(medicineID)
-	if err != nil || len(analogs) == 0 {
+	sendAnalogs(ctx, b, callbackChatID(update.CallbackQuery), &update.CallbackQuery.From, medicineID)
+}
+
+// sendAnalogs ищет аналоги лекарства и отправляет их в чат
+func sendAnalogs(ctx context.Context, b *bot.Bot, chatID int64, from *models.User, medicineID int) {
+	// Выбор лекарства из списка продолжает уже списанный поиск
+	if !allowSearch(ctx, b, from, PendingSearch{ChatID: chatID, MedicineID: medicineID}, 0) {
+		return
+	}
+
+	result, err := findAnalogsContext(ctx, medicineID, targetCountry(chatID))
+	if errors.Is(err, errSearchDisabled) {
 		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: update.CallbackQuery.Message.Chat.ID,
-			Text:   fmt.Sprintf("Мне не удалось найти аналоги для \"%s\".", medicineInfo.MedicineName),
+			ChatID: chatID,
+			Text:   "Поиск временно недоступен. Попробуйте позже.",
+		})
+		return
+	}
+	if err != nil || len(result.Analogs) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      fmt.Sprintf("Мне не удалось найти аналоги для %s.", bold(result.Medicine.MedicineName)),
+			ParseMode: models.ParseModeHTML,
 		})
 		return
 	}
 
+	Storage.AddHistory(from, HistoryEntry{
+		MedicineID:   medicineID,
+		MedicineName: result.Medicine.MedicineName,
+	})
+
+	sendAnalogList(ctx, b, chatID, medicineID, result.Medicine, result.Analogs,
+		fmt.Sprintf("Вот аналоги для %s:", bold(result.Medicine.MedicineName)))
+}
+
+// sendAnalogList отправляет список аналогов с заголовком header и запоминает сообщение
+func sendAnalogList(ctx context.Context, b *bot.Bot, chatID int64, medicineID int, medicineInfo MedicineInfo, analogs []Analog, header string) {
+	analogs, hidden := filterAnalogsByAge(ctx, chatID, analogs)
+	if hidden > 0 {
+		header += fmt.Sprintf("\n%s", italic(fmt.Sprintf("Скрыто аналогов, не подходящих по возрасту: %d. Изменить возраст: /age", hidden)))
+	}
+	header += restrictionWarnings(targetCountry(chatID), analogNames(medicineInfo.MedicineName, analogs)...)
+	header += safetyWarnings(ctx, chatID, medicineID)
+	header += atcSummary(ctx, medicineInfo, analogs)
+	if allergies := profileHealth(chatID).Allergies; len(allergies) > 0 {
+		header += allergyWarning(allergies, medicineInfo.MedicineName, medicineComponents(ctx, medicineInfo))
+	}
+	header += fallbackWarning(medicineInfo.Source)
+	header += "\n\n" + dataAttribution(medicineInfo)
+	header += "\n" + italic(footerText(chatID))
+
+	sent, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               header,
+		ParseMode:          models.ParseModeHTML,
+		ReplyMarkup:        analogsMarkup(ctx, chatID, medicineID, medicineInfo, analogs),
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	AnalogMessages.Set(replyKey{chatID: chatID, messageID: sent.ID}, ResultRef{
+		MedicineID:   medicineID,
+		MedicineName: medicineInfo.MedicineName,
+	})
+
+	sendVoiceSummary(ctx, b, chatID, medicineInfo.MedicineName, analogs)
+}
+
+// analogsMarkup готовит клавиатуру списка аналогов для чата chatID
+func analogsMarkup(ctx context.Context, chatID int64, medicineID int, medicineInfo MedicineInfo, analogs []Analog) *models.InlineKeyboardMarkup {
+	// Аналоги с общими компонентами отмечаются, если в исходном лекарстве есть аллерген
+	allergies := profileHealth(chatID).Allergies
+	flagged := len(allergies) > 0 && len(matchAllergies(allergies, medicineInfo.MedicineName, medicineComponents(ctx, medicineInfo))) > 0
+
 	buttons := [][]models.InlineKeyboardButton{}
 	for index, analog := range analogs {
 		if index == 10 {
 			break
 		}
+		marker := allergyMarker(allergies, analog.AnalogName)
+		if flagged && analog.ComponentsMatch > 0 {
+			marker = "⚠️ "
+		}
 		buttons = append(buttons, []models.InlineKeyboardButton{
 			{
-				Text: analog.AnalogName + " (" + strconv.Itoa(analog.Percentage) + "%)",
+				Text: marker + analog.AnalogName + " (" + strconv.Itoa(analog.Percentage) + "%)" + ageNote(ctx, chatID, analog),
 				// CallbackData: "show_medicine:" + analog.AnalogID,
-				URL: "https://pillintrip.com/ru/medicine/" + analog.AnalogSlug,
+				URL: analogURL(analog),
+			},
+			{
+				Text:         "🔳",
+				CallbackData: "analog_qr:" + strconv.Itoa(medicineID) + ":" + analog.AnalogID,
+			},
+			{
+				Text:         "⚠️",
+				CallbackData: "report_analog:" + strconv.Itoa(medicineID) + ":" + analog.AnalogID,
 			},
 		})
 	}
 
-	b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.CallbackQuery.Message.Chat.ID,
-		Text:   fmt.Sprintf("Вот аналоги для \"%s\":", medicineInfo.MedicineName),
-		ReplyMarkup: &models.InlineKeyboardMarkup{
-			InlineKeyboard: buttons,
+	buttons = append(buttons, []models.InlineKeyboardButton{
+		{
+			Text:         "📄 PDF",
+			CallbackData: "analog_pdf:" + strconv.Itoa(medicineID),
+		},
+		{
+			Text:         "💊 Для аптеки",
+			CallbackData: "pharmacy_card:" + strconv.Itoa(medicineID),
+		},
+		{
+			Text:         "ℹ️ Описание",
+			CallbackData: "medicine_info:" + strconv.Itoa(medicineID),
 		},
 	})
-}
 
-func showMedicineHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
-	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
-		CallbackQueryID: update.CallbackQuery.ID,
-		ShowAlert:       false,
-	})
+	watchText := "👁 Следить"
+	if _, ok := Storage.Watch(chatID, medicineID, targetCountry(chatID)); ok {
+		watchText = "👁 Не следить"
+	}
+	row := []models.InlineKeyboardButton{
+		{
+			Text:         watchText,
+			CallbackData: "watch:" + strconv.Itoa(medicineID),
+		},
+	}
+	if Prices != nil {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         "💰 Цены",
+			CallbackData: "show_medicine:" + strconv.Itoa(medicineID),
+		})
+	}
+	if len(BotUsername) > 0 {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         "🔳 QR",
+			CallbackData: "analog_qr:" + strconv.Itoa(medicineID),
+		})
+	}
+	if isPrivateChat(chatID) && Storage.ChatSettings(chatID).FamilyChannelID != 0 {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         "📌",
+			CallbackData: "analog_pin:" + strconv.Itoa(medicineID),
+		})
+	}
+	if len(WebAppURL) > 0 {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         "🔗 Ссылка",
+			CallbackData: "analog_link:" + strconv.Itoa(medicineID),
+		})
+	}
+	if dataIsStale(medicineInfo, time.Now()) {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         "🔄 Обновить",
+			CallbackData: "analog_refresh:" + strconv.Itoa(medicineID),
+		})
+	}
+	buttons = append(buttons, row)
 
-	medicineID, _ := strconv.Atoi(strings.Split(update.CallbackQuery.Data, ":")[1])
+	if Places != nil && hasRecentLocation(Storage.ChatSettings(chatID)) {
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{
+				Text:         "Где купить рядом",
+				CallbackData: "nearby_pharmacies",
+			},
+		})
+	}
 
-	b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.CallbackQuery.Message.Chat.ID,
-		Text:   fmt.Sprintf("Тут инфа по ценам для MedicineId=%d", medicineID),
-	})
-}
+	if len(WebAppURL) > 0 && isPrivateChat(chatID) {
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{
+				Text:   "Открыть таблицей",
+				WebApp: &models.WebAppInfo{URL: miniAppURL(medicineID)},
+			},
+		})
+	}
 
-func searchMedicines(query string) ([]Medicine, error) {
-	searchMedicineRequest := SearchMedicineRequest{
-		ApiKey:       ApiKey,
-		State:        "main_search",
-		HoumeCountry: HoumeCountryID,
-		Query:        query,
+	// Кнопка открывает выбор получателя с inline запросом по названию лекарства
+	if flagEnabled("inline") {
+		query := medicineInfo.MedicineName
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{
+				Text:              "Поделиться",
+				SwitchInlineQuery: &query,
+			},
+		})
 	}
 
-	log.Printf("Поиск лекарств: %s\n", query)
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: buttons,
+	}
+}
 
-	body, err := json.Marshal(searchMedicineRequest)
-	if err != nil {
-		log.Println(err)
-		return []Medicine{}, err
+// searchMedicinesContext ищет лекарства с контекстом ctx, он может ограничить поиск кэшем
+func searchMedicinesContext(ctx context.Context, query string) ([]Medicine, error) {
+	if sensitiveQuery(query) {
+		return nil, errSensitiveQuery
 	}
 
-	request, err := http.NewRequest("POST", ApiUrl, bytes.NewBuffer(body))
-	if err != nil {
-		log.Println(err)
-		return []Medicine{}, err
+	medicines, err := Medicines.SearchMedicines(ctx, query)
+	// Торговое название, которого нет в источнике, ищется по МНН
+	if err == nil && len(medicines) == 0 {
+		if inn, ok := innQuery(query); ok {
+			AppMetrics.Incr("inn_queries")
+			medicines, err = Medicines.SearchMedicines(ctx, inn)
+		}
+	}
+	if err != nil && !errors.Is(err, pills.ErrCacheOnly) {
+		logError(err)
 	}
 
-	request.Header.Add("Content-Type", "application/json")
+	return dedupMedicines(medicines), err
+}
 
-	client := &http.Client{}
-	response, err := client.Do(request)
-	if err != nil {
-		log.Println(err)
-		return []Medicine{}, err
+// searchAnalogsContext ищет аналоги с контекстом ctx и применяет к ним исправления администраторов
+func searchAnalogsContext(ctx context.Context, medicineID int, targetCountryID int) ([]Analog, MedicineInfo, error) {
+	analogs, info, err := Medicines.SearchAnalogs(ctx, medicineID, targetCountryID)
+	if err != nil && !errors.Is(err, pills.ErrCacheOnly) {
+		logError(err)
 	}
-	defer response.Body.Close()
+	info, analogs = applyOverrides(medicineID, targetCountryID, info, dedupAnalogs(analogs))
 
-	searchMedicineResponse := &SearchMedicineResponse{}
-	err = json.NewDecoder(response.Body).Decode(searchMedicineResponse)
-	if err != nil {
-		log.Println(err)
-		return []Medicine{}, err
+	return analogs, info, err
+}
+
+func medicineDetails(ctx context.Context, medicineID int) (MedicineDetails, error) {
+	details, err := Medicines.Details(ctx, medicineID)
+	if err != nil && !errors.Is(err, errBudgetExhausted) {
+		logError(err)
 	}
 
-	return searchMedicineResponse.Medicines, nil
+	return details, err
 }
 
-func searchAnalogs(medicineID int) ([]Analog, MedicineInfo, error) {
-	searchAnalogRequest := SearchAnalogRequest{
-		ApiKey:        ApiKey,
-		State:         "main_search",
-		HoumeCountry:  HoumeCountryID,
-		TargetCountry: TargetCountryID,
-		Language:      "ru",
-		Medicine:      medicineID,
+// checkAdapterConfig пишет в журнал, если часть переменных names задана, но адаптер не создан
+func checkAdapterConfig(adapter string, ok bool, names ...string) {
+	if ok {
+		return
 	}
+	set := []string{}
+	for _, name := range names {
+		if len(os.Getenv(name)) > 0 {
+			set = append(set, name)
+		}
+	}
+	if len(set) > 0 {
+		logError(fmt.Errorf("%s adapter is disabled, incomplete or invalid settings: %s", adapter, strings.Join(set, ", ")))
+	}
+}
 
-	log.Printf("Поиск аналогов: %d\n", medicineID)
-
-	body, err := json.Marshal(searchAnalogRequest)
+// loadPositiveDuration читает длительность из переменной окружения name в dst. Без переменной
+// dst не меняется, неверное или неположительное значение попадает в журнал
+func loadPositiveDuration(name string, dst *time.Duration) {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return
+	}
+	duration, err := time.ParseDuration(value)
 	if err != nil {
-		log.Println(err)
-		return []Analog{}, MedicineInfo{}, err
+		logError(err)
+		return
+	}
+	if duration <= 0 {
+		logError(fmt.Errorf("%s must be positive: %s", name, value))
+		return
 	}
+	*dst = duration
+}
 
-	request, err := http.NewRequest("POST", ApiUrl, bytes.NewBuffer(body))
-	if err != nil {
-		log.Println(err)
-		return []Analog{}, MedicineInfo{}, err
+// newAPIClient создает клиент API, запросы мимо кэша попадают в журнал и метрики
+func newAPIClient() *pills.Client {
+	client := pills.NewClient(ApiKey, HoumeCountryID)
+	client.URL = ApiUrl
+	if ttl, err := time.ParseDuration(os.Getenv("API_CACHE_TTL")); err == nil {
+		client.CacheTTL = ttl
+	}
+	client.OnRequest = func(method string, key string) {
+		switch method {
+		case "SearchMedicines":
+			log.Printf("Поиск лекарств: %s\n", key)
+		case "SearchAnalogs":
+			log.Printf("Поиск аналогов: %s\n", key)
+		case "Details":
+			log.Printf("Описание лекарства: %s\n", key)
+		}
+		AppMetrics.Incr("api_requests")
 	}
+	client.BeforeRequest = budgetGuard
 
-	request.Header.Add("Content-Type", "application/json")
+	return client
+}
 
-	client := &http.Client{}
-	response, err := client.Do(request)
-	if err != nil {
-		log.Println(err)
-		return []Analog{}, MedicineInfo{}, err
+// newMedicineProvider добавляет к API снимок популярных лекарств и запасной источник из файла
+// fallbackPath, они отвечают, когда API недоступно или не знает лекарства
+func newMedicineProvider(client *pills.Client, fallbackPath string) (MedicineProvider, error) {
+	providers := []pills.Provider{client}
+	if len(SnapshotPath) > 0 {
+		providers = append(providers, Snapshot)
 	}
-	defer response.Body.Close()
-
-	searchAnalogResponse := &SearchAnalogResponse{}
-	err = json.NewDecoder(response.Body).Decode(searchAnalogResponse)
-	if err != nil {
-		log.Println(err)
-		return searchAnalogResponse.Analogs, searchAnalogResponse.HomeCountry, err
+	if len(fallbackPath) > 0 {
+		fallback, err := pills.LoadStatic(fallbackPath)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, fallback)
 	}
 
-	return searchAnalogResponse.Analogs, searchAnalogResponse.MedicineInfo, nil
+	// Цепочка нужна и для одного API, она следит за его состоянием для панели администратора
+	return &pills.Chain{
+		Providers: providers,
+		OnFallback: func(provider string, method string, err error) {
+			if err != nil && !errors.Is(err, pills.ErrCacheOnly) && !errors.Is(err, errBudgetExhausted) {
+				logError(err)
+			}
+			AppMetrics.Incr("provider_fallbacks")
+		},
+		// Режим только кэша и исчерпанный бюджет запросов не говорят о неисправности API
+		IsFailure: func(err error) bool {
+			return !errors.Is(err, pills.ErrCacheOnly) && !errors.Is(err, errBudgetExhausted)
+		},
+	}, nil
 }
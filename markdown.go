@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// markdownMessageLimit длина, до которой Markdown отправляется сообщением, а не файлом
+const markdownMessageLimit = 3500
+
+// checklistMarkdown готовит список в дорогу в Markdown для заметок и писем
+func checklistMarkdown(trip Trip) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("# Список в дорогу: %s, %s\n\n", trip.CountryName(), trip.Dates()))
+	for _, item := range trip.Checklist {
+		mark := "[ ]"
+		if item.Packed {
+			mark = "[x]"
+		}
+		text.WriteString(fmt.Sprintf("- %s **%s**", mark, markdownEscape(item.Medicine)))
+		if len(item.AnalogName) == 0 {
+			text.WriteString(" — аналог не найден, возьмите с собой")
+		} else {
+			text.WriteString(fmt.Sprintf(" → [%s](%s) (%d%%)", markdownEscape(item.AnalogName), item.AnalogURL, item.Percentage))
+		}
+		if item.ClaimedBy != 0 {
+			text.WriteString(", берет " + markdownEscape(item.ClaimedName))
+		}
+		text.WriteString("\n")
+	}
+
+	names := []string{}
+	for _, item := range trip.Checklist {
+		names = append(names, item.Medicine, item.AnalogName)
+	}
+	if rules := Restrictions.Find(trip.CountryID, names...); len(rules) > 0 {
+		text.WriteString("\n## Ограничения на ввоз\n\n")
+		for _, rule := range rules {
+			level := "ограничено"
+			if rule.Level == "banned" {
+				level = "запрещено"
+			}
+			text.WriteString(fmt.Sprintf("- **%s** — %s: %s\n", markdownEscape(rule.Substance), level, markdownEscape(rule.Note)))
+		}
+		text.WriteString("\n_Правила меняются, перед поездкой уточните их в посольстве страны._\n")
+	}
+
+	return text.String()
+}
+
+func markdownEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "`", "\\`").Replace(value)
+}
+
+// tripMarkdownHandler отправляет список в Markdown: trip_md:<поездка>. Короткий список приходит
+// сообщением, которое удобно скопировать, длинный файлом
+func tripMarkdownHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	tripID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "trip_md:"))
+	chatID := callbackChatID(update.CallbackQuery)
+	trip, ok := Storage.Trip(tripID)
+	if !ok || !trip.VisibleIn(chatID) || len(trip.Checklist) == 0 {
+		return
+	}
+
+	text := checklistMarkdown(trip)
+	AppMetrics.Incr("markdown_exports")
+	if len(text) <= markdownMessageLimit {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      "<pre>" + escapeHTML(text) + "</pre>",
+			ParseMode: models.ParseModeHTML,
+		})
+		if err != nil {
+			logError(err)
+		}
+		return
+	}
+
+	_, err := b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: "checklist.md", Data: bytes.NewReader([]byte(text))},
+	})
+	if err != nil {
+		logError(err)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// matrixPageSize количество строк на странице ответа в Matrix
+	matrixPageSize = 5
+	matrixCommand  = "!pill"
+	// matrixSyncTimeout время ожидания событий в long polling запросе /sync
+	matrixSyncTimeout = 30 * time.Second
+)
+
+// Matrix адаптер Matrix, nil если он не настроен
+var Matrix *MatrixAdapter
+
+// MatrixAdapter получает сообщения через /sync и отвечает в комнату текстом с постраничным выводом.
+// Команда: !pill <название> [код страны], дальше номер из списка или «ещё» для следующей страницы
+type MatrixAdapter struct {
+	Homeserver string
+	Token      string
+	Client     *http.Client
+	userID     string
+	txnID      atomic.Int64
+	sessions   *RecentMap[matrixSessionKey, matrixSession]
+}
+
+type matrixSessionKey struct {
+	roomID string
+	sender string
+}
+
+// matrixSession текущий список, который пользователь листает в комнате
+type matrixSession struct {
+	medicines []Medicine
+	result    *AnalogsResult
+	countryID int
+	page      int
+}
+
+type matrixSync struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []matrixEvent `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+		Invite map[string]json.RawMessage `json:"invite"`
+	} `json:"rooms"`
+}
+
+type matrixEvent struct {
+	Type    string `json:"type"`
+	Sender  string `json:"sender"`
+	Content struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	} `json:"content"`
+}
+
+// newMatrixAdapter создает адаптер, если заданы MATRIX_HOMESERVER и MATRIX_TOKEN
+func newMatrixAdapter(homeserver string, token string) (*MatrixAdapter, bool) {
+	if len(homeserver) == 0 || len(token) == 0 {
+		return nil, false
+	}
+
+	return &MatrixAdapter{
+		Homeserver: strings.TrimRight(homeserver, "/"),
+		Token:      token,
+		Client:     &http.Client{Timeout: matrixSyncTimeout + 10*time.Second},
+		sessions:   NewRecentMap[matrixSessionKey, matrixSession](1000),
+	}, true
+}
+
+func (m *MatrixAdapter) request(ctx context.Context, method string, path string, payload any, result any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, m.Homeserver+path, body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+m.Token)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := m.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("matrix: %s %s: %d %s", method, path, response.StatusCode, message)
+	}
+	if result != nil {
+		return json.NewDecoder(response.Body).Decode(result)
+	}
+
+	return nil
+}
+
+// Run читает события до отмены ctx, сообщения до запуска пропускаются
+func (m *MatrixAdapter) Run(ctx context.Context) {
+	whoami := struct {
+		UserID string `json:"user_id"`
+	}{}
+	if err := m.request(ctx, http.MethodGet, "/_matrix/client/v3/account/whoami", nil, &whoami); err != nil {
+		logError(err)
+		return
+	}
+	m.userID = whoami.UserID
+
+	log.Printf("Matrix бот запущен: %s\n", m.userID)
+
+	since := ""
+	for ctx.Err() == nil {
+		path := "/_matrix/client/v3/sync?timeout=" + strconv.Itoa(int(matrixSyncTimeout/time.Millisecond))
+		if len(since) > 0 {
+			path += "&since=" + url.QueryEscape(since)
+		}
+
+		sync := matrixSync{}
+		if err := m.request(ctx, http.MethodGet, path, nil, &sync); err != nil {
+			if ctx.Err() == nil {
+				logError(err)
+				time.Sleep(5 * time.Second)
+			}
+			continue
+		}
+
+		initial := len(since) == 0
+		since = sync.NextBatch
+
+		for roomID := range sync.Rooms.Invite {
+			if err := m.request(ctx, http.MethodPost, "/_matrix/client/v3/join/"+url.PathEscape(roomID), struct{}{}, nil); err != nil {
+				logError(err)
+			}
+		}
+		if initial {
+			continue
+		}
+
+		for roomID, room := range sync.Rooms.Join {
+			for _, event := range room.Timeline.Events {
+				if event.Type != "m.room.message" || event.Content.MsgType != "m.text" || event.Sender == m.userID {
+					continue
+				}
+				m.handleMessage(ctx, roomID, event.Sender, strings.TrimSpace(event.Content.Body))
+			}
+		}
+	}
+}
+
+func (m *MatrixAdapter) send(ctx context.Context, roomID string, text string) {
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/m.room.message/%d-%d",
+		url.PathEscape(roomID), time.Now().UnixNano(), m.txnID.Add(1))
+	message := map[string]string{"msgtype": "m.text", "body": text}
+	if err := m.request(ctx, http.MethodPut, path, message, nil); err != nil {
+		logError(err)
+	}
+}
+
+func (m *MatrixAdapter) handleMessage(ctx context.Context, roomID string, sender string, text string) {
+	key := matrixSessionKey{roomID: roomID, sender: sender}
+
+	if query, ok := strings.CutPrefix(text, matrixCommand); ok {
+		m.search(ctx, key, query)
+		return
+	}
+
+	session, ok := m.sessions.Get(key)
+	if !ok {
+		return
+	}
+
+	switch {
+	case strings.EqualFold(text, "ещё") || strings.EqualFold(text, "еще") || strings.EqualFold(text, "more"):
+		session.page++
+		m.sessions.Set(key, session)
+		m.sendPage(ctx, roomID, session)
+	case session.result == nil:
+		number, err := strconv.Atoi(text)
+		if err != nil || number < 1 || number > len(session.medicines) {
+			return
+		}
+		medicineID, _ := strconv.Atoi(session.medicines[number-1].ID)
+		result, err := findAnalogsContext(ctx, medicineID, session.countryID)
+		if err != nil || len(result.Analogs) == 0 {
+			m.send(ctx, roomID, "Мне не удалось найти аналоги для "+session.medicines[number-1].Name+".")
+			return
+		}
+		session = matrixSession{result: &result, countryID: session.countryID}
+		m.sessions.Set(key, session)
+		m.sendPage(ctx, roomID, session)
+	}
+}
+
+func (m *MatrixAdapter) search(ctx context.Context, key matrixSessionKey, args string) {
+	AppMetrics.Incr("matrix_searches")
+
+	query, countryID := splitQueryCountry(args)
+	medicines, err := findMedicines(ctx, "matrix", query)
+	if errors.Is(err, errSensitiveQuery) {
+		m.send(ctx, key.roomID, sensitivePolicy)
+		return
+	}
+	if err != nil || len(medicines) == 0 {
+		m.send(ctx, key.roomID, "Мне не удалось ничего найти по запросу "+query+".")
+		return
+	}
+
+	session := matrixSession{medicines: medicines, countryID: countryID}
+	m.sessions.Set(key, session)
+	m.sendPage(ctx, key.roomID, session)
+}
+
+// sendPage отправляет страницу списка лекарств или аналогов
+func (m *MatrixAdapter) sendPage(ctx context.Context, roomID string, session matrixSession) {
+	lines := []string{}
+	header := "Выберите лекарство, ответив его номером:"
+	if session.result != nil {
+		header = "Вот аналоги для " + session.result.Medicine.MedicineName + ":"
+		for index, analog := range session.result.Analogs {
+			lines = append(lines, fmt.Sprintf("%d. %s (%d%%) %s", index+1, analog.AnalogName, analog.Percentage, analogURL(analog)))
+		}
+	} else {
+		for index, medicine := range session.medicines {
+			line := fmt.Sprintf("%d. %s", index+1, medicine.Name)
+			if len(medicine.Components) > 0 {
+				line += " — " + medicine.Components
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	start := session.page * matrixPageSize
+	if start >= len(lines) {
+		m.send(ctx, roomID, "Больше результатов нет.")
+		return
+	}
+	end := start + matrixPageSize
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	text := header + "\n" + strings.Join(lines[start:end], "\n")
+	if session.result != nil && session.page == 0 {
+		for _, rule := range session.result.Restrictions {
+			text += fmt.Sprintf("\n⚠️ %s: %s", rule.Substance, rule.Note)
+		}
+	}
+	if end < len(lines) {
+		text += fmt.Sprintf("\n\nСтраница %d из %d, напишите «ещё» для продолжения.", session.page+1, (len(lines)+matrixPageSize-1)/matrixPageSize)
+	}
+
+	m.send(ctx, roomID, text)
+}
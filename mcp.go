@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// MCPToken токен доступа к MCP серверу, пустой токен отключает /mcp
+var MCPToken string
+
+const mcpProtocolVersion = "2024-11-05"
+
+// Коды ошибок JSON-RPC 2.0
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// MCPTool описание инструмента в формате function calling: имя, назначение и JSON Schema аргументов
+type MCPTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+type MCPContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type MCPToolResult struct {
+	Content []MCPContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+var mcpTools = []MCPTool{
+	{
+		Name:        "search_medicines",
+		Description: "Ищет лекарства по торговому названию или действующему веществу. Возвращает идентификаторы для search_analogs.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string", "description": "название лекарства, не короче 2 символов"},
+			},
+			"required": []string{"query"},
+		},
+	},
+	{
+		Name:        "search_analogs",
+		Description: "Возвращает аналоги лекарства в стране поиска с процентом совпадения состава и правилами ввоза.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"medicine_id": map[string]any{"type": "integer", "description": "идентификатор из search_medicines"},
+				"country":     map[string]any{"type": "string", "description": "код страны, например TH, или ее идентификатор"},
+			},
+			"required": []string{"medicine_id"},
+		},
+	},
+}
+
+// startMCP подключает MCP сервер (JSON-RPC поверх HTTP) на /mcp, если задан MCP_TOKEN
+func startMCP(mux *http.ServeMux) {
+	if len(MCPToken) == 0 {
+		return
+	}
+
+	mux.HandleFunc("/mcp", mcpHandler)
+	mux.HandleFunc("/mcp/tools", mcpToolsHandler)
+}
+
+// mcpAuthorized проверяет заголовок Authorization: Bearer MCP_TOKEN
+func mcpAuthorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(MCPToken)) == 1
+}
+
+// mcpToolsHandler GET /mcp/tools отдает описания инструментов для подключения как function calling
+func mcpToolsHandler(w http.ResponseWriter, r *http.Request) {
+	if !mcpAuthorized(r) {
+		http.Error(w, "неверный токен", http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, mcpTools)
+}
+
+// mcpHandler POST /mcp принимает запросы JSON-RPC: initialize, tools/list и tools/call
+func mcpHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	if !mcpAuthorized(r) {
+		http.Error(w, "неверный токен", http.StatusUnauthorized)
+		return
+	}
+
+	var request RPCRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&request); err != nil {
+		writeJSON(w, RPCResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: rpcParseError, Message: "неверный JSON"}})
+		return
+	}
+	AppMetrics.Incr("mcp_requests")
+
+	// Уведомления не требуют ответа
+	if len(request.ID) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	response := RPCResponse{JSONRPC: "2.0", ID: request.ID}
+	if request.JSONRPC != "2.0" {
+		response.Error = &RPCError{Code: rpcInvalidRequest, Message: "ожидается jsonrpc 2.0"}
+		writeJSON(w, response)
+		return
+	}
+
+	switch request.Method {
+	case "initialize":
+		response.Result = map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "pills-bot", "version": "1.0"},
+		}
+	case "ping":
+		response.Result = map[string]any{}
+	case "tools/list":
+		response.Result = map[string]any{"tools": mcpTools}
+	case "tools/call":
+		result, err := callMCPTool(r.Context(), request.Params)
+		if err != nil {
+			response.Error = err
+		} else {
+			response.Result = result
+		}
+	default:
+		response.Error = &RPCError{Code: rpcMethodNotFound, Message: "неизвестный метод " + request.Method}
+	}
+
+	writeJSON(w, response)
+}
+
+// callMCPTool выполняет инструмент. Ошибки поиска возвращаются результатом с isError, чтобы ассистент их видел
+func callMCPTool(ctx context.Context, params json.RawMessage) (MCPToolResult, *RPCError) {
+	var call struct {
+		Name      string `json:"name"`
+		Arguments struct {
+			Query      string `json:"query"`
+			MedicineID int    `json:"medicine_id"`
+			Country    string `json:"country"`
+		} `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return MCPToolResult{}, &RPCError{Code: rpcInvalidParams, Message: "неверные параметры"}
+	}
+
+	var value any
+	var err error
+	switch call.Name {
+	case "search_medicines":
+		value, err = findMedicines(ctx, "mcp", call.Arguments.Query)
+	case "search_analogs":
+		countryID, ok := parseCountryParam(call.Arguments.Country)
+		if !ok {
+			err = errors.New("неизвестная страна")
+			break
+		}
+		if call.Arguments.MedicineID <= 0 {
+			err = errors.New("неверный идентификатор лекарства")
+			break
+		}
+		var result AnalogsResult
+		result, err = findAnalogsContext(ctx, call.Arguments.MedicineID, countryID)
+		value = struct {
+			APIAnalogs
+			Restrictions []Restriction `json:"restrictions,omitempty"`
+		}{APIAnalogs{result.Medicine, result.CountryID, result.Analogs}, result.Restrictions}
+	default:
+		return MCPToolResult{}, &RPCError{Code: rpcInvalidParams, Message: "неизвестный инструмент " + call.Name}
+	}
+
+	if err != nil {
+		return MCPToolResult{Content: []MCPContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return MCPToolResult{}, &RPCError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+
+	return MCPToolResult{Content: []MCPContent{{Type: "text", Text: string(data)}}}, nil
+}
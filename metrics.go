@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// recentErrorsLimit ограничивает количество последних ошибок в памяти
+	recentErrorsLimit = 50
+	// metricsFlushInterval как часто дневные счетчики сохраняются в хранилище
+	metricsFlushInterval = time.Minute
+	// usageRetention сколько хранятся дневные счетчики
+	usageRetention = 90 * 24 * time.Hour
+)
+
+type ErrorRecord struct {
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Metrics собирает счетчики и последние ошибки с момента запуска бота
+type Metrics struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	counters  map[string]int
+	errors    []ErrorRecord
+	store     *Store
+	// pending дневные счетчики, которые еще не сохранены в store
+	pending map[string]map[string]int
+}
+
+type MetricsSnapshot struct {
+	StartedAt time.Time      `json:"started_at"`
+	Counters  map[string]int `json:"counters"`
+	Errors    []ErrorRecord  `json:"errors"`
+}
+
+var AppMetrics = NewMetrics()
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		startedAt: time.Now(),
+		counters:  map[string]int{},
+		pending:   map[string]map[string]int{},
+	}
+}
+
+// Persist включает сохранение дневных счетчиков в хранилище, они копятся в памяти
+// и записываются задачей metricsJob
+func (m *Metrics) Persist(store *Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store = store
+}
+
+func (m *Metrics) Incr(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters[name]++
+	if m.store == nil {
+		return
+	}
+	day := time.Now().Format("2006-01-02")
+	if m.pending[day] == nil {
+		m.pending[day] = map[string]int{}
+	}
+	m.pending[day][name]++
+}
+
+// Flush сохраняет накопленные дневные счетчики одной записью хранилища и забывает
+// счетчики старше usageRetention. Блокировка держится до записи, чтобы UsageSince
+// не пропустил счетчики, которые уже забраны из памяти, но еще не записаны
+func (m *Metrics) Flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.store == nil {
+		return
+	}
+	m.store.AddUsage(m.pending, time.Now().Add(-usageRetention))
+	m.pending = map[string]map[string]int{}
+}
+
+// UsageSince суммирует дневные счетчики начиная с дня since вместе с еще не сохраненными
+func (m *Metrics) UsageSince(since time.Time) map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.store == nil {
+		return map[string]int{}
+	}
+	usage := m.store.UsageSince(since)
+	from := since.Format("2006-01-02")
+	for day, counters := range m.pending {
+		if day < from {
+			continue
+		}
+		for name, value := range counters {
+			usage[name] += value
+		}
+	}
+
+	return usage
+}
+
+func metricsJob() Job {
+	return Job{
+		Name: "metrics",
+		Next: every(metricsFlushInterval),
+		Run: func(ctx context.Context) {
+			AppMetrics.Flush()
+		},
+	}
+}
+
+func (m *Metrics) Error(err error) {
+	m.mu.Lock()
+	m.errors = append(m.errors, ErrorRecord{
+		Message:   err.Error(),
+		CreatedAt: time.Now(),
+	})
+	if len(m.errors) > recentErrorsLimit {
+		m.errors = m.errors[len(m.errors)-recentErrorsLimit:]
+	}
+	m.mu.Unlock()
+
+	m.Incr("errors")
+}
+
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counters := map[string]int{}
+	for name, value := range m.counters {
+		counters[name] = value
+	}
+
+	return MetricsSnapshot{
+		StartedAt: m.startedAt,
+		Counters:  counters,
+		Errors:    append([]ErrorRecord{}, m.errors...),
+	}
+}
+
+// logError пишет ошибку в лог и учитывает ее в метриках
+func logError(err error) {
+	log.Println(err)
+	AppMetrics.Error(err)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+type MiniAppAnalogs struct {
+	Medicine MedicineInfo `json:"medicine"`
+	Analogs  []Analog     `json:"analogs"`
+}
+
+// requireWebAppUser пропускает запросы с проверенными initData пользователя, прошедшего проверку
+// и принявшего предупреждение. Запросы расходуют лимит личного чата, как сообщения боту,
+// а пользователи из серого списка получают ответ с задержкой и только из кэша
+func requireWebAppUser(next func(w http.ResponseWriter, r *http.Request, user *models.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := validateInitData(r.Header.Get("X-Telegram-Init-Data"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if !userVerified(user) || !disclaimerAccepted(user, user.ID) {
+			http.Error(w, "начните поиск в чате с ботом, чтобы пройти проверку и принять условия использования", http.StatusForbidden)
+			return
+		}
+
+		if allowed, retry := allowChat(user.ID); !allowed {
+			AppMetrics.Incr("rate_limited")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			http.Error(w, "слишком много запросов", http.StatusTooManyRequests)
+			return
+		}
+
+		if Storage.Graylisted(user.ID) {
+			AppMetrics.Incr("graylisted_updates")
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(GraylistDelay):
+			}
+			r = r.WithContext(pills.WithCacheOnly(r.Context()))
+		}
+
+		next(w, r, user)
+	}
+}
+
+func miniAppMedicinesHandler(w http.ResponseWriter, r *http.Request, user *models.User) {
+	if !flagEnabled("search") {
+		http.Error(w, "поиск временно недоступен", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len([]rune(query)) < 2 {
+		writeJSON(w, []Medicine{})
+		return
+	}
+
+	if !consumeQuota(user, 1) {
+		http.Error(w, quotaExceededText(user), http.StatusTooManyRequests)
+		return
+	}
+
+	AppMetrics.Incr("webapp_searches")
+
+	medicines, err := searchMedicinesContext(r.Context(), query)
+	if err != nil {
+		http.Error(w, "ошибка поиска", http.StatusBadGateway)
+		return
+	}
+
+	writeJSON(w, medicines)
+}
+
+func miniAppAnalogsHandler(w http.ResponseWriter, r *http.Request, user *models.User) {
+	if !flagEnabled("search") {
+		http.Error(w, "поиск временно недоступен", http.StatusServiceUnavailable)
+		return
+	}
+
+	medicineID, err := strconv.Atoi(r.URL.Query().Get("medicine"))
+	if err != nil || medicineID <= 0 {
+		http.Error(w, "не указано лекарство", http.StatusBadRequest)
+		return
+	}
+
+	AppMetrics.Incr("analog_searches")
+
+	analogs, medicineInfo, err := searchAnalogsContext(r.Context(), medicineID, targetCountry(user.ID))
+	if err != nil {
+		http.Error(w, "ошибка поиска аналогов", http.StatusBadGateway)
+		return
+	}
+
+	Storage.AddHistory(user, HistoryEntry{
+		MedicineID:   medicineID,
+		MedicineName: medicineInfo.MedicineName,
+	})
+
+	writeJSON(w, MiniAppAnalogs{
+		Medicine: medicineInfo,
+		Analogs:  analogs,
+	})
+}
+
+// miniAppURL возвращает адрес каталога аналогов, medicineID открывает таблицу сразу для лекарства
+func miniAppURL(medicineID int) string {
+	url := strings.TrimRight(WebAppURL, "/") + "/app/"
+	if medicineID > 0 {
+		url += fmt.Sprintf("?medicine=%d", medicineID)
+	}
+
+	return url
+}
+
+func miniAppKeyboard() *models.ReplyKeyboardMarkup {
+	return &models.ReplyKeyboardMarkup{
+		Keyboard: [][]models.KeyboardButton{
+			{
+				{
+					Text:   "Каталог аналогов",
+					WebApp: &models.WebAppInfo{URL: miniAppURL(0)},
+				},
+			},
+		},
+		ResizeKeyboard: true,
+		IsPersistent:   true,
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// ocrCandidatesLimit ограничивает количество предлагаемых названий с фотографии
+	ocrCandidatesLimit = 6
+	// callbackDataLimit ограничение Telegram на размер callback_data в байтах
+	callbackDataLimit = 64
+	downloadTimeout   = 30 * time.Second
+)
+
+// OCRProvider распознает текст на изображении
+type OCRProvider interface {
+	Recognize(ctx context.Context, image []byte) (string, error)
+}
+
+// TesseractOCR запускает локальный бинарник tesseract
+type TesseractOCR struct {
+	Binary    string
+	Languages string
+}
+
+func (t *TesseractOCR) Recognize(ctx context.Context, image []byte) (string, error) {
+	cmd := exec.CommandContext(ctx, t.Binary, "stdin", "stdout", "-l", t.Languages)
+	cmd.Stdin = bytes.NewReader(image)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return string(output), nil
+}
+
+// VisionOCR использует Google Cloud Vision API
+type VisionOCR struct {
+	ApiKey string
+}
+
+type visionRequest struct {
+	Requests []visionImageRequest `json:"requests"`
+}
+
+type visionImageRequest struct {
+	Image struct {
+		Content string `json:"content"`
+	} `json:"image"`
+	Features []struct {
+		Type string `json:"type"`
+	} `json:"features"`
+}
+
+type visionResponse struct {
+	Responses []struct {
+		FullTextAnnotation struct {
+			Text string `json:"text"`
+		} `json:"fullTextAnnotation"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"responses"`
+}
+
+func (v *VisionOCR) Recognize(ctx context.Context, image []byte) (string, error) {
+	imageRequest := visionImageRequest{}
+	imageRequest.Image.Content = base64.StdEncoding.EncodeToString(image)
+	imageRequest.Features = []struct {
+		Type string `json:"type"`
+	}{{Type: "TEXT_DETECTION"}}
+
+	body, err := json.Marshal(visionRequest{Requests: []visionImageRequest{imageRequest}})
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", "https://vision.googleapis.com/v1/images:annotate?key="+v.ApiKey, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Add("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	visionResponse := &visionResponse{}
+	if err := json.NewDecoder(response.Body).Decode(visionResponse); err != nil {
+		return "", err
+	}
+	if len(visionResponse.Responses) == 0 {
+		return "", nil
+	}
+	if visionResponse.Responses[0].Error != nil {
+		return "", errors.New("vision: " + visionResponse.Responses[0].Error.Message)
+	}
+
+	return visionResponse.Responses[0].FullTextAnnotation.Text, nil
+}
+
+// newOCRProvider создает распознавание по настройке OCR_PROVIDER, nil если оно отключено
+func newOCRProvider(name string) OCRProvider {
+	switch name {
+	case "tesseract":
+		binary := os.Getenv("TESSERACT_PATH")
+		if len(binary) == 0 {
+			binary = "tesseract"
+		}
+		languages := os.Getenv("TESSERACT_LANG")
+		if len(languages) == 0 {
+			languages = "rus+eng"
+		}
+		return &TesseractOCR{Binary: binary, Languages: languages}
+	case "vision":
+		return &VisionOCR{ApiKey: os.Getenv("VISION_API_KEY")}
+	}
+
+	return nil
+}
+
+// ocrStopWords не являются названиями лекарств и часто встречаются на упаковках
+var ocrStopWords = map[string]bool{
+	"таблетки": true, "таблетка": true, "капсулы": true, "сироп": true, "раствор": true,
+	"покрытые": true, "оболочкой": true, "пленочной": true, "для": true, "детей": true,
+	"взрослых": true, "tablets": true, "tablet": true, "capsules": true, "syrup": true,
+	"solution": true, "film": true, "coated": true, "for": true, "oral": true,
+}
+
+// medicineCandidates выбирает из распознанного текста слова, похожие на названия лекарств
+func medicineCandidates(text string) []string {
+	seen := map[string]bool{}
+	candidates := []string{}
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '-'
+	}) {
+		word = strings.Trim(word, "-")
+		key := strings.ToLower(word)
+		if utf8.RuneCountInString(word) < 4 || seen[key] || ocrStopWords[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, word)
+		if len(candidates) == ocrCandidatesLimit {
+			break
+		}
+	}
+
+	return candidates
+}
+
+// downloadFile скачивает файл, отправленный пользователем боту
+func downloadFile(ctx context.Context, b *bot.Bot, fileID string) ([]byte, error) {
+	file, err := b.GetFile(ctx, &bot.GetFileParams{FileID: fileID})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, "GET", b.FileDownloadLink(file), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("скачивание файла: %s", response.Status)
+	}
+
+	return io.ReadAll(response.Body)
+}
+
+func photoHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	// Подпись к фотографии, например к пересланному фото с витрины аптеки,
+	// считается запросом, распознавание нужно только без подписи
+	if query := photoCaptionQuery(update.Message); len(query) > 0 {
+		sentID, ok := sendMedicineSearch(ctx, b, update.Message.Chat.ID, update.Message.From, query)
+		if ok {
+			SearchReplies.Set(replyKey{chatID: update.Message.Chat.ID, messageID: update.Message.ID}, sentID)
+		}
+		return
+	}
+
+	ocrEnabled := OCR != nil && flagEnabled("ocr")
+	if !ocrEnabled && Barcodes == nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Распознавание фотографий недоступно. Введите название лекарства текстом.",
+		})
+		return
+	}
+
+	// Последний размер в списке самый крупный
+	photo := update.Message.Photo[len(update.Message.Photo)-1]
+
+	image, err := downloadFile(ctx, b, photo.FileID)
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	if ocrEnabled && isPrescriptionPhoto(update.Message) {
+		handlePrescription(ctx, b, update.Message, image)
+		return
+	}
+
+	if Barcodes != nil && handleBarcode(ctx, b, update.Message, image) {
+		return
+	}
+
+	if !ocrEnabled {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Не удалось найти на фотографии штрихкод. Сфотографируйте его крупнее или введите название лекарства текстом.",
+		})
+		return
+	}
+
+	AppMetrics.Incr("ocr_requests")
+
+	text, err := OCR.Recognize(ctx, image)
+	if err != nil {
+		logError(err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Не удалось распознать фотографию. Попробуйте еще раз или введите название текстом.",
+		})
+		return
+	}
+
+	sendCandidates(ctx, b, update.Message.Chat.ID, medicineCandidates(text))
+}
+
+// photoCaptionQuery возвращает подпись к фотографии, если она похожа на поисковый запрос
+func photoCaptionQuery(message *models.Message) string {
+	caption := strings.TrimSpace(message.Caption)
+	if !hasLettersOrDigits(caption) || strings.Contains(strings.ToLower(caption), "рецепт") {
+		return ""
+	}
+
+	return caption
+}
+
+// sendCandidates предлагает найденные на фотографии названия кнопками поиска
+func sendCandidates(ctx context.Context, b *bot.Bot, chatID int64, candidates []string) {
+	if len(candidates) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Не удалось найти на фотографии название лекарства. Попробуйте сфотографировать упаковку крупнее или введите название текстом.",
+		})
+		return
+	}
+
+	buttons := [][]models.InlineKeyboardButton{}
+	for _, candidate := range candidates {
+		data := truncateBytes("search_query:"+candidate, callbackDataLimit)
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{
+				Text:         candidate,
+				CallbackData: data,
+			},
+		})
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "Вот что я распознал на фотографии. Выберите название для поиска.",
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: buttons,
+		},
+	})
+}
+
+func searchQueryHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	query := strings.TrimPrefix(update.CallbackQuery.Data, "search_query:")
+	sendMedicineSearch(ctx, b, callbackChatID(update.CallbackQuery), &update.CallbackQuery.From, query)
+}
+
+// truncateBytes обрезает строку до limit байт, не разрывая символы UTF-8
+func truncateBytes(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+
+	text = text[:limit]
+	for !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+
+	return text
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Виды исправлений данных API
+const (
+	// OverrideHide скрывает ошибочный аналог
+	OverrideHide = "hide"
+	// OverrideName исправляет название аналога или, без аналога, самого лекарства
+	OverrideName = "name"
+	// OverridePin ставит предпочтительный аналог первым в списке
+	OverridePin = "pin"
+)
+
+// Override исправление данных API для лекарства в стране поиска, CountryID 0 для всех стран.
+// Исправления применяются к результату поиска, кэш и ответы API не меняются
+type Override struct {
+	ID         int       `json:"id"`
+	Kind       string    `json:"kind"`
+	MedicineID int       `json:"medicine_id"`
+	CountryID  int       `json:"country_id,omitempty"`
+	AnalogID   string    `json:"analog_id,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	AuthorID   int64     `json:"author_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// sameTarget проверяет, что исправления относятся к одному месту. Предпочтительный аналог
+// у лекарства в стране один, поэтому новый заменяет прежний
+func (o Override) sameTarget(other Override) bool {
+	if o.Kind != other.Kind || o.MedicineID != other.MedicineID || o.CountryID != other.CountryID {
+		return false
+	}
+
+	return o.Kind == OverridePin || o.AnalogID == other.AnalogID
+}
+
+// applyOverrides применяет исправления администраторов к аналогам лекарства в стране countryID
+func applyOverrides(medicineID int, countryID int, info MedicineInfo, analogs []Analog) (MedicineInfo, []Analog) {
+	overrides := Storage.Overrides(medicineID, countryID)
+	if len(overrides) == 0 {
+		return info, analogs
+	}
+
+	hidden := map[string]bool{}
+	names := map[string]string{}
+	pinned := ""
+	for _, override := range overrides {
+		switch override.Kind {
+		case OverrideHide:
+			hidden[override.AnalogID] = true
+		case OverrideName:
+			if len(override.AnalogID) == 0 {
+				info.MedicineName = override.Name
+			} else {
+				names[override.AnalogID] = override.Name
+			}
+		case OverridePin:
+			pinned = override.AnalogID
+		}
+	}
+
+	result := make([]Analog, 0, len(analogs))
+	for _, analog := range analogs {
+		if hidden[analog.AnalogID] {
+			continue
+		}
+		if name, ok := names[analog.AnalogID]; ok {
+			analog.AnalogName = name
+		}
+		if analog.AnalogID == pinned {
+			result = append([]Analog{analog}, result...)
+			continue
+		}
+		result = append(result, analog)
+	}
+	AppMetrics.Incr("overrides_applied")
+
+	return info, result
+}
+
+// overrideCountry разбирает код страны, * означает все страны
+func overrideCountry(code string) (int, string, bool) {
+	if code == "*" {
+		return 0, "все страны", true
+	}
+	country, ok := countryByCode(code)
+	if !ok {
+		return 0, "", false
+	}
+
+	return country.ID, country.Name, true
+}
+
+// overrideText строка исправления для списка /override
+func overrideText(override Override) string {
+	country := "все страны"
+	if found, ok := countryByID(override.CountryID); ok && override.CountryID != 0 {
+		country = found.Name
+	}
+
+	text := fmt.Sprintf("%d. лекарство %d, %s: ", override.ID, override.MedicineID, country)
+	switch override.Kind {
+	case OverrideHide:
+		text += "скрыт аналог " + override.AnalogID
+	case OverridePin:
+		text += "первым аналог " + override.AnalogID
+	case OverrideName:
+		if len(override.AnalogID) == 0 {
+			text += "название лекарства «" + override.Name + "»"
+		} else {
+			text += "название аналога " + override.AnalogID + " «" + override.Name + "»"
+		}
+	}
+
+	return text
+}
+
+const overrideUsage = `Использование:
+/override hide <лекарство> <страна|*> <аналог> — скрыть ошибочный аналог
+/override pin <лекарство> <страна|*> <аналог> — показывать аналог первым
+/override name <лекарство> <страна|*> <аналог|-> <название> — исправить название аналога или лекарства
+/override delete <номер> — удалить исправление
+Лекарство и аналог задаются идентификаторами API, страна кодом, * для всех стран.`
+
+// overrideHandler ведет исправления данных API: /override без аргументов показывает список
+func overrideHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	fields := strings.Fields(update.Message.Text)
+	reply := func(text string) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   text,
+		})
+	}
+
+	if len(fields) == 1 {
+		overrides := Storage.Overrides(0, 0)
+		if len(overrides) == 0 {
+			reply("Исправлений данных нет.\n\n" + overrideUsage)
+			return
+		}
+		lines := []string{"Исправления данных:"}
+		for _, override := range overrides {
+			lines = append(lines, overrideText(override))
+		}
+		reply(strings.Join(lines, "\n"))
+		return
+	}
+
+	if fields[1] == "delete" {
+		overrideID := 0
+		if len(fields) == 3 {
+			overrideID, _ = strconv.Atoi(fields[2])
+		}
+		if !Storage.DeleteOverride(overrideID) {
+			reply("Исправление не найдено.")
+			return
+		}
+		reply(fmt.Sprintf("Исправление %d удалено.", overrideID))
+		return
+	}
+
+	if len(fields) < 5 || fields[1] != OverrideHide && fields[1] != OverridePin && fields[1] != OverrideName {
+		reply(overrideUsage)
+		return
+	}
+	medicineID, err := strconv.Atoi(fields[2])
+	countryID, _, ok := overrideCountry(strings.ToUpper(fields[3]))
+	if err != nil || medicineID <= 0 || !ok {
+		reply("Неверное лекарство или страна.\n\n" + overrideUsage)
+		return
+	}
+
+	override := Override{
+		Kind:       fields[1],
+		MedicineID: medicineID,
+		CountryID:  countryID,
+		AnalogID:   fields[4],
+		AuthorID:   update.Message.From.ID,
+	}
+	if override.Kind == OverrideName {
+		if len(fields) < 6 {
+			reply(overrideUsage)
+			return
+		}
+		if override.AnalogID == "-" {
+			override.AnalogID = ""
+		}
+		override.Name = strings.Join(fields[5:], " ")
+	}
+
+	override = Storage.SetOverride(override)
+	reply("Сохранено: " + overrideText(override))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// analogPDFHandler отправляет карточку аналогов в PDF для показа в аптеке без интернета
+func analogPDFHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            "Готовлю PDF…",
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	medicineID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "analog_pdf:"))
+	result, err := findAnalogsContext(ctx, medicineID, targetCountry(chatID))
+	if err != nil || len(result.Analogs) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Не удалось подготовить PDF. Попробуйте позже.",
+		})
+		return
+	}
+
+	file, err := analogsPDF(result, medicineComponents(ctx, result.Medicine), time.Now())
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	AppMetrics.Incr("pdf_exports")
+	_, err = b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: pdfFilename(result.Medicine), Data: bytes.NewReader(file)},
+		Caption:  "Карточка аналогов для аптеки",
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+// medicineComponents находит действующие вещества лекарства повторным поиском по названию
+func medicineComponents(ctx context.Context, info MedicineInfo) string {
+	medicines, err := findMedicines(ctx, "telegram", info.MedicineName)
+	if err != nil {
+		return ""
+	}
+	for _, medicine := range medicines {
+		if medicine.ID == info.MedicineID {
+			return medicine.Components
+		}
+	}
+
+	return ""
+}
+
+func pdfFilename(info MedicineInfo) string {
+	name := info.MedicineSlug
+	if len(name) == 0 {
+		name = info.MedicineID
+	}
+
+	return "analogs-" + name + ".pdf"
+}
+
+// analogsPDF верстает компактную карточку A5: лекарство, состав, страна и таблица аналогов со ссылками
+func analogsPDF(result AnalogsResult, components string, now time.Time) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A5", "")
+	// Встроенные шрифты PDF не поддерживают кириллицу
+	pdf.AddUTF8FontFromBytes("DejaVu", "", fontRegular)
+	pdf.AddUTF8FontFromBytes("DejaVu", "B", fontBold)
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 10)
+	pdf.SetTitle(result.Medicine.MedicineName, true)
+	pdf.AddPage()
+
+	width, _ := pdf.GetPageSize()
+	content := width - 20
+
+	pdf.SetFont("DejaVu", "B", 16)
+	pdf.MultiCell(content, 8, result.Medicine.MedicineName, "", "L", false)
+	if len(components) > 0 {
+		pdf.SetFont("DejaVu", "", 10)
+		pdf.MultiCell(content, 5, "Состав: "+components, "", "L", false)
+	}
+
+	pdf.SetFont("DejaVu", "", 10)
+	country := strconv.Itoa(result.CountryID)
+	if c, ok := countryByID(result.CountryID); ok {
+		country = c.Name
+	}
+	pdf.MultiCell(content, 5, "Аналоги в стране: "+country, "", "L", false)
+	pdf.Ln(3)
+
+	pdf.SetFont("DejaVu", "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	pdf.CellFormat(8, 7, "#", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(content-30, 7, "Аналог", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(22, 7, "Совпадение", "1", 1, "C", true, 0, "")
+
+	pdf.SetFont("DejaVu", "", 10)
+	for index, analog := range result.Analogs {
+		if index == 20 {
+			break
+		}
+		pdf.CellFormat(8, 7, strconv.Itoa(index+1), "1", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 180)
+		pdf.CellFormat(content-30, 7, analog.AnalogName, "1", 0, "L", false, 0, analogURL(analog))
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(22, 7, strconv.Itoa(analog.Percentage)+"%", "1", 1, "C", false, 0, "")
+	}
+
+	for _, restriction := range result.Restrictions {
+		pdf.Ln(2)
+		pdf.SetFont("DejaVu", "B", 9)
+		pdf.MultiCell(content, 5, "⚠ "+restriction.Substance, "", "L", false)
+		pdf.SetFont("DejaVu", "", 9)
+		pdf.MultiCell(content, 4.5, restriction.Note, "", "L", false)
+	}
+
+	pdf.Ln(4)
+	pdf.SetFont("DejaVu", "", 8)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.MultiCell(content, 4, fmt.Sprintf("Подготовлено %s по данным pillintrip.com. Справочная информация, не медицинская рекомендация: перед приемом посоветуйтесь с фармацевтом или врачом.",
+		now.Format("02.01.2006")), "", "L", false)
+
+	var buffer bytes.Buffer
+	if err := pdf.Output(&buffer); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// PermalinkTTL срок жизни ссылки на результаты поиска
+var PermalinkTTL = 7 * 24 * time.Hour
+
+// Permalink снимок результатов поиска, открытый без Telegram по ссылке с токеном
+type Permalink struct {
+	Token        string    `json:"token"`
+	MedicineID   int       `json:"medicine_id"`
+	MedicineName string    `json:"medicine_name"`
+	CountryID    int       `json:"country_id"`
+	Analogs      []Analog  `json:"analogs"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// permalinkURL возвращает адрес страницы или пустую строку без WEBAPP_URL
+func permalinkURL(token string) string {
+	if len(WebAppURL) == 0 {
+		return ""
+	}
+
+	return strings.TrimRight(WebAppURL, "/") + "/r/" + token
+}
+
+// analogLinkHandler сохраняет снимок аналогов и отправляет ссылку на него: analog_link:<лекарство>
+func analogLinkHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	medicineID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "analog_link:"))
+	result, err := findAnalogsContext(ctx, medicineID, targetCountry(chatID))
+	if err != nil || len(result.Analogs) == 0 {
+		return
+	}
+
+	now := time.Now()
+	permalink := Storage.AddPermalink(Permalink{
+		Token:        newShareToken(),
+		MedicineID:   medicineID,
+		MedicineName: result.Medicine.MedicineName,
+		CountryID:    result.CountryID,
+		Analogs:      result.Analogs,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(PermalinkTTL),
+	})
+	AppMetrics.Incr("permalinks")
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text: "Страницу с аналогами можно открыть без Telegram:\n" + permalinkURL(permalink.Token) +
+			"\n\nСсылка действует до " + permalink.ExpiresAt.In(chatLocation(chatID)).Format("02.01.2006 15:04") + ".",
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+}
+
+var permalinkPage = template.Must(template.New("permalink").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Аналоги {{.MedicineName}}</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; max-width: 640px; margin: 0 auto; padding: 16px; color: #202020; }
+table { width: 100%; border-collapse: collapse; }
+td, th { padding: 8px; border-bottom: 1px solid #ddd; text-align: left; }
+.muted { color: #707070; font-size: 14px; }
+</style>
+</head>
+<body>
+<h1>Аналоги {{.MedicineName}}</h1>
+<p class="muted">{{.Country}} · данные на {{.Created}}</p>
+<table>
+<tr><th>Аналог</th><th>Совпадение</th></tr>
+{{range .Analogs}}<tr><td><a href="{{.URL}}">{{.Name}}</a></td><td>{{.Percentage}}%</td></tr>
+{{end}}</table>
+<p class="muted">Ссылка действует до {{.Expires}}. Перед приемом посоветуйтесь с фармацевтом или врачом.</p>
+</body>
+</html>
+`))
+
+type permalinkRow struct {
+	Name       string
+	URL        string
+	Percentage int
+}
+
+// permalinkHandler GET /r/<token> показывает сохраненные результаты, пока ссылка не истекла
+func permalinkHandler(w http.ResponseWriter, r *http.Request) {
+	permalink, ok := Storage.Permalink(strings.TrimPrefix(r.URL.Path, "/r/"), time.Now())
+	if !ok {
+		http.Error(w, "ссылка недействительна или истекла", http.StatusNotFound)
+		return
+	}
+
+	country := strconv.Itoa(permalink.CountryID)
+	if c, ok := countryByID(permalink.CountryID); ok {
+		country = c.Name
+	}
+	rows := []permalinkRow{}
+	for _, analog := range permalink.Analogs {
+		rows = append(rows, permalinkRow{Name: analog.AnalogName, URL: analogURL(analog), Percentage: analog.Percentage})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := permalinkPage.Execute(w, map[string]any{
+		"MedicineName": permalink.MedicineName,
+		"Country":      country,
+		"Created":      permalink.CreatedAt.Format("02.01.2006"),
+		"Expires":      permalink.ExpiresAt.Format("02.01.2006"),
+		"Analogs":      rows,
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+// permalinksJob удаляет истекшие ссылки раз в час
+func permalinksJob() Job {
+	return Job{
+		Name: "permalinks",
+		Next: every(time.Hour),
+		Run: func(ctx context.Context) {
+			Storage.PrunePermalinks(time.Now())
+		},
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// PillCandidate лекарство, подходящее под описание таблетки
+type PillCandidate struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// PillIdentifier подбирает лекарства по внешнему виду таблетки
+type PillIdentifier interface {
+	Identify(ctx context.Context, color, shape, imprint string) ([]PillCandidate, error)
+}
+
+// HTTPPillIdentifier запрашивает справочник по адресу вида
+// URL?color=...&shape=...&imprint=..., ответ список PillCandidate в JSON
+type HTTPPillIdentifier struct {
+	URL string
+}
+
+func (h *HTTPPillIdentifier) Identify(ctx context.Context, color, shape, imprint string) ([]PillCandidate, error) {
+	query := url.Values{}
+	query.Set("color", color)
+	query.Set("shape", shape)
+	if len(imprint) > 0 {
+		query.Set("imprint", imprint)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", h.URL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("определение таблетки: %s", response.Status)
+	}
+
+	candidates := []PillCandidate{}
+	if err := json.NewDecoder(response.Body).Decode(&candidates); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// newPillIdentifier создает справочник по PILL_ID_URL, nil если он не настроен
+func newPillIdentifier() PillIdentifier {
+	if address := os.Getenv("PILL_ID_URL"); len(address) > 0 {
+		return &HTTPPillIdentifier{URL: address}
+	}
+
+	return nil
+}
+
+type pillOption struct {
+	Value string
+	Title string
+}
+
+var pillColors = []pillOption{
+	{Value: "white", Title: "Белая"},
+	{Value: "yellow", Title: "Желтая"},
+	{Value: "orange", Title: "Оранжевая"},
+	{Value: "pink", Title: "Розовая"},
+	{Value: "red", Title: "Красная"},
+	{Value: "blue", Title: "Синяя"},
+	{Value: "green", Title: "Зеленая"},
+	{Value: "brown", Title: "Коричневая"},
+}
+
+var pillShapes = []pillOption{
+	{Value: "round", Title: "Круглая"},
+	{Value: "oval", Title: "Овальная"},
+	{Value: "capsule", Title: "Капсула"},
+	{Value: "triangle", Title: "Треугольная"},
+	{Value: "square", Title: "Квадратная"},
+}
+
+// pillCandidatesLimit ограничивает количество предлагаемых лекарств
+const pillCandidatesLimit = 8
+
+func pillOptionsMarkup(prefix string, options []pillOption) *models.InlineKeyboardMarkup {
+	buttons := [][]models.InlineKeyboardButton{}
+	row := []models.InlineKeyboardButton{}
+	for _, option := range options {
+		row = append(row, models.InlineKeyboardButton{
+			Text:         option.Title,
+			CallbackData: prefix + ":" + option.Value,
+		})
+		if len(row) == 2 {
+			buttons = append(buttons, row)
+			row = []models.InlineKeyboardButton{}
+		}
+	}
+	if len(row) > 0 {
+		buttons = append(buttons, row)
+	}
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+func identifyHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if PillIdentification == nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Определение таблеток временно недоступно.",
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        "Помогу определить таблетку без упаковки. Какого она цвета?",
+		ReplyMarkup: pillOptionsMarkup("pill_color", pillColors),
+	})
+}
+
+func pillColorHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	ChatDialogs.Start(chatID, "pill", map[string]string{
+		"color": strings.TrimPrefix(update.CallbackQuery.Data, "pill_color:"),
+	})
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        "Какой она формы?",
+		ReplyMarkup: pillOptionsMarkup("pill_shape", pillShapes),
+	})
+}
+
+func pillShapeHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	dialog, ok := ChatDialogs.Peek(chatID)
+	if !ok || dialog.Kind != "pill" {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Начните заново командой /identify.",
+		})
+		return
+	}
+
+	dialog.Data["shape"] = strings.TrimPrefix(update.CallbackQuery.Data, "pill_shape:")
+	ChatDialogs.Start(chatID, "pill", dialog.Data)
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "Что выдавлено на таблетке? Напишите буквы и цифры с обеих сторон или отправьте «-», если надписи нет. Для отмены отправьте /cancel.",
+	})
+}
+
+func pillDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	if len(dialog.Data["shape"]) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Выберите форму таблетки кнопкой выше или начните заново командой /identify.",
+		})
+		ChatDialogs.Start(update.Message.Chat.ID, "pill", dialog.Data)
+		return
+	}
+
+	imprint := strings.TrimSpace(update.Message.Text)
+	if imprint == "-" {
+		imprint = ""
+	}
+
+	AppMetrics.Incr("pill_identifications")
+
+	candidates, err := PillIdentification.Identify(ctx, dialog.Data["color"], dialog.Data["shape"], imprint)
+	if err != nil {
+		logError(err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Не удалось выполнить поиск. Попробуйте позже.",
+		})
+		return
+	}
+	if len(candidates) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Не нашлось подходящих лекарств. Проверьте надпись на таблетке или начните заново командой /identify.",
+		})
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("Возможно, это одно из лекарств:\n")
+	buttons := [][]models.InlineKeyboardButton{}
+	for index, candidate := range candidates {
+		if index == pillCandidatesLimit {
+			break
+		}
+		text.WriteString(fmt.Sprintf("\n%d. %s", index+1, bold(candidate.Name)))
+		if len(candidate.Description) > 0 {
+			text.WriteString("\n" + italic(candidate.Description))
+		}
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{
+				Text:         candidate.Name,
+				CallbackData: truncateBytes("search_query:"+candidate.Name, callbackDataLimit),
+			},
+		})
+	}
+	text.WriteString("\n\nОпределение по внешнему виду не точное, сверьтесь с врачом или фармацевтом. Выберите лекарство, чтобы найти аналоги.")
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      text.String(),
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: buttons,
+		},
+	})
+}
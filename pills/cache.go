@@ -0,0 +1,94 @@
+package pills
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// cache хранит ограниченное количество ответов, вытесняя самые старые
+type cache[K comparable, V any] struct {
+	mu    sync.Mutex
+	limit int
+	items map[K]cacheEntry[V]
+	order []K
+}
+
+func newCache[K comparable, V any](limit int) *cache[K, V] {
+	return &cache[K, V]{
+		limit: limit,
+		items: map[K]cacheEntry[V]{},
+	}
+}
+
+func (c *cache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.items[key]
+	if !ok || time.Now().After(entry.expires) {
+		var empty V
+		return empty, false
+	}
+
+	return entry.value, true
+}
+
+func (c *cache[K, V]) set(key K, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.items[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.items[key] = cacheEntry[V]{value: value, expires: time.Now().Add(ttl)}
+
+	if len(c.order) > c.limit {
+		delete(c.items, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// entries возвращает действующие записи, начиная с самых старых
+func (c *cache[K, V]) entries() ([]K, []V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	keys := []K{}
+	values := []V{}
+	for _, key := range c.order {
+		entry, ok := c.items[key]
+		if !ok || now.After(entry.expires) {
+			continue
+		}
+		keys = append(keys, key)
+		values = append(values, entry.value)
+	}
+
+	return keys, values
+}
+
+func (c *cache[K, V]) delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.items[key]; !ok {
+		return
+	}
+	delete(c.items, key)
+	for index, existing := range c.order {
+		if existing == key {
+			c.order = append(c.order[:index], c.order[index+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package pills
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		wait  time.Duration
+		found bool
+	}{
+		{name: "fresh", ttl: time.Minute, found: true},
+		{name: "expired", ttl: time.Millisecond, wait: 5 * time.Millisecond, found: false},
+		{name: "zero ttl is not cached", ttl: 0, found: false},
+		{name: "negative ttl is not cached", ttl: -time.Minute, found: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newCache[string, int](10)
+			c.set("key", 1, test.ttl)
+			time.Sleep(test.wait)
+
+			value, ok := c.get("key")
+			if ok != test.found {
+				t.Fatalf("get() found = %v, want %v", ok, test.found)
+			}
+			if ok && value != 1 {
+				t.Fatalf("get() = %d, want 1", value)
+			}
+		})
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []string
+		evicted []string
+		kept    []string
+	}{
+		{name: "under limit", keys: []string{"a", "b"}, kept: []string{"a", "b"}},
+		{name: "oldest evicted", keys: []string{"a", "b", "c"}, evicted: []string{"a"}, kept: []string{"b", "c"}},
+		{name: "update keeps position", keys: []string{"a", "b", "a", "c"}, evicted: []string{"a"}, kept: []string{"b", "c"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newCache[string, int](2)
+			for index, key := range test.keys {
+				c.set(key, index, time.Minute)
+			}
+
+			for _, key := range test.evicted {
+				if _, ok := c.get(key); ok {
+					t.Errorf("get(%q) found, want evicted", key)
+				}
+			}
+			for _, key := range test.kept {
+				if _, ok := c.get(key); !ok {
+					t.Errorf("get(%q) not found, want kept", key)
+				}
+			}
+			if keys, _ := c.entries(); len(keys) != len(test.kept) {
+				t.Errorf("entries() = %v, want %v", keys, test.kept)
+			}
+		})
+	}
+}
+
+func TestCacheDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleted string
+		set     []string
+		evicted []string
+		kept    []string
+	}{
+		{name: "deleted key", deleted: "a", evicted: []string{"a"}, kept: []string{"b"}},
+		{name: "missing key", deleted: "x", kept: []string{"a", "b"}},
+		{name: "set again after delete", deleted: "a", set: []string{"a"}, kept: []string{"b", "a"}},
+		{name: "eviction after delete and set", deleted: "a", set: []string{"a", "c"}, evicted: []string{"b"}, kept: []string{"a", "c"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newCache[string, int](2)
+			c.set("a", 1, time.Minute)
+			c.set("b", 2, time.Minute)
+			c.delete(test.deleted)
+			for _, key := range test.set {
+				c.set(key, 3, time.Minute)
+			}
+
+			for _, key := range test.evicted {
+				if _, ok := c.get(key); ok {
+					t.Errorf("get(%q) found, want removed", key)
+				}
+			}
+			for _, key := range test.kept {
+				if _, ok := c.get(key); !ok {
+					t.Errorf("get(%q) not found, want kept", key)
+				}
+			}
+			if keys, _ := c.entries(); !reflect.DeepEqual(keys, test.kept) {
+				t.Errorf("entries() = %v, want %v", keys, test.kept)
+			}
+		})
+	}
+}
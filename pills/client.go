@@ -0,0 +1,244 @@
+package pills
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultURL адрес API по умолчанию
+const DefaultURL = "https://api.pillintrip.com/search"
+
+const (
+	defaultCacheLimit = 1000
+	defaultCacheTTL   = time.Hour
+)
+
+// Client клиент API. Поля можно менять до первого запроса, методы безопасны для параллельного вызова
+type Client struct {
+	URL    string
+	APIKey string
+	// HomeCountry страна, в которой ищутся исходные лекарства
+	HomeCountry int
+	Language    string
+	HTTPClient  *http.Client
+	// CacheTTL время жизни ответов в кэше, 0 отключает кэш
+	CacheTTL time.Duration
+	// OnRequest вызывается перед каждым запросом к API, который не нашелся в кэше,
+	// method имя метода клиента, key запрос или идентификатор лекарства
+	OnRequest func(method string, key string)
+	// BeforeRequest вызывается перед запросом к API мимо кэша, ошибка отменяет запрос
+	BeforeRequest func(method string) error
+
+	medicines *cache[string, []Medicine]
+	analogs   *cache[analogsKey, analogsEntry]
+	details   *cache[int, MedicineDetails]
+}
+
+type analogsKey struct {
+	medicineID int
+	countryID  int
+}
+
+type analogsEntry struct {
+	analogs []Analog
+	info    MedicineInfo
+}
+
+// NewClient создает клиент с адресом по умолчанию, русским языком и кэшем на час
+func NewClient(apiKey string, homeCountry int) *Client {
+	return &Client{
+		URL:         DefaultURL,
+		APIKey:      apiKey,
+		HomeCountry: homeCountry,
+		Language:    "ru",
+		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
+		CacheTTL:    defaultCacheTTL,
+		medicines:   newCache[string, []Medicine](defaultCacheLimit),
+		analogs:     newCache[analogsKey, analogsEntry](defaultCacheLimit),
+		details:     newCache[int, MedicineDetails](defaultCacheLimit),
+	}
+}
+
+func (c *Client) post(ctx context.Context, payload any, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Add("Content-Type", "application/json")
+
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("pills: ответ API %d", response.StatusCode)
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// ErrCacheOnly запрос не найден в кэше, а контекст запрещает обращаться к API
+var ErrCacheOnly = errors.New("pills: ответа нет в кэше")
+
+type cacheOnlyKey struct{}
+
+// WithCacheOnly запрещает запросы к API с контекстом ctx, отвечают только данные из кэша
+func WithCacheOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheOnlyKey{}, true)
+}
+
+func (c *Client) before(ctx context.Context, method string) error {
+	if cacheOnly, _ := ctx.Value(cacheOnlyKey{}).(bool); cacheOnly {
+		return ErrCacheOnly
+	}
+	if c.BeforeRequest != nil {
+		return c.BeforeRequest(method)
+	}
+
+	return nil
+}
+
+func (c *Client) notify(method string, key string) {
+	if c.OnRequest != nil {
+		c.OnRequest(method, key)
+	}
+}
+
+// SearchMedicines ищет лекарства по названию в домашней стране
+func (c *Client) SearchMedicines(ctx context.Context, query string) ([]Medicine, error) {
+	cacheKey := strings.ToLower(strings.TrimSpace(query))
+	if medicines, ok := c.medicines.get(cacheKey); ok {
+		return medicines, nil
+	}
+
+	if err := c.before(ctx, "SearchMedicines"); err != nil {
+		return []Medicine{}, err
+	}
+	c.notify("SearchMedicines", query)
+
+	response := &searchMedicineResponse{}
+	err := c.post(ctx, searchMedicineRequest{
+		ApiKey:       c.APIKey,
+		State:        "main_search",
+		HoumeCountry: c.HomeCountry,
+		Query:        query,
+	}, response)
+	if err != nil {
+		return []Medicine{}, err
+	}
+
+	c.medicines.set(cacheKey, response.Medicines, c.CacheTTL)
+
+	return response.Medicines, nil
+}
+
+// SearchAnalogs ищет аналоги лекарства medicineID в стране targetCountry
+func (c *Client) SearchAnalogs(ctx context.Context, medicineID int, targetCountry int) ([]Analog, MedicineInfo, error) {
+	cacheKey := analogsKey{medicineID: medicineID, countryID: targetCountry}
+	if entry, ok := c.analogs.get(cacheKey); ok {
+		return entry.analogs, entry.info, nil
+	}
+
+	return c.fetchAnalogs(ctx, medicineID, targetCountry)
+}
+
+// RefreshAnalogs запрашивает аналоги у API мимо кэша и при успехе заменяет ими ответ в кэше
+func (c *Client) RefreshAnalogs(ctx context.Context, medicineID int, targetCountry int) ([]Analog, MedicineInfo, error) {
+	return c.fetchAnalogs(ctx, medicineID, targetCountry)
+}
+
+// CachedAnalogs аналоги лекарства, сохраненные в кэше клиента
+type CachedAnalogs struct {
+	MedicineID int
+	CountryID  int
+	Info       MedicineInfo
+	Analogs    []Analog
+}
+
+// CachedAnalogs возвращает ответы SearchAnalogs, которые сейчас лежат в кэше
+func (c *Client) CachedAnalogs() []CachedAnalogs {
+	keys, entries := c.analogs.entries()
+	cached := make([]CachedAnalogs, 0, len(keys))
+	for index, key := range keys {
+		cached = append(cached, CachedAnalogs{
+			MedicineID: key.medicineID,
+			CountryID:  key.countryID,
+			Info:       entries[index].info,
+			Analogs:    entries[index].analogs,
+		})
+	}
+
+	return cached
+}
+
+func (c *Client) fetchAnalogs(ctx context.Context, medicineID int, targetCountry int) ([]Analog, MedicineInfo, error) {
+	if err := c.before(ctx, "SearchAnalogs"); err != nil {
+		return []Analog{}, MedicineInfo{}, err
+	}
+	c.notify("SearchAnalogs", fmt.Sprint(medicineID))
+
+	response := &searchAnalogResponse{}
+	err := c.post(ctx, searchAnalogRequest{
+		ApiKey:        c.APIKey,
+		State:         "main_search",
+		HoumeCountry:  c.HomeCountry,
+		TargetCountry: targetCountry,
+		Language:      c.Language,
+		Medicine:      medicineID,
+	}, response)
+	if err != nil {
+		return response.Analogs, response.HomeCountry, err
+	}
+
+	cacheKey := analogsKey{medicineID: medicineID, countryID: targetCountry}
+	c.analogs.set(cacheKey, analogsEntry{analogs: response.Analogs, info: response.MedicineInfo}, c.CacheTTL)
+
+	return response.Analogs, response.MedicineInfo, nil
+}
+
+// Details возвращает формы выпуска и дозировку лекарства
+func (c *Client) Details(ctx context.Context, medicineID int) (MedicineDetails, error) {
+	if details, ok := c.details.get(medicineID); ok {
+		return details, nil
+	}
+
+	if err := c.before(ctx, "Details"); err != nil {
+		return MedicineDetails{}, err
+	}
+	c.notify("Details", fmt.Sprint(medicineID))
+
+	response := &medicineDetailsResponse{}
+	err := c.post(ctx, medicineDetailsRequest{
+		ApiKey:       c.APIKey,
+		State:        "medicine_details",
+		HoumeCountry: c.HomeCountry,
+		Language:     c.Language,
+		Medicine:     medicineID,
+	}, response)
+	if err != nil {
+		return MedicineDetails{}, err
+	}
+
+	c.details.set(medicineID, response.Medicine, c.CacheTTL)
+
+	return response.Medicine, nil
+}
+
+// ForgetAnalogs удаляет из кэша аналоги лекарства, следующий запрос пойдет в API
+func (c *Client) ForgetAnalogs(medicineID int, targetCountry int) {
+	c.analogs.delete(analogsKey{medicineID: medicineID, countryID: targetCountry})
+	c.details.delete(medicineID)
+}
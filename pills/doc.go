@@ -0,0 +1,12 @@
+// Package pills клиент API поиска аналогов лекарств api.pillintrip.com с кэшем ответов
+// и ранжированием аналогов. Пакет не зависит от бота и может использоваться в любых программах на Go:
+//
+//	client := pills.NewClient(apiKey, homeCountryID)
+//	medicines, err := client.SearchMedicines(ctx, "нурофен")
+//	analogs, info, err := client.SearchAnalogs(ctx, medicineID, targetCountryID)
+//	best, ok := pills.BestAnalog(analogs)
+//
+// Provider общий интерфейс источников данных, Chain опрашивает несколько источников по очереди
+// и переносит в конец очереди источники, которые перестали отвечать, Health показывает их состояние.
+// Static отвечает из выгруженного JSON файла, когда API недоступно.
+package pills
@@ -0,0 +1,143 @@
+package pills
+
+import (
+	"time"
+)
+
+const (
+	// healthFailures сколько ошибок подряд выводят источник из строя
+	healthFailures = 3
+	// healthProbe через сколько неисправный источник проверяется первый раз, дальше интервал удваивается
+	healthProbe = 30 * time.Second
+	// healthProbeLimit наибольший интервал между проверками неисправного источника
+	healthProbeLimit = 5 * time.Minute
+	// healthLatencyWeight вес последнего запроса в скользящем среднем времени ответа
+	healthLatencyWeight = 0.2
+)
+
+// ProviderHealth состояние источника в цепочке: доля успешных запросов, среднее время ответа
+// и время следующей проверки, если источник выведен из строя
+type ProviderHealth struct {
+	Name        string        `json:"name"`
+	Requests    int           `json:"requests"`
+	Failures    int           `json:"failures"`
+	SuccessRate float64       `json:"success_rate"`
+	Latency     time.Duration `json:"latency"`
+	Down        bool          `json:"down"`
+	DownUntil   time.Time     `json:"down_until,omitempty"`
+}
+
+type providerHealth struct {
+	requests    int
+	failures    int
+	consecutive int
+	latency     time.Duration
+	down        bool
+	downUntil   time.Time
+	probe       time.Duration
+}
+
+func (c *Chain) state(name string) *providerHealth {
+	if c.health == nil {
+		c.health = map[string]*providerHealth{}
+	}
+	state, ok := c.health[name]
+	if !ok {
+		state = &providerHealth{}
+		c.health[name] = state
+	}
+
+	return state
+}
+
+// ordered источники в порядке опроса: сначала исправные и те, кому пора на проверку, затем
+// выведенные из строя. Проверку получает один запрос, остальные ждут ее результата
+func (c *Chain) ordered(now time.Time) []Provider {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active := make([]Provider, 0, len(c.Providers))
+	down := []Provider{}
+	for _, provider := range c.Providers {
+		state := c.state(provider.Name())
+		if !state.down {
+			active = append(active, provider)
+			continue
+		}
+		if !now.Before(state.downUntil) {
+			state.downUntil = now.Add(state.probe)
+			active = append(active, provider)
+			continue
+		}
+		down = append(down, provider)
+	}
+
+	// Если из строя вышли все, источники все равно опрашиваются по порядку
+	return append(active, down...)
+}
+
+// record учитывает ответ источника. Пустой ответ без ошибки считается успешным
+func (c *Chain) record(name string, err error, latency time.Duration, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.state(name)
+	state.requests++
+	if state.latency == 0 {
+		state.latency = latency
+	} else {
+		state.latency = time.Duration(healthLatencyWeight*float64(latency) + (1-healthLatencyWeight)*float64(state.latency))
+	}
+
+	failed := err != nil && (c.IsFailure == nil || c.IsFailure(err))
+	if !failed {
+		state.consecutive = 0
+		state.down = false
+		state.probe = 0
+		return
+	}
+
+	state.failures++
+	state.consecutive++
+	if state.down {
+		state.probe *= 2
+		if state.probe > healthProbeLimit {
+			state.probe = healthProbeLimit
+		}
+		state.downUntil = now.Add(state.probe)
+		return
+	}
+	if state.consecutive >= healthFailures {
+		state.down = true
+		state.probe = healthProbe
+		state.downUntil = now.Add(state.probe)
+	}
+}
+
+// Health состояние источников в порядке цепочки
+func (c *Chain) Health() []ProviderHealth {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	health := make([]ProviderHealth, 0, len(c.Providers))
+	for _, provider := range c.Providers {
+		state := c.state(provider.Name())
+		item := ProviderHealth{
+			Name:        provider.Name(),
+			Requests:    state.requests,
+			Failures:    state.failures,
+			SuccessRate: 1,
+			Latency:     state.latency,
+			Down:        state.down,
+		}
+		if state.requests > 0 {
+			item.SuccessRate = float64(state.requests-state.failures) / float64(state.requests)
+		}
+		if state.down {
+			item.DownUntil = state.downUntil
+		}
+		health = append(health, item)
+	}
+
+	return health
+}
@@ -0,0 +1,235 @@
+package pills
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider источник данных о лекарствах. Client реализует Provider для api.pillintrip.com
+type Provider interface {
+	// Name короткое имя источника для журнала и метрик
+	Name() string
+	SearchMedicines(ctx context.Context, query string) ([]Medicine, error)
+	SearchAnalogs(ctx context.Context, medicineID int, targetCountry int) ([]Analog, MedicineInfo, error)
+	Details(ctx context.Context, medicineID int) (MedicineDetails, error)
+}
+
+func (c *Client) Name() string {
+	return "pillintrip"
+}
+
+// Chain опрашивает источники по порядку. Следующий источник спрашивается, если предыдущий
+// вернул ошибку или пустой ответ. Если данных нет нигде, возвращается первая ошибка.
+// Источник, который несколько раз подряд ответил ошибкой, опрашивается последним, пока
+// очередная проверка не пройдет успешно
+type Chain struct {
+	Providers []Provider
+	// OnFallback вызывается, когда источник provider не ответил на запрос method и
+	// запрос уходит следующему, err nil если у источника просто нет данных
+	OnFallback func(provider string, method string, err error)
+	// IsFailure решает, говорит ли ошибка о неисправности источника, nil считает так любую ошибку
+	IsFailure func(err error) bool
+
+	mu     sync.Mutex
+	health map[string]*providerHealth
+}
+
+func (c *Chain) Name() string {
+	names := make([]string, 0, len(c.Providers))
+	for _, provider := range c.Providers {
+		names = append(names, provider.Name())
+	}
+
+	return strings.Join(names, ",")
+}
+
+// try вызывает call по очереди для источников, пока один из них не вернет данные
+func (c *Chain) try(ctx context.Context, method string, call func(provider Provider) (bool, error)) error {
+	var first error
+	providers := c.ordered(time.Now())
+	for index, provider := range providers {
+		start := time.Now()
+		found, err := call(provider)
+		// Отмененный запрос ничего не говорит об источнике
+		if ctx.Err() == nil {
+			c.record(provider.Name(), err, time.Since(start), time.Now())
+		}
+		if err == nil && found {
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if c.OnFallback != nil && index < len(providers)-1 {
+			c.OnFallback(provider.Name(), method, err)
+		}
+	}
+
+	return first
+}
+
+func (c *Chain) SearchMedicines(ctx context.Context, query string) ([]Medicine, error) {
+	medicines := []Medicine{}
+	err := c.try(ctx, "SearchMedicines", func(provider Provider) (bool, error) {
+		found, err := provider.SearchMedicines(ctx, query)
+		if err == nil && len(found) > 0 {
+			medicines = found
+		}
+		return len(found) > 0, err
+	})
+
+	return medicines, err
+}
+
+func (c *Chain) SearchAnalogs(ctx context.Context, medicineID int, targetCountry int) ([]Analog, MedicineInfo, error) {
+	analogs := []Analog{}
+	info := MedicineInfo{}
+	err := c.try(ctx, "SearchAnalogs", func(provider Provider) (bool, error) {
+		found, foundInfo, err := provider.SearchAnalogs(ctx, medicineID, targetCountry)
+		if err == nil && len(found) > 0 {
+			analogs, info = found, foundInfo
+		}
+		// Без аналогов пригодится хотя бы название лекарства
+		if err == nil && len(info.MedicineName) == 0 {
+			info = foundInfo
+		}
+		return len(found) > 0, err
+	})
+
+	return analogs, info, err
+}
+
+func (c *Chain) Details(ctx context.Context, medicineID int) (MedicineDetails, error) {
+	details := MedicineDetails{}
+	err := c.try(ctx, "Details", func(provider Provider) (bool, error) {
+		found, err := provider.Details(ctx, medicineID)
+		if err == nil && len(found.Forms) > 0 {
+			details = found
+		}
+		return len(found.Forms) > 0, err
+	})
+
+	return details, err
+}
+
+// StaticMedicine лекарство в заранее выгруженных данных. Analogs по идентификатору страны поиска
+type StaticMedicine struct {
+	Medicine
+	Info    MedicineInfo     `json:"info"`
+	Analogs map[int][]Analog `json:"analogs,omitempty"`
+	Details *MedicineDetails `json:"details,omitempty"`
+}
+
+// Static источник данных из JSON файла, запасной на случай, когда API недоступно.
+// Отвечает хотя бы названиями и составом лекарств, аналоги и описание если они выгружены
+type Static struct {
+	Source string `json:"source"`
+	// CreatedAt время выгрузки данных
+	CreatedAt time.Time        `json:"created_at,omitempty"`
+	Medicines []StaticMedicine `json:"medicines"`
+}
+
+// LoadStatic читает источник из файла path
+func LoadStatic(path string) (*Static, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	static := &Static{}
+	if err := json.Unmarshal(data, static); err != nil {
+		return nil, fmt.Errorf("pills: %s: %w", path, err)
+	}
+
+	return static, nil
+}
+
+// Save записывает источник в файл path через временный файл, чтобы читатели не увидели
+// недописанный файл
+func (s *Static) Save(path string) error {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (s *Static) Name() string {
+	if len(s.Source) > 0 {
+		return s.Source
+	}
+
+	return "static"
+}
+
+func (s *Static) medicine(medicineID int) (StaticMedicine, bool) {
+	id := strconv.Itoa(medicineID)
+	for _, medicine := range s.Medicines {
+		if medicine.ID == id {
+			return medicine, true
+		}
+	}
+
+	return StaticMedicine{}, false
+}
+
+// SearchMedicines ищет query в названии и составе без учета регистра
+func (s *Static) SearchMedicines(ctx context.Context, query string) ([]Medicine, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	medicines := []Medicine{}
+	if len(query) == 0 {
+		return medicines, nil
+	}
+	for _, medicine := range s.Medicines {
+		if strings.Contains(strings.ToLower(medicine.Name), query) || strings.Contains(strings.ToLower(medicine.Components), query) {
+			found := medicine.Medicine
+			found.Source = s.Name()
+			medicines = append(medicines, found)
+		}
+	}
+
+	return medicines, nil
+}
+
+func (s *Static) SearchAnalogs(ctx context.Context, medicineID int, targetCountry int) ([]Analog, MedicineInfo, error) {
+	medicine, ok := s.medicine(medicineID)
+	if !ok {
+		return []Analog{}, MedicineInfo{}, nil
+	}
+
+	info := medicine.Info
+	if len(info.MedicineName) == 0 {
+		info = MedicineInfo{MedicineID: medicine.ID, MedicineName: medicine.Name, MedicineSlug: medicine.Slug}
+	}
+	info.Source = s.Name()
+	analogs := medicine.Analogs[targetCountry]
+	if analogs == nil {
+		analogs = []Analog{}
+	}
+
+	return analogs, info, nil
+}
+
+func (s *Static) Details(ctx context.Context, medicineID int) (MedicineDetails, error) {
+	medicine, ok := s.medicine(medicineID)
+	if !ok || medicine.Details == nil {
+		return MedicineDetails{}, nil
+	}
+
+	return *medicine.Details, nil
+}
@@ -0,0 +1,201 @@
+package pills
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeProvider отвечает заданными аналогами или ошибкой и считает запросы
+type fakeProvider struct {
+	name    string
+	analogs []Analog
+	err     error
+	calls   int
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) SearchMedicines(ctx context.Context, query string) ([]Medicine, error) {
+	p.calls++
+	return []Medicine{}, p.err
+}
+
+func (p *fakeProvider) SearchAnalogs(ctx context.Context, medicineID int, targetCountry int) ([]Analog, MedicineInfo, error) {
+	p.calls++
+	if p.err != nil {
+		return []Analog{}, MedicineInfo{}, p.err
+	}
+
+	return p.analogs, MedicineInfo{MedicineName: p.name}, nil
+}
+
+func (p *fakeProvider) Details(ctx context.Context, medicineID int) (MedicineDetails, error) {
+	p.calls++
+	return MedicineDetails{}, p.err
+}
+
+func providerNames(providers []Provider) []string {
+	names := []string{}
+	for _, provider := range providers {
+		names = append(names, provider.Name())
+	}
+
+	return names
+}
+
+func TestChainFailover(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	found := []Analog{{AnalogID: "1"}}
+
+	tests := []struct {
+		name      string
+		first     fakeProvider
+		second    fakeProvider
+		wantFrom  string
+		wantErr   error
+		fallbacks int
+	}{
+		{name: "first answers", first: fakeProvider{analogs: found}, second: fakeProvider{analogs: found}, wantFrom: "first"},
+		{name: "first fails", first: fakeProvider{err: errFirst}, second: fakeProvider{analogs: found}, wantFrom: "second", fallbacks: 1},
+		{name: "first is empty", first: fakeProvider{}, second: fakeProvider{analogs: found}, wantFrom: "second", fallbacks: 1},
+		{name: "all fail", first: fakeProvider{err: errFirst}, second: fakeProvider{err: errSecond}, wantErr: errFirst, fallbacks: 1},
+		{name: "all empty", first: fakeProvider{}, second: fakeProvider{}, wantFrom: "first", fallbacks: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, second := test.first, test.second
+			first.name, second.name = "first", "second"
+			fallbacks := 0
+			chain := &Chain{
+				Providers: []Provider{&first, &second},
+				OnFallback: func(provider string, method string, err error) {
+					fallbacks++
+				},
+			}
+
+			_, info, err := chain.SearchAnalogs(context.Background(), 1, 1)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("SearchAnalogs() error = %v, want %v", err, test.wantErr)
+			}
+			if info.MedicineName != test.wantFrom {
+				t.Errorf("SearchAnalogs() answered by %q, want %q", info.MedicineName, test.wantFrom)
+			}
+			if fallbacks != test.fallbacks {
+				t.Errorf("OnFallback called %d times, want %d", fallbacks, test.fallbacks)
+			}
+		})
+	}
+}
+
+func TestChainRecovery(t *testing.T) {
+	errDown := errors.New("down")
+	errIgnored := errors.New("ignored")
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		// step меняет состояние цепочки, want порядок опроса после него
+		step func(chain *Chain)
+		at   time.Time
+		want []string
+		down bool
+	}{
+		{
+			name: "ignored errors do not count",
+			step: func(chain *Chain) {
+				for index := 0; index < healthFailures; index++ {
+					chain.record("a", errIgnored, time.Millisecond, now)
+				}
+			},
+			at:   now,
+			want: []string{"a", "b"},
+		},
+		{
+			name: "single failure keeps order",
+			step: func(chain *Chain) { chain.record("a", errDown, time.Millisecond, now) },
+			at:   now,
+			want: []string{"a", "b"},
+		},
+		{
+			name: "consecutive failures move it last",
+			step: func(chain *Chain) {
+				chain.record("a", errDown, time.Millisecond, now)
+				chain.record("a", errDown, time.Millisecond, now)
+			},
+			at:   now,
+			want: []string{"b", "a"},
+			down: true,
+		},
+		{
+			name: "probe is due",
+			step: func(chain *Chain) {},
+			at:   now.Add(healthProbe),
+			want: []string{"a", "b"},
+			down: true,
+		},
+		{
+			name: "probe is claimed once",
+			step: func(chain *Chain) {},
+			at:   now.Add(healthProbe),
+			want: []string{"b", "a"},
+			down: true,
+		},
+		{
+			name: "failed probe doubles the interval",
+			step: func(chain *Chain) { chain.record("a", errDown, time.Millisecond, now.Add(healthProbe)) },
+			at:   now.Add(2 * healthProbe),
+			want: []string{"b", "a"},
+			down: true,
+		},
+		{
+			name: "success recovers",
+			step: func(chain *Chain) { chain.record("a", nil, time.Millisecond, now.Add(3*healthProbe)) },
+			at:   now.Add(3 * healthProbe),
+			want: []string{"a", "b"},
+		},
+	}
+
+	// Шаги идут по порядку над одной цепочкой
+	chain := &Chain{
+		Providers: []Provider{&fakeProvider{name: "a"}, &fakeProvider{name: "b"}},
+		IsFailure: func(err error) bool {
+			return !errors.Is(err, errIgnored)
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.step(chain)
+			if got := providerNames(chain.ordered(test.at)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ordered() = %v, want %v", got, test.want)
+			}
+			if down := chain.Health()[0].Down; down != test.down {
+				t.Errorf("Health() down = %v, want %v", down, test.down)
+			}
+		})
+	}
+}
+
+func TestChainSkipsDownProvider(t *testing.T) {
+	broken := &fakeProvider{name: "broken", err: errors.New("timeout")}
+	backup := &fakeProvider{name: "backup", analogs: []Analog{{AnalogID: "1"}}}
+	chain := &Chain{Providers: []Provider{broken, backup}}
+
+	for index := 0; index < healthFailures+2; index++ {
+		if _, _, err := chain.SearchAnalogs(context.Background(), 1, 1); err != nil {
+			t.Fatalf("SearchAnalogs() error = %v", err)
+		}
+	}
+	if broken.calls != healthFailures {
+		t.Errorf("broken provider called %d times, want %d", broken.calls, healthFailures)
+	}
+	if backup.calls != healthFailures+2 {
+		t.Errorf("backup provider called %d times, want %d", backup.calls, healthFailures+2)
+	}
+}
@@ -0,0 +1,38 @@
+package pills
+
+import "sort"
+
+// ExactPercentage совпадение, при котором аналог считается точным
+const ExactPercentage = 100
+
+// BestAnalog возвращает аналог с наибольшим совпадением
+func BestAnalog(analogs []Analog) (Analog, bool) {
+	if len(analogs) == 0 {
+		return Analog{}, false
+	}
+
+	best := analogs[0]
+	for _, analog := range analogs[1:] {
+		if analog.Percentage > best.Percentage {
+			best = analog
+		}
+	}
+
+	return best, true
+}
+
+// SortAnalogs сортирует аналоги по убыванию совпадения, затем по совпадению состава
+func SortAnalogs(analogs []Analog) {
+	sort.SliceStable(analogs, func(i, j int) bool {
+		if analogs[i].Percentage != analogs[j].Percentage {
+			return analogs[i].Percentage > analogs[j].Percentage
+		}
+
+		return analogs[i].ComponentsMatch > analogs[j].ComponentsMatch
+	})
+}
+
+// IsExact проверяет, что аналог совпадает с лекарством полностью
+func IsExact(analog Analog) bool {
+	return analog.Percentage >= ExactPercentage
+}
@@ -0,0 +1,80 @@
+package pills
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBestAnalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		analogs []Analog
+		want    string
+		found   bool
+	}{
+		{name: "empty", analogs: nil, found: false},
+		{name: "single", analogs: []Analog{{AnalogID: "a", Percentage: 40}}, want: "a", found: true},
+		{
+			name:    "highest percentage",
+			analogs: []Analog{{AnalogID: "a", Percentage: 40}, {AnalogID: "b", Percentage: 90}, {AnalogID: "c", Percentage: 70}},
+			want:    "b",
+			found:   true,
+		},
+		{
+			name:    "first of equal",
+			analogs: []Analog{{AnalogID: "a", Percentage: 90}, {AnalogID: "b", Percentage: 90}},
+			want:    "a",
+			found:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			best, ok := BestAnalog(test.analogs)
+			if ok != test.found || best.AnalogID != test.want {
+				t.Fatalf("BestAnalog() = %q, %v, want %q, %v", best.AnalogID, ok, test.want, test.found)
+			}
+		})
+	}
+}
+
+func TestSortAnalogs(t *testing.T) {
+	tests := []struct {
+		name    string
+		analogs []Analog
+		want    []string
+	}{
+		{
+			name:    "by percentage",
+			analogs: []Analog{{AnalogID: "a", Percentage: 40}, {AnalogID: "b", Percentage: 100}, {AnalogID: "c", Percentage: 70}},
+			want:    []string{"b", "c", "a"},
+		},
+		{
+			name: "ties by components match",
+			analogs: []Analog{
+				{AnalogID: "a", Percentage: 80, ComponentsMatch: 1},
+				{AnalogID: "b", Percentage: 80, ComponentsMatch: 3},
+				{AnalogID: "c", Percentage: 90},
+			},
+			want: []string{"c", "b", "a"},
+		},
+		{
+			name:    "stable for full ties",
+			analogs: []Analog{{AnalogID: "a", Percentage: 50}, {AnalogID: "b", Percentage: 50}},
+			want:    []string{"a", "b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SortAnalogs(test.analogs)
+			got := []string{}
+			for _, analog := range test.analogs {
+				got = append(got, analog.AnalogID)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("SortAnalogs() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
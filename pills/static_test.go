@@ -0,0 +1,134 @@
+package pills
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testStatic() *Static {
+	return &Static{
+		Medicines: []StaticMedicine{
+			{
+				Medicine: Medicine{ID: "1", Name: "Nurofen", Components: "Ibuprofen", Slug: "nurofen"},
+				Info:     MedicineInfo{MedicineID: "1", MedicineName: "Nurofen", DateRevision: "2024-01-01"},
+				Analogs:  map[int][]Analog{7: {{AnalogID: "10", AnalogName: "Advil", Percentage: 100}}},
+				Details:  &MedicineDetails{MedicineID: "1", DoseMgPerKg: 10},
+			},
+			{
+				Medicine: Medicine{ID: "2", Name: "Panadol", Components: "Paracetamol", Slug: "panadol"},
+			},
+		},
+	}
+}
+
+func TestStaticSearchMedicines(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "by name", query: "nuro", want: []string{"1"}},
+		{name: "ignores case and spaces", query: "  PANADOL ", want: []string{"2"}},
+		{name: "by components", query: "paracetamol", want: []string{"2"}},
+		{name: "empty query", query: " ", want: []string{}},
+		{name: "not found", query: "aspirin", want: []string{}},
+	}
+
+	static := testStatic()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			medicines, err := static.SearchMedicines(context.Background(), test.query)
+			if err != nil {
+				t.Fatalf("SearchMedicines() error = %v", err)
+			}
+			got := []string{}
+			for _, medicine := range medicines {
+				got = append(got, medicine.ID)
+				if medicine.Source != "static" {
+					t.Errorf("SearchMedicines() source = %q, want static", medicine.Source)
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("SearchMedicines() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStaticSearchAnalogs(t *testing.T) {
+	tests := []struct {
+		name       string
+		medicineID int
+		country    int
+		analogs    int
+		medicine   string
+	}{
+		{name: "exported country", medicineID: 1, country: 7, analogs: 1, medicine: "Nurofen"},
+		{name: "other country", medicineID: 1, country: 8, analogs: 0, medicine: "Nurofen"},
+		{name: "name without info", medicineID: 2, country: 7, analogs: 0, medicine: "Panadol"},
+		{name: "unknown medicine", medicineID: 3, country: 7, analogs: 0, medicine: ""},
+	}
+
+	static := testStatic()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			analogs, info, err := static.SearchAnalogs(context.Background(), test.medicineID, test.country)
+			if err != nil {
+				t.Fatalf("SearchAnalogs() error = %v", err)
+			}
+			if analogs == nil || len(analogs) != test.analogs {
+				t.Errorf("SearchAnalogs() analogs = %v, want %d", analogs, test.analogs)
+			}
+			if info.MedicineName != test.medicine {
+				t.Errorf("SearchAnalogs() medicine = %q, want %q", info.MedicineName, test.medicine)
+			}
+			if len(test.medicine) > 0 && info.Source != "static" {
+				t.Errorf("SearchAnalogs() source = %q, want static", info.Source)
+			}
+		})
+	}
+}
+
+func TestStaticDetails(t *testing.T) {
+	tests := []struct {
+		name       string
+		medicineID int
+		dose       float64
+	}{
+		{name: "exported", medicineID: 1, dose: 10},
+		{name: "without details", medicineID: 2},
+		{name: "unknown", medicineID: 3},
+	}
+
+	static := testStatic()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			details, err := static.Details(context.Background(), test.medicineID)
+			if err != nil {
+				t.Fatalf("Details() error = %v", err)
+			}
+			if details.DoseMgPerKg != test.dose {
+				t.Errorf("Details() dose = %v, want %v", details.DoseMgPerKg, test.dose)
+			}
+		})
+	}
+}
+
+func TestStaticSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	static := testStatic()
+	static.Source = "snapshot"
+	if err := static.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadStatic(path)
+	if err != nil {
+		t.Fatalf("LoadStatic() error = %v", err)
+	}
+	if loaded.Name() != "snapshot" || !reflect.DeepEqual(loaded.Medicines, static.Medicines) {
+		t.Fatalf("LoadStatic() = %+v, want %+v", loaded, static)
+	}
+}
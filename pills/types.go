@@ -0,0 +1,98 @@
+package pills
+
+type Medicine struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Components string `json:"components"`
+	Slug       string `json:"slug"`
+	IsPopular  int    `json:"ispopular"`
+	// Source имя запасного источника ответа, пустое для ответов API
+	Source string `json:"source,omitempty"`
+}
+
+type MedicineInfo struct {
+	MedicineID   string `json:"medicine_id"`
+	MedicineName string `json:"medicine_name"`
+	MedicineSlug string `json:"medicine_slug"`
+	DateRevision string `json:"date_revision"`
+	// Source имя запасного источника ответа, пустое для ответов API
+	Source string `json:"source,omitempty"`
+}
+
+type Analog struct {
+	AnalogID        string `json:"analog_id"`
+	AnalogName      string `json:"analog_name"`
+	AnalogSlug      string `json:"analog_slug"`
+	ComponentsMatch int    `json:"components_match"`
+	ApplyingsMatch  int    `json:"applyings_match"`
+	TreatmentsMatch int    `json:"treatments_match"`
+	Percentage      int    `json:"percentage"`
+}
+
+// MedicineDetails описание лекарства с формами выпуска и дозировкой на кг веса
+type MedicineDetails struct {
+	MedicineID   string         `json:"medicine_id"`
+	MedicineName string         `json:"medicine_name"`
+	Forms        []MedicineForm `json:"forms"`
+	// DoseMgPerKg разовая доза в мг на кг веса, MaxDailyMgPerKg суточный максимум
+	DoseMgPerKg     float64 `json:"dose_mg_per_kg"`
+	MaxDailyMgPerKg float64 `json:"max_daily_mg_per_kg"`
+	// Pregnancy и Breastfeeding категории безопасности, пустые если данных нет
+	Pregnancy     SafetyCategory `json:"pregnancy,omitempty"`
+	Breastfeeding SafetyCategory `json:"breastfeeding,omitempty"`
+	// MinAgeMonths минимальный возраст приема в месяцах, 0 если ограничений нет
+	MinAgeMonths int `json:"min_age_months,omitempty"`
+}
+
+// SafetyCategory категория безопасности приема в особом состоянии
+type SafetyCategory string
+
+const (
+	SafetyAllowed         SafetyCategory = "allowed"
+	SafetyCaution         SafetyCategory = "caution"
+	SafetyContraindicated SafetyCategory = "contraindicated"
+)
+
+type MedicineForm struct {
+	Name string `json:"name"`
+	// Concentration концентрация в виде «100 мг/5 мл»
+	Concentration string `json:"concentration"`
+}
+
+type searchMedicineRequest struct {
+	ApiKey       string `json:"api_key"`
+	State        string `json:"state"`
+	HoumeCountry int    `json:"home_country"`
+	Query        string `json:"query"`
+}
+
+type searchMedicineResponse struct {
+	Medicines []Medicine `json:"medicines"`
+}
+
+type searchAnalogRequest struct {
+	ApiKey        string `json:"api_key"`
+	State         string `json:"state"`
+	HoumeCountry  int    `json:"home_country"`
+	TargetCountry int    `json:"target_country"`
+	Language      string `json:"language"`
+	Medicine      int    `json:"medicine"`
+}
+
+type searchAnalogResponse struct {
+	MedicineInfo MedicineInfo `json:"medicine_info"`
+	HomeCountry  MedicineInfo `json:"home_country"`
+	Analogs      []Analog     `json:"medicine_analogs"`
+}
+
+type medicineDetailsRequest struct {
+	ApiKey       string `json:"api_key"`
+	State        string `json:"state"`
+	HoumeCountry int    `json:"home_country"`
+	Language     string `json:"language"`
+	Medicine     int    `json:"medicine"`
+}
+
+type medicineDetailsResponse struct {
+	Medicine MedicineDetails `json:"medicine"`
+}
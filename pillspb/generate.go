@@ -0,0 +1,4 @@
+// Package pillspb содержит gRPC сервис поиска лекарств и аналогов, сгенерированный из pills.proto
+package pillspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative pills.proto
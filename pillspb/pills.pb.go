@@ -0,0 +1,1021 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        v4.24.4
+// source: pills.proto
+
+package pillspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Medicine struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id         string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name       string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Components string `protobuf:"bytes,3,opt,name=components,proto3" json:"components,omitempty"`
+	Slug       string `protobuf:"bytes,4,opt,name=slug,proto3" json:"slug,omitempty"`
+	Popular    bool   `protobuf:"varint,5,opt,name=popular,proto3" json:"popular,omitempty"`
+}
+
+func (x *Medicine) Reset() {
+	*x = Medicine{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Medicine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Medicine) ProtoMessage() {}
+
+func (x *Medicine) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Medicine.ProtoReflect.Descriptor instead.
+func (*Medicine) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Medicine) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Medicine) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Medicine) GetComponents() string {
+	if x != nil {
+		return x.Components
+	}
+	return ""
+}
+
+func (x *Medicine) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *Medicine) GetPopular() bool {
+	if x != nil {
+		return x.Popular
+	}
+	return false
+}
+
+type MedicineInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MedicineId   string `protobuf:"bytes,1,opt,name=medicine_id,json=medicineId,proto3" json:"medicine_id,omitempty"`
+	MedicineName string `protobuf:"bytes,2,opt,name=medicine_name,json=medicineName,proto3" json:"medicine_name,omitempty"`
+	MedicineSlug string `protobuf:"bytes,3,opt,name=medicine_slug,json=medicineSlug,proto3" json:"medicine_slug,omitempty"`
+	DateRevision string `protobuf:"bytes,4,opt,name=date_revision,json=dateRevision,proto3" json:"date_revision,omitempty"`
+}
+
+func (x *MedicineInfo) Reset() {
+	*x = MedicineInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MedicineInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MedicineInfo) ProtoMessage() {}
+
+func (x *MedicineInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MedicineInfo.ProtoReflect.Descriptor instead.
+func (*MedicineInfo) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MedicineInfo) GetMedicineId() string {
+	if x != nil {
+		return x.MedicineId
+	}
+	return ""
+}
+
+func (x *MedicineInfo) GetMedicineName() string {
+	if x != nil {
+		return x.MedicineName
+	}
+	return ""
+}
+
+func (x *MedicineInfo) GetMedicineSlug() string {
+	if x != nil {
+		return x.MedicineSlug
+	}
+	return ""
+}
+
+func (x *MedicineInfo) GetDateRevision() string {
+	if x != nil {
+		return x.DateRevision
+	}
+	return ""
+}
+
+type Analog struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AnalogId        string `protobuf:"bytes,1,opt,name=analog_id,json=analogId,proto3" json:"analog_id,omitempty"`
+	AnalogName      string `protobuf:"bytes,2,opt,name=analog_name,json=analogName,proto3" json:"analog_name,omitempty"`
+	AnalogSlug      string `protobuf:"bytes,3,opt,name=analog_slug,json=analogSlug,proto3" json:"analog_slug,omitempty"`
+	ComponentsMatch int32  `protobuf:"varint,4,opt,name=components_match,json=componentsMatch,proto3" json:"components_match,omitempty"`
+	ApplyingsMatch  int32  `protobuf:"varint,5,opt,name=applyings_match,json=applyingsMatch,proto3" json:"applyings_match,omitempty"`
+	TreatmentsMatch int32  `protobuf:"varint,6,opt,name=treatments_match,json=treatmentsMatch,proto3" json:"treatments_match,omitempty"`
+	Percentage      int32  `protobuf:"varint,7,opt,name=percentage,proto3" json:"percentage,omitempty"`
+}
+
+func (x *Analog) Reset() {
+	*x = Analog{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Analog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Analog) ProtoMessage() {}
+
+func (x *Analog) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Analog.ProtoReflect.Descriptor instead.
+func (*Analog) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Analog) GetAnalogId() string {
+	if x != nil {
+		return x.AnalogId
+	}
+	return ""
+}
+
+func (x *Analog) GetAnalogName() string {
+	if x != nil {
+		return x.AnalogName
+	}
+	return ""
+}
+
+func (x *Analog) GetAnalogSlug() string {
+	if x != nil {
+		return x.AnalogSlug
+	}
+	return ""
+}
+
+func (x *Analog) GetComponentsMatch() int32 {
+	if x != nil {
+		return x.ComponentsMatch
+	}
+	return 0
+}
+
+func (x *Analog) GetApplyingsMatch() int32 {
+	if x != nil {
+		return x.ApplyingsMatch
+	}
+	return 0
+}
+
+func (x *Analog) GetTreatmentsMatch() int32 {
+	if x != nil {
+		return x.TreatmentsMatch
+	}
+	return 0
+}
+
+func (x *Analog) GetPercentage() int32 {
+	if x != nil {
+		return x.Percentage
+	}
+	return 0
+}
+
+type MedicineForm struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Concentration string `protobuf:"bytes,2,opt,name=concentration,proto3" json:"concentration,omitempty"`
+}
+
+func (x *MedicineForm) Reset() {
+	*x = MedicineForm{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MedicineForm) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MedicineForm) ProtoMessage() {}
+
+func (x *MedicineForm) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MedicineForm.ProtoReflect.Descriptor instead.
+func (*MedicineForm) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MedicineForm) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MedicineForm) GetConcentration() string {
+	if x != nil {
+		return x.Concentration
+	}
+	return ""
+}
+
+type MedicineDetails struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MedicineId      string          `protobuf:"bytes,1,opt,name=medicine_id,json=medicineId,proto3" json:"medicine_id,omitempty"`
+	MedicineName    string          `protobuf:"bytes,2,opt,name=medicine_name,json=medicineName,proto3" json:"medicine_name,omitempty"`
+	Forms           []*MedicineForm `protobuf:"bytes,3,rep,name=forms,proto3" json:"forms,omitempty"`
+	DoseMgPerKg     float64         `protobuf:"fixed64,4,opt,name=dose_mg_per_kg,json=doseMgPerKg,proto3" json:"dose_mg_per_kg,omitempty"`
+	MaxDailyMgPerKg float64         `protobuf:"fixed64,5,opt,name=max_daily_mg_per_kg,json=maxDailyMgPerKg,proto3" json:"max_daily_mg_per_kg,omitempty"`
+}
+
+func (x *MedicineDetails) Reset() {
+	*x = MedicineDetails{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MedicineDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MedicineDetails) ProtoMessage() {}
+
+func (x *MedicineDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MedicineDetails.ProtoReflect.Descriptor instead.
+func (*MedicineDetails) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MedicineDetails) GetMedicineId() string {
+	if x != nil {
+		return x.MedicineId
+	}
+	return ""
+}
+
+func (x *MedicineDetails) GetMedicineName() string {
+	if x != nil {
+		return x.MedicineName
+	}
+	return ""
+}
+
+func (x *MedicineDetails) GetForms() []*MedicineForm {
+	if x != nil {
+		return x.Forms
+	}
+	return nil
+}
+
+func (x *MedicineDetails) GetDoseMgPerKg() float64 {
+	if x != nil {
+		return x.DoseMgPerKg
+	}
+	return 0
+}
+
+func (x *MedicineDetails) GetMaxDailyMgPerKg() float64 {
+	if x != nil {
+		return x.MaxDailyMgPerKg
+	}
+	return 0
+}
+
+type SearchMedicinesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+}
+
+func (x *SearchMedicinesRequest) Reset() {
+	*x = SearchMedicinesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchMedicinesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchMedicinesRequest) ProtoMessage() {}
+
+func (x *SearchMedicinesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchMedicinesRequest.ProtoReflect.Descriptor instead.
+func (*SearchMedicinesRequest) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SearchMedicinesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+type SearchMedicinesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Medicines []*Medicine `protobuf:"bytes,1,rep,name=medicines,proto3" json:"medicines,omitempty"`
+}
+
+func (x *SearchMedicinesResponse) Reset() {
+	*x = SearchMedicinesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchMedicinesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchMedicinesResponse) ProtoMessage() {}
+
+func (x *SearchMedicinesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchMedicinesResponse.ProtoReflect.Descriptor instead.
+func (*SearchMedicinesResponse) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchMedicinesResponse) GetMedicines() []*Medicine {
+	if x != nil {
+		return x.Medicines
+	}
+	return nil
+}
+
+type SearchAnalogsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MedicineId int32 `protobuf:"varint,1,opt,name=medicine_id,json=medicineId,proto3" json:"medicine_id,omitempty"`
+	// country_id страна поиска, по умолчанию TARGET_COUNTRY_ID
+	CountryId int32 `protobuf:"varint,2,opt,name=country_id,json=countryId,proto3" json:"country_id,omitempty"`
+}
+
+func (x *SearchAnalogsRequest) Reset() {
+	*x = SearchAnalogsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchAnalogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchAnalogsRequest) ProtoMessage() {}
+
+func (x *SearchAnalogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchAnalogsRequest.ProtoReflect.Descriptor instead.
+func (*SearchAnalogsRequest) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchAnalogsRequest) GetMedicineId() int32 {
+	if x != nil {
+		return x.MedicineId
+	}
+	return 0
+}
+
+func (x *SearchAnalogsRequest) GetCountryId() int32 {
+	if x != nil {
+		return x.CountryId
+	}
+	return 0
+}
+
+type SearchAnalogsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Medicine  *MedicineInfo `protobuf:"bytes,1,opt,name=medicine,proto3" json:"medicine,omitempty"`
+	CountryId int32         `protobuf:"varint,2,opt,name=country_id,json=countryId,proto3" json:"country_id,omitempty"`
+	Analogs   []*Analog     `protobuf:"bytes,3,rep,name=analogs,proto3" json:"analogs,omitempty"`
+}
+
+func (x *SearchAnalogsResponse) Reset() {
+	*x = SearchAnalogsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchAnalogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchAnalogsResponse) ProtoMessage() {}
+
+func (x *SearchAnalogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchAnalogsResponse.ProtoReflect.Descriptor instead.
+func (*SearchAnalogsResponse) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SearchAnalogsResponse) GetMedicine() *MedicineInfo {
+	if x != nil {
+		return x.Medicine
+	}
+	return nil
+}
+
+func (x *SearchAnalogsResponse) GetCountryId() int32 {
+	if x != nil {
+		return x.CountryId
+	}
+	return 0
+}
+
+func (x *SearchAnalogsResponse) GetAnalogs() []*Analog {
+	if x != nil {
+		return x.Analogs
+	}
+	return nil
+}
+
+type GetDetailsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MedicineId int32 `protobuf:"varint,1,opt,name=medicine_id,json=medicineId,proto3" json:"medicine_id,omitempty"`
+}
+
+func (x *GetDetailsRequest) Reset() {
+	*x = GetDetailsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDetailsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDetailsRequest) ProtoMessage() {}
+
+func (x *GetDetailsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDetailsRequest.ProtoReflect.Descriptor instead.
+func (*GetDetailsRequest) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetDetailsRequest) GetMedicineId() int32 {
+	if x != nil {
+		return x.MedicineId
+	}
+	return 0
+}
+
+type GetDetailsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Medicine *MedicineDetails `protobuf:"bytes,1,opt,name=medicine,proto3" json:"medicine,omitempty"`
+}
+
+func (x *GetDetailsResponse) Reset() {
+	*x = GetDetailsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pills_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDetailsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDetailsResponse) ProtoMessage() {}
+
+func (x *GetDetailsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pills_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDetailsResponse.ProtoReflect.Descriptor instead.
+func (*GetDetailsResponse) Descriptor() ([]byte, []int) {
+	return file_pills_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetDetailsResponse) GetMedicine() *MedicineDetails {
+	if x != nil {
+		return x.Medicine
+	}
+	return nil
+}
+
+var File_pills_proto protoreflect.FileDescriptor
+
+var file_pills_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x70,
+	0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x22, 0x7c, 0x0a, 0x08, 0x4d, 0x65, 0x64, 0x69, 0x63,
+	0x69, 0x6e, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f,
+	0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d,
+	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6c, 0x75, 0x67, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x6c, 0x75, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x70,
+	0x6f, 0x70, 0x75, 0x6c, 0x61, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x6f,
+	0x70, 0x75, 0x6c, 0x61, 0x72, 0x22, 0x9e, 0x01, 0x0a, 0x0c, 0x4d, 0x65, 0x64, 0x69, 0x63, 0x69,
+	0x6e, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69,
+	0x6e, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6d, 0x65, 0x64,
+	0x69, 0x63, 0x69, 0x6e, 0x65, 0x49, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x6d, 0x65, 0x64, 0x69, 0x63,
+	0x69, 0x6e, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x0d,
+	0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x5f, 0x73, 0x6c, 0x75, 0x67, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0c, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x53, 0x6c, 0x75,
+	0x67, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x72, 0x65, 0x76, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65,
+	0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x86, 0x02, 0x0a, 0x06, 0x41, 0x6e, 0x61, 0x6c, 0x6f,
+	0x67, 0x12, 0x1b, 0x0a, 0x09, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x49, 0x64, 0x12, 0x1f,
+	0x0a, 0x0b, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x1f, 0x0a, 0x0b, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x5f, 0x73, 0x6c, 0x75, 0x67, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x53, 0x6c, 0x75, 0x67,
+	0x12, 0x29, 0x0a, 0x10, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x5f, 0x6d,
+	0x61, 0x74, 0x63, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x63, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x27, 0x0a, 0x0f, 0x61,
+	0x70, 0x70, 0x6c, 0x79, 0x69, 0x6e, 0x67, 0x73, 0x5f, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x61, 0x70, 0x70, 0x6c, 0x79, 0x69, 0x6e, 0x67, 0x73, 0x4d,
+	0x61, 0x74, 0x63, 0x68, 0x12, 0x29, 0x0a, 0x10, 0x74, 0x72, 0x65, 0x61, 0x74, 0x6d, 0x65, 0x6e,
+	0x74, 0x73, 0x5f, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f,
+	0x74, 0x72, 0x65, 0x61, 0x74, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x12,
+	0x1e, 0x0a, 0x0a, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0a, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x61, 0x67, 0x65, 0x22,
+	0x48, 0x0a, 0x0c, 0x4d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x46, 0x6f, 0x72, 0x6d, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x63, 0x6f, 0x6e, 0x63, 0x65, 0x6e, 0x74, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x6f, 0x6e, 0x63,
+	0x65, 0x6e, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xd8, 0x01, 0x0a, 0x0f, 0x4d, 0x65,
+	0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x12, 0x1f, 0x0a,
+	0x0b, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x49, 0x64, 0x12, 0x23,
+	0x0a, 0x0d, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x2c, 0x0a, 0x05, 0x66, 0x6f, 0x72, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65,
+	0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x46, 0x6f, 0x72, 0x6d, 0x52, 0x05, 0x66, 0x6f, 0x72, 0x6d,
+	0x73, 0x12, 0x23, 0x0a, 0x0e, 0x64, 0x6f, 0x73, 0x65, 0x5f, 0x6d, 0x67, 0x5f, 0x70, 0x65, 0x72,
+	0x5f, 0x6b, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x64, 0x6f, 0x73, 0x65, 0x4d,
+	0x67, 0x50, 0x65, 0x72, 0x4b, 0x67, 0x12, 0x2c, 0x0a, 0x13, 0x6d, 0x61, 0x78, 0x5f, 0x64, 0x61,
+	0x69, 0x6c, 0x79, 0x5f, 0x6d, 0x67, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x6b, 0x67, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x0f, 0x6d, 0x61, 0x78, 0x44, 0x61, 0x69, 0x6c, 0x79, 0x4d, 0x67, 0x50,
+	0x65, 0x72, 0x4b, 0x67, 0x22, 0x2e, 0x0a, 0x16, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4d, 0x65,
+	0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71,
+	0x75, 0x65, 0x72, 0x79, 0x22, 0x4b, 0x0a, 0x17, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4d, 0x65,
+	0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x30, 0x0a, 0x09, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65,
+	0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x52, 0x09, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65,
+	0x73, 0x22, 0x56, 0x0a, 0x14, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x41, 0x6e, 0x61, 0x6c, 0x6f,
+	0x67, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x65, 0x64,
+	0x69, 0x63, 0x69, 0x6e, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a,
+	0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f,
+	0x75, 0x6e, 0x74, 0x72, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x49, 0x64, 0x22, 0x96, 0x01, 0x0a, 0x15, 0x53, 0x65,
+	0x61, 0x72, 0x63, 0x68, 0x41, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x08, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x4d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x6d,
+	0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x72, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x72, 0x79, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x07, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x52, 0x07, 0x61, 0x6e, 0x61, 0x6c, 0x6f,
+	0x67, 0x73, 0x22, 0x34, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x65, 0x64, 0x69, 0x63,
+	0x69, 0x6e, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x6d, 0x65,
+	0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x49, 0x64, 0x22, 0x4b, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x44,
+	0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35,
+	0x0a, 0x08, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x64, 0x69,
+	0x63, 0x69, 0x6e, 0x65, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x08, 0x6d, 0x65, 0x64,
+	0x69, 0x63, 0x69, 0x6e, 0x65, 0x32, 0x84, 0x02, 0x0a, 0x0f, 0x4d, 0x65, 0x64, 0x69, 0x63, 0x69,
+	0x6e, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x56, 0x0a, 0x0f, 0x53, 0x65, 0x61,
+	0x72, 0x63, 0x68, 0x4d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x73, 0x12, 0x20, 0x2e, 0x70,
+	0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4d, 0x65,
+	0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21,
+	0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68,
+	0x4d, 0x65, 0x64, 0x69, 0x63, 0x69, 0x6e, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x50, 0x0a, 0x0d, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x41, 0x6e, 0x61, 0x6c, 0x6f,
+	0x67, 0x73, 0x12, 0x1e, 0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65,
+	0x61, 0x72, 0x63, 0x68, 0x41, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65,
+	0x61, 0x72, 0x63, 0x68, 0x41, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x47, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c,
+	0x73, 0x12, 0x1b, 0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74,
+	0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c,
+	0x2e, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x74,
+	0x61, 0x69, 0x6c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x27, 0x5a, 0x25,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6e, 0x69, 0x67, 0x68, 0x74,
+	0x68, 0x74, 0x72, 0x2f, 0x70, 0x69, 0x6c, 0x6c, 0x73, 0x2d, 0x62, 0x6f, 0x74, 0x2f, 0x70, 0x69,
+	0x6c, 0x6c, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pills_proto_rawDescOnce sync.Once
+	file_pills_proto_rawDescData = file_pills_proto_rawDesc
+)
+
+func file_pills_proto_rawDescGZIP() []byte {
+	file_pills_proto_rawDescOnce.Do(func() {
+		file_pills_proto_rawDescData = protoimpl.X.CompressGZIP(file_pills_proto_rawDescData)
+	})
+	return file_pills_proto_rawDescData
+}
+
+var file_pills_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_pills_proto_goTypes = []interface{}{
+	(*Medicine)(nil),                // 0: pills.v1.Medicine
+	(*MedicineInfo)(nil),            // 1: pills.v1.MedicineInfo
+	(*Analog)(nil),                  // 2: pills.v1.Analog
+	(*MedicineForm)(nil),            // 3: pills.v1.MedicineForm
+	(*MedicineDetails)(nil),         // 4: pills.v1.MedicineDetails
+	(*SearchMedicinesRequest)(nil),  // 5: pills.v1.SearchMedicinesRequest
+	(*SearchMedicinesResponse)(nil), // 6: pills.v1.SearchMedicinesResponse
+	(*SearchAnalogsRequest)(nil),    // 7: pills.v1.SearchAnalogsRequest
+	(*SearchAnalogsResponse)(nil),   // 8: pills.v1.SearchAnalogsResponse
+	(*GetDetailsRequest)(nil),       // 9: pills.v1.GetDetailsRequest
+	(*GetDetailsResponse)(nil),      // 10: pills.v1.GetDetailsResponse
+}
+var file_pills_proto_depIdxs = []int32{
+	3,  // 0: pills.v1.MedicineDetails.forms:type_name -> pills.v1.MedicineForm
+	0,  // 1: pills.v1.SearchMedicinesResponse.medicines:type_name -> pills.v1.Medicine
+	1,  // 2: pills.v1.SearchAnalogsResponse.medicine:type_name -> pills.v1.MedicineInfo
+	2,  // 3: pills.v1.SearchAnalogsResponse.analogs:type_name -> pills.v1.Analog
+	4,  // 4: pills.v1.GetDetailsResponse.medicine:type_name -> pills.v1.MedicineDetails
+	5,  // 5: pills.v1.MedicineService.SearchMedicines:input_type -> pills.v1.SearchMedicinesRequest
+	7,  // 6: pills.v1.MedicineService.SearchAnalogs:input_type -> pills.v1.SearchAnalogsRequest
+	9,  // 7: pills.v1.MedicineService.GetDetails:input_type -> pills.v1.GetDetailsRequest
+	6,  // 8: pills.v1.MedicineService.SearchMedicines:output_type -> pills.v1.SearchMedicinesResponse
+	8,  // 9: pills.v1.MedicineService.SearchAnalogs:output_type -> pills.v1.SearchAnalogsResponse
+	10, // 10: pills.v1.MedicineService.GetDetails:output_type -> pills.v1.GetDetailsResponse
+	8,  // [8:11] is the sub-list for method output_type
+	5,  // [5:8] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_pills_proto_init() }
+func file_pills_proto_init() {
+	if File_pills_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pills_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Medicine); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MedicineInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Analog); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MedicineForm); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MedicineDetails); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchMedicinesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchMedicinesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchAnalogsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchAnalogsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDetailsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pills_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDetailsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pills_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pills_proto_goTypes,
+		DependencyIndexes: file_pills_proto_depIdxs,
+		MessageInfos:      file_pills_proto_msgTypes,
+	}.Build()
+	File_pills_proto = out.File
+	file_pills_proto_rawDesc = nil
+	file_pills_proto_goTypes = nil
+	file_pills_proto_depIdxs = nil
+}
@@ -0,0 +1,189 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: pills.proto
+
+package pillspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	MedicineService_SearchMedicines_FullMethodName = "/pills.v1.MedicineService/SearchMedicines"
+	MedicineService_SearchAnalogs_FullMethodName   = "/pills.v1.MedicineService/SearchAnalogs"
+	MedicineService_GetDetails_FullMethodName      = "/pills.v1.MedicineService/GetDetails"
+)
+
+// MedicineServiceClient is the client API for MedicineService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MedicineServiceClient interface {
+	// SearchMedicines ищет лекарства по названию в стране пользователя
+	SearchMedicines(ctx context.Context, in *SearchMedicinesRequest, opts ...grpc.CallOption) (*SearchMedicinesResponse, error)
+	// SearchAnalogs ищет аналоги лекарства в стране поиска
+	SearchAnalogs(ctx context.Context, in *SearchAnalogsRequest, opts ...grpc.CallOption) (*SearchAnalogsResponse, error)
+	// GetDetails возвращает формы выпуска и дозировку лекарства
+	GetDetails(ctx context.Context, in *GetDetailsRequest, opts ...grpc.CallOption) (*GetDetailsResponse, error)
+}
+
+type medicineServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMedicineServiceClient(cc grpc.ClientConnInterface) MedicineServiceClient {
+	return &medicineServiceClient{cc}
+}
+
+func (c *medicineServiceClient) SearchMedicines(ctx context.Context, in *SearchMedicinesRequest, opts ...grpc.CallOption) (*SearchMedicinesResponse, error) {
+	out := new(SearchMedicinesResponse)
+	err := c.cc.Invoke(ctx, MedicineService_SearchMedicines_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *medicineServiceClient) SearchAnalogs(ctx context.Context, in *SearchAnalogsRequest, opts ...grpc.CallOption) (*SearchAnalogsResponse, error) {
+	out := new(SearchAnalogsResponse)
+	err := c.cc.Invoke(ctx, MedicineService_SearchAnalogs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *medicineServiceClient) GetDetails(ctx context.Context, in *GetDetailsRequest, opts ...grpc.CallOption) (*GetDetailsResponse, error) {
+	out := new(GetDetailsResponse)
+	err := c.cc.Invoke(ctx, MedicineService_GetDetails_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MedicineServiceServer is the server API for MedicineService service.
+// All implementations must embed UnimplementedMedicineServiceServer
+// for forward compatibility
+type MedicineServiceServer interface {
+	// SearchMedicines ищет лекарства по названию в стране пользователя
+	SearchMedicines(context.Context, *SearchMedicinesRequest) (*SearchMedicinesResponse, error)
+	// SearchAnalogs ищет аналоги лекарства в стране поиска
+	SearchAnalogs(context.Context, *SearchAnalogsRequest) (*SearchAnalogsResponse, error)
+	// GetDetails возвращает формы выпуска и дозировку лекарства
+	GetDetails(context.Context, *GetDetailsRequest) (*GetDetailsResponse, error)
+	mustEmbedUnimplementedMedicineServiceServer()
+}
+
+// UnimplementedMedicineServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMedicineServiceServer struct {
+}
+
+func (UnimplementedMedicineServiceServer) SearchMedicines(context.Context, *SearchMedicinesRequest) (*SearchMedicinesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchMedicines not implemented")
+}
+func (UnimplementedMedicineServiceServer) SearchAnalogs(context.Context, *SearchAnalogsRequest) (*SearchAnalogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchAnalogs not implemented")
+}
+func (UnimplementedMedicineServiceServer) GetDetails(context.Context, *GetDetailsRequest) (*GetDetailsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDetails not implemented")
+}
+func (UnimplementedMedicineServiceServer) mustEmbedUnimplementedMedicineServiceServer() {}
+
+// UnsafeMedicineServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MedicineServiceServer will
+// result in compilation errors.
+type UnsafeMedicineServiceServer interface {
+	mustEmbedUnimplementedMedicineServiceServer()
+}
+
+func RegisterMedicineServiceServer(s grpc.ServiceRegistrar, srv MedicineServiceServer) {
+	s.RegisterService(&MedicineService_ServiceDesc, srv)
+}
+
+func _MedicineService_SearchMedicines_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchMedicinesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MedicineServiceServer).SearchMedicines(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MedicineService_SearchMedicines_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MedicineServiceServer).SearchMedicines(ctx, req.(*SearchMedicinesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MedicineService_SearchAnalogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchAnalogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MedicineServiceServer).SearchAnalogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MedicineService_SearchAnalogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MedicineServiceServer).SearchAnalogs(ctx, req.(*SearchAnalogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MedicineService_GetDetails_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDetailsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MedicineServiceServer).GetDetails(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MedicineService_GetDetails_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MedicineServiceServer).GetDetails(ctx, req.(*GetDetailsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MedicineService_ServiceDesc is the grpc.ServiceDesc for MedicineService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MedicineService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pills.v1.MedicineService",
+	HandlerType: (*MedicineServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SearchMedicines",
+			Handler:    _MedicineService_SearchMedicines_Handler,
+		},
+		{
+			MethodName: "SearchAnalogs",
+			Handler:    _MedicineService_SearchAnalogs_Handler,
+		},
+		{
+			MethodName: "GetDetails",
+			Handler:    _MedicineService_GetDetails_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pills.proto",
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// nearbyRadius радиус поиска аптек в метрах
+	nearbyRadius = 1500
+	// nearbyLimit количество предлагаемых аптек
+	nearbyLimit = 5
+	// locationTTL время, в течение которого отправленная геопозиция считается актуальной
+	locationTTL = 6 * time.Hour
+)
+
+// Place аптека рядом с пользователем
+type Place struct {
+	Name      string
+	Address   string
+	Latitude  float64
+	Longitude float64
+	// Distance расстояние до пользователя в метрах
+	Distance float64
+}
+
+// PlacesProvider определяет страну по координатам и ищет аптеки поблизости
+type PlacesProvider interface {
+	CountryCode(ctx context.Context, latitude, longitude float64) (string, error)
+	NearbyPharmacies(ctx context.Context, latitude, longitude float64) ([]Place, error)
+}
+
+// OSMPlaces использует Nominatim и Overpass API OpenStreetMap
+type OSMPlaces struct {
+	UserAgent string
+}
+
+func (o *OSMPlaces) get(ctx context.Context, address string, result any) error {
+	request, err := http.NewRequestWithContext(ctx, "GET", address, nil)
+	if err != nil {
+		return err
+	}
+	// Правила использования Nominatim требуют указывать User-Agent приложения
+	request.Header.Add("User-Agent", o.UserAgent)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("openstreetmap: %s", response.Status)
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (o *OSMPlaces) CountryCode(ctx context.Context, latitude, longitude float64) (string, error) {
+	query := url.Values{}
+	query.Set("format", "json")
+	query.Set("zoom", "3")
+	query.Set("lat", fmt.Sprintf("%f", latitude))
+	query.Set("lon", fmt.Sprintf("%f", longitude))
+
+	reverse := struct {
+		Address struct {
+			CountryCode string `json:"country_code"`
+		} `json:"address"`
+	}{}
+	if err := o.get(ctx, "https://nominatim.openstreetmap.org/reverse?"+query.Encode(), &reverse); err != nil {
+		return "", err
+	}
+
+	return strings.ToUpper(reverse.Address.CountryCode), nil
+}
+
+func (o *OSMPlaces) NearbyPharmacies(ctx context.Context, latitude, longitude float64) ([]Place, error) {
+	data := fmt.Sprintf(`[out:json][timeout:15];node["amenity"="pharmacy"](around:%d,%f,%f);out body %d;`,
+		nearbyRadius, latitude, longitude, nearbyLimit*4)
+
+	overpass := struct {
+		Elements []struct {
+			Lat  float64           `json:"lat"`
+			Lon  float64           `json:"lon"`
+			Tags map[string]string `json:"tags"`
+		} `json:"elements"`
+	}{}
+	if err := o.get(ctx, "https://overpass-api.de/api/interpreter?data="+url.QueryEscape(data), &overpass); err != nil {
+		return nil, err
+	}
+
+	places := []Place{}
+	for _, element := range overpass.Elements {
+		place := Place{
+			Name:      element.Tags["name"],
+			Address:   strings.TrimSpace(element.Tags["addr:street"] + " " + element.Tags["addr:housenumber"]),
+			Latitude:  element.Lat,
+			Longitude: element.Lon,
+			Distance:  distance(latitude, longitude, element.Lat, element.Lon),
+		}
+		if len(place.Name) == 0 {
+			place.Name = "Аптека"
+		}
+		places = append(places, place)
+	}
+	sort.Slice(places, func(i, j int) bool {
+		return places[i].Distance < places[j].Distance
+	})
+	if len(places) > nearbyLimit {
+		places = places[:nearbyLimit]
+	}
+
+	return places, nil
+}
+
+// newPlacesProvider создает поиск мест по настройке PLACES_PROVIDER, nil если он отключен
+func newPlacesProvider(name string) PlacesProvider {
+	switch name {
+	case "osm":
+		return &OSMPlaces{UserAgent: "pills-bot (https://github.com/nighthtr/pills-bot)"}
+	}
+
+	return nil
+}
+
+// distance возвращает расстояние между точками в метрах
+func distance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	const earthRadius = 6371000
+	radians := math.Pi / 180
+	dLatitude := (latitude2 - latitude1) * radians
+	dLongitude := (longitude2 - longitude1) * radians
+	a := math.Sin(dLatitude/2)*math.Sin(dLatitude/2) +
+		math.Cos(latitude1*radians)*math.Cos(latitude2*radians)*math.Sin(dLongitude/2)*math.Sin(dLongitude/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// hasRecentLocation проверяет, что чат недавно отправлял геопозицию
+func hasRecentLocation(settings ChatSettings) bool {
+	return !settings.LocatedAt.IsZero() && time.Since(settings.LocatedAt) < locationTTL
+}
+
+func locationHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	message := update.Message
+	location := message.Location
+	if location == nil && message.Venue != nil {
+		location = &message.Venue.Location
+	}
+
+	settings := Storage.UpdateChatSettings(message.Chat.ID, func(settings *ChatSettings) {
+		settings.Latitude = location.Latitude
+		settings.Longitude = location.Longitude
+		settings.LocatedAt = time.Now()
+	})
+
+	// Часовой пояс по геопозиции определяется, только если его не задали явно
+	inferTimezone := len(settings.Timezone) == 0 || settings.TimezoneInferred
+	if inferTimezone && Places == nil {
+		setInferredTimezone(message.Chat.ID, approximateTimezone(location.Longitude))
+	}
+
+	if Places == nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: message.Chat.ID,
+			Text:   "Определение страны по геопозиции недоступно.",
+		})
+		return
+	}
+
+	AppMetrics.Incr("places_requests")
+
+	code, err := Places.CountryCode(ctx, location.Latitude, location.Longitude)
+	if err != nil {
+		logError(err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: message.Chat.ID,
+			Text:   "Не удалось определить страну по геопозиции. Попробуйте позже.",
+		})
+		return
+	}
+
+	country, ok := countryByCode(code)
+	if inferTimezone {
+		timezone := country.Timezone
+		if len(timezone) == 0 {
+			timezone = approximateTimezone(location.Longitude)
+		}
+		setInferredTimezone(message.Chat.ID, timezone)
+	}
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: message.Chat.ID,
+			Text:   "К сожалению, для этой страны поиск аналогов пока не поддерживается.",
+		})
+		return
+	}
+
+	Storage.UpdateChatSettings(message.Chat.ID, func(settings *ChatSettings) {
+		settings.CountryID = country.ID
+	})
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    message.Chat.ID,
+		Text:      fmt.Sprintf("Страна поиска: %s. Теперь я ищу аналоги, которые продаются здесь, и подскажу ближайшие аптеки под списком аналогов.", bold(country.Name)),
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+func nearbyPharmaciesHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	settings := Storage.ChatSettings(chatID)
+	if Places == nil || !hasRecentLocation(settings) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Отправьте геопозицию, чтобы я нашел аптеки рядом.",
+		})
+		return
+	}
+
+	AppMetrics.Incr("places_requests")
+
+	places, err := Places.NearbyPharmacies(ctx, settings.Latitude, settings.Longitude)
+	if err != nil {
+		logError(err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Не удалось найти аптеки. Попробуйте позже.",
+		})
+		return
+	}
+	if len(places) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Поблизости не нашлось аптек.",
+		})
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(bold("Аптеки рядом") + "\n")
+	for index, place := range places {
+		mapURL := fmt.Sprintf("https://www.openstreetmap.org/?mlat=%f&mlon=%f#map=18/%f/%f",
+			place.Latitude, place.Longitude, place.Latitude, place.Longitude)
+		text.WriteString(fmt.Sprintf("\n%d. %s, %d м", index+1, link(place.Name, mapURL), int(place.Distance)))
+		if len(place.Address) > 0 {
+			text.WriteString("\n" + italic(place.Address))
+		}
+	}
+	text.WriteString("\n\nНаличие лекарств уточняйте в аптеке.")
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               text.String(),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+}
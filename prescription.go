@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// PrescriptionItem лекарство, распознанное в рецепте
+type PrescriptionItem struct {
+	Name   string
+	Dosage string
+}
+
+// PrescriptionReview хранит ход подтверждения распознанных лекарств
+type PrescriptionReview struct {
+	Items    []PrescriptionItem
+	Index    int
+	Accepted []string
+}
+
+// PrescriptionReviews незавершенные подтверждения рецептов по чатам
+var PrescriptionReviews = NewRecentMap[int64, PrescriptionReview](1000)
+
+// dosageRegexp находит дозировку вида 500 мг, 2,5 mg, 100 МЕ
+var dosageRegexp = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(мг|mg|мкг|mcg|µg|г|g|мл|ml|ме|iu|%)(?:[^\p{L}]|$)`)
+
+// prescriptionPrefixRegexp убирает пометки рецептурного бланка перед названием
+var prescriptionPrefixRegexp = regexp.MustCompile(`(?i)^\s*(?:\d+[.)]\s*|rp\.?:?\s*|recipe:?\s*)+`)
+
+// parsePrescription выбирает из текста рецепта строки с дозировкой
+// и берет из каждой название лекарства перед дозировкой
+func parsePrescription(text string) []PrescriptionItem {
+	seen := map[string]bool{}
+	items := []PrescriptionItem{}
+	for _, line := range strings.Split(text, "\n") {
+		match := dosageRegexp.FindStringSubmatchIndex(line)
+		if match == nil {
+			continue
+		}
+
+		candidates := medicineCandidates(prescriptionPrefixRegexp.ReplaceAllString(line[:match[0]], ""))
+		if len(candidates) == 0 {
+			continue
+		}
+
+		name := candidates[0]
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+
+		items = append(items, PrescriptionItem{
+			Name:   name,
+			Dosage: strings.ReplaceAll(line[match[2]:match[3]], ",", ".") + " " + strings.ToLower(line[match[4]:match[5]]),
+		})
+		if len(items) == bulkLimit {
+			break
+		}
+	}
+
+	return items
+}
+
+func prescriptionHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if OCR == nil || !flagEnabled("ocr") {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Распознавание фотографий недоступно. Введите названия лекарств текстом.",
+		})
+		return
+	}
+
+	ChatDialogs.Start(update.Message.Chat.ID, "prescription", nil)
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "Отправьте фотографию рецепта. Я найду в нем лекарства и попрошу подтвердить каждое. Для отмены отправьте /cancel.",
+	})
+}
+
+// prescriptionDialog напоминает, что сценарий ждет фотографию, а не текст
+func prescriptionDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	ChatDialogs.Start(update.Message.Chat.ID, "prescription", dialog.Data)
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "Жду фотографию рецепта. Для отмены отправьте /cancel.",
+	})
+}
+
+// isPrescriptionPhoto проверяет, ждет ли чат фотографию рецепта или она подписана как рецепт
+func isPrescriptionPhoto(message *models.Message) bool {
+	if strings.Contains(strings.ToLower(message.Caption), "рецепт") {
+		return true
+	}
+
+	dialog, ok := ChatDialogs.Peek(message.Chat.ID)
+	if !ok || dialog.Kind != "prescription" {
+		return false
+	}
+	ChatDialogs.Take(message.Chat.ID)
+
+	return true
+}
+
+// handlePrescription распознает рецепт и начинает подтверждение найденных лекарств
+func handlePrescription(ctx context.Context, b *bot.Bot, message *models.Message, image []byte) {
+	AppMetrics.Incr("ocr_requests")
+
+	text, err := OCR.Recognize(ctx, image)
+	if err != nil {
+		logError(err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: message.Chat.ID,
+			Text:   "Не удалось распознать рецепт. Попробуйте сфотографировать его при хорошем освещении.",
+		})
+		return
+	}
+
+	items := parsePrescription(text)
+	if len(items) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: message.Chat.ID,
+			Text:   "Не удалось найти в рецепте лекарства с дозировкой. Введите названия текстом или загрузите список файлом.",
+		})
+		return
+	}
+
+	review := PrescriptionReview{Items: items}
+	PrescriptionReviews.Set(message.Chat.ID, review)
+
+	text, markup := prescriptionReviewReply(review)
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      message.Chat.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: markup,
+	})
+}
+
+func prescriptionReviewReply(review PrescriptionReview) (string, *models.InlineKeyboardMarkup) {
+	item := review.Items[review.Index]
+	text := fmt.Sprintf("Лекарство %d из %d: %s, %s\nИскать для него аналоги?",
+		review.Index+1, len(review.Items), bold(item.Name), escapeHTML(item.Dosage))
+
+	return text, &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: "✅ Да", CallbackData: "prescription:yes"},
+				{Text: "Пропустить", CallbackData: "prescription:no"},
+			},
+		},
+	}
+}
+
+func prescriptionCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	review, ok := PrescriptionReviews.Get(chatID)
+	if !ok || review.Index >= len(review.Items) {
+		return
+	}
+	// Подтверждение остается на месте, после принятия предупреждения кнопку можно нажать снова
+	if !allowSearch(ctx, b, &update.CallbackQuery.From, PendingSearch{ChatID: chatID}, 0) {
+		return
+	}
+
+	if update.CallbackQuery.Data == "prescription:yes" {
+		review.Accepted = append(review.Accepted, review.Items[review.Index].Name)
+	}
+	review.Index++
+	PrescriptionReviews.Set(chatID, review)
+
+	message := update.CallbackQuery.Message.Message
+	if review.Index < len(review.Items) {
+		text, markup := prescriptionReviewReply(review)
+		if message != nil {
+			b.EditMessageText(ctx, &bot.EditMessageTextParams{
+				ChatID:      chatID,
+				MessageID:   message.ID,
+				Text:        text,
+				ParseMode:   models.ParseModeHTML,
+				ReplyMarkup: markup,
+			})
+		}
+		return
+	}
+
+	if message != nil {
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: message.ID,
+			Text:      fmt.Sprintf("Выбрано лекарств: %d из %d.", len(review.Accepted), len(review.Items)),
+		})
+	}
+
+	if len(review.Accepted) == 0 {
+		return
+	}
+	if !consumeQuota(&update.CallbackQuery.From, len(review.Accepted)) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("Выбрано %d лекарств, это больше остатка дневного лимита. %s", len(review.Accepted), quotaRemaining(&update.CallbackQuery.From)),
+		})
+		return
+	}
+
+	AppMetrics.Incr("bulk_searches")
+	sendBulkReport(ctx, b, chatID, &update.CallbackQuery.From, bulkSearch(ctx, review.Accepted, targetCountry(chatID)))
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// PriceAlert целевая цена аналога из списка слежения. LastPrice самая низкая цена в валюте
+// Currency при последней проверке, по ней видно, что цена опустилась ниже цели впервые.
+// UserID автор цели, в группе он может не совпадать с автором слежения
+type PriceAlert struct {
+	UserID     int64     `json:"user_id,omitempty"`
+	AnalogID   string    `json:"analog_id"`
+	AnalogName string    `json:"analog_name"`
+	Target     float64   `json:"target"`
+	Currency   string    `json:"currency"`
+	LastPrice  float64   `json:"last_price,omitempty"`
+	NotifiedAt time.Time `json:"notified_at,omitempty"`
+	Paused     bool      `json:"paused,omitempty"`
+}
+
+// cheapestPrice самое дешевое предложение в валюте currency, любой валюте если она пустая
+func cheapestPrice(prices []Price, currency string) (Price, bool) {
+	cheapest := Price{}
+	found := false
+	for _, price := range prices {
+		if len(currency) > 0 && !strings.EqualFold(price.Currency, currency) {
+			continue
+		}
+		if !found || price.Amount < cheapest.Amount {
+			cheapest, found = price, true
+		}
+	}
+
+	return cheapest, found
+}
+
+// priceAlertText строка целевой цены для /watchlist
+func priceAlertText(alert PriceAlert) string {
+	text := fmt.Sprintf("💰 %s дешевле %s %s", alert.AnalogName, formatMoney(alert.Target), alert.Currency)
+	if alert.Paused {
+		text += ", на паузе"
+	}
+	if alert.LastPrice > 0 {
+		text += fmt.Sprintf(", сейчас %s", formatMoney(alert.LastPrice))
+	}
+
+	return escapeHTML(text)
+}
+
+// priceAlertHandler предлагает выбрать аналог для целевой цены: price_alert:<запись>
+func priceAlertHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	watchID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "price_alert:"))
+	watch, ok := watchByID(chatID, watchID)
+	country, countryOK := countryByID(watch.CountryID)
+	if !ok || !countryOK || Prices == nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Цены для этого лекарства недоступны.",
+		})
+		return
+	}
+
+	analogs := append([]Analog{}, watch.Analogs...)
+	pills.SortAnalogs(analogs)
+	buttons := [][]models.InlineKeyboardButton{}
+	for _, analog := range analogs {
+		if len(buttons) == priceAnalogs {
+			break
+		}
+		prices, err := Prices.Prices(ctx, country.Code, analog.AnalogName)
+		if err != nil {
+			logError(err)
+			continue
+		}
+		cheapest, found := cheapestPrice(prices, "")
+		if !found {
+			continue
+		}
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{
+				Text:         fmt.Sprintf("%s, от %s %s", analog.AnalogName, formatMoney(cheapest.Amount), cheapest.Currency),
+				CallbackData: "price_target:" + strconv.Itoa(watch.ID) + ":" + analog.AnalogID,
+			},
+		})
+	}
+	if len(buttons) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Цены на аналоги не найдены.",
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        fmt.Sprintf("Выберите аналог %s, за ценой которого следить:", bold(watch.MedicineName)),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: buttons},
+	})
+}
+
+// priceTargetHandler спрашивает целевую цену аналога: price_target:<запись>:<аналог>
+func priceTargetHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	parts := strings.Split(strings.TrimPrefix(update.CallbackQuery.Data, "price_target:"), ":")
+	if len(parts) != 2 {
+		return
+	}
+	watchID, _ := strconv.Atoi(parts[0])
+	watch, ok := watchByID(chatID, watchID)
+	country, countryOK := countryByID(watch.CountryID)
+	if !ok || !countryOK || Prices == nil {
+		return
+	}
+	analog := Analog{}
+	for _, candidate := range watch.Analogs {
+		if candidate.AnalogID == parts[1] {
+			analog = candidate
+		}
+	}
+	if len(analog.AnalogID) == 0 {
+		return
+	}
+
+	prices, err := Prices.Prices(ctx, country.Code, analog.AnalogName)
+	if err != nil {
+		logError(err)
+	}
+	cheapest, found := cheapestPrice(prices, "")
+	if !found {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Цены на этот аналог не найдены.",
+		})
+		return
+	}
+
+	ChatDialogs.Start(chatID, "price_target", map[string]string{
+		"watch_id":    strconv.Itoa(watch.ID),
+		"user_id":     strconv.FormatInt(update.CallbackQuery.From.ID, 10),
+		"analog_id":   analog.AnalogID,
+		"analog_name": analog.AnalogName,
+		"currency":    cheapest.Currency,
+	})
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text: fmt.Sprintf("Сейчас %s стоит от %s %s. Напишите цену в %s, ниже которой сообщить. 0 удалит уведомление, /cancel отменит.",
+			analog.AnalogName, formatMoney(cheapest.Amount), cheapest.Currency, cheapest.Currency),
+	})
+}
+
+func priceTargetDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	chatID := update.Message.Chat.ID
+	target, err := parseNumber(strings.TrimSpace(update.Message.Text))
+	if err != nil || target < 0 {
+		ChatDialogs.Start(chatID, "price_target", dialog.Data)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Напишите цену числом, например 150. /cancel отменит.",
+		})
+		return
+	}
+
+	watchID, _ := strconv.Atoi(dialog.Data["watch_id"])
+	if _, ok := watchByID(chatID, watchID); !ok {
+		return
+	}
+	userID, _ := strconv.ParseInt(dialog.Data["user_id"], 10, 64)
+	alert := PriceAlert{
+		UserID:     userID,
+		AnalogID:   dialog.Data["analog_id"],
+		AnalogName: dialog.Data["analog_name"],
+		Target:     target,
+		Currency:   dialog.Data["currency"],
+	}
+	Storage.UpdateWatch(watchID, func(watch *Watch) {
+		alerts := []PriceAlert{}
+		for _, existing := range watch.PriceAlerts {
+			if existing.AnalogID != alert.AnalogID {
+				alerts = append(alerts, existing)
+			}
+		}
+		if target > 0 {
+			alerts = append(alerts, alert)
+		}
+		watch.PriceAlerts = alerts
+	})
+
+	text := fmt.Sprintf("Сообщу, когда %s будет дешевле %s %s.", alert.AnalogName, formatMoney(target), alert.Currency)
+	if target == 0 {
+		text = "Уведомление о цене " + alert.AnalogName + " удалено."
+	} else {
+		AppMetrics.Incr("price_alerts")
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+	})
+}
+
+// watchByID запись слежения чата chatID
+func watchByID(chatID int64, watchID int) (Watch, bool) {
+	for _, watch := range Storage.Watches(chatID) {
+		if watch.ID == watchID {
+			return watch, true
+		}
+	}
+
+	return Watch{}, false
+}
+
+// checkPriceAlerts проверяет цены аналогов с целевой ценой и сообщает, когда самая низкая
+// цена впервые опускается ниже цели. Пока цена остается ниже, повторных уведомлений нет
+func checkPriceAlerts(ctx context.Context, b *bot.Bot, now time.Time) {
+	if Prices == nil {
+		return
+	}
+
+	for _, watch := range Storage.AllWatches() {
+		if ctx.Err() != nil {
+			return
+		}
+		if watch.Paused || len(watch.PriceAlerts) == 0 {
+			continue
+		}
+		if _, quiet := chatQuietUntil(watch.ChatID, now); quiet {
+			continue
+		}
+		country, ok := countryByID(watch.CountryID)
+		if !ok {
+			continue
+		}
+
+		for _, alert := range watch.PriceAlerts {
+			if alert.Paused {
+				continue
+			}
+			prices, err := Prices.Prices(ctx, country.Code, alert.AnalogName)
+			if err != nil {
+				logError(err)
+				continue
+			}
+			cheapest, found := cheapestPrice(prices, alert.Currency)
+			if !found {
+				continue
+			}
+			dropped := cheapest.Amount < alert.Target && !(alert.LastPrice > 0 && alert.LastPrice < alert.Target)
+			Storage.UpdateWatch(watch.ID, func(watch *Watch) {
+				for index := range watch.PriceAlerts {
+					if watch.PriceAlerts[index].AnalogID == alert.AnalogID {
+						watch.PriceAlerts[index].LastPrice = cheapest.Amount
+						if dropped {
+							watch.PriceAlerts[index].NotifiedAt = now
+						}
+					}
+				}
+				if dropped {
+					watch.addChange(now, fmt.Sprintf("💰 %s подешевел до %s %s", escapeHTML(alert.AnalogName), formatMoney(cheapest.Amount), escapeHTML(cheapest.Currency)))
+				}
+			})
+			if !dropped {
+				continue
+			}
+
+			AppMetrics.Incr("price_alert_notifications")
+			_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: watch.ChatID,
+				Text: fmt.Sprintf("💰 %s (%s) подешевел: %s, цель была %s %s.",
+					bold(alert.AnalogName), escapeHTML(country.Name), formatPrice(cheapest), formatMoney(alert.Target), escapeHTML(alert.Currency)),
+				ParseMode:          models.ParseModeHTML,
+				LinkPreviewOptions: disabledLinkPreview(),
+				ReplyMarkup:        watchMarkup(watch),
+			})
+			if err != nil {
+				logError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+const (
+	// priceListTTL как часто перечитывается прайс-лист аптечной сети
+	priceListTTL = 12 * time.Hour
+	// priceAnalogs для скольких лучших аналогов показываются цены
+	priceAnalogs = 5
+	// pricesPerAnalog сколько самых дешевых предложений показывается для аналога
+	pricesPerAnalog = 3
+)
+
+// Price цена лекарства в аптеке страны поиска
+type Price struct {
+	Name     string  `json:"name"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Pharmacy string  `json:"pharmacy,omitempty"`
+	URL      string  `json:"url,omitempty"`
+}
+
+// PriceProvider находит цены лекарства по торговому названию в стране с кодом country
+type PriceProvider interface {
+	Prices(ctx context.Context, country string, name string) ([]Price, error)
+}
+
+// HTTPPrices цены из внешнего сервиса: GET URL?country=TR&name=advil отвечает {"prices": [...]}
+type HTTPPrices struct {
+	URL    string
+	client http.Client
+}
+
+func (h *HTTPPrices) Prices(ctx context.Context, country string, name string) ([]Price, error) {
+	query := url.Values{}
+	query.Set("country", country)
+	query.Set("name", name)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New("prices: " + response.Status)
+	}
+
+	var result struct {
+		Prices []Price `json:"prices"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.Prices, nil
+}
+
+// PriceList открытый прайс-лист аптечной сети в CSV: название, цена, валюта и ссылка.
+// Source путь к файлу или адрес, по которому сеть публикует прайс-лист
+type PriceList struct {
+	Source   string
+	Pharmacy string
+	// Country код страны, в которой работает сеть
+	Country string
+	client  http.Client
+
+	mu       sync.Mutex
+	items    []Price
+	loadedAt time.Time
+}
+
+func (l *PriceList) open(ctx context.Context) (io.ReadCloser, error) {
+	if !strings.HasPrefix(l.Source, "http://") && !strings.HasPrefix(l.Source, "https://") {
+		return os.Open(l.Source)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, l.Source, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := l.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, errors.New("price list: " + response.Status)
+	}
+
+	return response.Body, nil
+}
+
+// load читает строки «название,цена,валюта[,ссылка]», строки с ошибками пропускаются
+func (l *PriceList) load(ctx context.Context) ([]Price, error) {
+	body, err := l.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Price{}
+	for _, record := range records {
+		if len(record) < 3 {
+			continue
+		}
+		amount, err := parseNumber(strings.TrimSpace(record[1]))
+		if err != nil || amount <= 0 {
+			continue
+		}
+		price := Price{
+			Name:     strings.TrimSpace(record[0]),
+			Amount:   amount,
+			Currency: strings.ToUpper(strings.TrimSpace(record[2])),
+			Pharmacy: l.Pharmacy,
+		}
+		if len(record) > 3 {
+			price.URL = strings.TrimSpace(record[3])
+		}
+		items = append(items, price)
+	}
+
+	return items, nil
+}
+
+func (l *PriceList) Prices(ctx context.Context, country string, name string) ([]Price, error) {
+	if len(l.Country) > 0 && !strings.EqualFold(l.Country, country) {
+		return []Price{}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.loadedAt) > priceListTTL {
+		items, err := l.load(ctx)
+		if err != nil && len(l.items) == 0 {
+			return nil, err
+		}
+		if err != nil {
+			// Пока сеть недоступна, отвечает прежний прайс-лист
+			logError(err)
+		} else {
+			l.items = items
+		}
+		l.loadedAt = time.Now()
+	}
+
+	query := normalizeMedicineName(name)
+	prices := []Price{}
+	if len(query) == 0 {
+		return prices, nil
+	}
+	for _, item := range l.items {
+		// Варианты марки вроде «Advil Cold» другие лекарства, совпадать должно название целиком
+		if normalizeMedicineName(item.Name) == query {
+			prices = append(prices, item)
+		}
+	}
+
+	return prices, nil
+}
+
+// MultiPrices опрашивает все источники цен и объединяет ответы
+type MultiPrices []PriceProvider
+
+func (m MultiPrices) Prices(ctx context.Context, country string, name string) ([]Price, error) {
+	prices := []Price{}
+	var lastErr error
+	for _, provider := range m {
+		found, err := provider.Prices(ctx, country, name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		prices = append(prices, found...)
+	}
+	if len(prices) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	sort.SliceStable(prices, func(i, j int) bool {
+		if prices[i].Currency != prices[j].Currency {
+			return prices[i].Currency < prices[j].Currency
+		}
+		return prices[i].Amount < prices[j].Amount
+	})
+
+	return prices, nil
+}
+
+// newPriceProvider создает источники цен по списку из PRICE_PROVIDERS: http для внешнего
+// сервиса PRICES_URL, list для прайс-листа аптечной сети PRICE_LIST, nil если цены отключены
+func newPriceProvider(names string) PriceProvider {
+	providers := MultiPrices{}
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "http":
+			if address := os.Getenv("PRICES_URL"); len(address) > 0 {
+				providers = append(providers, &HTTPPrices{URL: address})
+			}
+		case "list":
+			if source := os.Getenv("PRICE_LIST"); len(source) > 0 {
+				providers = append(providers, &PriceList{
+					Source:   source,
+					Pharmacy: os.Getenv("PRICE_LIST_PHARMACY"),
+					Country:  os.Getenv("PRICE_LIST_COUNTRY"),
+				})
+			}
+		}
+	}
+	if len(providers) == 0 {
+		return nil
+	}
+
+	return providers
+}
+
+// formatPrice строка предложения: цена, аптека и ссылка
+func formatPrice(price Price) string {
+	text := fmt.Sprintf("%s %s", formatMoney(price.Amount), price.Currency)
+	pharmacy := price.Pharmacy
+	if len(pharmacy) == 0 {
+		pharmacy = "аптека"
+	}
+	if len(price.URL) > 0 {
+		return text + " — " + link(pharmacy, price.URL)
+	}
+
+	return text + " — " + escapeHTML(pharmacy)
+}
+
+// showMedicineHandler показывает цены лучших аналогов в стране поиска: show_medicine:<лекарство>
+func showMedicineHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	medicineID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "show_medicine:"))
+	country, ok := countryByID(targetCountry(chatID))
+	if Prices == nil || !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Цены для этой страны пока недоступны.",
+		})
+		return
+	}
+	result, err := findAnalogsContext(ctx, medicineID, country.ID)
+	if err != nil {
+		return
+	}
+
+	analogs := append([]Analog{}, result.Analogs...)
+	pills.SortAnalogs(analogs)
+	var text strings.Builder
+	text.WriteString(bold("💰 Цены: "+country.Name) + "\n")
+	found := 0
+	for index, analog := range analogs {
+		if index == priceAnalogs {
+			break
+		}
+		prices, err := Prices.Prices(ctx, country.Code, analog.AnalogName)
+		if err != nil {
+			logError(err)
+			continue
+		}
+		if len(prices) == 0 {
+			continue
+		}
+		found++
+		text.WriteString("\n" + bold(analog.AnalogName))
+		for priceIndex, price := range prices {
+			if priceIndex == pricesPerAnalog {
+				break
+			}
+			text.WriteString("\n• " + formatPrice(price))
+		}
+	}
+	if found == 0 {
+		text.WriteString("\nЦены на аналоги не найдены.")
+	}
+	text.WriteString("\n\n" + italic("Цены справочные, уточняйте их в аптеке."))
+
+	AppMetrics.Incr("price_requests")
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               text.String(),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+	if err != nil {
+		logError(err)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// PrivacyExport все данные пользователя, которые хранит бот
+type PrivacyExport struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	User       User           `json:"user"`
+	History    []HistoryEntry `json:"history"`
+	Favorites  []Favorite     `json:"favorites"`
+	Reminders  []Reminder     `json:"reminders"`
+	Doses      []Dose         `json:"doses"`
+	Trips      []Trip         `json:"trips"`
+	Shares     []SharedList   `json:"shares"`
+	Chat       *ChatSettings  `json:"chat_settings,omitempty"`
+	Reports    []Report       `json:"reports"`
+	Watches    []Watch        `json:"watches"`
+	// PriceAlerts цели цены пользователя в слежении, которое завел другой участник группы
+	PriceAlerts []PriceAlert `json:"price_alerts"`
+}
+
+// analyticsAllowed проверяет, что пользователь не отказался от участия в статистике
+func analyticsAllowed(from *models.User) bool {
+	if from == nil {
+		return true
+	}
+
+	return analyticsAllowedID(from.ID)
+}
+
+// analyticsAllowedID то же для пользователя по идентификатору, например автора напоминания
+func analyticsAllowedID(userID int64) bool {
+	user, ok := Storage.User(userID)
+
+	return !ok || !user.AnalyticsDisabled
+}
+
+func privacyHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message.From == nil {
+		return
+	}
+	if update.Message.Chat.Type != models.ChatTypePrivate {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Настройки приватности доступны в личном чате с ботом.",
+		})
+		return
+	}
+
+	Storage.TouchUser(update.Message.From)
+	data := Storage.UserExport(update.Message.From.ID, time.Now())
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        privacyStatus(data),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: privacyMarkup(data.User),
+	})
+}
+
+func privacyStatus(data PrivacyExport) string {
+	enabled := func(value bool) string {
+		if value {
+			return "включена"
+		}
+		return "выключена"
+	}
+
+	var text strings.Builder
+	text.WriteString(bold("Приватность") + "\n\nЧто хранится о вас:\n")
+	text.WriteString(fmt.Sprintf("• история поиска: %d записей\n", len(data.History)))
+	text.WriteString(fmt.Sprintf("• избранное: %d\n", len(data.Favorites)))
+	text.WriteString(fmt.Sprintf("• напоминания: %d, отметки приема: %d\n", len(data.Reminders), len(data.Doses)))
+	text.WriteString(fmt.Sprintf("• поездки: %d, общие списки: %d\n", len(data.Trips), len(data.Shares)))
+	alerts := len(data.PriceAlerts)
+	for _, watch := range data.Watches {
+		alerts += len(watch.PriceAlerts)
+	}
+	text.WriteString(fmt.Sprintf("• слежение за аналогами: %d, цели цены: %d\n", len(data.Watches), alerts))
+	text.WriteString(fmt.Sprintf("• жалобы на данные: %d\n", len(data.Reports)))
+	text.WriteString(fmt.Sprintf("\nЗапись истории: %s\n", bold(enabled(!data.User.HistoryDisabled))))
+	text.WriteString(fmt.Sprintf("Участие в статистике: %s\n", bold(enabled(!data.User.AnalyticsDisabled))))
+	text.WriteString("\n" + italic("Без истории /stats и /feedback не смогут опираться на ваши последние запросы. Статистика используется только в обезличенном виде."))
+
+	return text.String()
+}
+
+func privacyMarkup(user User) *models.InlineKeyboardMarkup {
+	toggle := func(title string, disabled bool) string {
+		if disabled {
+			return "▶️ Включить " + title
+		}
+		return "⏸ Выключить " + title
+	}
+
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: toggle("историю", user.HistoryDisabled), CallbackData: "privacy:history"}},
+			{{Text: toggle("статистику", user.AnalyticsDisabled), CallbackData: "privacy:analytics"}},
+			{{Text: "📦 Выгрузить мои данные", CallbackData: "privacy:export"}},
+			{{Text: "🗑 Удалить все мои данные", CallbackData: "privacy:delete"}},
+		},
+	}
+}
+
+func privacyCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	query := update.CallbackQuery
+	chatID := callbackChatID(query)
+	userID := query.From.ID
+	switch strings.TrimPrefix(query.Data, "privacy:") {
+	case "history":
+		Storage.UpdatePrivacy(&query.From, func(user *User) {
+			user.HistoryDisabled = !user.HistoryDisabled
+		})
+	case "analytics":
+		Storage.UpdatePrivacy(&query.From, func(user *User) {
+			user.AnalyticsDisabled = !user.AnalyticsDisabled
+		})
+	case "export":
+		body, err := json.MarshalIndent(Storage.UserExport(userID, time.Now()), "", "  ")
+		if err != nil {
+			logError(err)
+			return
+		}
+		_, err = b.SendDocument(ctx, &bot.SendDocumentParams{
+			ChatID:   chatID,
+			Document: &models.InputFileUpload{Filename: "my-data.json", Data: bytes.NewReader(body)},
+			Caption:  "Все данные, которые бот хранит о вас",
+		})
+		if err != nil {
+			logError(err)
+		}
+		return
+	case "delete":
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Удалить историю, избранное, напоминания, поездки, слежение за аналогами, цели цены и настройки? Это нельзя отменить.",
+			ReplyMarkup: &models.InlineKeyboardMarkup{
+				InlineKeyboard: [][]models.InlineKeyboardButton{{
+					{Text: "Да, удалить", CallbackData: "privacy:delete_confirm"},
+				}},
+			},
+		})
+		return
+	case "delete_confirm":
+		Storage.DeleteUserData(userID)
+		AppMetrics.Incr("privacy_deletions")
+		editCallbackMessage(ctx, b, query, "Ваши данные удалены.")
+		return
+	default:
+		return
+	}
+
+	if query.Message.Message == nil {
+		return
+	}
+	data := Storage.UserExport(userID, time.Now())
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   query.Message.Message.ID,
+		Text:        privacyStatus(data),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: privacyMarkup(data.User),
+	})
+	if err != nil {
+		logError(err)
+	}
+}
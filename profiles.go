@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// defaultProfile профиль владельца чата, существует всегда
+	defaultProfile = "я"
+	profilesLimit  = 8
+	// profileNameLimit ограничивает длину имени, чтобы оно помещалось в callback_data
+	profileNameLimit = 20
+)
+
+// activeProfile возвращает выбранный в чате профиль
+func activeProfile(chatID int64) string {
+	if profile := Storage.ChatSettings(chatID).ActiveProfile; len(profile) > 0 {
+		return profile
+	}
+
+	return defaultProfile
+}
+
+// chatProfiles возвращает все профили чата, профиль по умолчанию первый
+func chatProfiles(settings ChatSettings) []string {
+	return append([]string{defaultProfile}, settings.Profiles...)
+}
+
+// profileLabel добавляет имя профиля к тексту, если в чате больше одного профиля
+func profileLabel(chatID int64, profile string) string {
+	if len(Storage.ChatSettings(chatID).Profiles) == 0 {
+		return ""
+	}
+	if len(profile) == 0 {
+		profile = defaultProfile
+	}
+
+	return "[" + profile + "] "
+}
+
+func profilesMarkup(settings ChatSettings) *models.InlineKeyboardMarkup {
+	active := settings.ActiveProfile
+	if len(active) == 0 {
+		active = defaultProfile
+	}
+
+	buttons := [][]models.InlineKeyboardButton{}
+	for _, profile := range chatProfiles(settings) {
+		title := profile
+		if profile == active {
+			title = "✅ " + profile
+		}
+		row := []models.InlineKeyboardButton{
+			{Text: title, CallbackData: "profile_switch:" + profile},
+		}
+		if profile != defaultProfile {
+			row = append(row, models.InlineKeyboardButton{Text: "Удалить", CallbackData: "profile_delete:" + profile})
+		}
+		buttons = append(buttons, row)
+	}
+
+	return &models.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+func profileHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	_, name, _ := strings.Cut(update.Message.Text, " ")
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	if len(name) == 0 {
+		settings := Storage.ChatSettings(chatID)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        "Профили помогают вести лекарства и напоминания для нескольких человек, например: /profile мама\n\nВыберите профиль, для которого сохранять избранное и напоминания:",
+			ReplyMarkup: profilesMarkup(settings),
+		})
+		return
+	}
+
+	if utf8.RuneCountInString(name) > profileNameLimit || strings.Contains(name, ":") {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("Имя профиля должно быть не длиннее %d символов и без двоеточий.", profileNameLimit),
+		})
+		return
+	}
+
+	created := false
+	limited := false
+	Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+		for _, profile := range chatProfiles(*settings) {
+			if profile == name {
+				settings.ActiveProfile = name
+				return
+			}
+		}
+		if len(settings.Profiles) >= profilesLimit {
+			limited = true
+			return
+		}
+		settings.Profiles = append(settings.Profiles, name)
+		settings.ActiveProfile = name
+		created = true
+	})
+
+	text := fmt.Sprintf("Текущий профиль: %s.", bold(name))
+	switch {
+	case limited:
+		text = "Слишком много профилей. Удалите ненужные в /profile."
+	case created:
+		text = fmt.Sprintf("Профиль %s создан и выбран. Избранное и новые напоминания будут сохраняться для него.", bold(name))
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+func profileSwitchHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	name := strings.TrimPrefix(update.CallbackQuery.Data, "profile_switch:")
+	settings := Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+		for _, profile := range chatProfiles(*settings) {
+			if profile == name {
+				settings.ActiveProfile = name
+			}
+		}
+	})
+
+	editProfilesMessage(ctx, b, update.CallbackQuery, settings)
+}
+
+func profileDeleteHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	name := strings.TrimPrefix(update.CallbackQuery.Data, "profile_delete:")
+	settings := Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+		profiles := []string{}
+		for _, profile := range settings.Profiles {
+			if profile != name {
+				profiles = append(profiles, profile)
+			}
+		}
+		settings.Profiles = profiles
+		delete(settings.Health, name)
+		if settings.ActiveProfile == name {
+			settings.ActiveProfile = ""
+		}
+	})
+
+	// Напоминания удаленного профиля больше некому показывать
+	for _, reminder := range Storage.Reminders(chatID) {
+		if reminder.Profile == name {
+			Storage.DeleteReminder(reminder.ID)
+		}
+	}
+
+	editProfilesMessage(ctx, b, update.CallbackQuery, settings)
+}
+
+func editProfilesMessage(ctx context.Context, b *bot.Bot, query *models.CallbackQuery, settings ChatSettings) {
+	if query.Message.Message == nil {
+		return
+	}
+
+	active := settings.ActiveProfile
+	if len(active) == 0 {
+		active = defaultProfile
+	}
+
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      query.Message.Message.Chat.ID,
+		MessageID:   query.Message.Message.ID,
+		Text:        fmt.Sprintf("Текущий профиль: %s", active),
+		ReplyMarkup: profilesMarkup(settings),
+	})
+	if err != nil {
+		logError(err)
+	}
+}
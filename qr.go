@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/skip2/go-qrcode"
+)
+
+// qrSize размер стороны изображения QR кода в пикселях
+const qrSize = 512
+
+// medicineDeepLink ссылка t.me/<bot>?start=med_<id>, открывающая аналоги лекарства в боте
+func medicineDeepLink(medicineID int) string {
+	if len(BotUsername) == 0 {
+		return ""
+	}
+
+	return "https://t.me/" + BotUsername + "?start=med_" + strconv.Itoa(medicineID)
+}
+
+// analogQRHandler отправляет QR код: analog_qr:<лекарство>:<аналог> ведет на страницу аналога
+// на pillintrip, analog_qr:<лекарство> открывает аналоги лекарства в боте
+func analogQRHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	parts := strings.Split(update.CallbackQuery.Data, ":")
+	medicineID, _ := strconv.Atoi(parts[1])
+
+	url := medicineDeepLink(medicineID)
+	caption := "Отсканируйте, чтобы открыть аналоги в боте"
+	if len(parts) == 3 {
+		result, err := findAnalogsContext(ctx, medicineID, targetCountry(chatID))
+		if err != nil {
+			return
+		}
+		url = ""
+		for _, analog := range result.Analogs {
+			if analog.AnalogID == parts[2] {
+				url = analogURL(analog)
+				caption = analog.AnalogName + " на pillintrip.com"
+				break
+			}
+		}
+	}
+	if len(url) == 0 {
+		return
+	}
+
+	image, err := qrcode.Encode(url, qrcode.Medium, qrSize)
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	AppMetrics.Incr("qr_codes")
+	_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID:  chatID,
+		Photo:   &models.InputFileUpload{Filename: "qr.png", Data: bytes.NewReader(image)},
+		Caption: caption + "\n" + url,
+	})
+	if err != nil {
+		logError(err)
+	}
+}
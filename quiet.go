@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// quietUntil возвращает окончание тихих часов чата, если now попадает в них.
+// Окно может переходить через полночь, например 23:00-07:00
+func quietUntil(settings ChatSettings, location *time.Location, now time.Time) (time.Time, bool) {
+	if len(settings.QuietStart) == 0 || len(settings.QuietEnd) == 0 {
+		return time.Time{}, false
+	}
+	start, err := time.Parse("15:04", settings.QuietStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := time.Parse("15:04", settings.QuietEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := now.In(location)
+	minutes := local.Hour()*60 + local.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+
+	var quiet bool
+	if startMinutes <= endMinutes {
+		quiet = minutes >= startMinutes && minutes < endMinutes
+	} else {
+		quiet = minutes >= startMinutes || minutes < endMinutes
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, location)
+	if !until.After(now) {
+		until = until.AddDate(0, 0, 1)
+	}
+
+	return until, true
+}
+
+// chatQuietUntil проверяет тихие часы чата в его часовом поясе
+func chatQuietUntil(chatID int64, now time.Time) (time.Time, bool) {
+	return quietUntil(Storage.ChatSettings(chatID), chatLocation(chatID), now)
+}
+
+// runOutsideQuietHours выполняет отправку сразу или откладывает ее до конца тихих часов чата.
+// Отложенная отправка не переживает перезапуск, поэтому подходит для сводок,
+// а напоминания откладываются через хранилище
+func runOutsideQuietHours(ctx context.Context, chatID int64, send func(ctx context.Context)) {
+	until, quiet := chatQuietUntil(chatID, time.Now())
+	if !quiet {
+		send(ctx)
+		return
+	}
+
+	go func() {
+		timer := time.NewTimer(time.Until(until))
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+			send(ctx)
+		}
+	}()
+}
+
+func quietHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	_, arg, _ := strings.Cut(update.Message.Text, " ")
+	arg = strings.ToLower(strings.TrimSpace(arg))
+
+	var text string
+	switch {
+	case len(arg) == 0:
+		settings := Storage.ChatSettings(chatID)
+		text = "Тихие часы выключены."
+		if len(settings.QuietStart) > 0 {
+			text = fmt.Sprintf("Тихие часы: %s–%s (%s).", settings.QuietStart, settings.QuietEnd, chatLocation(chatID).String())
+		}
+		text += "\n\nВ тихие часы напоминания и рассылки откладываются до их окончания. Пример: /quiet 23:00-07:00, выключить: /quiet off"
+	case arg == "off" || arg == "выкл":
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.QuietStart = ""
+			settings.QuietEnd = ""
+		})
+		text = "Тихие часы выключены."
+	default:
+		startText, endText, _ := strings.Cut(strings.ReplaceAll(arg, "–", "-"), "-")
+		start, startErr := time.Parse("15:04", strings.TrimSpace(startText))
+		end, endErr := time.Parse("15:04", strings.TrimSpace(endText))
+		if startErr != nil || endErr != nil || start.Equal(end) {
+			text = "Укажите начало и конец тихих часов, например: /quiet 23:00-07:00"
+			break
+		}
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.QuietStart = start.Format("15:04")
+			settings.QuietEnd = end.Format("15:04")
+		})
+		text = fmt.Sprintf("Тихие часы: %s–%s (%s). Напоминания в это время придут после их окончания.",
+			start.Format("15:04"), end.Format("15:04"), chatLocation(chatID).String())
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+	})
+}
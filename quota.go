@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// QuotaTiers дневные лимиты поисков по тарифам из QUOTA_TIERS, 0 снимает лимит.
+// Пустой список отключает лимиты
+var QuotaTiers = map[string]int{}
+
+// DefaultQuotaTier тариф пользователей без назначенного тарифа из QUOTA_DEFAULT_TIER
+var DefaultQuotaTier = "free"
+
+// parseQuotaTiers разбирает список вида free:30,plus:100,unlimited:0
+func parseQuotaTiers(value string) map[string]int {
+	tiers := map[string]int{}
+	for _, item := range strings.Split(value, ",") {
+		name, limitValue, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || len(name) == 0 {
+			continue
+		}
+		limit, err := strconv.Atoi(limitValue)
+		if err != nil || limit < 0 {
+			continue
+		}
+		tiers[strings.ToLower(name)] = limit
+	}
+
+	return tiers
+}
+
+func quotaDay(now time.Time) string {
+	return now.Format("2006-01-02")
+}
+
+// userQuota возвращает тариф и дневной лимит пользователя, 0 без ограничения.
+// Пользователи с ролью не ограничиваются
+func userQuota(userID int64) (string, int) {
+	if userRole(userID) != "" {
+		return "admin", 0
+	}
+
+	tier := DefaultQuotaTier
+	if user, ok := Storage.User(userID); ok && len(user.QuotaTier) > 0 {
+		tier = user.QuotaTier
+	}
+
+	return tier, QuotaTiers[tier]
+}
+
+// consumeQuota списывает count поисков из дневного лимита пользователя, если они помещаются целиком
+func consumeQuota(from *models.User, count int) bool {
+	if len(QuotaTiers) == 0 || from == nil {
+		return true
+	}
+
+	_, limit := userQuota(from.ID)
+	if Storage.ConsumeQuota(from, quotaDay(time.Now()), limit, count) {
+		return true
+	}
+	AppMetrics.Incr("quota_exceeded")
+
+	return false
+}
+
+// quotaAvailable проверяет без списания, что у пользователя остался хотя бы один поиск на сегодня
+func quotaAvailable(from *models.User) bool {
+	if len(QuotaTiers) == 0 || from == nil {
+		return true
+	}
+
+	_, limit := userQuota(from.ID)
+
+	return limit == 0 || Storage.QuotaUsed(from.ID, quotaDay(time.Now())) < limit
+}
+
+// quotaRemaining подпись с остатком лимита на сегодня, пустая без ограничения
+func quotaRemaining(from *models.User) string {
+	if len(QuotaTiers) == 0 || from == nil {
+		return ""
+	}
+
+	_, limit := userQuota(from.ID)
+	if limit == 0 {
+		return ""
+	}
+	remaining := limit - Storage.QuotaUsed(from.ID, quotaDay(time.Now()))
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return fmt.Sprintf("Осталось поисков на сегодня: %d из %d", remaining, limit)
+}
+
+func quotaExceededText(from *models.User) string {
+	_, limit := userQuota(from.ID)
+
+	return fmt.Sprintf("Дневной лимит поисков (%d) исчерпан, он обновится завтра. Посмотреть лимит: /limits", limit)
+}
+
+// limitsHandler показывает тариф и остаток дневного лимита
+func limitsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message.From == nil {
+		return
+	}
+
+	text := "Поиск без ограничений."
+	tier, limit := userQuota(update.Message.From.ID)
+	if len(QuotaTiers) > 0 && limit > 0 {
+		text = fmt.Sprintf("Тариф: %s\n%s", bold(escapeHTML(tier)), quotaRemaining(update.Message.From))
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+// quotaHandler назначает тариф пользователю: /quota <id пользователя> <тариф>, тариф default
+// возвращает тариф по умолчанию
+func quotaHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	tiers := []string{}
+	for name, limit := range QuotaTiers {
+		tiers = append(tiers, fmt.Sprintf("%s:%d", name, limit))
+	}
+	sort.Strings(tiers)
+
+	args := strings.Fields(update.Message.Text)
+	if len(args) != 3 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Использование: /quota <id пользователя> <тариф|default>\nТарифы: " + strings.Join(tiers, ", "),
+		})
+		return
+	}
+
+	userID, err := strconv.ParseInt(args[1], 10, 64)
+	tier := strings.ToLower(args[2])
+	if _, ok := QuotaTiers[tier]; err != nil || (!ok && tier != "default") {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Неверный пользователь или тариф. Тарифы: " + strings.Join(tiers, ", "),
+		})
+		return
+	}
+	if tier == "default" {
+		tier = ""
+	}
+
+	Storage.SetQuotaTier(userID, tier)
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("Пользователю %d назначен тариф %s.", userID, args[2]),
+	})
+}
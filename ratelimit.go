@@ -0,0 +1,98 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiterCleanup размер карты корзин, после которого из нее удаляются неактивные ключи
+const rateLimiterCleanup = 10000
+
+// RateLimiter ограничивает частоту запросов по ключу алгоритмом token bucket
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter разрешает perMinute запросов в минуту с запасом burst, perMinute 0 снимает ограничение
+func NewRateLimiter(perMinute int, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// Allow расходует запрос из корзины key и сообщает, разрешен ли он
+func (l *RateLimiter) Allow(key string) bool {
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= rateLimiterCleanup {
+			l.cleanup(now)
+		}
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.updated).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+
+	return true
+}
+
+// Retry возвращает, через сколько в корзине key появится запрос
+func (l *RateLimiter) Retry(key string) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		return 0
+	}
+	tokens := bucket.tokens + time.Since(bucket.updated).Seconds()*l.rate
+	if tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - tokens) / l.rate * float64(time.Second))
+}
+
+// cleanup удаляет заполненные корзины, чтобы карта не росла бесконечно
+func (l *RateLimiter) cleanup(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
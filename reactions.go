@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type ResultRef struct {
+	MedicineID   int
+	MedicineName string
+}
+
+// AnalogMessages связывает отправленные списки аналогов с лекарством, для которого они найдены
+var AnalogMessages = NewRecentMap[replyKey, ResultRef](1000)
+
+// messageReactionHandler обрабатывает реакции на списки аналогов:
+// 👍 добавляет лекарство в избранное, 👎 отправляет жалобу на данные
+func messageReactionHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	reaction := update.MessageReaction
+	if reaction.User == nil {
+		return
+	}
+
+	ref, ok := AnalogMessages.Get(replyKey{chatID: reaction.Chat.ID, messageID: reaction.MessageID})
+	if !ok {
+		return
+	}
+
+	for _, emoji := range addedReactions(reaction.OldReaction, reaction.NewReaction) {
+		switch emoji {
+		case "👍":
+			text := fmt.Sprintf("%s уже в избранном.", bold(ref.MedicineName))
+			favorite := Favorite{MedicineID: ref.MedicineID, MedicineName: ref.MedicineName}
+			if profile := activeProfile(reaction.Chat.ID); profile != defaultProfile {
+				favorite.Profile = profile
+			}
+			addToSharedLists(reaction.User.ID, favorite)
+			if Storage.AddFavorite(reaction.User.ID, favorite) {
+				text = fmt.Sprintf("%s%s добавлено в избранное.", escapeHTML(profileLabel(reaction.Chat.ID, favorite.Profile)), bold(ref.MedicineName))
+				text += favoriteInteractions(ctx, reaction.User.ID, favorite)
+			}
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    reaction.Chat.ID,
+				Text:      text,
+				ParseMode: models.ParseModeHTML,
+			})
+		case "👎":
+			report := Storage.AddReport(Report{
+				UserID:       reaction.User.ID,
+				ChatID:       reaction.Chat.ID,
+				MedicineID:   ref.MedicineID,
+				MedicineName: ref.MedicineName,
+				Comment:      "Реакция 👎 на список аналогов",
+			})
+			AppMetrics.Incr("reports")
+			if analyticsAllowed(reaction.User) {
+				Webhooks.Emit(EventReportFiled, report)
+			}
+			if AdminChatID != 0 {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID:    AdminChatID,
+					Text:      formatReport(report),
+					ParseMode: models.ParseModeHTML,
+				})
+			}
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: reaction.Chat.ID,
+				Text:   "Спасибо! Мы проверим данные об аналогах.",
+			})
+		}
+	}
+}
+
+// addedReactions возвращает эмодзи, которые появились в новом наборе реакций
+func addedReactions(old []models.ReactionType, current []models.ReactionType) []string {
+	seen := map[string]bool{}
+	for _, reaction := range old {
+		if reaction.ReactionTypeEmoji != nil {
+			seen[reaction.ReactionTypeEmoji.Emoji] = true
+		}
+	}
+
+	added := []string{}
+	for _, reaction := range current {
+		if reaction.ReactionTypeEmoji != nil && !seen[reaction.ReactionTypeEmoji.Emoji] {
+			added = append(added, reaction.ReactionTypeEmoji.Emoji)
+		}
+	}
+
+	return added
+}
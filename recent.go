@@ -0,0 +1,58 @@
+package main
+
+import "sync"
+
+// RecentMap хранит ограниченное количество последних записей, вытесняя самые старые
+type RecentMap[K comparable, V any] struct {
+	mu    sync.Mutex
+	limit int
+	items map[K]V
+	order []K
+}
+
+func NewRecentMap[K comparable, V any](limit int) *RecentMap[K, V] {
+	return &RecentMap[K, V]{
+		limit: limit,
+		items: map[K]V{},
+	}
+}
+
+func (m *RecentMap[K, V]) Set(key K, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.items[key]; !ok {
+		m.order = append(m.order, key)
+	}
+	m.items[key] = value
+
+	if len(m.order) > m.limit {
+		delete(m.items, m.order[0])
+		m.order = m.order[1:]
+	}
+}
+
+func (m *RecentMap[K, V]) Get(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.items[key]
+
+	return value, ok
+}
+
+func (m *RecentMap[K, V]) Delete(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.items[key]; !ok {
+		return
+	}
+	delete(m.items, key)
+	for index, value := range m.order {
+		if value == key {
+			m.order = append(m.order[:index], m.order[index+1:]...)
+			break
+		}
+	}
+}
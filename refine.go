@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// refinementFillers не несут смысла для фильтрации: "только сироп" равно "сироп"
+var refinementFillers = map[string]bool{
+	"только": true,
+	"лишь":   true,
+	"нужен":  true,
+	"нужна":  true,
+	"нужно":  true,
+	"в":      true,
+	"форме":  true,
+}
+
+// Refinement описывает уточнение результатов: слова, которые должны
+// встретиться, и слова после "без", которых быть не должно
+type Refinement struct {
+	Text    string
+	Include []string
+	Exclude []string
+}
+
+func parseRefinement(text string) Refinement {
+	refinement := Refinement{Text: strings.TrimSpace(text)}
+
+	exclude := false
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		word = strings.Trim(word, ".,!?;:«»\"'")
+		switch {
+		case len(word) == 0 || refinementFillers[word]:
+			continue
+		case word == "без" || word == "не":
+			exclude = true
+		case exclude:
+			refinement.Exclude = append(refinement.Exclude, word)
+			exclude = false
+		default:
+			refinement.Include = append(refinement.Include, word)
+		}
+	}
+
+	return refinement
+}
+
+func (r Refinement) Empty() bool {
+	return len(r.Include) == 0 && len(r.Exclude) == 0
+}
+
+func (r Refinement) Match(text string) bool {
+	text = strings.ToLower(text)
+	for _, word := range r.Include {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	for _, word := range r.Exclude {
+		if strings.Contains(text, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// handleRefinement обрабатывает ответ на сообщение с результатами как уточнение этого поиска
+func handleRefinement(ctx context.Context, b *bot.Bot, update *models.Update) bool {
+	message := update.Message
+	if message.ReplyToMessage == nil || len(message.Text) == 0 || !flagEnabled("search") {
+		return false
+	}
+
+	key := replyKey{chatID: message.Chat.ID, messageID: message.ReplyToMessage.ID}
+	refinement := parseRefinement(message.Text)
+	if refinement.Empty() {
+		return false
+	}
+
+	if query, ok := SearchQueries.Get(key); ok {
+		if !allowSearch(ctx, b, message.From, PendingSearch{ChatID: message.Chat.ID, Query: query}, 1) {
+			return true
+		}
+		AppMetrics.Incr("refinements")
+
+		text, markup := medicineSearchReply(ctx, message.Chat.ID, query, refinement)
+		params := &bot.SendMessageParams{
+			ChatID:    message.Chat.ID,
+			Text:      text,
+			ParseMode: models.ParseModeHTML,
+		}
+		if markup != nil {
+			params.ReplyMarkup = markup
+		}
+		sent, err := b.SendMessage(ctx, params)
+		if err != nil {
+			logError(err)
+			return true
+		}
+		SearchQueries.Set(replyKey{chatID: message.Chat.ID, messageID: sent.ID}, query)
+
+		return true
+	}
+
+	if ref, ok := AnalogMessages.Get(key); ok {
+		if !allowSearch(ctx, b, message.From, PendingSearch{ChatID: message.Chat.ID, MedicineID: ref.MedicineID}, 0) {
+			return true
+		}
+		AppMetrics.Incr("refinements")
+
+		analogs, medicineInfo, err := searchAnalogsContext(ctx, ref.MedicineID, targetCountry(message.Chat.ID))
+		filtered := []Analog{}
+		for _, analog := range analogs {
+			if refinement.Match(analog.AnalogName) {
+				filtered = append(filtered, analog)
+			}
+		}
+		if err != nil || len(filtered) == 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    message.Chat.ID,
+				Text:      fmt.Sprintf("Среди аналогов для %s нет подходящих под %s.", bold(ref.MedicineName), bold(refinement.Text)),
+				ParseMode: models.ParseModeHTML,
+			})
+			return true
+		}
+
+		sendAnalogList(ctx, b, message.Chat.ID, ref.MedicineID, medicineInfo, filtered,
+			fmt.Sprintf("Аналоги для %s (%s):", bold(medicineInfo.MedicineName), escapeHTML(refinement.Text)))
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,519 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	// defaultSnoozeMinutes для кнопок без указанного интервала
+	defaultSnoozeMinutes = 15
+	// snoozeLimit максимальный интервал, на который можно отложить напоминание
+	snoozeLimit = 12 * time.Hour
+	// reminderStaleAfter напоминания, пропущенные дольше этого времени
+	// (например, пока бот был выключен), не отправляются
+	reminderStaleAfter = time.Hour
+	// remindersLimit максимальное количество напоминаний в одном чате
+	remindersLimit = 20
+)
+
+type Reminder struct {
+	ID       int      `json:"id"`
+	ChatID   int64    `json:"chat_id"`
+	UserID   int64    `json:"user_id"`
+	Medicine string   `json:"medicine"`
+	Dose     string   `json:"dose,omitempty"`
+	Times    []string `json:"times"`
+	// Timezone часовой пояс напоминания, пустой означает часовой пояс чата
+	Timezone string `json:"timezone,omitempty"`
+	// NextAt время следующего напоминания по расписанию
+	NextAt time.Time `json:"next_at"`
+	// SnoozedUntil время повтора отложенного напоминания
+	SnoozedUntil time.Time `json:"snoozed_until,omitempty"`
+	// PendingDoseID прием, к которому относится последнее отправленное напоминание
+	PendingDoseID int `json:"pending_dose_id,omitempty"`
+	// CourseDays длительность курса, CourseEnd момент его окончания
+	CourseDays int       `json:"course_days,omitempty"`
+	CourseEnd  time.Time `json:"course_end,omitempty"`
+	// PillsLeft оставшийся запас, PillsPerDose сколько таблеток в одном приеме
+	PillsLeft    int  `json:"pills_left,omitempty"`
+	PillsPerDose int  `json:"pills_per_dose,omitempty"`
+	CourseWarned bool `json:"course_warned,omitempty"`
+	SupplyWarned bool `json:"supply_warned,omitempty"`
+	// Profile профиль чата, для которого создано напоминание
+	Profile   string    `json:"profile,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Location возвращает часовой пояс напоминания, не вызывается под блокировкой хранилища
+func (r Reminder) Location() *time.Location {
+	if len(r.Timezone) == 0 {
+		return chatLocation(r.ChatID)
+	}
+
+	location, err := loadTimezone(r.Timezone)
+	if err != nil {
+		return chatLocation(r.ChatID)
+	}
+
+	return location
+}
+
+// Title возвращает название лекарства с дозировкой
+func (r Reminder) Title() string {
+	if len(r.Dose) == 0 {
+		return r.Medicine
+	}
+
+	return r.Medicine + ", " + r.Dose
+}
+
+// nextReminderTime возвращает ближайшее после after время приема из списка times
+// в часовом поясе location. Время считается через time.Date, поэтому переходы
+// на летнее время не сдвигают час приема
+func nextReminderTime(times []string, location *time.Location, after time.Time) time.Time {
+	local := after.In(location)
+	var next time.Time
+	for _, value := range times {
+		clock, err := time.Parse("15:04", value)
+		if err != nil {
+			continue
+		}
+		for day := 0; day < 2; day++ {
+			candidate := time.Date(local.Year(), local.Month(), local.Day()+day, clock.Hour(), clock.Minute(), 0, 0, location)
+			if !candidate.After(after) {
+				continue
+			}
+			if next.IsZero() || candidate.Before(next) {
+				next = candidate
+			}
+			break
+		}
+	}
+
+	return next
+}
+
+// parseReminder разбирает аргументы /remind: название, дозировку, время приема и часовой пояс
+func parseReminder(args string) (Reminder, bool) {
+	reminder := Reminder{}
+	words := []string{}
+	for _, token := range strings.Fields(args) {
+		token = strings.Trim(token, ",;")
+		if clock, err := time.Parse("15:04", token); err == nil {
+			reminder.Times = append(reminder.Times, clock.Format("15:04"))
+			continue
+		}
+		if _, err := loadTimezone(token); err == nil {
+			reminder.Timezone = token
+			continue
+		}
+		words = append(words, token)
+	}
+
+	rest := parseCourse(&reminder, strings.Join(words, " "))
+	if match := dosageRegexp.FindStringSubmatchIndex(rest); match != nil {
+		reminder.Dose = strings.ReplaceAll(rest[match[2]:match[3]], ",", ".") + " " + strings.ToLower(rest[match[4]:match[5]])
+		rest = rest[:match[0]] + rest[match[1]:]
+	}
+	reminder.Medicine = strings.TrimSpace(rest)
+
+	sort.Strings(reminder.Times)
+
+	return reminder, len(reminder.Medicine) > 0 && len(reminder.Times) > 0
+}
+
+const remindUsage = "Чтобы создать напоминание, укажите лекарство, дозировку и время приема, например:\n" +
+	"/remind Нурофен 200 мг 08:00 20:00\n\n" +
+	"Можно указать длительность курса и запас, чтобы я предупредил заранее: /remind Амоксициллин 500 мг 08:00 20:00 курс 7 дней осталось 14 шт\n" +
+	"По умолчанию используется часовой пояс чата (/timezone), другой можно указать в конце: /remind Нурофен 08:00 Europe/Moscow\n" +
+	"Список напоминаний: /reminders"
+
+func remindHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message.From == nil {
+		return
+	}
+
+	_, args, _ := strings.Cut(update.Message.Text, " ")
+	reminder, ok := parseReminder(args)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   remindUsage,
+		})
+		return
+	}
+
+	if len(Storage.Reminders(update.Message.Chat.ID)) >= remindersLimit {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Слишком много напоминаний. Удалите ненужные в /reminders.",
+		})
+		return
+	}
+
+	reminder.ChatID = update.Message.Chat.ID
+	reminder.UserID = update.Message.From.ID
+	if profile := activeProfile(reminder.ChatID); profile != defaultProfile {
+		reminder.Profile = profile
+	}
+	reminder.NextAt = nextReminderTime(reminder.Times, reminder.Location(), time.Now())
+	if reminder.CourseDays > 0 {
+		reminder.CourseEnd = courseEnd(time.Now(), reminder.CourseDays, reminder.Location())
+	}
+	reminder = Storage.AddReminder(reminder)
+
+	AppMetrics.Incr("reminders_created")
+
+	text := fmt.Sprintf("%sНапоминание создано: %s в %s (%s).\nСледующее: %s.",
+		escapeHTML(profileLabel(reminder.ChatID, reminder.Profile)), bold(reminder.Title()), strings.Join(reminder.Times, ", "), escapeHTML(reminder.Location().String()),
+		reminder.NextAt.In(reminder.Location()).Format("02.01 15:04"))
+	if !reminder.CourseEnd.IsZero() {
+		text += fmt.Sprintf("\nКурс до %s включительно.", reminder.CourseEnd.Add(-time.Minute).In(reminder.Location()).Format("02.01"))
+	}
+	if reminder.PillsLeft > 0 {
+		text += fmt.Sprintf("\nЗапаса хватит примерно на %d дн., предупрежу заранее.", supplyDaysLeft(reminder))
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+func remindersHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	reminders := Storage.Reminders(update.Message.Chat.ID)
+	if len(reminders) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Напоминаний нет.\n\n" + remindUsage,
+		})
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(bold("Напоминания") + "\n")
+	buttons := [][]models.InlineKeyboardButton{}
+	for index, reminder := range reminders {
+		text.WriteString(fmt.Sprintf("\n%d. %s%s — %s", index+1, escapeHTML(profileLabel(reminder.ChatID, reminder.Profile)), bold(reminder.Title()), strings.Join(reminder.Times, ", ")))
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{
+				Text:         "Удалить: " + reminder.Title(),
+				CallbackData: "reminder_delete:" + strconv.Itoa(reminder.ID),
+			},
+		})
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      text.String(),
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: buttons,
+		},
+	})
+}
+
+// remindersJob проверяет напоминания каждую минуту, расписание хранится в базе,
+// поэтому напоминания продолжают работать после перезапуска
+func remindersJob(b *bot.Bot) Job {
+	return Job{
+		Name: "reminders",
+		Next: every(time.Minute),
+		Run: func(ctx context.Context) {
+			sendDueReminders(ctx, b, time.Now())
+		},
+	}
+}
+
+func sendDueReminders(ctx context.Context, b *bot.Bot, now time.Time) {
+	for _, reminder := range Storage.DueReminders(now) {
+		// Повтор отложенного напоминания относится к тому же приему
+		scheduled := !reminder.NextAt.After(now)
+		due := reminder.NextAt
+		if !scheduled {
+			due = reminder.SnoozedUntil
+		}
+		stale := now.Sub(due) > reminderStaleAfter
+
+		doseID := reminder.PendingDoseID
+		// Новый прием по расписанию означает, что предыдущий так и не отмечен
+		if scheduled && doseID != 0 {
+			if previous, ok := Storage.Dose(doseID); ok && previous.TakenAt.IsZero() && analyticsAllowedID(reminder.UserID) {
+				Webhooks.Emit(EventReminderMissed, map[string]any{
+					"reminder_id":  reminder.ID,
+					"chat_id":      reminder.ChatID,
+					"medicine":     reminder.Title(),
+					"scheduled_at": previous.ScheduledAt,
+				})
+			}
+		}
+		if scheduled && !stale {
+			doseID = Storage.AddDose(Dose{
+				ReminderID:  reminder.ID,
+				ChatID:      reminder.ChatID,
+				UserID:      reminder.UserID,
+				ScheduledAt: reminder.NextAt,
+			}).ID
+		}
+
+		// В тихие часы напоминание откладывается до их окончания как при отсрочке
+		quietEnd, quiet := chatQuietUntil(reminder.ChatID, now)
+
+		location := reminder.Location()
+		Storage.UpdateReminder(reminder.ID, func(reminder *Reminder) {
+			if !reminder.SnoozedUntil.After(now) {
+				reminder.SnoozedUntil = time.Time{}
+			}
+			if !reminder.NextAt.After(now) {
+				reminder.NextAt = nextReminderTime(reminder.Times, location, now)
+			}
+			reminder.PendingDoseID = doseID
+			if quiet && !stale {
+				reminder.SnoozedUntil = quietEnd
+			}
+		})
+
+		if stale || quiet {
+			continue
+		}
+
+		sendReminder(ctx, b, reminder, doseID)
+		refreshToday(ctx, b, reminder.ChatID, now)
+	}
+}
+
+func sendReminder(ctx context.Context, b *bot.Bot, reminder Reminder, doseID int) {
+	AppMetrics.Incr("reminders_sent")
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      reminder.ChatID,
+		Text:        "⏰ " + escapeHTML(profileLabel(reminder.ChatID, reminder.Profile)) + "Пора принять лекарство: " + bold(reminder.Title()),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: reminderMarkup(reminder.ID, doseID),
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+func reminderMarkup(reminderID int, doseID int) *models.InlineKeyboardMarkup {
+	id := strconv.Itoa(reminderID)
+
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: "✅ Принял", CallbackData: "reminder_taken:" + id + ":" + strconv.Itoa(doseID)},
+			},
+			{
+				{Text: "+15 мин", CallbackData: "reminder_snooze:" + id + ":15"},
+				{Text: "+30 мин", CallbackData: "reminder_snooze:" + id + ":30"},
+				{Text: "+1 час", CallbackData: "reminder_snooze:" + id + ":60"},
+			},
+			{
+				{Text: "Изменить время", CallbackData: "reminder_reschedule:" + id},
+			},
+		},
+	}
+}
+
+// reminderCallback разбирает номер напоминания из кнопки и проверяет, что оно принадлежит чату
+func reminderCallback(update *models.Update) (Reminder, bool) {
+	parts := strings.Split(update.CallbackQuery.Data, ":")
+	if len(parts) < 2 {
+		return Reminder{}, false
+	}
+	reminderID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Reminder{}, false
+	}
+
+	reminder, ok := Storage.Reminder(reminderID)
+	if !ok || reminder.ChatID != callbackChatID(update.CallbackQuery) {
+		return Reminder{}, false
+	}
+
+	return reminder, true
+}
+
+// editCallbackMessage заменяет текст сообщения с кнопкой, убирая клавиатуру
+func editCallbackMessage(ctx context.Context, b *bot.Bot, query *models.CallbackQuery, text string) {
+	if query.Message.Message == nil {
+		return
+	}
+
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    query.Message.Message.Chat.ID,
+		MessageID: query.Message.Message.ID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+func reminderTakenHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	reminder, ok := reminderCallback(update)
+	if !ok {
+		return
+	}
+
+	AppMetrics.Incr("reminders_taken")
+
+	// Кнопки напоминаний, отправленных до учета приемов, не содержат номера приема
+	if parts := strings.Split(update.CallbackQuery.Data, ":"); len(parts) == 3 {
+		doseID, _ := strconv.Atoi(parts[2])
+		if Storage.MarkDoseTaken(doseID, time.Now()) && reminder.PillsLeft > 0 {
+			Storage.UpdateReminder(reminder.ID, func(reminder *Reminder) {
+				perDose := reminder.PillsPerDose
+				if perDose == 0 {
+					perDose = 1
+				}
+				reminder.PillsLeft -= perDose
+				if reminder.PillsLeft < 0 {
+					reminder.PillsLeft = 0
+				}
+			})
+		}
+	}
+
+	refreshToday(ctx, b, reminder.ChatID, time.Now())
+
+	editCallbackMessage(ctx, b, update.CallbackQuery, fmt.Sprintf("✅ %s принято в %s",
+		bold(reminder.Title()), time.Now().In(reminder.Location()).Format("15:04")))
+}
+
+func reminderSnoozeHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	reminder, ok := reminderCallback(update)
+	if !ok {
+		return
+	}
+
+	minutes := defaultSnoozeMinutes
+	if parts := strings.Split(update.CallbackQuery.Data, ":"); len(parts) == 3 {
+		if value, err := strconv.Atoi(parts[2]); err == nil && value > 0 {
+			minutes = value
+		}
+	}
+	duration := time.Duration(minutes) * time.Minute
+	if duration > snoozeLimit {
+		duration = snoozeLimit
+	}
+
+	until := time.Now().Add(duration)
+	Storage.UpdateReminder(reminder.ID, func(reminder *Reminder) {
+		reminder.SnoozedUntil = until
+	})
+
+	editCallbackMessage(ctx, b, update.CallbackQuery, fmt.Sprintf("⏰ %s: напомню в %s",
+		bold(reminder.Title()), until.In(reminder.Location()).Format("15:04")))
+}
+
+func reminderDeleteHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	reminder, ok := reminderCallback(update)
+	if !ok {
+		return
+	}
+
+	Storage.DeleteReminder(reminder.ID)
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    reminder.ChatID,
+		Text:      fmt.Sprintf("Напоминание %s удалено.", bold(reminder.Title())),
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+func reminderRescheduleHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	reminder, ok := reminderCallback(update)
+	if !ok {
+		return
+	}
+
+	ChatDialogs.Start(reminder.ChatID, "reminder_time", map[string]string{
+		"reminder_id": strconv.Itoa(reminder.ID),
+	})
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: reminder.ChatID,
+		Text: fmt.Sprintf("Сейчас %s напоминает в %s. Отправьте новое время приема, например: 09:00 21:00. Для отмены отправьте /cancel.",
+			bold(reminder.Title()), strings.Join(reminder.Times, ", ")),
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+// parseTimes выбирает из текста время приема в формате ЧЧ:ММ
+func parseTimes(text string) []string {
+	times := []string{}
+	for _, token := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == ',' || r == ';' || r == '\n'
+	}) {
+		if clock, err := time.Parse("15:04", token); err == nil {
+			times = append(times, clock.Format("15:04"))
+		}
+	}
+	sort.Strings(times)
+
+	return times
+}
+
+func reminderTimeDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	reminderID, _ := strconv.Atoi(dialog.Data["reminder_id"])
+	reminder, ok := Storage.Reminder(reminderID)
+	if !ok || reminder.ChatID != update.Message.Chat.ID {
+		return
+	}
+
+	times := parseTimes(update.Message.Text)
+	if len(times) == 0 {
+		ChatDialogs.Start(update.Message.Chat.ID, "reminder_time", dialog.Data)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Не понял время. Укажите его в формате ЧЧ:ММ, например: 08:30 20:30. Для отмены отправьте /cancel.",
+		})
+		return
+	}
+
+	location := reminder.Location()
+	reminder, _ = Storage.UpdateReminder(reminder.ID, func(reminder *Reminder) {
+		reminder.Times = times
+		reminder.NextAt = nextReminderTime(times, location, time.Now())
+		reminder.SnoozedUntil = time.Time{}
+	})
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text: fmt.Sprintf("Готово, %s теперь в %s. Следующее напоминание: %s.",
+			bold(reminder.Title()), strings.Join(reminder.Times, ", "), reminder.NextAt.In(location).Format("02.01 15:04")),
+		ParseMode: models.ParseModeHTML,
+	})
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+type Report struct {
+	ID           int       `json:"id"`
+	UserID       int64     `json:"user_id"`
+	ChatID       int64     `json:"chat_id"`
+	MedicineID   int       `json:"medicine_id"`
+	MedicineName string    `json:"medicine_name,omitempty"`
+	AnalogID     int       `json:"analog_id"`
+	AnalogName   string    `json:"analog_name,omitempty"`
+	Comment      string    `json:"comment,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func reportAnalogHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	parts := strings.Split(update.CallbackQuery.Data, ":")
+	if len(parts) != 3 {
+		return
+	}
+
+	analogName := buttonText(update.CallbackQuery.Message.Message, update.CallbackQuery.Data)
+	ChatDialogs.Start(callbackChatID(update.CallbackQuery), "report", map[string]string{
+		"medicine_id": parts[1],
+		"analog_id":   parts[2],
+		"analog_name": analogName,
+	})
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    callbackChatID(update.CallbackQuery),
+		Text:      fmt.Sprintf("Что не так с аналогом %s? Опишите ошибку одним сообщением или отправьте «-», чтобы отправить жалобу без комментария. Для отмены отправьте /cancel.", bold(analogName)),
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+func reportDialog(ctx context.Context, b *bot.Bot, update *models.Update, dialog Dialog) {
+	medicineID, _ := strconv.Atoi(dialog.Data["medicine_id"])
+	analogID, _ := strconv.Atoi(dialog.Data["analog_id"])
+
+	comment := strings.TrimSpace(update.Message.Text)
+	if comment == "-" {
+		comment = ""
+	}
+
+	report := Report{
+		ChatID:       update.Message.Chat.ID,
+		MedicineID:   medicineID,
+		MedicineName: medicineName(update.Message.From, medicineID),
+		AnalogID:     analogID,
+		AnalogName:   dialog.Data["analog_name"],
+		Comment:      comment,
+	}
+	if update.Message.From != nil {
+		report.UserID = update.Message.From.ID
+	}
+	report = Storage.AddReport(report)
+
+	AppMetrics.Incr("reports")
+	if analyticsAllowed(update.Message.From) {
+		Webhooks.Emit(EventReportFiled, report)
+	}
+
+	if AdminChatID != 0 {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    AdminChatID,
+			Text:      formatReport(report),
+			ParseMode: models.ParseModeHTML,
+		})
+		if err != nil {
+			logError(err)
+		}
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   "Спасибо! Мы проверим данные об этом аналоге.",
+	})
+}
+
+func formatReport(report Report) string {
+	var text strings.Builder
+	text.WriteString(bold(fmt.Sprintf("Жалоба #%d на аналог", report.ID)) + "\n")
+	text.WriteString(fmt.Sprintf("Лекарство: %s (id %d)\n", bold(report.MedicineName), report.MedicineID))
+	text.WriteString(fmt.Sprintf("Аналог: %s (id %d)\n", bold(report.AnalogName), report.AnalogID))
+	text.WriteString(fmt.Sprintf("Пользователь: %d", report.UserID))
+	if len(report.Comment) > 0 {
+		text.WriteString("\n\n" + escapeHTML(report.Comment))
+	}
+
+	return text.String()
+}
+
+// buttonText возвращает текст первой кнопки в строке клавиатуры, содержащей кнопку с данными data
+func buttonText(message *models.Message, data string) string {
+	if message == nil || message.ReplyMarkup == nil {
+		return ""
+	}
+
+	for _, row := range message.ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			if button.CallbackData == data {
+				return row[0].Text
+			}
+		}
+	}
+
+	return ""
+}
+
+// medicineName ищет название лекарства в истории поиска пользователя
+func medicineName(from *models.User, medicineID int) string {
+	if from == nil {
+		return ""
+	}
+
+	history := Storage.History(from.ID)
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].MedicineID == medicineID {
+			return history[i].MedicineName
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Restriction правило ввоза лекарства в страну
+type Restriction struct {
+	// Country код страны из COUNTRIES
+	Country   string `json:"country"`
+	Substance string `json:"substance"`
+	// Keywords вещества и торговые названия, по которым правило находится в названии лекарства
+	Keywords []string `json:"keywords"`
+	// Level banned: ввоз запрещен, restricted: нужны документы
+	Level string `json:"level"`
+	Note  string `json:"note"`
+}
+
+//go:embed data/restrictions.json
+var embeddedRestrictions []byte
+
+// Restrictions правила ввоза: встроенные, дополненные файлом RESTRICTIONS_FILE
+var Restrictions = &RestrictionSet{}
+
+type RestrictionSet struct {
+	mu    sync.RWMutex
+	rules []Restriction
+}
+
+// Load загружает встроенные правила и правила из файла path, если он указан.
+// Правила из файла заменяют встроенные правила для тех же стран
+func (s *RestrictionSet) Load(path string) error {
+	rules := []Restriction{}
+	if err := json.Unmarshal(embeddedRestrictions, &rules); err != nil {
+		return err
+	}
+
+	if len(path) > 0 {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		updated := []Restriction{}
+		if err := json.Unmarshal(body, &updated); err != nil {
+			return err
+		}
+
+		countries := map[string]bool{}
+		for _, rule := range updated {
+			countries[strings.ToUpper(rule.Country)] = true
+		}
+		merged := updated
+		for _, rule := range rules {
+			if !countries[strings.ToUpper(rule.Country)] {
+				merged = append(merged, rule)
+			}
+		}
+		rules = merged
+	}
+
+	s.mu.Lock()
+	s.rules = rules
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Find возвращает правила страны countryID, подходящие под названия лекарств
+func (s *RestrictionSet) Find(countryID int, names ...string) []Restriction {
+	country, ok := countryByID(countryID)
+	if !ok {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := []Restriction{}
+	for _, rule := range s.rules {
+		if !strings.EqualFold(rule.Country, country.Code) || !matchesRestriction(rule, names) {
+			continue
+		}
+		found = append(found, rule)
+	}
+
+	return found
+}
+
+func (s *RestrictionSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.rules)
+}
+
+func matchesRestriction(rule Restriction, names []string) bool {
+	for _, name := range names {
+		name = strings.ToLower(name)
+		for _, keyword := range rule.Keywords {
+			if len(keyword) > 0 && strings.Contains(name, strings.ToLower(keyword)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// restrictionWarnings форматирует предупреждения о правилах ввоза в страну countryID
+func restrictionWarnings(countryID int, names ...string) string {
+	rules := Restrictions.Find(countryID, names...)
+	if len(rules) == 0 {
+		return ""
+	}
+
+	countryName := ""
+	if country, ok := countryByID(countryID); ok {
+		countryName = country.Name
+	}
+
+	var text strings.Builder
+	for _, rule := range rules {
+		level := "⚠️ Ограничено"
+		if rule.Level == "banned" {
+			level = "⛔️ Запрещено"
+		}
+		text.WriteString(fmt.Sprintf("\n%s в %s: %s — %s", level, escapeHTML(countryName), bold(rule.Substance), escapeHTML(rule.Note)))
+	}
+	text.WriteString("\n" + italic("Правила меняются, перед поездкой уточните их в посольстве страны."))
+	if entry := entryLink(countryID); len(entry) > 0 {
+		text.WriteString("\n" + entry)
+	}
+
+	return text.String()
+}
+
+// analogNames возвращает названия лекарства и его аналогов для проверки правил ввоза
+func analogNames(medicineName string, analogs []Analog) []string {
+	names := []string{medicineName}
+	for _, analog := range analogs {
+		names = append(names, analog.AnalogName)
+	}
+
+	return names
+}
+
+// restrictionsReloadHandler перечитывает правила ввоза из RESTRICTIONS_FILE
+func restrictionsReloadHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	var text string
+	if err := Restrictions.Load(os.Getenv("RESTRICTIONS_FILE")); err != nil {
+		logError(err)
+		text = "Не удалось обновить правила ввоза: " + err.Error()
+	} else {
+		text = fmt.Sprintf("Правила ввоза обновлены, всего правил: %d.", Restrictions.Len())
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
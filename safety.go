@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// Состояния профиля, при которых предупреждения о безопасности выделяются в каждом результате
+const (
+	conditionPregnancy     = "pregnancy"
+	conditionBreastfeeding = "breastfeeding"
+)
+
+// ProfileHealth особенности здоровья члена семьи, учитываемые в результатах поиска
+type ProfileHealth struct {
+	// Condition беременность или грудное вскармливание, пустое если не отмечено
+	Condition string `json:"condition,omitempty"`
+	// Allergies идентификаторы групп аллергенов или названия отдельных веществ
+	Allergies []string `json:"allergies,omitempty"`
+	// AgeGroup возрастная группа: child, infant или пустая для взрослого
+	AgeGroup string `json:"age_group,omitempty"`
+}
+
+// empty проверяет, что для профиля ничего не отмечено
+func (h ProfileHealth) empty() bool {
+	return len(h.Condition) == 0 && len(h.Allergies) == 0 && len(h.AgeGroup) == 0
+}
+
+// profileHealth возвращает особенности здоровья выбранного в чате профиля
+func profileHealth(chatID int64) ProfileHealth {
+	return Storage.ChatSettings(chatID).Health[activeProfile(chatID)]
+}
+
+// updateProfileHealth изменяет особенности здоровья выбранного профиля
+func updateProfileHealth(chatID int64, update func(health *ProfileHealth)) ProfileHealth {
+	profile := activeProfile(chatID)
+	settings := Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+		if settings.Health == nil {
+			settings.Health = map[string]ProfileHealth{}
+		}
+		health := settings.Health[profile]
+		update(&health)
+		if health.empty() {
+			delete(settings.Health, profile)
+			return
+		}
+		settings.Health[profile] = health
+	})
+
+	return settings.Health[profile]
+}
+
+var safetyLabels = map[SafetyCategory]string{
+	pills.SafetyAllowed:         "✅ разрешено",
+	pills.SafetyCaution:         "⚠️ с осторожностью",
+	pills.SafetyContraindicated: "⛔️ противопоказано",
+}
+
+var conditionLabels = map[string]string{
+	conditionPregnancy:     "🤰 Беременность",
+	conditionBreastfeeding: "🤱 Грудное вскармливание",
+}
+
+// safetyWarnings показывает категории безопасности лекарства и выделяет ту, что важна для профиля
+func safetyWarnings(ctx context.Context, chatID int64, medicineID int) string {
+	if !featureAvailable(chatID, "pregnancy") {
+		return ""
+	}
+
+	health := profileHealth(chatID)
+	details, err := medicineDetails(ctx, medicineID)
+	if err != nil {
+		details = MedicineDetails{}
+	}
+
+	categories := map[string]SafetyCategory{
+		conditionPregnancy:     details.Pregnancy,
+		conditionBreastfeeding: details.Breastfeeding,
+	}
+
+	var text strings.Builder
+	for _, condition := range []string{conditionPregnancy, conditionBreastfeeding} {
+		category := categories[condition]
+		label, known := safetyLabels[category]
+		highlighted := health.Condition == condition
+		switch {
+		case highlighted && !known:
+			text.WriteString("\n❔ " + bold(conditionLabels[condition]+": нет данных о безопасности") + ", посоветуйтесь с врачом")
+		case highlighted && category != pills.SafetyAllowed:
+			text.WriteString("\n❗️ " + bold(conditionLabels[condition]+": "+label) + " — " + escapeHTML(profileLabel(chatID, activeProfile(chatID))) + "посоветуйтесь с врачом")
+		case known:
+			text.WriteString(fmt.Sprintf("\n%s: %s", conditionLabels[condition], label))
+		}
+	}
+
+	return text.String()
+}
+
+// pregnancyHandler /pregnancy отмечает беременность или кормление грудью для выбранного профиля
+func pregnancyHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	if !featureAvailable(chatID, "pregnancy") {
+		sendFeatureUnavailable(ctx, b, chatID)
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        pregnancyStatus(chatID, profileHealth(chatID)),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: pregnancyMarkup(),
+	})
+}
+
+func pregnancyStatus(chatID int64, health ProfileHealth) string {
+	status := "не отмечено"
+	if label, ok := conditionLabels[health.Condition]; ok {
+		status = label
+	}
+
+	return fmt.Sprintf("%sОсобое состояние: %s\n\nЕсли отметить беременность или кормление грудью, предупреждения о безопасности будут выделяться в каждом результате поиска.",
+		escapeHTML(profileLabel(chatID, activeProfile(chatID))), bold(status))
+}
+
+func pregnancyMarkup() *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: conditionLabels[conditionPregnancy], CallbackData: "pregnancy:" + conditionPregnancy},
+				{Text: conditionLabels[conditionBreastfeeding], CallbackData: "pregnancy:" + conditionBreastfeeding},
+			},
+			{{Text: "Снять отметку", CallbackData: "pregnancy:off"}},
+		},
+	}
+}
+
+func pregnancyCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	chatID := callbackChatID(update.CallbackQuery)
+	condition := strings.TrimPrefix(update.CallbackQuery.Data, "pregnancy:")
+	if _, ok := conditionLabels[condition]; !ok {
+		condition = ""
+	}
+	health := updateProfileHealth(chatID, func(health *ProfileHealth) {
+		health.Condition = condition
+	})
+
+	editCallbackMessage(ctx, b, update.CallbackQuery, pregnancyStatus(chatID, health))
+}
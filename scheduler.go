@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// schedulerTick задает точность срабатывания задач
+const schedulerTick = 30 * time.Second
+
+// Job описывает периодическую задачу: Next вычисляет время следующего запуска
+type Job struct {
+	Name string
+	Next func(now time.Time) time.Time
+	Run  func(ctx context.Context)
+}
+
+type scheduledJob struct {
+	Job
+	nextRun time.Time
+	// running выставлен, пока идет запуск задачи
+	running atomic.Bool
+}
+
+// Scheduler запускает задачи по расписанию внутри бота
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs []*scheduledJob
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, &scheduledJob{
+		Job:     job,
+		nextRun: job.Next(time.Now()),
+	})
+	log.Printf("Задача %s запланирована\n", job.Name)
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runDue(ctx, now)
+		}
+	}
+}
+
+func (s *Scheduler) runDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if now.Before(job.nextRun) {
+			continue
+		}
+		job.nextRun = job.Next(now)
+		// Запуски одной задачи не пересекаются, пока идет прошлый, очередной пропускается
+		if !job.running.CompareAndSwap(false, true) {
+			log.Printf("Задача %s еще выполняется, запуск пропущен\n", job.Name)
+			continue
+		}
+		// Сообщения задач уступают очередь ответам пользователям
+		go func(job *scheduledJob) {
+			defer job.running.Store(false)
+			job.Run(withSendPriority(ctx, PriorityBackground))
+		}(job)
+	}
+}
+
+// every запускает задачу с постоянным интервалом
+func every(interval time.Duration) func(time.Time) time.Time {
+	return func(now time.Time) time.Time {
+		return now.Add(interval)
+	}
+}
+
+// dailyAt запускает задачу каждый день в указанное время часового пояса location.
+// Время считается через time.Date, поэтому переход на летнее время не сдвигает запуск
+func dailyAt(hour, minute int, location func() *time.Location) func(time.Time) time.Time {
+	return func(now time.Time) time.Time {
+		now = now.In(location())
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	}
+}
+
+// weeklyAt запускает задачу раз в неделю в указанный день и время часового пояса location
+func weeklyAt(weekday time.Weekday, hour, minute int, location func() *time.Location) func(time.Time) time.Time {
+	return func(now time.Time) time.Time {
+		now = now.In(location())
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		next = next.AddDate(0, 0, int(weekday-next.Weekday()+7)%7)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+)
+
+// Ограничения Telegram: около 30 сообщений в секунду на бота, одно в секунду в личный чат
+// и 20 в минуту в группу
+const (
+	defaultSendRate     = 25
+	privateChatInterval = time.Second
+	groupChatInterval   = 3 * time.Second
+	// senderRetries сколько раз повторяется запрос после ответа 429
+	senderRetries = 2
+)
+
+// SendPriority очередность исходящих запросов, ответы пользователям уходят раньше фоновых рассылок
+type SendPriority int
+
+const (
+	PriorityReply SendPriority = iota
+	PriorityBackground
+)
+
+type sendPriorityKey struct{}
+
+// withSendPriority помечает запросы к Telegram, выполняемые с ctx
+func withSendPriority(ctx context.Context, priority SendPriority) context.Context {
+	return context.WithValue(ctx, sendPriorityKey{}, priority)
+}
+
+func sendPriority(ctx context.Context) SendPriority {
+	priority, _ := ctx.Value(sendPriorityKey{}).(SendPriority)
+
+	return priority
+}
+
+// Sender HTTP клиент бота, который пропускает отправку и редактирование сообщений через очередь
+// с общим ограничением и ограничением на чат. Остальные методы API выполняются сразу
+type Sender struct {
+	client   bot.HttpClient
+	interval time.Duration
+
+	mu sync.Mutex
+	// queues очереди по приоритетам, wake будит диспетчер при новом запросе
+	queues     [2][]*sendTicket
+	wake       chan struct{}
+	globalNext time.Time
+	chatNext   map[int64]time.Time
+}
+
+type sendTicket struct {
+	chatID    int64
+	ready     chan struct{}
+	cancelled bool
+}
+
+// NewSender создает очередь с perSecond запросами в секунду на весь бот
+func NewSender(client bot.HttpClient, perSecond int) *Sender {
+	if perSecond <= 0 {
+		perSecond = defaultSendRate
+	}
+
+	sender := &Sender{
+		client:   client,
+		interval: time.Second / time.Duration(perSecond),
+		wake:     make(chan struct{}, 1),
+		chatNext: map[int64]time.Time{},
+	}
+	go sender.dispatch()
+
+	return sender
+}
+
+func newTelegramSender() *Sender {
+	rate, _ := strconv.Atoi(os.Getenv("TELEGRAM_SEND_RATE"))
+
+	return NewSender(&http.Client{Timeout: time.Minute}, rate)
+}
+
+// throttledMethod отбирает методы, которые отправляют или меняют сообщения в чате
+func throttledMethod(method string) bool {
+	method = strings.ToLower(method)
+	if method == "sendchataction" {
+		return false
+	}
+
+	return strings.HasPrefix(method, "send") || strings.HasPrefix(method, "edit") ||
+		method == "copymessage" || method == "forwardmessage"
+}
+
+func chatInterval(chatID int64) time.Duration {
+	if chatID < 0 {
+		return groupChatInterval
+	}
+
+	return privateChatInterval
+}
+
+func (s *Sender) Do(request *http.Request) (*http.Response, error) {
+	if !throttledMethod(path.Base(request.URL.Path)) {
+		return s.client.Do(request)
+	}
+
+	// Тело читается целиком, чтобы узнать чат и повторить запрос после 429
+	body, err := io.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	chatID := formChatID(request.Header.Get("Content-Type"), body)
+	priority := sendPriority(request.Context())
+
+	for attempt := 0; ; attempt++ {
+		if err := s.wait(request.Context(), chatID, priority); err != nil {
+			return nil, err
+		}
+
+		retry := request.Clone(request.Context())
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+		retry.ContentLength = int64(len(body))
+		response, err := s.client.Do(retry)
+		if err != nil || response.StatusCode != http.StatusTooManyRequests || attempt == senderRetries {
+			return response, err
+		}
+
+		answer, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		AppMetrics.Incr("telegram_429")
+		s.pause(chatID, retryAfter(answer))
+		response.Body = io.NopCloser(bytes.NewReader(answer))
+	}
+}
+
+// formChatID достает chat_id из multipart формы запроса, 0 для inline сообщений без чата
+func formChatID(contentType string, body []byte) int64 {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || len(params["boundary"]) == 0 {
+		return 0
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return 0
+		}
+		if part.FormName() != "chat_id" {
+			continue
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			return 0
+		}
+		chatID, _ := strconv.ParseInt(strings.TrimSpace(string(value)), 10, 64)
+		return chatID
+	}
+}
+
+// retryAfter читает паузу из ответа 429, по умолчанию секунда
+func retryAfter(body []byte) time.Duration {
+	answer := struct {
+		Parameters struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}{}
+	if err := json.Unmarshal(body, &answer); err != nil || answer.Parameters.RetryAfter <= 0 {
+		return time.Second
+	}
+
+	return time.Duration(answer.Parameters.RetryAfter) * time.Second
+}
+
+// pause откладывает отправку в чат, а без чата все отправки, на время retry
+func (s *Sender) pause(chatID int64, retry time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until := time.Now().Add(retry)
+	if chatID == 0 {
+		s.globalNext = until
+		return
+	}
+	s.chatNext[chatID] = until
+}
+
+// wait ставит запрос в очередь и ждет своей очереди или отмены ctx
+func (s *Sender) wait(ctx context.Context, chatID int64, priority SendPriority) error {
+	ticket := &sendTicket{chatID: chatID, ready: make(chan struct{})}
+
+	s.mu.Lock()
+	s.queues[priority] = append(s.queues[priority], ticket)
+	s.mu.Unlock()
+	s.notify()
+
+	select {
+	case <-ticket.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		ticket.cancelled = true
+		s.mu.Unlock()
+		s.notify()
+		return ctx.Err()
+	}
+}
+
+func (s *Sender) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch выпускает запросы по одному: сначала ответы, затем фоновые, пропуская чаты,
+// в которые еще рано отправлять
+func (s *Sender) dispatch() {
+	timer := time.NewTimer(time.Hour)
+	for {
+		delay := s.next(time.Now())
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(delay)
+
+		select {
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// next выпускает готовый запрос и возвращает, через сколько проверить очередь снова
+func (s *Sender) next(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Before(s.globalNext) {
+		return s.globalNext.Sub(now)
+	}
+
+	delay := time.Hour
+	for priority := range s.queues {
+		queue := s.queues[priority][:0]
+		released := false
+		for _, ticket := range s.queues[priority] {
+			if ticket.cancelled {
+				continue
+			}
+			available := s.chatNext[ticket.chatID]
+			if released || now.Before(available) {
+				if !released && available.Sub(now) < delay {
+					delay = available.Sub(now)
+				}
+				queue = append(queue, ticket)
+				continue
+			}
+
+			released = true
+			close(ticket.ready)
+			s.globalNext = now.Add(s.interval)
+			if ticket.chatID != 0 {
+				s.chatNext[ticket.chatID] = now.Add(chatInterval(ticket.chatID))
+			}
+		}
+		s.queues[priority] = queue
+		if released {
+			s.cleanup(now)
+			return s.interval
+		}
+	}
+
+	return delay
+}
+
+// cleanup удаляет чаты, для которых пауза уже закончилась
+func (s *Sender) cleanup(now time.Time) {
+	if len(s.chatNext) < rateLimiterCleanup {
+		return
+	}
+	for chatID, available := range s.chatNext {
+		if now.After(available) {
+			delete(s.chatNext, chatID)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"unicode"
+)
+
+//go:embed data/sensitive.json
+var embeddedSensitiveTerms []byte
+
+// SensitiveTerms группа контролируемых веществ, поиск которых бот не выполняет
+type SensitiveTerms struct {
+	Category string   `json:"category"`
+	Keywords []string `json:"keywords"`
+}
+
+// SensitiveDenylist правила из data/sensitive.json или SENSITIVE_FILE
+var SensitiveDenylist = []SensitiveTerms{}
+
+var errSensitiveQuery = errors.New("поиск контролируемых веществ не выполняется")
+
+// sensitivePolicy ответ на запрос из SensitiveDenylist
+const sensitivePolicy = "🚫 Бот не ищет аналоги наркотических и психоактивных веществ. " +
+	"Если такое вещество назначил врач, уточните замену у него или у фармацевта. " +
+	"Если вам нужна помощь при зависимости, обратитесь к врачу-наркологу — это можно сделать анонимно."
+
+func loadSensitiveDenylist(path string) error {
+	body := embeddedSensitiveTerms
+	if len(path) > 0 {
+		file, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		body = file
+	}
+
+	terms := []SensitiveTerms{}
+	if err := json.Unmarshal(body, &terms); err != nil {
+		return err
+	}
+	SensitiveDenylist = terms
+
+	return nil
+}
+
+// sensitiveCategory находит группу веществ, слово запроса должно начинаться с ключевого слова,
+// чтобы совпадали падежные формы
+func sensitiveCategory(query string) (string, bool) {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+	for _, terms := range SensitiveDenylist {
+		for _, keyword := range terms.Keywords {
+			keyword = strings.ToLower(keyword)
+			for _, word := range words {
+				if len(keyword) > 0 && strings.HasPrefix(word, keyword) {
+					return terms.Category, true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// sensitiveQuery проверяет запрос по SensitiveDenylist и считает совпадения по группам.
+// Сам запрос и пользователь не сохраняются ни в истории, ни в журнале
+func sensitiveQuery(query string) bool {
+	category, ok := sensitiveCategory(query)
+	if !ok {
+		return false
+	}
+
+	AppMetrics.Incr("sensitive_queries")
+	AppMetrics.Incr("sensitive_queries_" + category)
+
+	return true
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// SharedList открывает избранное профиля владельца другим пользователям.
+// Участники видят список владельца напрямую из хранилища, поэтому он всегда актуален
+type SharedList struct {
+	Token     string `json:"token"`
+	OwnerID   int64  `json:"owner_id"`
+	OwnerName string `json:"owner_name"`
+	Profile   string `json:"profile,omitempty"`
+	// Editable позволяет участникам добавлять лекарства в список владельца
+	Editable  bool      `json:"editable,omitempty"`
+	Members   []int64   `json:"members,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Title возвращает название списка для участников
+func (l SharedList) Title() string {
+	title := "Список " + l.OwnerName
+	if len(l.Profile) > 0 {
+		title += " (" + l.Profile + ")"
+	}
+
+	return title
+}
+
+func newShareToken() string {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		logError(err)
+	}
+
+	return hex.EncodeToString(token)
+}
+
+// shareLink возвращает ссылку, по которой можно подключиться к списку
+func shareLink(token string) string {
+	return "https://t.me/" + BotUsername + "?start=share_" + token
+}
+
+func shareHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message.From == nil {
+		return
+	}
+
+	if len(BotUsername) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Не удалось создать ссылку. Попробуйте позже.",
+		})
+		return
+	}
+
+	_, arg, _ := strings.Cut(update.Message.Text, " ")
+	editable := strings.TrimSpace(strings.ToLower(arg)) == "edit"
+
+	list := SharedList{
+		Token:     newShareToken(),
+		OwnerID:   update.Message.From.ID,
+		OwnerName: update.Message.From.FirstName,
+		Editable:  editable,
+	}
+	if profile := activeProfile(update.Message.Chat.ID); profile != defaultProfile {
+		list.Profile = profile
+	}
+	Storage.AddShare(list)
+
+	mode := "Получатели смогут только смотреть список. Чтобы они могли добавлять лекарства, отправьте /share edit."
+	if editable {
+		mode = "Получатели смогут добавлять лекарства в список реакцией 👍."
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             update.Message.Chat.ID,
+		Text:               fmt.Sprintf("Отправьте эту ссылку тому, с кем хотите поделиться избранным:\n%s\n\n%s", shareLink(list.Token), mode),
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+}
+
+// joinSharedList подключает пользователя к списку по ссылке share_<token>
+func joinSharedList(ctx context.Context, b *bot.Bot, message *models.Message, token string) {
+	if message.From == nil {
+		return
+	}
+
+	list, ok := Storage.JoinShare(token, message.From.ID)
+	text := "Ссылка недействительна."
+	switch {
+	case ok && list.OwnerID == message.From.ID:
+		text = "Это ваш собственный список."
+	case ok:
+		text = fmt.Sprintf("Вы подключены к списку %s. Он появится в /favorites и будет обновляться вместе с оригиналом.", bold(list.Title()))
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    message.Chat.ID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+// sharedListFavorites возвращает текущие лекарства списка
+func sharedListFavorites(list SharedList) []Favorite {
+	favorites := []Favorite{}
+	for _, favorite := range Storage.Favorites(list.OwnerID) {
+		if favorite.Profile == list.Profile {
+			favorites = append(favorites, favorite)
+		}
+	}
+
+	return favorites
+}
+
+// addToSharedLists добавляет лекарство в общие списки, которые участник может редактировать
+func addToSharedLists(userID int64, favorite Favorite) {
+	for _, list := range Storage.SharesFor(userID) {
+		if !list.Editable {
+			continue
+		}
+		Storage.AddFavorite(list.OwnerID, Favorite{
+			MedicineID:   favorite.MedicineID,
+			MedicineName: favorite.MedicineName,
+			Profile:      list.Profile,
+		})
+	}
+}
+
+func shareLeaveHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	token := strings.TrimPrefix(update.CallbackQuery.Data, "share_leave:")
+	list, ok := Storage.LeaveShare(token, update.CallbackQuery.From.ID)
+	if !ok {
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    callbackChatID(update.CallbackQuery),
+		Text:      fmt.Sprintf("Вы отключились от списка %s.", bold(list.Title())),
+		ParseMode: models.ParseModeHTML,
+	})
+}
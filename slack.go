@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// slackSignatureTTL допустимое расхождение времени запроса Slack
+	slackSignatureTTL = 5 * time.Minute
+	// slackSelectLimit максимальное количество вариантов в меню выбора Slack
+	slackSelectLimit   = 100
+	slackActionAnalogs = "analogs"
+)
+
+// Slack адаптер Slack, nil если он не настроен
+var Slack *SlackAdapter
+
+// SlackAdapter обрабатывает slash команду и интерактивные блоки Slack через общие сценарии поиска
+type SlackAdapter struct {
+	SigningSecret string
+	Client        *http.Client
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackOption struct {
+	Text  slackText `json:"text"`
+	Value string    `json:"value"`
+}
+
+type slackElement struct {
+	Type        string        `json:"type"`
+	ActionID    string        `json:"action_id,omitempty"`
+	Placeholder *slackText    `json:"placeholder,omitempty"`
+	Options     []slackOption `json:"options,omitempty"`
+}
+
+type slackBlock struct {
+	Type     string         `json:"type"`
+	BlockID  string         `json:"block_id,omitempty"`
+	Text     *slackText     `json:"text,omitempty"`
+	Elements []slackElement `json:"elements,omitempty"`
+}
+
+type slackMessage struct {
+	ResponseType    string       `json:"response_type,omitempty"`
+	ReplaceOriginal bool         `json:"replace_original,omitempty"`
+	Text            string       `json:"text"`
+	Blocks          []slackBlock `json:"blocks,omitempty"`
+}
+
+type slackInteraction struct {
+	Type        string `json:"type"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID       string      `json:"action_id"`
+		BlockID        string      `json:"block_id"`
+		SelectedOption slackOption `json:"selected_option"`
+	} `json:"actions"`
+}
+
+// newSlackAdapter создает адаптер, если задан SLACK_SIGNING_SECRET
+func newSlackAdapter(signingSecret string) (*SlackAdapter, bool) {
+	if len(signingSecret) == 0 {
+		return nil, false
+	}
+
+	return &SlackAdapter{
+		SigningSecret: signingSecret,
+		Client:        &http.Client{Timeout: 10 * time.Second},
+	}, true
+}
+
+// verify проверяет подпись запроса Slack и возвращает его тело
+func (s *SlackAdapter) verify(r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64*1024))
+	if err != nil {
+		return nil, false
+	}
+
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > slackSignatureTTL || age < -slackSignatureTTL {
+		return nil, false
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.SigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	return body, hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Slack-Signature")))
+}
+
+// respond отправляет сообщение по response_url из запроса Slack
+func (s *SlackAdapter) respond(responseURL string, message slackMessage) {
+	if !strings.HasPrefix(responseURL, "https://hooks.slack.com/") {
+		return
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	response, err := s.Client.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logError(err)
+		return
+	}
+	response.Body.Close()
+}
+
+// commandHandler обрабатывает /pill <название> [код страны]
+func (s *SlackAdapter) commandHandler(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.verify(r)
+	if !ok {
+		http.Error(w, "неверная подпись", http.StatusUnauthorized)
+		return
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query, countryID := splitQueryCountry(values.Get("text"))
+	if len(strings.TrimSpace(query)) == 0 {
+		writeJSON(w, slackMessage{ResponseType: "ephemeral", Text: "Укажите название лекарства, например: /pill нурофен TH"})
+		return
+	}
+
+	// Ответ на команду должен прийти за 3 секунды, поэтому список отправляется по response_url
+	// Контекст запроса отменяется после ответа, поиск идет в своем
+	writeJSON(w, slackMessage{ResponseType: "ephemeral", Text: "Ищу " + slackEscape(query) + "…"})
+	go s.sendSearch(context.Background(), values.Get("response_url"), query, countryID)
+}
+
+// splitQueryCountry отделяет код страны в конце запроса
+func splitQueryCountry(text string) (string, int) {
+	fields := strings.Fields(text)
+	if len(fields) > 1 {
+		if country, ok := countryByCode(fields[len(fields)-1]); ok {
+			return strings.Join(fields[:len(fields)-1], " "), country.ID
+		}
+	}
+
+	return strings.Join(fields, " "), TargetCountryID
+}
+
+func (s *SlackAdapter) sendSearch(ctx context.Context, responseURL string, query string, countryID int) {
+	AppMetrics.Incr("slack_searches")
+
+	medicines, err := findMedicines(ctx, "slack", query)
+	if errors.Is(err, errSensitiveQuery) {
+		s.respond(responseURL, slackMessage{ReplaceOriginal: true, Text: sensitivePolicy})
+		return
+	}
+	if err != nil || len(medicines) == 0 {
+		s.respond(responseURL, slackMessage{ReplaceOriginal: true, Text: fmt.Sprintf("Мне не удалось ничего найти по запросу *%s*.", slackEscape(query))})
+		return
+	}
+
+	options := []slackOption{}
+	for index, medicine := range medicines {
+		if index == slackSelectLimit {
+			break
+		}
+		options = append(options, slackOption{
+			Text:  slackText{Type: "plain_text", Text: truncateRunes(medicine.Name, 75)},
+			Value: medicine.ID,
+		})
+	}
+
+	text := fmt.Sprintf("Вот что я нашел по запросу *%s*. Выберите лекарство, для которого нужно найти аналоги.", slackEscape(query))
+	s.respond(responseURL, slackMessage{
+		ReplaceOriginal: true,
+		Text:            text,
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}},
+			{
+				Type: "actions",
+				// block_id переносит страну поиска в обработчик выбора
+				BlockID: "country:" + strconv.Itoa(countryID),
+				Elements: []slackElement{
+					{
+						Type:        "static_select",
+						ActionID:    slackActionAnalogs,
+						Placeholder: &slackText{Type: "plain_text", Text: "Лекарство"},
+						Options:     options,
+					},
+				},
+			},
+		},
+	})
+}
+
+// interactionHandler обрабатывает выбор лекарства в меню
+func (s *SlackAdapter) interactionHandler(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.verify(r)
+	if !ok {
+		http.Error(w, "неверная подпись", http.StatusUnauthorized)
+		return
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	interaction := slackInteraction{}
+	if err := json.Unmarshal([]byte(values.Get("payload")), &interaction); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if interaction.Type != "block_actions" || len(interaction.Actions) == 0 || interaction.Actions[0].ActionID != slackActionAnalogs {
+		return
+	}
+	action := interaction.Actions[0]
+	medicineID, err := strconv.Atoi(action.SelectedOption.Value)
+	if err != nil {
+		return
+	}
+	countryID, _ := strconv.Atoi(strings.TrimPrefix(action.BlockID, "country:"))
+
+	go s.sendAnalogs(context.Background(), interaction.ResponseURL, medicineID, countryID)
+}
+
+func (s *SlackAdapter) sendAnalogs(ctx context.Context, responseURL string, medicineID int, countryID int) {
+	result, err := findAnalogsContext(ctx, medicineID, countryID)
+	if err != nil || len(result.Analogs) == 0 {
+		s.respond(responseURL, slackMessage{ReplaceOriginal: true, Text: fmt.Sprintf("Мне не удалось найти аналоги для *%s*.", result.Medicine.MedicineName)})
+		return
+	}
+
+	text := formatAnalogsSlack(result)
+	s.respond(responseURL, slackMessage{
+		ReplaceOriginal: true,
+		Text:            text,
+		Blocks:          []slackBlock{{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}}},
+	})
+}
+
+// formatAnalogsSlack оформляет аналоги в разметке mrkdwn
+func formatAnalogsSlack(result AnalogsResult) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Вот аналоги для *%s*:\n", slackEscape(result.Medicine.MedicineName)))
+	for index, analog := range result.Analogs {
+		if index == 10 {
+			break
+		}
+		text.WriteString(fmt.Sprintf("%d. <%s|%s> (%d%%)\n", index+1, analogURL(analog), slackEscape(analog.AnalogName), analog.Percentage))
+	}
+	for _, rule := range result.Restrictions {
+		text.WriteString(fmt.Sprintf(":warning: %s: %s\n", slackEscape(rule.Substance), slackEscape(rule.Note)))
+	}
+
+	return text.String()
+}
+
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// startSlack подключает обработчики команды и интерактивных блоков Slack
+func startSlack(mux *http.ServeMux) {
+	if Slack == nil {
+		return
+	}
+
+	mux.HandleFunc("/slack/commands", Slack.commandHandler)
+	mux.HandleFunc("/slack/interactions", Slack.interactionHandler)
+}
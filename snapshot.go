@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+const (
+	// snapshotSource имя снимка в цепочке источников и в ответах из него
+	snapshotSource = "snapshot"
+	// snapshotWindow за сколько дней считаются популярные лекарства
+	snapshotWindow = 90 * 24 * time.Hour
+)
+
+var (
+	// SnapshotPath файл снимка популярных лекарств из SNAPSHOT_PATH, пустой отключает снимок
+	SnapshotPath string
+	// SnapshotSize сколько популярных лекарств попадает в снимок, SNAPSHOT_SIZE
+	SnapshotSize = 200
+	// SnapshotInterval как часто снимок выгружается заново, SNAPSHOT_INTERVAL
+	SnapshotInterval = 24 * time.Hour
+	// SnapshotCountries коды стран поиска из SNAPSHOT_COUNTRIES, по умолчанию страна поиска бота
+	SnapshotCountries []string
+)
+
+// Snapshot снимок популярных лекарств с аналогами, отвечает, когда API недоступно
+var Snapshot = &snapshotProvider{}
+
+// snapshotProvider источник из снимка, который задача snapshot заменяет целиком
+type snapshotProvider struct {
+	mu     sync.RWMutex
+	static *pills.Static
+}
+
+func (p *snapshotProvider) current() *pills.Static {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.static == nil {
+		return &pills.Static{Source: snapshotSource}
+	}
+
+	return p.static
+}
+
+func (p *snapshotProvider) Replace(static *pills.Static) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.static = static
+}
+
+// CreatedAt время выгрузки снимка, нулевое если снимка еще нет
+func (p *snapshotProvider) CreatedAt() time.Time {
+	return p.current().CreatedAt
+}
+
+func (p *snapshotProvider) Name() string {
+	return snapshotSource
+}
+
+func (p *snapshotProvider) SearchMedicines(ctx context.Context, query string) ([]Medicine, error) {
+	return p.current().SearchMedicines(ctx, query)
+}
+
+func (p *snapshotProvider) SearchAnalogs(ctx context.Context, medicineID int, targetCountry int) ([]Analog, MedicineInfo, error) {
+	return p.current().SearchAnalogs(ctx, medicineID, targetCountry)
+}
+
+func (p *snapshotProvider) Details(ctx context.Context, medicineID int) (MedicineDetails, error) {
+	return p.current().Details(ctx, medicineID)
+}
+
+// loadSnapshot читает настройки и прошлый снимок, если он уже выгружался
+func loadSnapshot() error {
+	SnapshotPath = os.Getenv("SNAPSHOT_PATH")
+	if size, err := strconv.Atoi(os.Getenv("SNAPSHOT_SIZE")); err == nil && size > 0 {
+		SnapshotSize = size
+	}
+	loadPositiveDuration("SNAPSHOT_INTERVAL", &SnapshotInterval)
+	for _, code := range strings.Split(os.Getenv("SNAPSHOT_COUNTRIES"), ",") {
+		if code = strings.TrimSpace(code); len(code) > 0 {
+			SnapshotCountries = append(SnapshotCountries, code)
+		}
+	}
+	if len(SnapshotPath) == 0 {
+		return nil
+	}
+
+	static, err := pills.LoadStatic(SnapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	static.Source = snapshotSource
+	Snapshot.Replace(static)
+
+	return nil
+}
+
+// snapshotCountryIDs страны поиска снимка, коды проверяются при каждой выгрузке по списку COUNTRIES
+func snapshotCountryIDs() []int {
+	ids := []int{}
+	for _, code := range SnapshotCountries {
+		if country, ok := countryByCode(code); ok {
+			ids = append(ids, country.ID)
+		}
+	}
+	if len(ids) == 0 {
+		ids = append(ids, TargetCountryID)
+	}
+
+	return ids
+}
+
+// exportSnapshot выгружает популярные лекарства с аналогами прямо из API, минуя запасные
+// источники. Если API не ответило ни на один запрос, прежний снимок остается
+func exportSnapshot(ctx context.Context, now time.Time) (*pills.Static, error) {
+	static := &pills.Static{Source: snapshotSource, CreatedAt: now, Medicines: []pills.StaticMedicine{}}
+	countries := snapshotCountryIDs()
+
+	var lastErr error
+	for _, top := range Storage.TopMedicinesSince(now.Add(-snapshotWindow), SnapshotSize) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		medicine := pills.StaticMedicine{
+			Medicine: Medicine{ID: strconv.Itoa(top.MedicineID), Name: top.Name},
+			Analogs:  map[int][]Analog{},
+		}
+		if found, err := API.SearchMedicines(ctx, top.Name); err == nil {
+			for _, candidate := range found {
+				if candidate.ID == medicine.ID {
+					medicine.Medicine = candidate
+				}
+			}
+		}
+		for _, countryID := range countries {
+			analogs, info, err := API.SearchAnalogs(ctx, top.MedicineID, countryID)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			medicine.Info = info
+			medicine.Analogs[countryID] = analogs
+		}
+		if len(medicine.Analogs) == 0 {
+			continue
+		}
+		if details, err := API.Details(ctx, top.MedicineID); err == nil && len(details.Forms) > 0 {
+			medicine.Details = &details
+		}
+		static.Medicines = append(static.Medicines, medicine)
+	}
+	if len(static.Medicines) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	return static, nil
+}
+
+// snapshotJob выгружает снимок раз в SnapshotInterval
+func snapshotJob() Job {
+	return Job{
+		Name: "snapshot",
+		Next: every(SnapshotInterval),
+		Run: func(ctx context.Context) {
+			static, err := exportSnapshot(ctx, time.Now())
+			if err != nil {
+				logError(err)
+				return
+			}
+			// Без истории поисков выгружать нечего, прежний снимок остается
+			if len(static.Medicines) == 0 {
+				return
+			}
+			if err := static.Save(SnapshotPath); err != nil {
+				logError(err)
+				return
+			}
+			Snapshot.Replace(static)
+			AppMetrics.Incr("snapshot_exports")
+			log.Printf("Снимок данных: %d лекарств\n", len(static.Medicines))
+		},
+	}
+}
+
+// fallbackWarning предупреждает, что ответ взят не из API, а из снимка или запасного источника
+func fallbackWarning(source string) string {
+	switch source {
+	case "":
+		return ""
+	case snapshotSource:
+		text := "Источник данных недоступен, показан сохраненный снимок"
+		if created := Snapshot.CreatedAt(); !created.IsZero() {
+			text += fmt.Sprintf(" от %s", created.Format("02.01.2006"))
+		}
+		return "\n⚠️ " + italic(text+", данные могли устареть.")
+	}
+
+	return "\n⚠️ " + italic("Источник данных недоступен, показаны только основные сведения из запасного источника.")
+}
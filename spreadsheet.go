@@ -0,0 +1,229 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// SheetRow строка таблицы: лекарство и лучший найденный аналог
+type SheetRow struct {
+	Query      string
+	Medicine   string
+	Analog     string
+	Percentage int
+	URL        string
+}
+
+var sheetHeader = []string{"Запрос", "Лекарство", "Лучший аналог", "Совпадение, %", "Ссылка"}
+
+// BulkReports связывает сводные сообщения с результатами поиска по списку для выгрузки таблицы
+var BulkReports = NewRecentMap[replyKey, []SheetRow](200)
+
+// bulkSheetRows берет для каждого лекарства из списка лучший аналог
+func bulkSheetRows(results []BulkResult) []SheetRow {
+	rows := []SheetRow{}
+	for _, result := range results {
+		row := SheetRow{Query: result.Query, Medicine: result.MedicineName}
+		if len(result.Analogs) > 0 {
+			analog := result.Analogs[0]
+			row.Analog = analog.AnalogName
+			row.Percentage = analog.Percentage
+			row.URL = analogURL(analog)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func checklistSheetRows(trip Trip) []SheetRow {
+	rows := []SheetRow{}
+	for _, item := range trip.Checklist {
+		rows = append(rows, SheetRow{
+			Query:      item.Medicine,
+			Medicine:   item.Medicine,
+			Analog:     item.AnalogName,
+			Percentage: item.Percentage,
+			URL:        item.AnalogURL,
+		})
+	}
+
+	return rows
+}
+
+// sheetButtons кнопки выгрузки таблицы с префиксом callback данных prefix
+func sheetButtons(prefix string) []models.InlineKeyboardButton {
+	return []models.InlineKeyboardButton{
+		{Text: "📊 CSV", CallbackData: prefix + ":csv"},
+		{Text: "📊 XLSX", CallbackData: prefix + ":xlsx"},
+	}
+}
+
+func (row SheetRow) values() []string {
+	percentage := ""
+	if len(row.Analog) > 0 {
+		percentage = strconv.Itoa(row.Percentage)
+	}
+
+	return []string{row.Query, row.Medicine, row.Analog, percentage, row.URL}
+}
+
+func sheetCSV(rows []SheetRow) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write(sheetHeader)
+	for _, row := range rows {
+		writer.Write(row.values())
+	}
+	writer.Flush()
+
+	return buffer.Bytes(), writer.Error()
+}
+
+// Минимальный набор частей книги Office Open XML с одним листом
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Аналоги" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+// sheetXLSX собирает книгу Excel без внешних зависимостей, строки хранятся как inline строки
+func sheetXLSX(rows []SheetRow) ([]byte, error) {
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	writeXLSXRow(&sheet, 1, sheetHeader, -1)
+	for index, row := range rows {
+		numeric := -1
+		if len(row.Analog) > 0 {
+			numeric = 3
+		}
+		writeXLSXRow(&sheet, index+2, row.values(), numeric)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// writeXLSXRow записывает строку листа, колонка numeric сохраняется числом
+func writeXLSXRow(sheet *strings.Builder, number int, values []string, numeric int) {
+	sheet.WriteString(fmt.Sprintf(`<row r="%d">`, number))
+	for column, value := range values {
+		ref := string(rune('A'+column)) + strconv.Itoa(number)
+		if column == numeric {
+			sheet.WriteString(fmt.Sprintf(`<c r="%s"><v>%s</v></c>`, ref, value))
+			continue
+		}
+		var escaped bytes.Buffer
+		xml.EscapeText(&escaped, []byte(value))
+		sheet.WriteString(fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, escaped.String()))
+	}
+	sheet.WriteString(`</row>`)
+}
+
+// sendSpreadsheet отправляет таблицу name в формате csv или xlsx
+func sendSpreadsheet(ctx context.Context, b *bot.Bot, chatID int64, rows []SheetRow, format string, name string) {
+	var file []byte
+	var err error
+	if format == "xlsx" {
+		file, err = sheetXLSX(rows)
+	} else {
+		format = "csv"
+		file, err = sheetCSV(rows)
+	}
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	AppMetrics.Incr("sheet_exports")
+	_, err = b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: name + "." + format, Data: bytes.NewReader(file)},
+		Caption:  "Лучшие аналоги по списку",
+	})
+	if err != nil {
+		logError(err)
+	}
+}
+
+// bulkSheetHandler выгружает таблицу по сводному сообщению поиска по списку
+func bulkSheetHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := callbackChatID(update.CallbackQuery)
+	var rows []SheetRow
+	ok := false
+	if update.CallbackQuery.Message.Message != nil {
+		rows, ok = BulkReports.Get(replyKey{chatID: chatID, messageID: update.CallbackQuery.Message.Message.ID})
+	}
+	if !ok {
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: update.CallbackQuery.ID,
+			Text:            "Результаты устарели, отправьте список еще раз.",
+			ShowAlert:       true,
+		})
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	sendSpreadsheet(ctx, b, chatID, rows, strings.TrimPrefix(update.CallbackQuery.Data, "bulk_sheet:"), "analogs")
+}
+
+// tripSheetHandler выгружает таблицу по списку в дорогу: trip_sheet:<поездка>:<формат>
+func tripSheetHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	parts := strings.Split(update.CallbackQuery.Data, ":")
+	if len(parts) != 3 {
+		return
+	}
+	tripID, _ := strconv.Atoi(parts[1])
+	chatID := callbackChatID(update.CallbackQuery)
+	trip, ok := Storage.Trip(tripID)
+	if !ok || !trip.VisibleIn(chatID) || len(trip.Checklist) == 0 {
+		return
+	}
+
+	sendSpreadsheet(ctx, b, chatID, checklistSheetRows(trip), parts[2], "checklist")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func statsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message.From == nil {
+		return
+	}
+
+	if !flagEnabled("stats") {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Статистика временно недоступна.",
+		})
+		return
+	}
+
+	user, ok := Storage.User(update.Message.From.ID)
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "У вас пока нет статистики. Для поиска введите название лекарства.",
+		})
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(bold("Ваша статистика") + "\n")
+	text.WriteString(fmt.Sprintf("Поисков: %d\n", user.Searches))
+	if name, count := mostSearchedMedicine(Storage.History(user.ID)); count > 0 {
+		text.WriteString(fmt.Sprintf("Чаще всего искали: %s (%d)\n", bold(name), count))
+	}
+	text.WriteString(fmt.Sprintf("В избранном: %d\n", len(Storage.Favorites(user.ID))))
+	text.WriteString(fmt.Sprintf("С нами с %s", user.CreatedAt.Format("02.01.2006")))
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    update.Message.Chat.ID,
+		Text:      text.String(),
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+// mostSearchedMedicine возвращает лекарство, для которого чаще всего искали аналоги
+func mostSearchedMedicine(history []HistoryEntry) (string, int) {
+	counts := map[int]int{}
+	names := map[int]string{}
+	bestID := 0
+	for _, entry := range history {
+		if entry.MedicineID == 0 {
+			continue
+		}
+		counts[entry.MedicineID]++
+		names[entry.MedicineID] = entry.MedicineName
+		if counts[entry.MedicineID] > counts[bestID] {
+			bestID = entry.MedicineID
+		}
+	}
+
+	return names[bestID], counts[bestID]
+}
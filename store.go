@@ -0,0 +1,1732 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// historyLimit ограничивает количество хранимых записей истории на пользователя
+const historyLimit = 500
+
+type User struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username,omitempty"`
+	FirstName string    `json:"first_name,omitempty"`
+	Language  string    `json:"language,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Searches  int       `json:"searches"`
+	// DisclaimerAcceptedAt время последнего принятия медицинского предупреждения,
+	// Disclaimers принятые версии по ключам предупреждений
+	DisclaimerAcceptedAt time.Time      `json:"disclaimer_accepted_at,omitempty"`
+	Disclaimers          map[string]int `json:"disclaimers,omitempty"`
+	// HistoryDisabled и AnalyticsDisabled настройки приватности из /privacy
+	HistoryDisabled   bool `json:"history_disabled,omitempty"`
+	AnalyticsDisabled bool `json:"analytics_disabled,omitempty"`
+	// QuotaTier тариф дневного лимита поисков, пустой для тарифа по умолчанию,
+	// QuotaUsed израсходовано за день QuotaDay
+	QuotaTier string `json:"quota_tier,omitempty"`
+	QuotaDay  string `json:"quota_day,omitempty"`
+	QuotaUsed int    `json:"quota_used,omitempty"`
+	// GraylistReason причина попадания в серый список, пустая вне списка
+	GraylistReason string    `json:"graylist_reason,omitempty"`
+	GraylistedAt   time.Time `json:"graylisted_at,omitempty"`
+	// VerifiedAt время прохождения проверки CAPTCHA для новых пользователей
+	VerifiedAt time.Time `json:"verified_at,omitempty"`
+	// MutedUntil окончание ограничения за флуд, MutedAt время последнего ограничения,
+	// Mutes число ограничений для нарастания срока
+	MutedUntil time.Time `json:"muted_until,omitempty"`
+	MutedAt    time.Time `json:"muted_at,omitempty"`
+	Mutes      int       `json:"mutes,omitempty"`
+}
+
+type HistoryEntry struct {
+	Query        string    `json:"query,omitempty"`
+	MedicineID   int       `json:"medicine_id,omitempty"`
+	MedicineName string    `json:"medicine_name,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Favorite struct {
+	MedicineID   int    `json:"medicine_id"`
+	MedicineName string `json:"medicine_name"`
+	// Profile профиль чата, для которого сохранено лекарство, пустой для профиля по умолчанию
+	Profile   string    `json:"profile,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatSettings хранит настройки отдельного чата
+type ChatSettings struct {
+	Voice bool `json:"voice,omitempty"`
+	// CountryID страна поиска аналогов, 0 означает страну по умолчанию
+	CountryID int       `json:"country_id,omitempty"`
+	Latitude  float64   `json:"latitude,omitempty"`
+	Longitude float64   `json:"longitude,omitempty"`
+	LocatedAt time.Time `json:"located_at,omitempty"`
+	// Timezone часовой пояс для напоминаний и рассылок
+	Timezone string `json:"timezone,omitempty"`
+	// TimezoneInferred отмечает часовой пояс, определенный по геопозиции
+	TimezoneInferred bool `json:"timezone_inferred,omitempty"`
+	// AdherenceReport включает еженедельный отчет о приеме лекарств
+	AdherenceReport bool `json:"adherence_report,omitempty"`
+	// AdherenceSentAt время последней отправки еженедельного отчета
+	AdherenceSentAt time.Time `json:"adherence_sent_at,omitempty"`
+	// WeeklyDigest включает личную сводку за неделю, WeeklyDigestSentAt время последней отправки
+	WeeklyDigest       bool      `json:"weekly_digest,omitempty"`
+	WeeklyDigestSentAt time.Time `json:"weekly_digest_sent_at,omitempty"`
+	// Profiles дополнительные профили членов семьи, ActiveProfile выбранный из них
+	Profiles      []string `json:"profiles,omitempty"`
+	ActiveProfile string   `json:"active_profile,omitempty"`
+	// TodayMessageID сообщение /today, которое обновляется в течение дня TodayDate
+	TodayMessageID int    `json:"today_message_id,omitempty"`
+	TodayDate      string `json:"today_date,omitempty"`
+	// QuietStart и QuietEnd задают тихие часы в формате 15:04
+	QuietStart string `json:"quiet_start,omitempty"`
+	QuietEnd   string `json:"quiet_end,omitempty"`
+	// CalendarToken секрет ссылки подписки на календарь напоминаний
+	CalendarToken string `json:"calendar_token,omitempty"`
+	// FamilyChannelID канал, куда кнопкой 📌 сохраняются результаты поиска
+	FamilyChannelID    int64  `json:"family_channel_id,omitempty"`
+	FamilyChannelTitle string `json:"family_channel_title,omitempty"`
+	// Health особенности здоровья по профилям чата
+	Health map[string]ProfileHealth `json:"health,omitempty"`
+	// HomeCountry код домашней страны, от нее зависят тексты предупреждений и доступные функции
+	HomeCountry string `json:"home_country,omitempty"`
+}
+
+type storeData struct {
+	Users     map[int64]*User          `json:"users"`
+	History   map[int64][]HistoryEntry `json:"history"`
+	Favorites map[int64][]Favorite     `json:"favorites"`
+	Flags     map[string]bool          `json:"flags"`
+	Roles     map[int64]Role           `json:"roles"`
+	// Feedback связывает сообщения в чате администраторов с чатами пользователей
+	Feedback map[int]int64 `json:"feedback"`
+	Reports  []Report      `json:"reports"`
+	// Usage хранит счетчики по дням в формате 2006-01-02
+	Usage map[string]map[string]int `json:"usage"`
+	Chats map[int64]*ChatSettings   `json:"chats"`
+	// Reminders напоминания о приеме, LastReminderID последний выданный номер
+	Reminders      []Reminder `json:"reminders"`
+	LastReminderID int        `json:"last_reminder_id"`
+	// Doses журнал приемов по напоминаниям
+	Doses      []Dose       `json:"doses"`
+	LastDoseID int          `json:"last_dose_id"`
+	Shares     []SharedList `json:"shares"`
+	Trips      []Trip       `json:"trips"`
+	LastTripID int          `json:"last_trip_id"`
+	// EntryOverrides правила ввоза лекарств, измененные администраторами
+	EntryOverrides map[string]string `json:"entry_overrides,omitempty"`
+	// Permalinks снимки результатов поиска для просмотра без Telegram
+	Permalinks []Permalink `json:"permalinks,omitempty"`
+	APIKeys    []APIKey    `json:"api_keys,omitempty"`
+	// BudgetAlerts последний порог бюджета API, о котором предупреждены администраторы, по месяцам
+	BudgetAlerts map[string]int `json:"budget_alerts,omitempty"`
+	// Overrides исправления данных API, которые ведут администраторы
+	Overrides      []Override `json:"overrides,omitempty"`
+	LastOverrideID int        `json:"last_override_id,omitempty"`
+	// Watches лекарства, за аналогами которых следят пользователи
+	Watches     []Watch `json:"watches,omitempty"`
+	LastWatchID int     `json:"last_watch_id,omitempty"`
+}
+
+type QueryCount struct {
+	Query string `json:"query"`
+	Count int    `json:"count"`
+}
+
+type MedicineCount struct {
+	MedicineID int    `json:"medicine_id,omitempty"`
+	Name       string `json:"name"`
+	Count      int    `json:"count"`
+}
+
+// Store хранит данные пользователей в JSON файле
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data storeData
+	// apiKeysSavedAt когда счетчики ключей API последний раз записывались из UseAPIKey
+	apiKeysSavedAt time.Time
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		data: storeData{
+			Users:     map[int64]*User{},
+			History:   map[int64][]HistoryEntry{},
+			Favorites: map[int64][]Favorite{},
+			Flags:     map[string]bool{},
+			Roles:     map[int64]Role{},
+			Feedback:  map[int]int64{},
+			Usage:     map[string]map[string]int{},
+			Chats:     map[int64]*ChatSettings{},
+		},
+	}
+
+	file, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(file, &s.data); err != nil {
+		return nil, err
+	}
+	if s.data.Users == nil {
+		s.data.Users = map[int64]*User{}
+	}
+	if s.data.History == nil {
+		s.data.History = map[int64][]HistoryEntry{}
+	}
+	if s.data.Favorites == nil {
+		s.data.Favorites = map[int64][]Favorite{}
+	}
+	if s.data.Flags == nil {
+		s.data.Flags = map[string]bool{}
+	}
+	if s.data.Roles == nil {
+		s.data.Roles = map[int64]Role{}
+	}
+	if s.data.Feedback == nil {
+		s.data.Feedback = map[int]int64{}
+	}
+	if s.data.Usage == nil {
+		s.data.Usage = map[string]map[string]int{}
+	}
+	if s.data.Chats == nil {
+		s.data.Chats = map[int64]*ChatSettings{}
+	}
+
+	return s, nil
+}
+
+// save записывает данные на диск, вызывается под блокировкой,
+// поэтому ошибки только пишутся в лог без учета в метриках
+func (s *Store) save() {
+	body, err := json.Marshal(s.data)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0600); err != nil {
+		log.Println(err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		log.Println(err)
+	}
+}
+
+// touchUser создает или обновляет пользователя, вызывается под блокировкой
+func (s *Store) touchUser(from *models.User) *User {
+	user, ok := s.data.Users[from.ID]
+	if !ok {
+		user = &User{
+			ID:        from.ID,
+			CreatedAt: time.Now(),
+		}
+		s.data.Users[from.ID] = user
+	}
+	user.Username = from.Username
+	user.FirstName = from.FirstName
+	user.Language = from.LanguageCode
+
+	return user
+}
+
+func (s *Store) TouchUser(from *models.User) {
+	if from == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.touchUser(from)
+	s.save()
+}
+
+func (s *Store) AddHistory(from *models.User, entry HistoryEntry) {
+	if from == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.touchUser(from)
+	if entry.Query != "" && !user.AnalyticsDisabled {
+		user.Searches++
+	}
+	if user.HistoryDisabled {
+		s.save()
+		return
+	}
+
+	entry.CreatedAt = time.Now()
+	history := append(s.data.History[from.ID], entry)
+	if len(history) > historyLimit {
+		history = history[len(history)-historyLimit:]
+	}
+	s.data.History[from.ID] = history
+
+	s.save()
+}
+
+func (s *Store) User(userID int64) (User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.data.Users[userID]
+	if !ok {
+		return User{}, false
+	}
+
+	return user.clone(), true
+}
+
+// clone копирует пользователя вместе с картой принятых предупреждений
+func (u *User) clone() User {
+	copied := *u
+	if u.Disclaimers != nil {
+		copied.Disclaimers = map[string]int{}
+		for key, version := range u.Disclaimers {
+			copied.Disclaimers[key] = version
+		}
+	}
+
+	return copied
+}
+
+// AcceptedDisclaimer возвращает принятую пользователем версию предупреждения key, 0 если не принималось.
+// Согласие до появления версий считается первой версией предупреждения по умолчанию
+func (s *Store) AcceptedDisclaimer(userID int64, key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.data.Users[userID]
+	if !ok {
+		return 0
+	}
+	if version, ok := user.Disclaimers[key]; ok {
+		return version
+	}
+	if key == defaultDisclaimerKey && len(user.Disclaimers) == 0 && !user.DisclaimerAcceptedAt.IsZero() {
+		return 1
+	}
+
+	return 0
+}
+
+func (s *Store) analyticsDisabled(userID int64) bool {
+	user, ok := s.data.Users[userID]
+
+	return ok && user.AnalyticsDisabled
+}
+
+// UpdatePrivacy меняет настройки приватности пользователя
+func (s *Store) UpdatePrivacy(from *models.User, update func(user *User)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.touchUser(from)
+	update(user)
+	if user.HistoryDisabled {
+		delete(s.data.History, user.ID)
+	}
+	s.save()
+}
+
+// UserExport собирает все данные, связанные с пользователем
+func (s *Store) UserExport(userID int64, now time.Time) PrivacyExport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := PrivacyExport{
+		ExportedAt:  now,
+		History:     append([]HistoryEntry{}, s.data.History[userID]...),
+		Favorites:   append([]Favorite{}, s.data.Favorites[userID]...),
+		Reminders:   []Reminder{},
+		Doses:       []Dose{},
+		Trips:       []Trip{},
+		Shares:      []SharedList{},
+		Reports:     []Report{},
+		Watches:     []Watch{},
+		PriceAlerts: []PriceAlert{},
+	}
+	if user, ok := s.data.Users[userID]; ok {
+		data.User = user.clone()
+	}
+	for _, reminder := range s.data.Reminders {
+		if reminder.UserID == userID {
+			data.Reminders = append(data.Reminders, reminder)
+		}
+	}
+	for _, dose := range s.data.Doses {
+		if dose.UserID == userID {
+			data.Doses = append(data.Doses, dose)
+		}
+	}
+	for _, trip := range s.data.Trips {
+		if trip.UserID == userID {
+			data.Trips = append(data.Trips, trip)
+		}
+	}
+	for _, list := range s.data.Shares {
+		if list.OwnerID == userID {
+			data.Shares = append(data.Shares, list)
+		}
+	}
+	for _, report := range s.data.Reports {
+		if report.UserID == userID {
+			data.Reports = append(data.Reports, report)
+		}
+	}
+	for _, watch := range s.data.Watches {
+		if watch.UserID == userID || watch.ChatID == userID {
+			data.Watches = append(data.Watches, watch)
+			continue
+		}
+		for _, alert := range watch.PriceAlerts {
+			if alert.UserID == userID {
+				data.PriceAlerts = append(data.PriceAlerts, alert)
+			}
+		}
+	}
+	if settings, ok := s.data.Chats[userID]; ok {
+		copied := settings.clone()
+		data.Chat = &copied
+	}
+
+	return data
+}
+
+// DeleteUserData удаляет все данные пользователя, жалобы остаются обезличенными
+func (s *Store) DeleteUserData(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data.Users, userID)
+	delete(s.data.History, userID)
+	delete(s.data.Favorites, userID)
+	// личный чат совпадает с ID пользователя
+	delete(s.data.Chats, userID)
+
+	reminders := s.data.Reminders[:0]
+	for _, reminder := range s.data.Reminders {
+		if reminder.UserID != userID && reminder.ChatID != userID {
+			reminders = append(reminders, reminder)
+		}
+	}
+	s.data.Reminders = reminders
+
+	doses := s.data.Doses[:0]
+	for _, dose := range s.data.Doses {
+		if dose.UserID != userID && dose.ChatID != userID {
+			doses = append(doses, dose)
+		}
+	}
+	s.data.Doses = doses
+
+	trips := s.data.Trips[:0]
+	for _, trip := range s.data.Trips {
+		if trip.UserID != userID && trip.ChatID != userID {
+			trips = append(trips, trip)
+		}
+	}
+	s.data.Trips = trips
+
+	watches := s.data.Watches[:0]
+	for _, watch := range s.data.Watches {
+		if watch.UserID == userID || watch.ChatID == userID {
+			continue
+		}
+		// Цели цены пользователя удаляются и из слежения других участников группы
+		alerts := []PriceAlert{}
+		for _, alert := range watch.PriceAlerts {
+			if alert.UserID != userID {
+				alerts = append(alerts, alert)
+			}
+		}
+		watch.PriceAlerts = alerts
+		watches = append(watches, watch)
+	}
+	s.data.Watches = watches
+
+	shares := s.data.Shares[:0]
+	for _, list := range s.data.Shares {
+		if list.OwnerID == userID {
+			continue
+		}
+		members := []int64{}
+		for _, member := range list.Members {
+			if member != userID {
+				members = append(members, member)
+			}
+		}
+		list.Members = members
+		shares = append(shares, list)
+	}
+	s.data.Shares = shares
+
+	keys := s.data.APIKeys[:0]
+	for _, key := range s.data.APIKeys {
+		if key.OwnerID != userID || key.Blocked() {
+			keys = append(keys, key)
+		}
+	}
+	s.data.APIKeys = keys
+
+	for messageID, chatID := range s.data.Feedback {
+		if chatID == userID {
+			delete(s.data.Feedback, messageID)
+		}
+	}
+	for index := range s.data.Reports {
+		if s.data.Reports[index].UserID == userID {
+			s.data.Reports[index].UserID = 0
+			if s.data.Reports[index].ChatID == userID {
+				s.data.Reports[index].ChatID = 0
+			}
+		}
+	}
+
+	s.save()
+}
+
+// ConsumeQuota списывает count поисков за день day, если они помещаются в limit, 0 без ограничения
+func (s *Store) ConsumeQuota(from *models.User, day string, limit int, count int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.touchUser(from)
+	if user.QuotaDay != day {
+		user.QuotaDay, user.QuotaUsed = day, 0
+	}
+	if limit > 0 && user.QuotaUsed+count > limit {
+		return false
+	}
+	user.QuotaUsed += count
+	s.save()
+
+	return true
+}
+
+// QuotaUsed возвращает число поисков пользователя за день day
+func (s *Store) QuotaUsed(userID int64, day string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.data.Users[userID]
+	if !ok || user.QuotaDay != day {
+		return 0
+	}
+
+	return user.QuotaUsed
+}
+
+// SetQuotaTier назначает тариф пользователю, пустой тариф возвращает тариф по умолчанию
+func (s *Store) SetQuotaTier(userID int64, tier string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.data.Users[userID]
+	if !ok {
+		user = &User{ID: userID, CreatedAt: time.Now()}
+		s.data.Users[userID] = user
+	}
+	user.QuotaTier = tier
+	s.save()
+}
+
+func (s *Store) Graylisted(userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.data.Users[userID]
+
+	return ok && len(user.GraylistReason) > 0
+}
+
+// SetGraylisted добавляет пользователя в серый список с причиной reason, пустая причина убирает его
+func (s *Store) SetGraylisted(userID int64, reason string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.data.Users[userID]
+	if !ok {
+		user = &User{ID: userID, CreatedAt: now}
+		s.data.Users[userID] = user
+	}
+	user.GraylistReason = reason
+	user.GraylistedAt = now
+	if len(reason) == 0 {
+		user.GraylistedAt = time.Time{}
+	}
+	s.save()
+}
+
+func (s *Store) GraylistedUsers() []User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := []User{}
+	for _, user := range s.data.Users {
+		if len(user.GraylistReason) > 0 {
+			users = append(users, user.clone())
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].GraylistedAt.Before(users[j].GraylistedAt)
+	})
+
+	return users
+}
+
+// VerifyUser отмечает, что пользователь прошел проверку CAPTCHA
+func (s *Store) VerifyUser(from *models.User, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.touchUser(from).VerifiedAt = now
+	s.save()
+}
+
+// MutedUntil возвращает окончание ограничения пользователя за флуд
+func (s *Store) MutedUntil(userID int64) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.data.Users[userID]; ok {
+		return user.MutedUntil
+	}
+
+	return time.Time{}
+}
+
+// Mute ограничивает пользователя на срок из muteDurations по номеру нарушения
+func (s *Store) Mute(userID int64, now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.data.Users[userID]
+	if !ok {
+		user = &User{ID: userID, CreatedAt: now}
+		s.data.Users[userID] = user
+	}
+	if now.Sub(user.MutedUntil) > muteForgetAfter {
+		user.Mutes = 0
+	}
+
+	duration := muteDurations[len(muteDurations)-1]
+	if user.Mutes < len(muteDurations) {
+		duration = muteDurations[user.Mutes]
+	}
+	user.Mutes++
+	user.MutedAt = now
+	user.MutedUntil = now.Add(duration)
+	if user.Mutes >= graylistAfterMutes && len(user.GraylistReason) == 0 {
+		user.GraylistReason = "повторный флуд"
+		user.GraylistedAt = now
+	}
+	s.save()
+
+	return user.MutedUntil
+}
+
+func (s *Store) Unmute(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.data.Users[userID]; ok {
+		user.MutedUntil = time.Time{}
+		s.save()
+	}
+}
+
+// AcceptDisclaimer отмечает, что пользователь принял версию version предупреждения key
+func (s *Store) AcceptDisclaimer(from *models.User, key string, version int, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.touchUser(from)
+	if user.Disclaimers == nil {
+		user.Disclaimers = map[string]int{}
+	}
+	if version > user.Disclaimers[key] {
+		user.Disclaimers[key] = version
+	}
+	user.DisclaimerAcceptedAt = now
+	s.save()
+}
+
+func (s *Store) History(userID int64) []HistoryEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]HistoryEntry{}, s.data.History[userID]...)
+}
+
+func (s *Store) Favorites(userID int64) []Favorite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Favorite{}, s.data.Favorites[userID]...)
+}
+
+// TopQueries возвращает самые частые поисковые запросы всех пользователей
+func (s *Store) TopQueries(limit int) []QueryCount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := map[string]int{}
+	for userID, history := range s.data.History {
+		if s.analyticsDisabled(userID) {
+			continue
+		}
+		for _, entry := range history {
+			if entry.Query == "" {
+				continue
+			}
+			counts[strings.ToLower(strings.TrimSpace(entry.Query))]++
+		}
+	}
+
+	top := []QueryCount{}
+	for query, count := range counts {
+		top = append(top, QueryCount{Query: query, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count == top[j].Count {
+			return top[i].Query < top[j].Query
+		}
+		return top[i].Count > top[j].Count
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+
+	return top
+}
+
+func (s *Store) Flag(name string, defaultValue bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.data.Flags[name]
+	if !ok {
+		return defaultValue
+	}
+
+	return value
+}
+
+func (s *Store) SetFlag(name string, value bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Flags[name] = value
+	s.save()
+}
+
+func (s *Store) Role(userID int64) Role {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.Roles[userID]
+}
+
+func (s *Store) Roles() map[int64]Role {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles := map[int64]Role{}
+	for userID, role := range s.data.Roles {
+		roles[userID] = role
+	}
+
+	return roles
+}
+
+// SetRole назначает роль пользователю, пустая роль удаляет назначение
+func (s *Store) SetRole(userID int64, role Role) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if role == "" {
+		delete(s.data.Roles, userID)
+	} else {
+		s.data.Roles[userID] = role
+	}
+	s.save()
+}
+
+func (s *Store) SetFeedbackThread(messageID int, chatID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Feedback[messageID] = chatID
+	s.save()
+}
+
+func (s *Store) FeedbackThread(messageID int) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chatID, ok := s.data.Feedback[messageID]
+
+	return chatID, ok
+}
+
+// AddReport сохраняет жалобу и присваивает ей номер
+func (s *Store) AddReport(report Report) Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report.ID = len(s.data.Reports) + 1
+	report.CreatedAt = time.Now()
+	s.data.Reports = append(s.data.Reports, report)
+	s.save()
+
+	return report
+}
+
+// AddUsage прибавляет дневные счетчики и удаляет дни раньше cutoff
+func (s *Store) AddUsage(usage map[string]map[string]int, cutoff time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for day, counters := range usage {
+		if s.data.Usage[day] == nil {
+			s.data.Usage[day] = map[string]int{}
+		}
+		for name, value := range counters {
+			s.data.Usage[day][name] += value
+		}
+	}
+	from := cutoff.Format("2006-01-02")
+	for day := range s.data.Usage {
+		if day < from {
+			delete(s.data.Usage, day)
+		}
+	}
+	s.save()
+}
+
+// UsageSince суммирует счетчики по дням начиная с дня since
+func (s *Store) UsageSince(since time.Time) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := since.Format("2006-01-02")
+	usage := map[string]int{}
+	for day, counters := range s.data.Usage {
+		if day < from {
+			continue
+		}
+		for name, value := range counters {
+			usage[name] += value
+		}
+	}
+
+	return usage
+}
+
+func (s *Store) NewUsersSince(since time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, user := range s.data.Users {
+		if user.CreatedAt.After(since) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// TopMedicinesSince возвращает лекарства, для которых чаще всего искали аналоги
+func (s *Store) TopMedicinesSince(since time.Time, limit int) []MedicineCount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := map[int]int{}
+	names := map[int]string{}
+	for userID, history := range s.data.History {
+		if s.analyticsDisabled(userID) {
+			continue
+		}
+		for _, entry := range history {
+			if entry.MedicineID == 0 || !entry.CreatedAt.After(since) {
+				continue
+			}
+			counts[entry.MedicineID]++
+			names[entry.MedicineID] = entry.MedicineName
+		}
+	}
+
+	top := []MedicineCount{}
+	for id, count := range counts {
+		top = append(top, MedicineCount{MedicineID: id, Name: names[id], Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count == top[j].Count {
+			return top[i].Name < top[j].Name
+		}
+		return top[i].Count > top[j].Count
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+
+	return top
+}
+
+// AddFavorite добавляет лекарство в избранное, возвращает false если оно уже там
+func (s *Store) AddFavorite(userID int64, favorite Favorite) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.data.Favorites[userID] {
+		if item.MedicineID == favorite.MedicineID && item.Profile == favorite.Profile {
+			return false
+		}
+	}
+
+	favorite.CreatedAt = time.Now()
+	s.data.Favorites[userID] = append(s.data.Favorites[userID], favorite)
+	s.save()
+
+	return true
+}
+
+func (s *Store) ChatSettings(chatID int64) ChatSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.data.Chats[chatID]
+	if !ok {
+		return ChatSettings{}
+	}
+
+	return settings.clone()
+}
+
+// clone копирует настройки чата вместе с профилями и особенностями здоровья,
+// чтобы копию можно было читать без блокировки хранилища
+func (c *ChatSettings) clone() ChatSettings {
+	copied := *c
+	if c.Profiles != nil {
+		copied.Profiles = append([]string{}, c.Profiles...)
+	}
+	if c.Health != nil {
+		copied.Health = map[string]ProfileHealth{}
+		for profile, health := range c.Health {
+			if health.Allergies != nil {
+				health.Allergies = append([]string{}, health.Allergies...)
+			}
+			copied.Health[profile] = health
+		}
+	}
+
+	return copied
+}
+
+// ChatByCalendarToken находит чат по секрету ссылки подписки на календарь
+func (s *Store) ChatByCalendarToken(token string) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for chatID, settings := range s.data.Chats {
+		if len(token) > 0 && settings.CalendarToken == token {
+			return chatID, true
+		}
+	}
+
+	return 0, false
+}
+
+// UpdateChatSettings изменяет настройки чата функцией update и сохраняет их
+func (s *Store) UpdateChatSettings(chatID int64, update func(settings *ChatSettings)) ChatSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.data.Chats[chatID]
+	if !ok {
+		settings = &ChatSettings{}
+		s.data.Chats[chatID] = settings
+	}
+	update(settings)
+	s.save()
+
+	return settings.clone()
+}
+
+// AddReminder сохраняет напоминание и присваивает ему номер
+func (s *Store) AddReminder(reminder Reminder) Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.LastReminderID++
+	reminder.ID = s.data.LastReminderID
+	reminder.CreatedAt = time.Now()
+	s.data.Reminders = append(s.data.Reminders, reminder)
+	s.save()
+
+	return reminder
+}
+
+func (s *Store) Reminder(reminderID int) (Reminder, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, reminder := range s.data.Reminders {
+		if reminder.ID == reminderID {
+			return reminder, true
+		}
+	}
+
+	return Reminder{}, false
+}
+
+func (s *Store) Reminders(chatID int64) []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminders := []Reminder{}
+	for _, reminder := range s.data.Reminders {
+		if reminder.ChatID == chatID {
+			reminders = append(reminders, reminder)
+		}
+	}
+
+	return reminders
+}
+
+func (s *Store) AllReminders() []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Reminder{}, s.data.Reminders...)
+}
+
+// DueReminders возвращает напоминания, время которых наступило к now
+func (s *Store) DueReminders(now time.Time) []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []Reminder{}
+	for _, reminder := range s.data.Reminders {
+		scheduled := !reminder.NextAt.IsZero() && !reminder.NextAt.After(now)
+		snoozed := !reminder.SnoozedUntil.IsZero() && !reminder.SnoozedUntil.After(now)
+		if scheduled || snoozed {
+			due = append(due, reminder)
+		}
+	}
+
+	return due
+}
+
+// UpdateReminder изменяет напоминание функцией update и сохраняет его
+func (s *Store) UpdateReminder(reminderID int, update func(reminder *Reminder)) (Reminder, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.Reminders {
+		if s.data.Reminders[index].ID == reminderID {
+			update(&s.data.Reminders[index])
+			s.save()
+			return s.data.Reminders[index], true
+		}
+	}
+
+	return Reminder{}, false
+}
+
+func (s *Store) DeleteReminder(reminderID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index, reminder := range s.data.Reminders {
+		if reminder.ID == reminderID {
+			s.data.Reminders = append(s.data.Reminders[:index], s.data.Reminders[index+1:]...)
+			s.save()
+			return
+		}
+	}
+}
+
+// AddDose записывает отправленное напоминание о приеме, старые записи удаляются
+func (s *Store) AddDose(dose Dose) Dose {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.LastDoseID++
+	dose.ID = s.data.LastDoseID
+
+	cutoff := time.Now().Add(-doseRetention)
+	doses := []Dose{}
+	for _, item := range s.data.Doses {
+		if item.ScheduledAt.After(cutoff) {
+			doses = append(doses, item)
+		}
+	}
+	s.data.Doses = append(doses, dose)
+	s.save()
+
+	return dose
+}
+
+func (s *Store) Dose(doseID int) (Dose, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, dose := range s.data.Doses {
+		if dose.ID == doseID {
+			return dose, true
+		}
+	}
+
+	return Dose{}, false
+}
+
+// MarkDoseTaken отмечает прием, возвращает false если он уже был отмечен
+func (s *Store) MarkDoseTaken(doseID int, takenAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.Doses {
+		if s.data.Doses[index].ID == doseID {
+			if !s.data.Doses[index].TakenAt.IsZero() {
+				return false
+			}
+			s.data.Doses[index].TakenAt = takenAt
+			s.save()
+			return true
+		}
+	}
+
+	return false
+}
+
+// DosesSince возвращает приемы чата, назначенные начиная с since
+func (s *Store) DosesSince(chatID int64, since time.Time) []Dose {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doses := []Dose{}
+	for _, dose := range s.data.Doses {
+		if dose.ChatID == chatID && !dose.ScheduledAt.Before(since) {
+			doses = append(doses, dose)
+		}
+	}
+
+	return doses
+}
+
+// AdherenceChats возвращает чаты, включившие еженедельный отчет о приеме
+func (s *Store) AdherenceChats() map[int64]ChatSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := map[int64]ChatSettings{}
+	for chatID, settings := range s.data.Chats {
+		if settings.AdherenceReport {
+			chats[chatID] = settings.clone()
+		}
+	}
+
+	return chats
+}
+
+// WeeklyDigestChats возвращает чаты, включившие личную сводку за неделю
+func (s *Store) WeeklyDigestChats() map[int64]ChatSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := map[int64]ChatSettings{}
+	for chatID, settings := range s.data.Chats {
+		if settings.WeeklyDigest {
+			chats[chatID] = settings.clone()
+		}
+	}
+
+	return chats
+}
+
+func (s *Store) AddShare(list SharedList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list.CreatedAt = time.Now()
+	s.data.Shares = append(s.data.Shares, list)
+	s.save()
+}
+
+// JoinShare добавляет пользователя в участники списка, владелец в участники не добавляется
+func (s *Store) JoinShare(token string, userID int64) (SharedList, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.Shares {
+		list := &s.data.Shares[index]
+		if list.Token != token {
+			continue
+		}
+		if list.OwnerID == userID {
+			return *list, true
+		}
+		for _, member := range list.Members {
+			if member == userID {
+				return *list, true
+			}
+		}
+		list.Members = append(list.Members, userID)
+		s.save()
+		return *list, true
+	}
+
+	return SharedList{}, false
+}
+
+func (s *Store) LeaveShare(token string, userID int64) (SharedList, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.Shares {
+		list := &s.data.Shares[index]
+		if list.Token != token {
+			continue
+		}
+		members := []int64{}
+		for _, member := range list.Members {
+			if member != userID {
+				members = append(members, member)
+			}
+		}
+		list.Members = members
+		s.save()
+		return *list, true
+	}
+
+	return SharedList{}, false
+}
+
+// SharesFor возвращает списки, к которым подключен пользователь
+func (s *Store) SharesFor(userID int64) []SharedList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lists := []SharedList{}
+	for _, list := range s.data.Shares {
+		for _, member := range list.Members {
+			if member == userID {
+				lists = append(lists, list)
+				break
+			}
+		}
+	}
+
+	return lists
+}
+
+// TodayChats возвращает чаты с сообщением /today
+func (s *Store) TodayChats() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chats := []int64{}
+	for chatID, settings := range s.data.Chats {
+		if settings.TodayMessageID != 0 {
+			chats = append(chats, chatID)
+		}
+	}
+
+	return chats
+}
+
+// AddTrip сохраняет поездку и присваивает ей номер
+func (s *Store) AddTrip(trip Trip) Trip {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.LastTripID++
+	trip.ID = s.data.LastTripID
+	trip.CreatedAt = time.Now()
+	s.data.Trips = append(s.data.Trips, trip)
+	s.save()
+
+	return trip
+}
+
+func (s *Store) Trip(tripID int) (Trip, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, trip := range s.data.Trips {
+		if trip.ID == tripID {
+			return trip, true
+		}
+	}
+
+	return Trip{}, false
+}
+
+func (s *Store) Trips(chatID int64) []Trip {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	trips := []Trip{}
+	for _, trip := range s.data.Trips {
+		if trip.ChatID == chatID {
+			trips = append(trips, trip)
+		}
+	}
+
+	return trips
+}
+
+func (s *Store) AllTrips() []Trip {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Trip{}, s.data.Trips...)
+}
+
+// UpdateTrip изменяет поездку функцией update и сохраняет ее
+func (s *Store) UpdateTrip(tripID int, update func(trip *Trip)) (Trip, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.Trips {
+		if s.data.Trips[index].ID == tripID {
+			update(&s.data.Trips[index])
+			s.save()
+			return s.data.Trips[index], true
+		}
+	}
+
+	return Trip{}, false
+}
+
+func (s *Store) DeleteTrip(tripID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index, trip := range s.data.Trips {
+		if trip.ID == tripID {
+			s.data.Trips = append(s.data.Trips[:index], s.data.Trips[index+1:]...)
+			s.save()
+			return
+		}
+	}
+}
+
+// ArchiveTripHistory переносит поиски пользователя за даты поездки из истории в поездку
+func (s *Store) ArchiveTripHistory(tripID int, location *time.Location) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.Trips {
+		trip := &s.data.Trips[index]
+		if trip.ID != tripID || trip.Archived {
+			continue
+		}
+
+		kept := []HistoryEntry{}
+		for _, entry := range s.data.History[trip.UserID] {
+			day := entry.CreatedAt.In(location).Format("2006-01-02")
+			if trip.Start <= day && day <= trip.End {
+				trip.History = append(trip.History, entry)
+			} else {
+				kept = append(kept, entry)
+			}
+		}
+		s.data.History[trip.UserID] = kept
+		trip.Archived = true
+		s.save()
+
+		return len(trip.History)
+	}
+
+	return 0
+}
+
+func (s *Store) TripByToken(token string) (Trip, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, trip := range s.data.Trips {
+		if len(token) > 0 && trip.ShareToken == token {
+			return trip, true
+		}
+	}
+
+	return Trip{}, false
+}
+
+// EntryOverride возвращает измененные администраторами правила ввоза в страну
+func (s *Store) EntryOverride(code string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text, ok := s.data.EntryOverrides[code]
+
+	return text, ok
+}
+
+// SetEntryOverride сохраняет правила ввоза в страну, пустой текст удаляет изменение
+func (s *Store) SetEntryOverride(code string, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(text) == 0 {
+		delete(s.data.EntryOverrides, code)
+	} else {
+		if s.data.EntryOverrides == nil {
+			s.data.EntryOverrides = map[string]string{}
+		}
+		s.data.EntryOverrides[code] = text
+	}
+	s.save()
+}
+
+// AddPermalink сохраняет снимок результатов поиска
+func (s *Store) AddPermalink(permalink Permalink) Permalink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Permalinks = append(s.data.Permalinks, permalink)
+	s.save()
+
+	return permalink
+}
+
+// Permalink возвращает снимок по токену, если срок его действия не истек к now
+func (s *Store) Permalink(token string, now time.Time) (Permalink, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, permalink := range s.data.Permalinks {
+		if len(token) > 0 && permalink.Token == token && now.Before(permalink.ExpiresAt) {
+			return permalink, true
+		}
+	}
+
+	return Permalink{}, false
+}
+
+// PrunePermalinks удаляет истекшие снимки
+func (s *Store) PrunePermalinks(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.data.Permalinks[:0]
+	for _, permalink := range s.data.Permalinks {
+		if now.Before(permalink.ExpiresAt) {
+			kept = append(kept, permalink)
+		}
+	}
+	if len(kept) == len(s.data.Permalinks) {
+		return
+	}
+	s.data.Permalinks = kept
+	s.save()
+}
+
+// IssueAPIKey заменяет ключи владельца новым ключом, владельцу заблокированного ключа новый не выдается
+func (s *Store) IssueAPIKey(key APIKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.data.APIKeys[:0]
+	for _, existing := range s.data.APIKeys {
+		if existing.OwnerID != key.OwnerID {
+			kept = append(kept, existing)
+			continue
+		}
+		if existing.Blocked() {
+			return false
+		}
+	}
+	s.data.APIKeys = append(kept, key)
+	s.save()
+
+	return true
+}
+
+// UseAPIKey находит ключ по хэшу и учитывает запрос, если ключ не заблокирован. На диск
+// счетчики пишутся не чаще раза в apiKeyUsageSave
+func (s *Store) UseAPIKey(hash string, now time.Time) (APIKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.APIKeys {
+		key := &s.data.APIKeys[index]
+		if key.Hash != hash {
+			continue
+		}
+		if !key.Blocked() {
+			key.Requests++
+			key.UsedAt = now
+			// Между записями счетчик остается в памяти и сохраняется вместе с любым другим
+			// изменением, в том числе со сбросом дневных счетчиков метрик
+			if now.Sub(s.apiKeysSavedAt) >= apiKeyUsageSave {
+				s.apiKeysSavedAt = now
+				s.save()
+			}
+		}
+		return *key, true
+	}
+
+	return APIKey{}, false
+}
+
+// APIKeys возвращает ключи владельца, ownerID 0 возвращает все ключи
+func (s *Store) APIKeys(ownerID int64) []APIKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := []APIKey{}
+	for _, key := range s.data.APIKeys {
+		if ownerID == 0 || key.OwnerID == ownerID {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// RevokeAPIKeys удаляет ключи владельца, заблокированные остаются, чтобы блокировку нельзя было обойти
+func (s *Store) RevokeAPIKeys(ownerID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.data.APIKeys[:0]
+	for _, key := range s.data.APIKeys {
+		if key.OwnerID != ownerID || key.Blocked() {
+			kept = append(kept, key)
+		}
+	}
+	s.data.APIKeys = kept
+	s.save()
+}
+
+// BlockAPIKey блокирует ключ id с причиной reason, пустая причина снимает блокировку
+func (s *Store) BlockAPIKey(id string, reason string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.APIKeys {
+		key := &s.data.APIKeys[index]
+		if key.ID != id {
+			continue
+		}
+		key.BlockReason = reason
+		key.BlockedAt = time.Time{}
+		if len(reason) > 0 {
+			key.BlockedAt = now
+		}
+		s.save()
+		return true
+	}
+
+	return false
+}
+
+// BudgetAlert возвращает последний порог бюджета, о котором предупреждали в месяце month
+func (s *Store) BudgetAlert(month string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.BudgetAlerts[month]
+}
+
+func (s *Store) SetBudgetAlert(month string, threshold int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.BudgetAlerts == nil {
+		s.data.BudgetAlerts = map[string]int{}
+	}
+	s.data.BudgetAlerts[month] = threshold
+	s.save()
+}
+
+// SetOverride сохраняет исправление данных. Исправление того же вида для того же лекарства,
+// страны и аналога заменяется, его номер остается прежним
+func (s *Store) SetOverride(override Override) Override {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	override.CreatedAt = time.Now()
+	for index, existing := range s.data.Overrides {
+		if existing.sameTarget(override) {
+			override.ID = existing.ID
+			s.data.Overrides[index] = override
+			s.save()
+			return override
+		}
+	}
+
+	s.data.LastOverrideID++
+	override.ID = s.data.LastOverrideID
+	s.data.Overrides = append(s.data.Overrides, override)
+	s.save()
+
+	return override
+}
+
+// Overrides возвращает исправления лекарства medicineID для страны countryID, включая
+// исправления для всех стран. Нулевое лекарство возвращает все исправления
+func (s *Store) Overrides(medicineID int, countryID int) []Override {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	overrides := []Override{}
+	for _, override := range s.data.Overrides {
+		if medicineID != 0 && (override.MedicineID != medicineID || override.CountryID != 0 && override.CountryID != countryID) {
+			continue
+		}
+		overrides = append(overrides, override)
+	}
+
+	return overrides
+}
+
+func (s *Store) DeleteOverride(overrideID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index, override := range s.data.Overrides {
+		if override.ID == overrideID {
+			s.data.Overrides = append(s.data.Overrides[:index], s.data.Overrides[index+1:]...)
+			s.save()
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddWatch начинает следить за лекарством в стране. Если чат уже следит за ним, возвращается
+// прежняя запись и false
+func (s *Store) AddWatch(watch Watch) (Watch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.data.Watches {
+		if existing.ChatID == watch.ChatID && existing.MedicineID == watch.MedicineID && existing.CountryID == watch.CountryID {
+			return existing, false
+		}
+	}
+
+	s.data.LastWatchID++
+	watch.ID = s.data.LastWatchID
+	watch.CreatedAt = time.Now()
+	watch.CheckedAt = watch.CreatedAt
+	s.data.Watches = append(s.data.Watches, watch)
+	s.save()
+
+	return watch, true
+}
+
+// Watch возвращает запись, за которой чат следит для лекарства в стране
+func (s *Store) Watch(chatID int64, medicineID int, countryID int) (Watch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, watch := range s.data.Watches {
+		if watch.ChatID == chatID && watch.MedicineID == medicineID && watch.CountryID == countryID {
+			return watch, true
+		}
+	}
+
+	return Watch{}, false
+}
+
+// Watches возвращает лекарства, за которыми следит чат
+func (s *Store) Watches(chatID int64) []Watch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watches := []Watch{}
+	for _, watch := range s.data.Watches {
+		if watch.ChatID == chatID {
+			watches = append(watches, watch)
+		}
+	}
+
+	return watches
+}
+
+func (s *Store) AllWatches() []Watch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Watch{}, s.data.Watches...)
+}
+
+// UpdateWatch изменяет запись функцией update и сохраняет ее
+func (s *Store) UpdateWatch(watchID int, update func(watch *Watch)) (Watch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.Watches {
+		if s.data.Watches[index].ID == watchID {
+			update(&s.data.Watches[index])
+			s.save()
+			return s.data.Watches[index], true
+		}
+	}
+
+	return Watch{}, false
+}
+
+// DeleteWatch перестает следить за лекарством, чужие записи чат удалить не может
+func (s *Store) DeleteWatch(chatID int64, watchID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index, watch := range s.data.Watches {
+		if watch.ID == watchID && watch.ChatID == chatID {
+			s.data.Watches = append(s.data.Watches[:index], s.data.Watches[index+1:]...)
+			s.save()
+			return true
+		}
+	}
+
+	return false
+}
+
+// PauseWatches ставит на паузу или возобновляет все записи слежения чата вместе с целевыми ценами
+func (s *Store) PauseWatches(chatID int64, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for index := range s.data.Watches {
+		watch := &s.data.Watches[index]
+		if watch.ChatID != chatID {
+			continue
+		}
+		watch.Paused = paused
+		for alert := range watch.PriceAlerts {
+			watch.PriceAlerts[alert].Paused = paused
+		}
+	}
+	s.save()
+}
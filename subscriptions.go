@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// subscriptionsView список подписок чата: слежение за аналогами, целевые цены, сводка и отчет
+// о приеме. Каждая строка клавиатуры включает или удаляет одну подписку
+func subscriptionsView(chatID int64) (string, *models.InlineKeyboardMarkup) {
+	settings := Storage.ChatSettings(chatID)
+	watches := Storage.Watches(chatID)
+
+	lines := []string{bold("Подписки")}
+	buttons := [][]models.InlineKeyboardButton{}
+	toggle := func(enabled bool, title string) string {
+		if enabled {
+			return "🔔 " + title
+		}
+		return "🔕 " + title
+	}
+
+	active := false
+	for _, watch := range watches {
+		state := "включено"
+		if watch.Paused {
+			state = "на паузе"
+		}
+		active = active || !watch.Paused
+		lines = append(lines, fmt.Sprintf("👁 %s, %s: %s", bold(watch.MedicineName), escapeHTML(watch.CountryName()), state))
+		id := strconv.Itoa(watch.ID)
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: toggle(!watch.Paused, watch.MedicineName), CallbackData: "subs:watch:" + id},
+			{Text: "🗑", CallbackData: "subs:watch_delete:" + id},
+		})
+		for _, alert := range watch.PriceAlerts {
+			active = active || !alert.Paused
+			lines = append(lines, "  "+priceAlertText(alert))
+			buttons = append(buttons, []models.InlineKeyboardButton{
+				{Text: toggle(!alert.Paused, "💰 "+alert.AnalogName), CallbackData: "subs:price:" + id + ":" + alert.AnalogID},
+				{Text: "🗑", CallbackData: "subs:price_delete:" + id + ":" + alert.AnalogID},
+			})
+		}
+	}
+
+	lines = append(lines, toggle(settings.WeeklyDigest, "Сводка за неделю, /weekly"))
+	buttons = append(buttons, []models.InlineKeyboardButton{
+		{Text: toggle(settings.WeeklyDigest, "Сводка за неделю"), CallbackData: "subs:weekly"},
+	})
+	lines = append(lines, toggle(settings.AdherenceReport, "Отчет о приеме, /adherence"))
+	buttons = append(buttons, []models.InlineKeyboardButton{
+		{Text: toggle(settings.AdherenceReport, "Отчет о приеме"), CallbackData: "subs:adherence"},
+	})
+
+	if active || settings.WeeklyDigest || settings.AdherenceReport {
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: "🔕 Отключить всё", CallbackData: "subs:off_all"},
+		})
+	}
+	if len(watches) == 0 {
+		lines = append(lines, "\n"+italic("Следить за аналогами можно кнопкой 👁 Следить под списком аналогов."))
+	}
+
+	return strings.Join(lines, "\n"), &models.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+func subscriptionsHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	text, markup := subscriptionsView(update.Message.Chat.ID)
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: markup,
+	})
+}
+
+// canManageSubscription проверяет, что пользователь может изменить подписку автора ownerID.
+// В группе чужие подписки и настройки всего чата меняют только администраторы
+func canManageSubscription(ctx context.Context, b *bot.Bot, chatID int64, userID int64, ownerID int64) bool {
+	if chatID > 0 || (ownerID != 0 && ownerID == userID) {
+		return true
+	}
+
+	member, err := b.GetChatMember(ctx, &bot.GetChatMemberParams{ChatID: chatID, UserID: userID})
+
+	return err == nil && (member.Type == models.ChatMemberTypeOwner || member.Type == models.ChatMemberTypeAdministrator)
+}
+
+// subscriptionCallbackHandler переключает подписки из /subscriptions:
+// subs:watch:<запись>, subs:watch_delete:<запись>, subs:price:<запись>:<аналог>,
+// subs:price_delete:<запись>:<аналог>, subs:weekly, subs:adherence, subs:off_all
+func subscriptionCallbackHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.CallbackQuery
+	chatID := callbackChatID(query)
+	parts := strings.Split(strings.TrimPrefix(query.Data, "subs:"), ":")
+	watchID := 0
+	if len(parts) > 1 {
+		watchID, _ = strconv.Atoi(parts[1])
+	}
+	analogID := ""
+	if len(parts) > 2 {
+		analogID = parts[2]
+	}
+	// Записи чужого чата не меняются
+	watch, owned := watchByID(chatID, watchID)
+
+	// Владелец 0 у настроек всего чата, в группе их меняют только администраторы
+	ownerID := int64(0)
+	switch parts[0] {
+	case "watch", "watch_delete":
+		ownerID = watch.UserID
+	case "price", "price_delete":
+		for _, alert := range watch.PriceAlerts {
+			if alert.AnalogID == analogID {
+				ownerID = alert.UserID
+			}
+		}
+	}
+	if !canManageSubscription(ctx, b, chatID, query.From.ID, ownerID) {
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: query.ID,
+			Text:            "Изменить подписку может ее автор или администратор чата",
+			ShowAlert:       true,
+		})
+		return
+	}
+
+	note := ""
+	switch parts[0] {
+	case "watch":
+		if owned {
+			Storage.UpdateWatch(watchID, func(watch *Watch) {
+				watch.Paused = !watch.Paused
+			})
+		}
+	case "watch_delete":
+		if Storage.DeleteWatch(chatID, watchID) {
+			note = "Слежение удалено"
+		}
+	case "price", "price_delete":
+		if owned {
+			Storage.UpdateWatch(watchID, func(watch *Watch) {
+				alerts := []PriceAlert{}
+				for _, alert := range watch.PriceAlerts {
+					if alert.AnalogID == analogID {
+						if parts[0] == "price_delete" {
+							continue
+						}
+						alert.Paused = !alert.Paused
+					}
+					alerts = append(alerts, alert)
+				}
+				watch.PriceAlerts = alerts
+			})
+		}
+	case "weekly":
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.WeeklyDigest = !settings.WeeklyDigest
+			// Включенная заново сводка не приходит сразу за прошлую неделю
+			settings.WeeklyDigestSentAt = time.Now()
+		})
+	case "adherence":
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.AdherenceReport = !settings.AdherenceReport
+			settings.AdherenceSentAt = time.Now()
+		})
+	case "off_all":
+		Storage.PauseWatches(chatID, true)
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.WeeklyDigest = false
+			settings.AdherenceReport = false
+		})
+		AppMetrics.Incr("subscriptions_off_all")
+		note = "Все уведомления отключены"
+	}
+
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: query.ID,
+		Text:            note,
+	})
+	if query.Message.Message == nil {
+		return
+	}
+
+	text, markup := subscriptionsView(chatID)
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:      query.Message.Message.Chat.ID,
+		MessageID:   query.Message.Message.ID,
+		Text:        text,
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		logError(err)
+	}
+}
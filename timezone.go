@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	// База часовых поясов встроена в бинарник для запуска в контейнерах без tzdata
+	_ "time/tzdata"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// loadTimezone понимает названия IANA (Europe/Moscow) и смещения вида UTC+3 или UTC-5:30
+func loadTimezone(name string) (*time.Location, error) {
+	if offset, ok := strings.CutPrefix(strings.ToUpper(name), "UTC"); ok {
+		if len(offset) == 0 {
+			return time.UTC, nil
+		}
+
+		sign := 1
+		switch offset[0] {
+		case '+':
+		case '-':
+			sign = -1
+		default:
+			return nil, errors.New("неизвестный часовой пояс " + name)
+		}
+
+		hoursText, minutesText, _ := strings.Cut(offset[1:], ":")
+		hours, err := strconv.Atoi(hoursText)
+		if err != nil || hours > 14 {
+			return nil, errors.New("неизвестный часовой пояс " + name)
+		}
+		minutes := 0
+		if len(minutesText) > 0 {
+			if minutes, err = strconv.Atoi(minutesText); err != nil || minutes >= 60 {
+				return nil, errors.New("неизвестный часовой пояс " + name)
+			}
+		}
+
+		return time.FixedZone(name, sign*(hours*3600+minutes*60)), nil
+	}
+
+	if !strings.Contains(name, "/") {
+		return nil, errors.New("неизвестный часовой пояс " + name)
+	}
+
+	return time.LoadLocation(name)
+}
+
+// chatLocation возвращает часовой пояс чата, по умолчанию часовой пояс сервера
+func chatLocation(chatID int64) *time.Location {
+	timezone := Storage.ChatSettings(chatID).Timezone
+	if len(timezone) == 0 {
+		return time.Local
+	}
+
+	location, err := loadTimezone(timezone)
+	if err != nil {
+		return time.Local
+	}
+
+	return location
+}
+
+// approximateTimezone оценивает часовой пояс по долготе, если для страны он не задан.
+// Такой пояс не учитывает летнее время, поэтому используется только как запасной
+func approximateTimezone(longitude float64) string {
+	hours := int(math.Round(longitude / 15))
+	if hours >= 0 {
+		return fmt.Sprintf("UTC+%d", hours)
+	}
+
+	return fmt.Sprintf("UTC%d", hours)
+}
+
+// setChatTimezone сохраняет часовой пояс чата и пересчитывает его напоминания
+func setChatTimezone(chatID int64, timezone string) {
+	Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+		settings.Timezone = timezone
+		settings.TimezoneInferred = false
+	})
+	rescheduleReminders(chatID)
+}
+
+// setInferredTimezone сохраняет часовой пояс, определенный по геопозиции
+func setInferredTimezone(chatID int64, timezone string) {
+	Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+		settings.Timezone = timezone
+		settings.TimezoneInferred = true
+	})
+	rescheduleReminders(chatID)
+}
+
+// rescheduleReminders пересчитывает время следующих напоминаний чата
+func rescheduleReminders(chatID int64) {
+	now := time.Now()
+	for _, reminder := range Storage.Reminders(chatID) {
+		location := reminder.Location()
+		Storage.UpdateReminder(reminder.ID, func(reminder *Reminder) {
+			reminder.NextAt = nextReminderTime(reminder.Times, location, now)
+		})
+	}
+}
+
+func timezoneHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	_, name, _ := strings.Cut(update.Message.Text, " ")
+	name = strings.TrimSpace(name)
+
+	if len(name) == 0 {
+		location := chatLocation(update.Message.Chat.ID)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text: fmt.Sprintf("Часовой пояс: %s, сейчас %s.\n\nЧтобы изменить его, укажите название или смещение: /timezone Europe/Moscow или /timezone UTC+7. Также можно просто отправить геопозицию.",
+				location.String(), time.Now().In(location).Format("15:04")),
+		})
+		return
+	}
+
+	location, err := loadTimezone(name)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Не знаю такого часового пояса. Примеры: Europe/Moscow, Asia/Bangkok, UTC+7.",
+		})
+		return
+	}
+
+	setChatTimezone(update.Message.Chat.ID, name)
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("Часовой пояс: %s, сейчас %s. Напоминания пересчитаны.", location.String(), time.Now().In(location).Format("15:04")),
+	})
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// todayTexts хранит последний текст обзора дня по чатам, чтобы не редактировать сообщение без изменений
+var todayTexts = NewRecentMap[int64, string](1000)
+
+type todayEntry struct {
+	At       time.Time
+	Reminder Reminder
+	Mark     string
+}
+
+// formatToday готовит обзор приемов на сегодня по всем профилям чата
+func formatToday(chatID int64, now time.Time) string {
+	location := chatLocation(chatID)
+	local := now.In(location)
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	taken := map[string]bool{}
+	sent := map[string]bool{}
+	for _, dose := range Storage.DosesSince(chatID, dayStart) {
+		key := fmt.Sprintf("%d:%d", dose.ReminderID, dose.ScheduledAt.Unix())
+		sent[key] = true
+		if !dose.TakenAt.IsZero() {
+			taken[key] = true
+		}
+	}
+
+	entries := []todayEntry{}
+	for _, reminder := range Storage.Reminders(chatID) {
+		reminderLocation := reminder.Location()
+		for _, value := range reminder.Times {
+			clock, err := time.Parse("15:04", value)
+			if err != nil {
+				continue
+			}
+			reminderDay := dayStart.In(reminderLocation)
+			at := time.Date(reminderDay.Year(), reminderDay.Month(), reminderDay.Day(), clock.Hour(), clock.Minute(), 0, 0, reminderLocation)
+			if at.Before(dayStart) || !at.Before(dayEnd) {
+				continue
+			}
+
+			key := fmt.Sprintf("%d:%d", reminder.ID, at.Unix())
+			mark := "⏳"
+			switch {
+			case taken[key]:
+				mark = "✅"
+			case sent[key] && now.Sub(at) < reminderStaleAfter:
+				mark = "⏰"
+			case at.Before(now):
+				mark = "❌"
+			}
+			entries = append(entries, todayEntry{At: at, Reminder: reminder, Mark: mark})
+		}
+	}
+
+	if len(entries) == 0 {
+		return "На сегодня приемов нет. Создать напоминание: /remind"
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].At.Before(entries[j].At)
+	})
+
+	var text strings.Builder
+	text.WriteString(bold("Приемы на "+local.Format("02.01")) + "\n")
+	for _, entry := range entries {
+		text.WriteString(fmt.Sprintf("\n%s <code>%s</code> %s%s", entry.Mark, entry.At.In(location).Format("15:04"),
+			escapeHTML(profileLabel(chatID, entry.Reminder.Profile)), escapeHTML(entry.Reminder.Title())))
+	}
+	text.WriteString("\n\n✅ принято ⏰ ждет отметки ❌ пропущено ⏳ впереди")
+	text.WriteString(fmt.Sprintf("\nОбновлено в %s", now.In(location).Format("15:04")))
+
+	return text.String()
+}
+
+func todayHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	now := time.Now()
+	text := formatToday(chatID, now)
+
+	sent, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	// Последнее сообщение /today обновляется до конца дня
+	Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+		settings.TodayMessageID = sent.ID
+		settings.TodayDate = now.In(chatLocation(chatID)).Format("2006-01-02")
+	})
+	todayTexts.Set(chatID, text)
+}
+
+// refreshToday обновляет сегодняшний обзор приемов чата, если он был запрошен
+func refreshToday(ctx context.Context, b *bot.Bot, chatID int64, now time.Time) {
+	settings := Storage.ChatSettings(chatID)
+	if settings.TodayMessageID == 0 || settings.TodayDate != now.In(chatLocation(chatID)).Format("2006-01-02") {
+		return
+	}
+
+	text := formatToday(chatID, now)
+	// Время обновления не считается изменением
+	if previous, ok := todayTexts.Get(chatID); ok && stripUpdated(previous) == stripUpdated(text) {
+		return
+	}
+
+	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatID,
+		MessageID: settings.TodayMessageID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+	})
+	if err != nil {
+		logError(err)
+		return
+	}
+	todayTexts.Set(chatID, text)
+}
+
+func stripUpdated(text string) string {
+	before, _, _ := strings.Cut(text, "\nОбновлено в ")
+
+	return before
+}
+
+// todayJob отмечает пропущенные приемы в обзорах дня
+func todayJob(b *bot.Bot) Job {
+	return Job{
+		Name: "today",
+		Next: every(5 * time.Minute),
+		Run: func(ctx context.Context) {
+			now := time.Now()
+			for _, chatID := range Storage.TodayChats() {
+				refreshToday(ctx, b, chatID, now)
+			}
+		},
+	}
+}
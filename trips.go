@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// Trip поездка, на время которой страной поиска становится страна поездки.
+// Start и End даты в формате 2006-01-02 по часовому поясу чата, End включительно
+type Trip struct {
+	ID        int    `json:"id"`
+	ChatID    int64  `json:"chat_id"`
+	UserID    int64  `json:"user_id"`
+	CountryID int    `json:"country_id"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	// Checklist список лекарств в дорогу
+	Checklist []ChecklistItem `json:"checklist,omitempty"`
+	// ChecklistMessages сообщения со списком, которые обновляются при каждом изменении
+	ChecklistMessages []MessageRef `json:"checklist_messages,omitempty"`
+	// ShareToken токен ссылки для добавления поездки в группу попутчиков
+	ShareToken  string `json:"share_token,omitempty"`
+	GroupChatID int64  `json:"group_chat_id,omitempty"`
+	// Expenses покупки лекарств во время поездки
+	Expenses []Expense `json:"expenses,omitempty"`
+	// Rechecked отмечает, что аналоги из списка перепроверены перед отъездом
+	Rechecked bool `json:"rechecked,omitempty"`
+	// Ended отмечает поездку, об окончании которой пользователь уже уведомлен
+	Ended bool `json:"ended,omitempty"`
+	// History поиски во время поездки, перенесенные в архив
+	History   []HistoryEntry `json:"history,omitempty"`
+	Archived  bool           `json:"archived,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// MessageRef ссылка на отправленное сообщение
+type MessageRef struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int   `json:"message_id"`
+}
+
+// Active проверяет, идет ли поездка в день today
+func (t Trip) Active(today string) bool {
+	return !t.Ended && t.Start <= today && today <= t.End
+}
+
+// VisibleIn проверяет, что поездка доступна в чате: личном чате владельца или группе попутчиков
+func (t Trip) VisibleIn(chatID int64) bool {
+	return t.ChatID == chatID || (t.GroupChatID != 0 && t.GroupChatID == chatID)
+}
+
+func (t Trip) CountryName() string {
+	if country, ok := countryByID(t.CountryID); ok {
+		return country.Name
+	}
+
+	return strconv.Itoa(t.CountryID)
+}
+
+// Dates возвращает даты поездки для показа пользователю
+func (t Trip) Dates() string {
+	start, _ := time.Parse("2006-01-02", t.Start)
+	end, _ := time.Parse("2006-01-02", t.End)
+
+	return start.Format("02.01.2006") + "–" + end.Format("02.01.2006")
+}
+
+// chatToday возвращает сегодняшнюю дату в часовом поясе чата
+func chatToday(chatID int64) string {
+	return time.Now().In(chatLocation(chatID)).Format("2006-01-02")
+}
+
+// activeTrip возвращает текущую поездку чата
+func activeTrip(chatID int64) (Trip, bool) {
+	today := chatToday(chatID)
+	for _, trip := range Storage.Trips(chatID) {
+		if trip.Active(today) {
+			return trip, true
+		}
+	}
+
+	return Trip{}, false
+}
+
+// findCountry ищет страну по коду или названию
+func findCountry(text string) (Country, bool) {
+	if country, ok := countryByCode(text); ok {
+		return country, true
+	}
+	for _, country := range Countries {
+		if strings.EqualFold(country.Name, text) {
+			return country, true
+		}
+	}
+
+	return Country{}, false
+}
+
+// parseTripDate понимает 01.11 и 01.11.2026, дата без года считается ближайшей будущей
+func parseTripDate(text string, today time.Time) (time.Time, bool) {
+	if date, err := time.Parse("02.01.2006", text); err == nil {
+		return date, true
+	}
+
+	date, err := time.Parse("02.01", text)
+	if err != nil {
+		return time.Time{}, false
+	}
+	date = time.Date(today.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if date.Before(time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)) {
+		date = date.AddDate(1, 0, 0)
+	}
+
+	return date, true
+}
+
+// parseTrip разбирает аргументы /trip: страну и даты «01.11-15.11» или «01.11 15.11»
+func parseTrip(args string, today time.Time) (Trip, bool) {
+	fields := strings.Fields(strings.ReplaceAll(strings.ReplaceAll(args, "–", " "), "-", " "))
+	if len(fields) < 3 {
+		return Trip{}, false
+	}
+
+	start, ok := parseTripDate(fields[len(fields)-2], today)
+	if !ok {
+		return Trip{}, false
+	}
+	end, ok := parseTripDate(fields[len(fields)-1], today)
+	if !ok {
+		return Trip{}, false
+	}
+	if end.Before(start) {
+		end = end.AddDate(1, 0, 0)
+	}
+
+	country, ok := findCountry(strings.Join(fields[:len(fields)-2], " "))
+	if !ok {
+		return Trip{}, false
+	}
+
+	return Trip{
+		CountryID: country.ID,
+		Start:     start.Format("2006-01-02"),
+		End:       end.Format("2006-01-02"),
+	}, true
+}
+
+func countryNames() string {
+	names := []string{}
+	for _, country := range Countries {
+		names = append(names, country.Name+" ("+country.Code+")")
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func tripHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message.From == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	_, args, _ := strings.Cut(update.Message.Text, " ")
+	if len(strings.TrimSpace(args)) == 0 {
+		sendTrips(ctx, b, chatID)
+		return
+	}
+
+	trip, ok := parseTrip(args, time.Now().In(chatLocation(chatID)))
+	if !ok {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Укажите страну и даты поездки, например: /trip Таиланд 01.11-15.11\n\nДоступные страны: " + countryNames(),
+		})
+		return
+	}
+
+	trip.ChatID = chatID
+	trip.UserID = update.Message.From.ID
+	trip.ShareToken = newShareToken()
+	trip = Storage.AddTrip(trip)
+
+	AppMetrics.Incr("trips_created")
+
+	text := fmt.Sprintf("Поездка в %s на %s сохранена. В эти дни я буду искать аналоги, которые продаются в %s.",
+		bold(trip.CountryName()), trip.Dates(), escapeHTML(trip.CountryName()))
+	if trip.Active(chatToday(chatID)) {
+		text = fmt.Sprintf("Поездка в %s на %s началась, теперь я ищу аналоги, которые продаются там.",
+			bold(trip.CountryName()), trip.Dates())
+	}
+
+	buttons := [][]models.InlineKeyboardButton{
+		{{Text: "Список в дорогу", CallbackData: "trip_checklist:" + strconv.Itoa(trip.ID)}},
+	}
+	// В повторной поездке в ту же страну можно сразу взять найденные тогда аналоги
+	if past, ok := previousTrip(trip); ok {
+		text += fmt.Sprintf("\n\nВы уже были в %s %s, можно взять список из той поездки.", escapeHTML(past.CountryName()), past.Dates())
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: "Список из прошлой поездки", CallbackData: fmt.Sprintf("trip_reuse:%d:%d", trip.ID, past.ID)},
+		})
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: buttons,
+		},
+	})
+}
+
+func sendTrips(ctx context.Context, b *bot.Bot, chatID int64) {
+	today := chatToday(chatID)
+	var text strings.Builder
+	buttons := [][]models.InlineKeyboardButton{}
+	for _, trip := range Storage.Trips(chatID) {
+		if trip.Ended || trip.End < today {
+			continue
+		}
+		status := "запланирована"
+		if trip.Active(today) {
+			status = "идет сейчас"
+		}
+		text.WriteString(fmt.Sprintf("\n• %s, %s — %s", bold(trip.CountryName()), trip.Dates(), status))
+		buttons = append(buttons, []models.InlineKeyboardButton{
+			{Text: "Список в дорогу: " + trip.CountryName(), CallbackData: "trip_checklist:" + strconv.Itoa(trip.ID)},
+			{Text: "Отменить", CallbackData: "trip_cancel:" + strconv.Itoa(trip.ID)},
+		})
+		if len(BotUsername) > 0 && len(trip.ShareToken) > 0 && trip.GroupChatID == 0 {
+			buttons = append(buttons, []models.InlineKeyboardButton{
+				{Text: "Добавить в группу попутчиков", URL: tripGroupLink(trip.ShareToken)},
+			})
+		}
+	}
+
+	if text.Len() == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Поездок не запланировано. Добавьте поездку, например: /trip Таиланд 01.11-15.11",
+		})
+		return
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      bold("Поездки") + "\n" + text.String(),
+		ParseMode: models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: buttons,
+		},
+	})
+}
+
+// tripCallback разбирает номер поездки из кнопки и проверяет, что она принадлежит чату
+func tripCallback(update *models.Update) (Trip, bool) {
+	_, value, _ := strings.Cut(update.CallbackQuery.Data, ":")
+	tripID, err := strconv.Atoi(value)
+	if err != nil {
+		return Trip{}, false
+	}
+
+	trip, ok := Storage.Trip(tripID)
+	if !ok || !trip.VisibleIn(callbackChatID(update.CallbackQuery)) {
+		return Trip{}, false
+	}
+
+	return trip, true
+}
+
+func tripCancelHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	trip, ok := tripCallback(update)
+	if !ok || trip.ChatID != callbackChatID(update.CallbackQuery) {
+		return
+	}
+
+	Storage.DeleteTrip(trip.ID)
+	editCallbackMessage(ctx, b, update.CallbackQuery, fmt.Sprintf("Поездка в %s на %s отменена.", bold(trip.CountryName()), trip.Dates()))
+}
+
+// tripsJob перепроверяет списки перед отъездом и завершает закончившиеся поездки
+func tripsJob(b *bot.Bot) Job {
+	return Job{
+		Name: "trips",
+		Next: every(30 * time.Minute),
+		Run: func(ctx context.Context) {
+			now := time.Now()
+			recheckTrips(ctx, b, now)
+			finishTrips(ctx, b, now)
+		},
+	}
+}
+
+func finishTrips(ctx context.Context, b *bot.Bot, now time.Time) {
+	for _, trip := range Storage.AllTrips() {
+		if trip.Ended || trip.End >= now.In(chatLocation(trip.ChatID)).Format("2006-01-02") {
+			continue
+		}
+		if _, quiet := chatQuietUntil(trip.ChatID, now); quiet {
+			continue
+		}
+
+		Storage.UpdateTrip(trip.ID, func(trip *Trip) {
+			trip.Ended = true
+		})
+
+		if len(trip.Expenses) > 0 {
+			_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    trip.ChatID,
+				Text:      formatExpenses(trip) + "\n\nСохраните эту сводку и чеки для страховой компании.",
+				ParseMode: models.ParseModeHTML,
+			})
+			if err != nil {
+				logError(err)
+			}
+		}
+
+		text := fmt.Sprintf("Поездка в %s закончилась, я снова ищу аналоги в стране по умолчанию. Перенести поиски за время поездки в архив? Его можно посмотреть в /trips.",
+			bold(trip.CountryName()))
+		if country, ok := countryByID(targetCountry(trip.ChatID)); ok {
+			text = fmt.Sprintf("Поездка в %s закончилась, страна поиска снова %s. Перенести поиски за время поездки в архив? Его можно посмотреть в /trips.",
+				bold(trip.CountryName()), bold(country.Name))
+		}
+
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    trip.ChatID,
+			Text:      text,
+			ParseMode: models.ParseModeHTML,
+			ReplyMarkup: &models.InlineKeyboardMarkup{
+				InlineKeyboard: [][]models.InlineKeyboardButton{
+					{
+						{Text: "В архив", CallbackData: "trip_archive:" + strconv.Itoa(trip.ID)},
+						{Text: "Оставить", CallbackData: "trip_keep:" + strconv.Itoa(trip.ID)},
+					},
+				},
+			},
+		})
+		if err != nil {
+			logError(err)
+		}
+	}
+}
+
+func tripArchiveHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	trip, ok := tripCallback(update)
+	if !ok {
+		return
+	}
+
+	count := Storage.ArchiveTripHistory(trip.ID, chatLocation(trip.ChatID))
+	editCallbackMessage(ctx, b, update.CallbackQuery, fmt.Sprintf("Поездка в %s закончилась. В архив перенесено поисков: %d.", bold(trip.CountryName()), count))
+}
+
+func tripKeepHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		ShowAlert:       false,
+	})
+
+	trip, ok := tripCallback(update)
+	if !ok {
+		return
+	}
+
+	editCallbackMessage(ctx, b, update.CallbackQuery, fmt.Sprintf("Поездка в %s закончилась, история поисков сохранена.", bold(trip.CountryName())))
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// voiceAnalogsLimit количество аналогов, зачитываемых в голосовом сообщении
+const voiceAnalogsLimit = 3
+
+// TTSProvider озвучивает текст и возвращает аудио OGG/Opus для голосового сообщения
+type TTSProvider interface {
+	Synthesize(ctx context.Context, text string) ([]byte, error)
+}
+
+// ExecTTS запускает команду, которая читает текст из stdin и пишет OGG/Opus в stdout,
+// например скрипт, передающий вывод espeak-ng в opusenc
+type ExecTTS struct {
+	Command []string
+}
+
+func (e *ExecTTS) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("tts: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
+
+// GoogleTTS использует Google Cloud Text-to-Speech
+type GoogleTTS struct {
+	ApiKey   string
+	Language string
+}
+
+func (g *GoogleTTS) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	body, err := json.Marshal(map[string]any{
+		"input":       map[string]string{"text": text},
+		"voice":       map[string]string{"languageCode": g.Language},
+		"audioConfig": map[string]string{"audioEncoding": "OGG_OPUS"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", "https://texttospeech.googleapis.com/v1/text:synthesize?key="+g.ApiKey, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	synthesizeResponse := struct {
+		AudioContent string `json:"audioContent"`
+		Error        *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&synthesizeResponse); err != nil {
+		return nil, err
+	}
+	if synthesizeResponse.Error != nil {
+		return nil, errors.New("tts: " + synthesizeResponse.Error.Message)
+	}
+
+	return base64.StdEncoding.DecodeString(synthesizeResponse.AudioContent)
+}
+
+// newTTSProvider создает озвучивание по настройке TTS_PROVIDER, nil если оно отключено
+func newTTSProvider(name string) TTSProvider {
+	switch name {
+	case "exec":
+		command := strings.Fields(os.Getenv("TTS_COMMAND"))
+		if len(command) == 0 {
+			return nil
+		}
+		return &ExecTTS{Command: command}
+	case "google":
+		language := os.Getenv("TTS_LANGUAGE")
+		if len(language) == 0 {
+			language = "ru-RU"
+		}
+		return &GoogleTTS{ApiKey: os.Getenv("TTS_API_KEY"), Language: language}
+	}
+
+	return nil
+}
+
+func voiceHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if TTS == nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: update.Message.Chat.ID,
+			Text:   "Голосовые ответы недоступны.",
+		})
+		return
+	}
+
+	settings := Storage.UpdateChatSettings(update.Message.Chat.ID, func(settings *ChatSettings) {
+		settings.Voice = !settings.Voice
+	})
+
+	text := "Голосовые ответы выключены."
+	if settings.Voice {
+		text = "Голосовые ответы включены. После списка аналогов я зачитаю самые близкие из них."
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+}
+
+// voiceSummary готовит короткий текст для озвучивания списка аналогов
+func voiceSummary(medicineName string, analogs []Analog) string {
+	names := []string{}
+	for index, analog := range analogs {
+		if index == voiceAnalogsLimit {
+			break
+		}
+		names = append(names, fmt.Sprintf("%s, совпадение %d процентов", analog.AnalogName, analog.Percentage))
+	}
+
+	return fmt.Sprintf("Аналоги для %s: %s.", medicineName, strings.Join(names, "; "))
+}
+
+// sendVoiceSummary зачитывает аналоги, если в чате включены голосовые ответы
+func sendVoiceSummary(ctx context.Context, b *bot.Bot, chatID int64, medicineName string, analogs []Analog) {
+	if TTS == nil || len(analogs) == 0 || !Storage.ChatSettings(chatID).Voice {
+		return
+	}
+
+	AppMetrics.Incr("tts_requests")
+
+	audio, err := TTS.Synthesize(ctx, voiceSummary(medicineName, analogs))
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	_, err = b.SendVoice(ctx, &bot.SendVoiceParams{
+		ChatID: chatID,
+		Voice:  &models.InputFileUpload{Filename: "analogs.ogg", Data: bytes.NewReader(audio)},
+	})
+	if err != nil {
+		logError(err)
+	}
+}
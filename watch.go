@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// watchChangeRetention сколько хранятся изменения для еженедельной сводки
+const watchChangeRetention = 30 * 24 * time.Hour
+
+// WatchInterval как часто перепроверяются аналоги лекарств из списка слежения, WATCH_INTERVAL
+var WatchInterval = 6 * time.Hour
+
+// Watch лекарство, за аналогами которого следит чат. Analogs аналоги при последней проверке,
+// с ними сравнивается следующий ответ
+type Watch struct {
+	ID           int      `json:"id"`
+	ChatID       int64    `json:"chat_id"`
+	UserID       int64    `json:"user_id"`
+	MedicineID   int      `json:"medicine_id"`
+	MedicineName string   `json:"medicine_name"`
+	CountryID    int      `json:"country_id"`
+	Analogs      []Analog `json:"analogs"`
+	// PriceAlerts целевые цены аналогов
+	PriceAlerts []PriceAlert `json:"price_alerts,omitempty"`
+	// Changes изменения, о которых сообщалось, для еженедельной сводки
+	Changes   []WatchChange `json:"changes,omitempty"`
+	Paused    bool          `json:"paused,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// WatchChange изменение аналогов или цены, Text в HTML разметке
+type WatchChange struct {
+	At   time.Time `json:"at"`
+	Text string    `json:"text"`
+}
+
+// addChange запоминает изменение и забывает изменения старше watchChangeRetention
+func (w *Watch) addChange(now time.Time, text string) {
+	changes := []WatchChange{}
+	for _, change := range w.Changes {
+		if now.Sub(change.At) < watchChangeRetention {
+			changes = append(changes, change)
+		}
+	}
+	w.Changes = append(changes, WatchChange{At: now, Text: text})
+}
+
+func (w Watch) CountryName() string {
+	if country, ok := countryByID(w.CountryID); ok {
+		return country.Name
+	}
+
+	return strconv.Itoa(w.CountryID)
+}
+
+// bestPercentage процент совпадения лучшего аналога, 0 если аналогов нет
+func bestPercentage(analogs []Analog) int {
+	best, ok := pills.BestAnalog(analogs)
+	if !ok {
+		return 0
+	}
+
+	return best.Percentage
+}
+
+// watchChanges описывает, чем новые аналоги отличаются от прежних, пустая строка если ничем
+func watchChanges(before []Analog, after []Analog, info MedicineInfo) string {
+	known := map[string]bool{}
+	for _, analog := range before {
+		known[analog.AnalogID] = true
+	}
+	added := []string{}
+	for _, analog := range after {
+		if !known[analog.AnalogID] {
+			added = append(added, analog.AnalogName)
+		}
+	}
+
+	changes := []string{}
+	if len(added) > 0 {
+		changes = append(changes, "➕ Новые аналоги: "+escapeHTML(strings.Join(added, ", ")))
+	}
+	if removed := vanishedAnalogs(before, after, info); len(removed) > 0 {
+		changes = append(changes, availabilityText(removed, after))
+	}
+	if was, now := bestPercentage(before), bestPercentage(after); was != now {
+		changes = append(changes, fmt.Sprintf("🎯 Лучшее совпадение: %d%% → %d%%", was, now))
+	}
+
+	return strings.Join(changes, "\n")
+}
+
+// watchMarkup кнопки уведомления об изменениях
+func watchMarkup(watch Watch) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{
+				{Text: "Аналоги", CallbackData: "search_analog:" + strconv.Itoa(watch.MedicineID)},
+				{Text: "Не следить", CallbackData: "unwatch:" + strconv.Itoa(watch.ID)},
+			},
+		},
+	}
+}
+
+// watchHandler включает и выключает слежение за аналогами лекарства в стране поиска чата: watch:<лекарство>
+func watchHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := callbackChatID(update.CallbackQuery)
+	medicineID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "watch:"))
+	countryID := targetCountry(chatID)
+
+	if watch, ok := Storage.Watch(chatID, medicineID, countryID); ok {
+		Storage.DeleteWatch(chatID, watch.ID)
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: update.CallbackQuery.ID,
+			Text:            "Больше не слежу за аналогами " + watch.MedicineName,
+		})
+		return
+	}
+
+	result, err := findAnalogsContext(ctx, medicineID, countryID)
+	if err != nil {
+		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+			CallbackQueryID: update.CallbackQuery.ID,
+			Text:            "Не удалось загрузить аналоги, попробуйте позже.",
+		})
+		return
+	}
+
+	watch, _ := Storage.AddWatch(Watch{
+		ChatID:       chatID,
+		UserID:       update.CallbackQuery.From.ID,
+		MedicineID:   medicineID,
+		MedicineName: result.Medicine.MedicineName,
+		CountryID:    countryID,
+		Analogs:      result.Analogs,
+	})
+	AppMetrics.Incr("watches")
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            fmt.Sprintf("Слежу за аналогами %s (%s). Сообщу, если они изменятся. Список: /watchlist", watch.MedicineName, watch.CountryName()),
+		ShowAlert:       true,
+	})
+}
+
+// unwatchHandler перестает следить за лекарством из уведомления или списка: unwatch:<запись>
+func unwatchHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := callbackChatID(update.CallbackQuery)
+	watchID, _ := strconv.Atoi(strings.TrimPrefix(update.CallbackQuery.Data, "unwatch:"))
+
+	text := "Запись уже удалена."
+	if Storage.DeleteWatch(chatID, watchID) {
+		text = "Больше не слежу за этим лекарством."
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            text,
+	})
+}
+
+// watchlistHandler показывает лекарства, за аналогами которых следит чат
+func watchlistHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	watches := Storage.Watches(chatID)
+	if len(watches) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Вы пока ни за чем не следите. Нажмите 👁 Следить под списком аналогов, и я сообщу, когда они изменятся.",
+		})
+		return
+	}
+
+	lines := []string{bold("Слежу за аналогами:")}
+	buttons := [][]models.InlineKeyboardButton{}
+	for _, watch := range watches {
+		lines = append(lines, fmt.Sprintf("• %s, %s: аналогов %d, лучшее совпадение %d%%",
+			bold(watch.MedicineName), escapeHTML(watch.CountryName()), len(watch.Analogs), bestPercentage(watch.Analogs)))
+		for _, alert := range watch.PriceAlerts {
+			lines = append(lines, "  "+priceAlertText(alert))
+		}
+		row := []models.InlineKeyboardButton{
+			{Text: "❌ " + watch.MedicineName, CallbackData: "unwatch:" + strconv.Itoa(watch.ID)},
+		}
+		if Prices != nil {
+			row = append(row, models.InlineKeyboardButton{Text: "💰 Цель цены", CallbackData: "price_alert:" + strconv.Itoa(watch.ID)})
+		}
+		buttons = append(buttons, row)
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        strings.Join(lines, "\n"),
+		ParseMode:   models.ParseModeHTML,
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: buttons},
+	})
+}
+
+// checkWatches перепроверяет аналоги и сообщает подписчикам об изменениях. Одно лекарство
+// в стране запрашивается один раз для всех подписчиков. В тихие часы чата уведомление
+// откладывается до следующей проверки
+func checkWatches(ctx context.Context, b *bot.Bot, now time.Time) {
+	type watchKey struct {
+		medicineID int
+		countryID  int
+	}
+	type watchResult struct {
+		info    MedicineInfo
+		analogs []Analog
+		found   bool
+	}
+	results := map[watchKey]watchResult{}
+
+	for _, watch := range Storage.AllWatches() {
+		if ctx.Err() != nil {
+			return
+		}
+		if watch.Paused {
+			continue
+		}
+		if _, quiet := chatQuietUntil(watch.ChatID, now); quiet {
+			continue
+		}
+
+		key := watchKey{medicineID: watch.MedicineID, countryID: watch.CountryID}
+		result, ok := results[key]
+		if !ok {
+			found, err := findAnalogsContext(ctx, watch.MedicineID, watch.CountryID)
+			result = watchResult{
+				info:    found.Medicine,
+				analogs: found.Analogs,
+				found:   err == nil && len(found.Medicine.MedicineName) > 0,
+			}
+			results[key] = result
+		}
+		// Ошибка источника не значит, что аналоги пропали, а ответы снимка и запасного
+		// файла с прошлой проверкой не сравниваются
+		if !result.found || !liveSource(result.info) {
+			continue
+		}
+		analogs := result.analogs
+
+		parts := []string{}
+		if changed := watchChanges(watch.Analogs, analogs, result.info); len(changed) > 0 {
+			parts = append(parts, changed)
+		}
+		if len(vanishedAnalogs(watch.Analogs, analogs, result.info)) > 0 {
+			AppMetrics.Incr("availability_alerts")
+		}
+		alerts := availablePriceAlerts(watch.PriceAlerts, analogs, result.info)
+		if len(alerts) < len(watch.PriceAlerts) {
+			parts = append(parts, "Цель цены для пропавших аналогов снята.")
+		}
+		changes := strings.Join(parts, "\n")
+		watch, _ = Storage.UpdateWatch(watch.ID, func(watch *Watch) {
+			watch.PriceAlerts = alerts
+			watch.Analogs = analogs
+			watch.CheckedAt = now
+			if len(changes) > 0 {
+				watch.addChange(now, changes)
+			}
+		})
+		if len(changes) == 0 {
+			continue
+		}
+
+		AppMetrics.Incr("watch_notifications")
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:             watch.ChatID,
+			Text:               fmt.Sprintf("Изменились аналоги %s, %s:\n%s", bold(watch.MedicineName), escapeHTML(watch.CountryName()), changes),
+			ParseMode:          models.ParseModeHTML,
+			LinkPreviewOptions: disabledLinkPreview(),
+			ReplyMarkup:        watchMarkup(watch),
+		})
+		if err != nil {
+			logError(err)
+		}
+	}
+}
+
+func watchJob(b *bot.Bot) Job {
+	return Job{
+		Name: "watches",
+		Next: every(WatchInterval),
+		Run: func(ctx context.Context) {
+			checkWatches(ctx, b, time.Now())
+			checkPriceAlerts(ctx, b, time.Now())
+		},
+	}
+}
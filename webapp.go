@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/nighthtr/pills-bot/pills"
+)
+
+// initDataTTL ограничивает срок действия подписи initData
+const initDataTTL = 24 * time.Hour
+
+//go:embed web
+var webFiles embed.FS
+
+type Dashboard struct {
+	Metrics    MetricsSnapshot `json:"metrics"`
+	TopQueries []QueryCount    `json:"top_queries"`
+	Flags      []FlagState     `json:"flags"`
+	// Providers состояние источников данных о лекарствах
+	Providers []pills.ProviderHealth `json:"providers"`
+}
+
+type FlagState struct {
+	FeatureFlag
+	Enabled bool `json:"enabled"`
+}
+
+type SetFlagRequest struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+func startHTTPServer(ctx context.Context, addr string) {
+	admin, _ := fs.Sub(webFiles, "web/admin")
+	app, _ := fs.Sub(webFiles, "web/app")
+
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", http.StripPrefix("/admin/", http.FileServer(http.FS(admin))))
+	mux.HandleFunc("/admin/api/dashboard", requirePermission(PermissionDashboard, dashboardHandler))
+	mux.HandleFunc("/admin/api/flags", requirePermission(PermissionFlags, setFlagHandler))
+	mux.Handle("/app/", http.StripPrefix("/app/", http.FileServer(http.FS(app))))
+	mux.HandleFunc("/app/api/medicines", requireWebAppUser(miniAppMedicinesHandler))
+	mux.HandleFunc("/app/api/analogs", requireWebAppUser(miniAppAnalogsHandler))
+	mux.HandleFunc("/calendar/", calendarFeedHandler)
+	mux.HandleFunc("/r/", permalinkHandler)
+	if RestAPIEnabled {
+		registerRestAPI(mux)
+	}
+	startDiscord(mux)
+	startSlack(mux)
+	startWhatsApp(mux)
+	startMCP(mux)
+
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	log.Printf("HTTP сервер запущен на %s\n", addr)
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logError(err)
+	}
+}
+
+// validateInitData проверяет подпись данных Telegram Web App и возвращает пользователя
+func validateInitData(initData string) (*models.User, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := values.Get("hash")
+	if len(hash) == 0 {
+		return nil, errors.New("initData без подписи")
+	}
+
+	pairs := []string{}
+	for key := range values {
+		if key == "hash" {
+			continue
+		}
+		pairs = append(pairs, key+"="+values.Get(key))
+	}
+	sort.Strings(pairs)
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(BotToken))
+
+	check := hmac.New(sha256.New, secret.Sum(nil))
+	check.Write([]byte(strings.Join(pairs, "\n")))
+
+	if !hmac.Equal([]byte(hex.EncodeToString(check.Sum(nil))), []byte(hash)) {
+		return nil, errors.New("неверная подпись initData")
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil || time.Since(time.Unix(authDate, 0)) > initDataTTL {
+		return nil, errors.New("initData устарели")
+	}
+
+	user := &models.User{}
+	if err := json.Unmarshal([]byte(values.Get("user")), user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// requirePermission пропускает только запросы из Web App от пользователей с нужной ролью
+func requirePermission(permission Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := validateInitData(r.Header.Get("X-Telegram-Init-Data"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !hasPermission(user.ID, permission) {
+			http.Error(w, "доступ запрещен", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logError(err)
+	}
+}
+
+func dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	flags := []FlagState{}
+	for _, flag := range featureFlags {
+		flags = append(flags, FlagState{
+			FeatureFlag: flag,
+			Enabled:     flagEnabled(flag.Name),
+		})
+	}
+
+	writeJSON(w, Dashboard{
+		Metrics:    AppMetrics.Snapshot(),
+		TopQueries: Storage.TopQueries(10),
+		Flags:      flags,
+		Providers:  providerHealth(),
+	})
+}
+
+// providerHealth состояние источников, если Medicines следит за ним
+func providerHealth() []pills.ProviderHealth {
+	chain, ok := Medicines.(interface{ Health() []pills.ProviderHealth })
+	if !ok {
+		return []pills.ProviderHealth{}
+	}
+
+	return chain.Health()
+}
+
+func setFlagHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+
+	request := SetFlagRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := findFeatureFlag(request.Name); !ok {
+		http.Error(w, "неизвестный флаг", http.StatusNotFound)
+		return
+	}
+
+	Storage.SetFlag(request.Name, request.Enabled)
+	log.Printf("Флаг %s: %t\n", request.Name, request.Enabled)
+
+	dashboardHandler(w, r)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// События для внешних интеграций
+const (
+	EventSearchPerformed = "search.performed"
+	EventReportFiled     = "report.filed"
+	EventReminderMissed  = "reminder.missed"
+)
+
+const (
+	webhookQueueSize = 256
+	webhookAttempts  = 3
+)
+
+// Webhooks рассылка событий на WEBHOOK_URLS, nil если адреса не заданы
+var Webhooks *WebhookDispatcher
+
+// WebhookEvent тело запроса, которое получает интеграция
+type WebhookEvent struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookDispatcher отправляет события в фоне, подписывая тело HMAC-SHA256 в заголовке X-Pills-Signature
+type WebhookDispatcher struct {
+	URLs   []string
+	Secret string
+	Client *http.Client
+	queue  chan WebhookEvent
+}
+
+// newWebhookDispatcher создает рассылку для адресов через запятую
+func newWebhookDispatcher(urls string, secret string) (*WebhookDispatcher, bool) {
+	list := []string{}
+	for _, item := range strings.Split(urls, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return nil, false
+	}
+
+	return &WebhookDispatcher{
+		URLs:   list,
+		Secret: secret,
+		Client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan WebhookEvent, webhookQueueSize),
+	}, true
+}
+
+// Emit ставит событие в очередь, при переполненной очереди событие отбрасывается
+func (w *WebhookDispatcher) Emit(event string, data any) {
+	if w == nil {
+		return
+	}
+
+	select {
+	case w.queue <- WebhookEvent{Event: event, CreatedAt: time.Now(), Data: data}:
+	default:
+		AppMetrics.Incr("webhooks_dropped")
+	}
+}
+
+// Run отправляет события из очереди до отмены ctx
+func (w *WebhookDispatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-w.queue:
+			body, err := json.Marshal(event)
+			if err != nil {
+				logError(err)
+				continue
+			}
+			for _, url := range w.URLs {
+				w.deliver(ctx, url, event.Event, body)
+			}
+		}
+	}
+}
+
+// sign возвращает подпись тела запроса в виде sha256=<hex>
+func (w *WebhookDispatcher) sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver отправляет событие, повторяя запрос при ошибках сети и ответах 5xx
+func (w *WebhookDispatcher) deliver(ctx context.Context, url string, event string, body []byte) {
+	var err error
+	for attempt := 0; attempt < webhookAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Duration(attempt) * 5 * time.Second):
+			}
+		}
+
+		err = w.post(ctx, url, event, body)
+		if err == nil {
+			AppMetrics.Incr("webhooks_sent")
+			return
+		}
+	}
+
+	AppMetrics.Incr("webhooks_failed")
+	logError(err)
+}
+
+func (w *WebhookDispatcher) post(ctx context.Context, url string, event string, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Pills-Event", event)
+	if len(w.Secret) > 0 {
+		request.Header.Set("X-Pills-Signature", w.sign(body))
+	}
+
+	response, err := w.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode >= 500 {
+		return fmt.Errorf("webhook %s: %d", url, response.StatusCode)
+	}
+
+	return nil
+}
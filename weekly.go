@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// weeklyDigestHour час отправки личной сводки по воскресеньям
+const weeklyDigestHour = 18
+
+// weeklyWatchSection изменения аналогов и цен за неделю по списку слежения
+func weeklyWatchSection(chatID int64, since time.Time) string {
+	watches := Storage.Watches(chatID)
+	if len(watches) == 0 {
+		return ""
+	}
+
+	lines := []string{bold("👁 Слежение за аналогами")}
+	for _, watch := range watches {
+		for _, change := range watch.Changes {
+			if change.At.Before(since) {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s, %s, %s:\n%s",
+				bold(watch.MedicineName), escapeHTML(watch.CountryName()), change.At.In(chatLocation(chatID)).Format("02.01"), change.Text))
+		}
+	}
+	if len(lines) == 1 {
+		lines = append(lines, fmt.Sprintf("Лекарств в списке: %d, за неделю изменений не было.", len(watches)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// weeklyTripSection собранность списка в дорогу для поездок, которые еще не закончились
+func weeklyTripSection(chatID int64) string {
+	today := chatToday(chatID)
+	lines := []string{bold("🧳 Поездки")}
+	for _, trip := range Storage.Trips(chatID) {
+		if trip.Ended || trip.End < today {
+			continue
+		}
+		if len(trip.Checklist) == 0 {
+			lines = append(lines, fmt.Sprintf("%s, %s: список в дорогу пуст", bold(trip.CountryName()), trip.Dates()))
+			continue
+		}
+		packed := 0
+		for _, item := range trip.Checklist {
+			if item.Packed {
+				packed++
+			}
+		}
+		line := fmt.Sprintf("%s, %s: собрано %d из %d", bold(trip.CountryName()), trip.Dates(), packed, len(trip.Checklist))
+		if packed == len(trip.Checklist) {
+			line += " ✅"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 1 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// weeklyAdherenceSection доля принятых за неделю доз по напоминаниям
+func weeklyAdherenceSection(chatID int64, since time.Time) string {
+	doses := Storage.DosesSince(chatID, since)
+	if len(doses) == 0 {
+		return ""
+	}
+	taken := 0
+	for _, dose := range doses {
+		if !dose.TakenAt.IsZero() {
+			taken++
+		}
+	}
+
+	return fmt.Sprintf("%s\n%s %d из %d, пропущено %d. Подробнее: /adherence",
+		bold("💊 Прием лекарств"), adherenceBar(taken, len(doses)), taken, len(doses), len(doses)-taken)
+}
+
+// formatWeeklyDigest собирает личную сводку за неделю, пустая строка если рассказывать не о чем
+func formatWeeklyDigest(chatID int64, now time.Time) string {
+	since := now.AddDate(0, 0, -7)
+	sections := []string{}
+	for _, section := range []string{
+		weeklyWatchSection(chatID, since),
+		weeklyTripSection(chatID),
+		weeklyAdherenceSection(chatID, since),
+	} {
+		if len(section) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	if len(sections) == 0 {
+		return ""
+	}
+
+	return bold("Ваша неделя") + "\n\n" + strings.Join(sections, "\n\n")
+}
+
+func weeklyHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	_, arg, _ := strings.Cut(update.Message.Text, " ")
+
+	var note string
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "on", "вкл":
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.WeeklyDigest = true
+			// Первая сводка придет в ближайшее воскресенье
+			settings.WeeklyDigestSentAt = time.Now()
+		})
+		note = "Сводка включена, она будет приходить по воскресеньям вечером."
+	case "off", "выкл":
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.WeeklyDigest = false
+		})
+		note = "Сводка выключена."
+	default:
+		if Storage.ChatSettings(chatID).WeeklyDigest {
+			note = "Сводка включена. Выключить: /weekly off"
+		} else {
+			note = "Чтобы получать сводку каждое воскресенье, отправьте /weekly on"
+		}
+	}
+
+	text := formatWeeklyDigest(chatID, time.Now())
+	if len(text) == 0 {
+		text = "За неделю ничего не произошло: нет лекарств в /watchlist, поездок и напоминаний."
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               text + "\n\n" + escapeHTML(note),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: disabledLinkPreview(),
+	})
+}
+
+// weeklyDigestJob рассылает личные сводки в воскресенье вечером по времени каждого чата
+func weeklyDigestJob(b *bot.Bot) Job {
+	return Job{
+		Name: "weekly_digests",
+		Next: every(10 * time.Minute),
+		Run: func(ctx context.Context) {
+			sendWeeklyDigests(ctx, b, time.Now())
+		},
+	}
+}
+
+func sendWeeklyDigests(ctx context.Context, b *bot.Bot, now time.Time) {
+	for chatID, settings := range Storage.WeeklyDigestChats() {
+		local := now.In(chatLocation(chatID))
+		sunday := time.Date(local.Year(), local.Month(), local.Day()-int(local.Weekday()), weeklyDigestHour, 0, 0, 0, local.Location())
+		if now.Before(sunday) || settings.WeeklyDigestSentAt.After(sunday) {
+			continue
+		}
+		// Сводка отправится при следующей проверке после тихих часов
+		if _, quiet := quietUntil(settings, local.Location(), now); quiet {
+			continue
+		}
+
+		Storage.UpdateChatSettings(chatID, func(settings *ChatSettings) {
+			settings.WeeklyDigestSentAt = now
+		})
+		text := formatWeeklyDigest(chatID, now)
+		if len(text) == 0 {
+			continue
+		}
+
+		AppMetrics.Incr("weekly_digests")
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:             chatID,
+			Text:               text,
+			ParseMode:          models.ParseModeHTML,
+			LinkPreviewOptions: disabledLinkPreview(),
+		})
+		if err != nil {
+			logError(err)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	whatsappAPI = "https://graph.facebook.com/v18.0"
+	// whatsappListLimit максимальное количество строк в list message
+	whatsappListLimit = 10
+)
+
+// WhatsApp адаптер WhatsApp Cloud API, nil если он не настроен
+var WhatsApp *WhatsAppAdapter
+
+// WhatsAppAdapter принимает webhook WhatsApp Cloud API, лекарство выбирается из list message
+type WhatsAppAdapter struct {
+	Token       string
+	PhoneID     string
+	VerifyToken string
+	AppSecret   string
+	Client      *http.Client
+}
+
+type whatsappWebhook struct {
+	Entry []struct {
+		Changes []struct {
+			Value struct {
+				Messages []whatsappMessage `json:"messages"`
+			} `json:"value"`
+		} `json:"changes"`
+	} `json:"entry"`
+}
+
+type whatsappMessage struct {
+	From string `json:"from"`
+	Type string `json:"type"`
+	Text struct {
+		Body string `json:"body"`
+	} `json:"text"`
+	Interactive struct {
+		Type      string `json:"type"`
+		ListReply struct {
+			ID string `json:"id"`
+		} `json:"list_reply"`
+	} `json:"interactive"`
+}
+
+type whatsappRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// newWhatsAppAdapter создает адаптер, если заданы WHATSAPP_TOKEN, WHATSAPP_PHONE_ID и WHATSAPP_VERIFY_TOKEN
+func newWhatsAppAdapter(token string, phoneID string, verifyToken string, appSecret string) (*WhatsAppAdapter, bool) {
+	if len(token) == 0 || len(phoneID) == 0 || len(verifyToken) == 0 {
+		return nil, false
+	}
+
+	return &WhatsAppAdapter{
+		Token:       token,
+		PhoneID:     phoneID,
+		VerifyToken: verifyToken,
+		AppSecret:   appSecret,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}, true
+}
+
+// ServeHTTP подтверждает подписку webhook и обрабатывает входящие сообщения
+func (w *WhatsAppAdapter) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		if query.Get("hub.mode") != "subscribe" || query.Get("hub.verify_token") != w.VerifyToken {
+			http.Error(rw, "неверный токен", http.StatusForbidden)
+			return
+		}
+		io.WriteString(rw, query.Get("hub.challenge"))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 256*1024))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(w.AppSecret) > 0 {
+		mac := hmac.New(sha256.New, []byte(w.AppSecret))
+		mac.Write(body)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Hub-Signature-256"))) {
+			http.Error(rw, "неверная подпись", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	webhook := whatsappWebhook{}
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+
+	for _, entry := range webhook.Entry {
+		for _, change := range entry.Changes {
+			for _, message := range change.Value.Messages {
+				go w.handleMessage(context.Background(), message)
+			}
+		}
+	}
+}
+
+func (w *WhatsAppAdapter) send(payload map[string]any) {
+	payload["messaging_product"] = "whatsapp"
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	request, err := http.NewRequest(http.MethodPost, whatsappAPI+"/"+w.PhoneID+"/messages", bytes.NewReader(body))
+	if err != nil {
+		logError(err)
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+w.Token)
+
+	response, err := w.Client.Do(request)
+	if err != nil {
+		logError(err)
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		logError(fmt.Errorf("whatsapp: %d %s", response.StatusCode, message))
+	}
+}
+
+func (w *WhatsAppAdapter) sendText(to string, text string) {
+	w.send(map[string]any{
+		"to":   to,
+		"type": "text",
+		"text": map[string]any{"body": text, "preview_url": false},
+	})
+}
+
+func (w *WhatsAppAdapter) handleMessage(ctx context.Context, message whatsappMessage) {
+	switch {
+	case message.Type == "text":
+		w.search(ctx, message.From, message.Text.Body)
+	case message.Type == "interactive" && message.Interactive.Type == "list_reply":
+		w.analogs(ctx, message.From, message.Interactive.ListReply.ID)
+	}
+}
+
+// search отправляет найденные лекарства списком, в id строки хранятся лекарство и страна
+func (w *WhatsAppAdapter) search(ctx context.Context, to string, text string) {
+	AppMetrics.Incr("whatsapp_searches")
+
+	query, countryID := splitQueryCountry(text)
+	medicines, err := findMedicines(ctx, "whatsapp", query)
+	if errors.Is(err, errSensitiveQuery) {
+		w.sendText(to, sensitivePolicy)
+		return
+	}
+	if err != nil || len(medicines) == 0 {
+		w.sendText(to, "Мне не удалось ничего найти по запросу «"+query+"». Напишите название лекарства и при необходимости код страны, например: нурофен TH")
+		return
+	}
+
+	rows := []whatsappRow{}
+	for index, medicine := range medicines {
+		if index == whatsappListLimit {
+			break
+		}
+		rows = append(rows, whatsappRow{
+			ID:          fmt.Sprintf("med:%s:%d", medicine.ID, countryID),
+			Title:       truncateRunes(medicine.Name, 24),
+			Description: truncateRunes(medicine.Components, 72),
+		})
+	}
+
+	w.send(map[string]any{
+		"to":   to,
+		"type": "interactive",
+		"interactive": map[string]any{
+			"type": "list",
+			"body": map[string]any{"text": truncateRunes("Вот что я нашел по запросу «"+query+"». Выберите лекарство, для которого нужно найти аналоги.", 1024)},
+			"action": map[string]any{
+				"button":   "Выбрать лекарство",
+				"sections": []map[string]any{{"title": "Лекарства", "rows": rows}},
+			},
+		},
+	})
+}
+
+func (w *WhatsAppAdapter) analogs(ctx context.Context, to string, rowID string) {
+	parts := strings.Split(rowID, ":")
+	if len(parts) != 3 || parts[0] != "med" {
+		return
+	}
+	medicineID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return
+	}
+	countryID, _ := strconv.Atoi(parts[2])
+
+	result, err := findAnalogsContext(ctx, medicineID, countryID)
+	if err != nil || len(result.Analogs) == 0 {
+		w.sendText(to, "Мне не удалось найти аналоги для "+result.Medicine.MedicineName+".")
+		return
+	}
+
+	w.sendText(to, formatAnalogsPlain(result))
+}
+
+// formatAnalogsPlain оформляет аналоги для WhatsApp, где ссылки не поддерживают текст
+func formatAnalogsPlain(result AnalogsResult) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Вот аналоги для *%s*:\n", result.Medicine.MedicineName))
+	for index, analog := range result.Analogs {
+		if index == 10 {
+			break
+		}
+		text.WriteString(fmt.Sprintf("\n%d. %s (%d%%)\n%s", index+1, analog.AnalogName, analog.Percentage, analogURL(analog)))
+	}
+	for _, rule := range result.Restrictions {
+		text.WriteString(fmt.Sprintf("\n\n⚠️ %s: %s", rule.Substance, rule.Note))
+	}
+
+	return text.String()
+}
+
+// startWhatsApp подключает обработчик webhook WhatsApp
+func startWhatsApp(mux *http.ServeMux) {
+	if WhatsApp == nil {
+		return
+	}
+
+	mux.Handle("/whatsapp/webhook", WhatsApp)
+}